@@ -0,0 +1,87 @@
+package api
+
+import "time"
+
+// Roles a file or folder can be shared with, from least to most access. A
+// role on a folder applies to everything in it. Viewers read files.
+// Commenters may also comment, and until there are comments can do what
+// viewers can. Editors also change tags and versions and trash files.
+// Co-owners also share and manage who has access.
+const (
+	RoleViewer    = "viewer"
+	RoleCommenter = "commenter"
+	RoleEditor    = "editor"
+	RoleCoOwner   = "co_owner"
+	// RoleOwner is reported for the owner; it cannot be granted.
+	RoleOwner = "owner"
+)
+
+// FileRoles lists the roles that can be granted, in order of access.
+var FileRoles = []string{RoleViewer, RoleCommenter, RoleEditor, RoleCoOwner}
+
+// FileAccess lists who has access to a file, owner first.
+type FileAccess struct {
+	FileID int64 `json:"file_id"`
+	// Public is set when anyone logged in can read the file.
+	Public bool          `json:"public"`
+	Access []AccessEntry `json:"access"`
+}
+
+// FolderAccess lists who has access to a folder, owner first.
+type FolderAccess struct {
+	FolderID int64         `json:"folder_id"`
+	Access   []AccessEntry `json:"access"`
+}
+
+// AccessEntry is the owner or a role granted to a user or a group. A user
+// can appear more than once, granted on the object, on folders above it
+// and through groups; the highest role counts.
+type AccessEntry struct {
+	// UserID, Username and Email are set for users, GroupID and Group for
+	// groups.
+	UserID   int64  `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	GroupID  int64  `json:"group_id,omitempty"`
+	Group    string `json:"group,omitempty"`
+	Role     string `json:"role"`
+	// ViaFolderID is the folder above the object the role was granted on,
+	// unset for roles granted on the object itself.
+	ViaFolderID *int64 `json:"via_folder_id,omitempty"`
+	// GrantedBy and GrantedAt are unset for the owner.
+	GrantedBy *int64     `json:"granted_by,omitempty"`
+	GrantedAt *time.Time `json:"granted_at,omitempty"`
+}
+
+// GrantAccessRequest gives the user with Email, or the group with GroupID,
+// a role on a file or folder, replacing any role they had on it.
+type GrantAccessRequest struct {
+	Email   string `json:"email,omitempty"`
+	GroupID int64  `json:"group_id,omitempty"`
+	Role    string `json:"role"`
+}
+
+// SharedWithMe lists what other users have shared with the caller: files
+// and folders granted to them, and everything within those folders.
+type SharedWithMe struct {
+	Folders []SharedWithMeFolder `json:"folders"`
+	Files   []SharedWithMeFile   `json:"files"`
+}
+
+// SharedWithMeFolder is a folder shared with the caller. ViaFolderID is the
+// folder the role was granted on, the folder itself or one above it.
+type SharedWithMeFolder struct {
+	Folder
+	Owner       string `json:"owner"`
+	Role        string `json:"role"`
+	ViaFolderID int64  `json:"via_folder_id"`
+}
+
+// SharedWithMeFile is a file shared with the caller, with File.Owner set.
+type SharedWithMeFile struct {
+	File
+	Role string `json:"role"`
+	// ViaFolderID is the folder the role was granted on, unset when it was
+	// granted on the file.
+	ViaFolderID *int64 `json:"via_folder_id,omitempty"`
+}
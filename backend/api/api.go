@@ -0,0 +1,44 @@
+// Package api defines the request and response bodies of the HTTP API.
+// The server encodes its responses with these types and the client
+// package decodes them, so every JSON key is spelled out once, here.
+//
+// Within a Version fields are only ever added; renaming or removing one,
+// or changing its meaning, bumps Version. Every response carries it in
+// VersionHeader.
+package api
+
+// Version is the version of the contract described by this package.
+const Version = "1"
+
+// VersionHeader is the response header carrying the server's Version.
+const VersionHeader = "X-API-Version"
+
+// Error is the body of every 4xx and 5xx JSON response.
+type Error struct {
+	Error string `json:"error"`
+	// Path is set by the /fs routes to the path the error is about.
+	Path string `json:"path,omitempty"`
+	// Declared, Detected and Status are set when an upload is rejected
+	// because its declared content type does not match its content.
+	Declared string `json:"declared,omitempty"`
+	Detected string `json:"detected,omitempty"`
+	Status   string `json:"status,omitempty"`
+	// PasswordRequired and EmailRequired are set on the 401 a protected
+	// share link answers until it is unlocked.
+	PasswordRequired bool `json:"password_required,omitempty"`
+	EmailRequired    bool `json:"email_required,omitempty"`
+}
+
+// Message acknowledges a request that has nothing else to report.
+type Message struct {
+	Message string `json:"message"`
+}
+
+// Event is a change notification pushed over GET /ws/stats. The "event"
+// key names the change; the other keys depend on it.
+type Event map[string]any
+
+// Health is the body of GET /health.
+type Health struct {
+	Status string `json:"status"`
+}
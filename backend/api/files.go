@@ -0,0 +1,125 @@
+package api
+
+import "time"
+
+// File is a file as every listing reports it.
+type File struct {
+	ID               int64     `json:"id"`
+	Filename         string    `json:"filename"`
+	FolderID         *int64    `json:"folder_id"`
+	Size             int64     `json:"size"`
+	MIMEType         string    `json:"mime_type"`
+	Hash             string    `json:"hash"`
+	Tags             []string  `json:"tags"`
+	PreviewAvailable bool      `json:"preview_available"`
+	IsPublic         bool      `json:"is_public"`
+	DownloadCount    int64     `json:"download_count"`
+	CreatedAt        time.Time `json:"created_at"`
+	// TrashedAt is set for files in the trash.
+	TrashedAt *time.Time `json:"trashed_at,omitempty"`
+	// Owner is the owner's username, only reported to admins and for
+	// files shared with the caller.
+	Owner string `json:"owner,omitempty"`
+}
+
+type FileList struct {
+	Files []File `json:"files"`
+}
+
+// SearchResult is one page of GET /files/search.
+type SearchResult struct {
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	TotalCount int    `json:"total_count"`
+	Results    []File `json:"results"`
+}
+
+const (
+	UploadStatusUploaded = "uploaded"
+	UploadStatusRejected = "rejected"
+)
+
+// UploadResult reports one uploaded file. In a multi-upload a rejected
+// file only has Filename, Status and Reason set.
+type UploadResult struct {
+	FileID   int64    `json:"file_id,omitempty"`
+	BlobID   int64    `json:"blob_id,omitempty"`
+	Filename string   `json:"filename"`
+	Size     int64    `json:"size,omitempty"`
+	Hash     string   `json:"hash,omitempty"`
+	MIMEType string   `json:"mime_type,omitempty"`
+	Tags     []string `json:"tags"`
+	FolderID *int64   `json:"folder_id"`
+	// Path is set by PUT /fs.
+	Path   string `json:"path,omitempty"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type MultiUploadResult struct {
+	Results []UploadResult `json:"results"`
+}
+
+// FileStatus acknowledges a change to one file.
+type FileStatus struct {
+	Message string `json:"message"`
+	FileID  int64  `json:"file_id"`
+}
+
+type MoveFileRequest struct {
+	FolderID *int64 `json:"folder_id"`
+}
+
+type FileMoved struct {
+	Message  string `json:"message"`
+	FileID   int64  `json:"file_id"`
+	FolderID *int64 `json:"folder_id"`
+}
+
+type BulkMoveRequest struct {
+	FileIDs  []int64 `json:"file_ids"`
+	FolderID *int64  `json:"folder_id"`
+}
+
+type FilesMoved struct {
+	Message  string  `json:"message"`
+	FileIDs  []int64 `json:"file_ids"`
+	FolderID *int64  `json:"folder_id"`
+}
+
+type TagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type FileTags struct {
+	FileID int64    `json:"file_id"`
+	Tags   []string `json:"tags"`
+}
+
+type EditorRequest struct {
+	Email string `json:"email"`
+}
+
+type EditorStatus struct {
+	Message  string `json:"message"`
+	FileID   int64  `json:"file_id"`
+	EditorID int64  `json:"editor_id"`
+}
+
+type FileVersion struct {
+	Version   int       `json:"version"`
+	Hash      string    `json:"hash"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FileVersions struct {
+	FileID   int64         `json:"file_id"`
+	Versions []FileVersion `json:"versions"`
+}
+
+type VersionRestored struct {
+	Message string `json:"message"`
+	FileID  int64  `json:"file_id"`
+	Version int    `json:"version"`
+}
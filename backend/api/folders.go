@@ -0,0 +1,61 @@
+package api
+
+import "time"
+
+type Folder struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	ParentID  *int64    `json:"parent_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// TrashedAt is set for folders in the trash.
+	TrashedAt *time.Time `json:"trashed_at,omitempty"`
+}
+
+type FolderList struct {
+	Folders []Folder `json:"folders"`
+}
+
+// FolderNode is a folder in GET /folders/tree.
+type FolderNode struct {
+	ID       int64         `json:"id"`
+	Name     string        `json:"name"`
+	ParentID *int64        `json:"parent_id"`
+	Children []*FolderNode `json:"children"`
+}
+
+type FolderTree struct {
+	Tree []*FolderNode `json:"tree"`
+}
+
+type CreateFolderRequest struct {
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id"`
+}
+
+type RenameFolderRequest struct {
+	Name string `json:"name"`
+}
+
+type MoveFolderRequest struct {
+	NewParentID *int64 `json:"new_parent_id"`
+}
+
+// FolderStatus acknowledges a change to one folder.
+type FolderStatus struct {
+	Message  string `json:"message"`
+	FolderID int64  `json:"folder_id"`
+	// Path is set by the /fs routes.
+	Path string `json:"path,omitempty"`
+}
+
+type FolderMoved struct {
+	Message     string `json:"message"`
+	FolderID    int64  `json:"folder_id"`
+	NewParentID *int64 `json:"new_parent_id"`
+}
+
+// Trash lists the caller's trashed files and folders, most recent first.
+type Trash struct {
+	Files   []File   `json:"files"`
+	Folders []Folder `json:"folders"`
+}
@@ -0,0 +1,45 @@
+package api
+
+import "time"
+
+const (
+	EntryFile   = "file"
+	EntryFolder = "folder"
+)
+
+// Entry is a file or folder as the path-based /fs routes report it. The
+// root folder has no ID.
+type Entry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	ID   *int64 `json:"id"`
+	Name string `json:"name"`
+	// ParentID is the folder holding the entry, nil for the root.
+	ParentID  *int64     `json:"parent_id"`
+	Size      int64      `json:"size,omitempty"`
+	MIMEType  string     `json:"mime_type,omitempty"`
+	Hash      string     `json:"hash,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+func (e *Entry) IsDir() bool { return e.Type == EntryFolder }
+
+type EntryList struct {
+	Path    string  `json:"path"`
+	Entries []Entry `json:"entries"`
+}
+
+type MoveRequest struct {
+	To string `json:"to"`
+}
+
+type PathMoved struct {
+	Message string `json:"message"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+type PathStatus struct {
+	Message string `json:"message"`
+	Path    string `json:"path"`
+}
@@ -0,0 +1,46 @@
+package api
+
+import "time"
+
+// Group is a team that file and folder roles can be granted to.
+type Group struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Members     int       `json:"members"`
+	CreatedAt   time.Time `json:"created_at"`
+	// IsAdmin is set when the caller administers the group.
+	IsAdmin bool `json:"is_admin"`
+}
+
+type GroupList struct {
+	Groups []Group `json:"groups"`
+}
+
+// GroupDetail is a group with its members, as its members see it.
+type GroupDetail struct {
+	Group
+	MemberList []GroupMember `json:"member_list"`
+}
+
+type GroupMember struct {
+	UserID   int64     `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	IsAdmin  bool      `json:"is_admin"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// GroupRequest creates a group, or renames it and changes its description
+// when the fields are set.
+type GroupRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+// GroupMemberRequest adds the user with Email to a group, or changes
+// whether they are an admin of it.
+type GroupMemberRequest struct {
+	Email   string `json:"email"`
+	IsAdmin bool   `json:"is_admin"`
+}
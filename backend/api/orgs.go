@@ -0,0 +1,98 @@
+package api
+
+import "time"
+
+// Roles in an organization, kept in users.role.
+const (
+	OrgRoleMember = "user"
+	OrgRoleAdmin  = "admin"
+)
+
+// Organization is a tenant: its members only see each other's files,
+// groups and shares.
+type Organization struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	// StorageQuota caps what the members store together, in bytes, 0 for
+	// no cap. MemberQuota is the quota new members start with.
+	StorageQuota int64     `json:"storage_quota"`
+	MemberQuota  int64     `json:"member_quota"`
+	Used         int64     `json:"used"`
+	Members      int64     `json:"members"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Role is the caller's role in it, unset in the instance admin's list.
+	Role string `json:"role,omitempty"`
+}
+
+type OrganizationList struct {
+	Organizations []Organization `json:"organizations"`
+}
+
+// CreateOrganizationRequest creates an organization and invites AdminEmail
+// to administer it.
+type CreateOrganizationRequest struct {
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	StorageQuota int64  `json:"storage_quota"`
+	MemberQuota  *int64 `json:"member_quota,omitempty"`
+	AdminEmail   string `json:"admin_email"`
+}
+
+// UpdateOrganizationRequest changes the fields that are set. Only instance
+// admins change StorageQuota.
+type UpdateOrganizationRequest struct {
+	Name         *string `json:"name,omitempty"`
+	StorageQuota *int64  `json:"storage_quota,omitempty"`
+	MemberQuota  *int64  `json:"member_quota,omitempty"`
+}
+
+// NewOrganization is a created organization with the invitation for its
+// first admin.
+type NewOrganization struct {
+	Organization
+	Invite Invite `json:"invite"`
+}
+
+type Member struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Quota    int64  `json:"quota"`
+	Used     int64  `json:"used"`
+	// TwoFactor is set when the member has turned on two-factor
+	// authentication.
+	TwoFactor bool      `json:"two_factor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MemberList struct {
+	Members []Member `json:"members"`
+}
+
+// UpdateMemberRequest changes a member's role or quota, whichever is set.
+type UpdateMemberRequest struct {
+	Role  *string `json:"role,omitempty"`
+	Quota *int64  `json:"quota,omitempty"`
+}
+
+// Invite is an invitation to join an organization. The link with its
+// token is only sent by email.
+type Invite struct {
+	ID        int64     `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type InviteList struct {
+	Invites []Invite `json:"invites"`
+}
+
+type InviteRequest struct {
+	Email string `json:"email"`
+	// Role is OrgRoleMember, the default, or OrgRoleAdmin.
+	Role string `json:"role,omitempty"`
+}
@@ -0,0 +1,156 @@
+package api
+
+import "time"
+
+// Share is a public link to a file, FileID and Filename, or to a folder,
+// FolderID and FolderName.
+type Share struct {
+	ID            int64      `json:"id"`
+	ShareURL      string     `json:"share_url"`
+	FileID        int64      `json:"file_id,omitempty"`
+	Filename      string     `json:"filename,omitempty"`
+	FolderID      *int64     `json:"folder_id,omitempty"`
+	FolderName    string     `json:"folder_name,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	AllowDownload bool       `json:"allow_download"`
+	Expired       bool       `json:"expired"`
+	// HasPassword and RequireEmail are set when visitors must unlock the
+	// link with a password or their email first.
+	HasPassword  bool `json:"has_password"`
+	RequireEmail bool `json:"require_email"`
+	// MaxDownloads caps Downloads, nil for no cap.
+	MaxDownloads *int `json:"max_downloads"`
+	Downloads    int  `json:"downloads"`
+	// CreatedBy and CreatedAt are unset for links made before they were
+	// recorded.
+	CreatedBy *int64     `json:"created_by,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// CreateShareRequest is the optional body of POST /share/:id and POST
+// /folders/:id/share; the expiry and download permission are query
+// parameters.
+type CreateShareRequest struct {
+	// Password, up to 72 bytes, must be given to unlock the link.
+	Password     string `json:"password,omitempty"`
+	MaxDownloads *int   `json:"max_downloads,omitempty"`
+	// RequireEmail has visitors leave their email to unlock the link.
+	RequireEmail bool `json:"require_email,omitempty"`
+}
+
+// UpdateShareRequest changes the fields of a link that are set.
+type UpdateShareRequest struct {
+	// Expiry is hours from now until the link expires, 0 for never.
+	Expiry        *float64 `json:"expiry,omitempty"`
+	AllowDownload *bool    `json:"allow_download,omitempty"`
+	// Password replaces the password, "" removes it.
+	Password *string `json:"password,omitempty"`
+	// MaxDownloads replaces the download cap, 0 removes it. Downloads
+	// already made still count.
+	MaxDownloads *int  `json:"max_downloads,omitempty"`
+	RequireEmail *bool `json:"require_email,omitempty"`
+}
+
+// ShareStats sums up the audited visits to a link: views of its page,
+// previews, downloads and unlocks, and where they came from. A folder
+// link's downloads are the ZIPs taken from it.
+type ShareStats struct {
+	ShareID      int64      `json:"share_id"`
+	Views        int64      `json:"views"`
+	Previews     int64      `json:"previews"`
+	Downloads    int64      `json:"downloads"`
+	Unlocks      int64      `json:"unlocks"`
+	LastAccessAt *time.Time `json:"last_access_at"`
+	// Referrers are the sites visitors came from, by scheme and host, or
+	// "direct"; IPs are their addresses. Both list the 10 most frequent.
+	Referrers []ShareSource `json:"referrers"`
+	IPs       []ShareSource `json:"ips"`
+}
+
+type ShareSource struct {
+	Value        string    `json:"value"`
+	Count        int64     `json:"count"`
+	LastAccessAt time.Time `json:"last_access_at"`
+}
+
+// UnlockShareRequest unlocks a protected link with its password and the
+// visitor's email, whichever the link requires.
+type UnlockShareRequest struct {
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// ShareUnlocked acknowledges an unlock; the cookie set with it opens the
+// link until ExpiresAt.
+type ShareUnlocked struct {
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ShareViewer is an email a link was unlocked with.
+type ShareViewer struct {
+	Email         string    `json:"email"`
+	Views         int64     `json:"views"`
+	FirstViewedAt time.Time `json:"first_viewed_at"`
+	LastViewedAt  time.Time `json:"last_viewed_at"`
+}
+
+type ShareViewerList struct {
+	Viewers []ShareViewer `json:"viewers"`
+}
+
+type ShareList struct {
+	Shares []Share `json:"shares"`
+}
+
+type ShareStatus struct {
+	Message string `json:"message"`
+	ShareID int64  `json:"share_id"`
+}
+
+// SharedFile is what GET /s/:token tells anyone holding the link.
+type SharedFile struct {
+	FileID           int64  `json:"file_id"`
+	Filename         string `json:"filename"`
+	MIMEType         string `json:"mime_type"`
+	AllowDownload    bool   `json:"allow_download"`
+	PreviewAvailable bool   `json:"preview_available"`
+	DownloadURL      string `json:"download_url"`
+	PreviewURL       string `json:"preview_url"`
+	// DownloadsLeft is how many downloads the link has left, nil for no
+	// cap.
+	DownloadsLeft *int `json:"downloads_left,omitempty"`
+}
+
+// SharedFolder is what GET /s/:token/tree tells anyone holding a folder
+// link: everything under the folder, with paths relative to it. Paths are
+// the ones the folder's ZIP uses.
+type SharedFolder struct {
+	FolderID      int64              `json:"folder_id"`
+	Name          string             `json:"name"`
+	AllowDownload bool               `json:"allow_download"`
+	Folders       []SharedSubfolder  `json:"folders"`
+	Files         []SharedFolderFile `json:"files"`
+	// DownloadURL is the ZIP of the whole folder. Add file and folder
+	// query parameters, repeated, for a ZIP of just those.
+	DownloadURL   string `json:"download_url"`
+	DownloadsLeft *int   `json:"downloads_left,omitempty"`
+}
+
+type SharedSubfolder struct {
+	ID       int64  `json:"id"`
+	ParentID int64  `json:"parent_id"`
+	Name     string `json:"name"`
+	// Path ends in a slash.
+	Path string `json:"path"`
+}
+
+type SharedFolderFile struct {
+	ID        int64     `json:"id"`
+	FolderID  int64     `json:"folder_id"`
+	Filename  string    `json:"filename"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	MIMEType  string    `json:"mime_type"`
+	CreatedAt time.Time `json:"created_at"`
+}
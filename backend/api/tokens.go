@@ -0,0 +1,55 @@
+package api
+
+import "time"
+
+// Scopes of personal access tokens. A token can only call the routes its
+// scopes cover; logins (access tokens from /login) have them all.
+const (
+	ScopeFilesRead    = "files:read"
+	ScopeFilesWrite   = "files:write"
+	ScopeSharesManage = "shares:manage"
+	ScopeAdmin        = "admin"
+)
+
+// Scopes lists every scope, in the order they are documented.
+var Scopes = []string{ScopeFilesRead, ScopeFilesWrite, ScopeSharesManage, ScopeAdmin}
+
+// PersonalAccessTokenPrefix starts every personal access token, so they can
+// be told apart from JWTs and found by secret scanners.
+const PersonalAccessTokenPrefix = "bkp_"
+
+// PersonalAccessToken describes a token for scripts and CI jobs. The token
+// itself is only returned once, on creation, as part of
+// NewPersonalAccessToken.
+type PersonalAccessToken struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Prefix is the start of the token, to recognize it by.
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	// FolderID, when set, restricts the token to that folder and its
+	// subfolders.
+	FolderID   *int64     `json:"folder_id"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresAt is optional; a token without it works until revoked.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	FolderID  *int64     `json:"folder_id,omitempty"`
+}
+
+type NewPersonalAccessToken struct {
+	PersonalAccessToken
+	Token   string `json:"token"`
+	Message string `json:"message"`
+}
+
+type PersonalAccessTokenList struct {
+	Tokens []PersonalAccessToken `json:"tokens"`
+}
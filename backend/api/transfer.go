@@ -0,0 +1,33 @@
+package api
+
+// TransferRequest hands a file or folder, or all of a departing member's
+// files, to the user with Email in the same organization.
+type TransferRequest struct {
+	Email string `json:"email"`
+	// KeepRole, when set, is the role the previous owner keeps on a
+	// transferred file or folder. It does not apply to bulk transfers.
+	KeepRole string `json:"keep_role,omitempty"`
+}
+
+// Transfer reports what an ownership transfer moved and both users'
+// storage after it.
+type Transfer struct {
+	Files   int64 `json:"files"`
+	Folders int64 `json:"folders"`
+	// Bytes is the size of the live files moved, which now counts against
+	// the new owner's quota.
+	Bytes int64 `json:"bytes"`
+	// FolderID is the folder a bulk transfer put everything in.
+	FolderID *int64 `json:"folder_id,omitempty"`
+	From     Usage  `json:"from"`
+	To       Usage  `json:"to"`
+}
+
+// Usage is a user's storage: the live files they own against their quota
+// (0 for no quota).
+type Usage struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Used     int64  `json:"used"`
+	Quota    int64  `json:"quota"`
+}
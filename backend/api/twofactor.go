@@ -0,0 +1,61 @@
+package api
+
+import "time"
+
+// TwoFactorChallenge is what POST /login returns instead of a LoginResponse
+// when the user has two-factor authentication on. The token is exchanged,
+// together with a code, at POST /login/2fa before ExpiresAt.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	TwoFactorToken    string    `json:"two_factor_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// TwoFactorLoginRequest completes a login. Code is a six-digit code from
+// the authenticator app or one of the recovery codes.
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	Code           string `json:"code"`
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+	// Required is set when an admin policy requires two-factor
+	// authentication for the user's role.
+	Required bool `json:"required"`
+}
+
+// TwoFactorEnrollment is the secret to add to an authenticator app, also as
+// an otpauth:// URI for QR codes. It takes effect once POST /2fa/enable
+// confirms a code generated from it.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// DisableTwoFactorRequest needs the password too, for accounts that have
+// one.
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// RecoveryCodes are shown once; each can replace a code from the app one
+// time.
+type RecoveryCodes struct {
+	Codes   []string `json:"codes"`
+	Message string   `json:"message"`
+}
+
+// SecuritySettings are the organization's settings at /admin/security.
+type SecuritySettings struct {
+	// RequireAdminTwoFactor withholds admin rights from admins who have
+	// not turned on two-factor authentication.
+	RequireAdminTwoFactor bool `json:"require_admin_two_factor"`
+}
@@ -0,0 +1,147 @@
+package api
+
+import "time"
+
+type SignupRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	// Invite is the token from an invitation to an organization, sent to
+	// Email. Without one the user joins the default organization.
+	Invite string `json:"invite,omitempty"`
+}
+
+type SignupResponse struct {
+	Message string `json:"message"`
+	UserID  int64  `json:"user_id"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// EmailTokenRequest carries a token from an email link, as for
+// POST /verify-email.
+type EmailTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// PasswordResetRequest asks for a reset link. The answer is the same
+// whether or not an account uses the email.
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// Lockout is a username or IP whose login attempts are refused after too
+// many failures, for RetryAfter more seconds.
+type Lockout struct {
+	Username   string `json:"username,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	Failures   int64  `json:"failures"`
+	Locked     bool   `json:"locked"`
+	RetryAfter int64  `json:"retry_after"`
+}
+
+type LockoutList struct {
+	Lockouts []Lockout `json:"lockouts"`
+}
+
+// UnlockRequest names the username, IP or both to unlock.
+type UnlockRequest struct {
+	Username string `json:"username,omitempty"`
+	IP       string `json:"ip,omitempty"`
+}
+
+type LoginResponse struct {
+	TokenPair
+	User User `json:"user"`
+}
+
+// TokenPair is a short-lived access token, sent as a Bearer token, and the
+// refresh token that replaces it via POST /refresh. A refresh token can be
+// used once; each refresh returns a new pair.
+type TokenPair struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LogoutRequest is the optional body of POST /logout. Without a refresh
+// token only the access token making the request is revoked.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// TokenInfo is the body of GET /verify-token.
+type TokenInfo struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Status   string `json:"status"`
+}
+
+// AccessKey is an S3 access key. The secret is only returned once, on
+// creation, as part of NewAccessKey.
+type AccessKey struct {
+	ID         int64      `json:"id"`
+	AccessKey  string     `json:"access_key"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type NewAccessKey struct {
+	AccessKey
+	SecretKey string `json:"secret_key"`
+	Message   string `json:"message"`
+}
+
+type AccessKeyList struct {
+	AccessKeys []AccessKey `json:"access_keys"`
+}
+
+// Stats is the caller's storage usage, in bytes.
+type Stats struct {
+	TotalUsed   int64   `json:"total_used"`
+	Quota       int64   `json:"quota"`
+	UsedPercent float64 `json:"used_percent"`
+	// Breakdown maps MIME types to the bytes stored under them.
+	Breakdown map[string]int64 `json:"breakdown"`
+	TrashSize int64            `json:"trash_size"`
+	// Savings is what deduplication saves across the caller's organization.
+	Savings int64 `json:"savings"`
+}
+
+// AdminStats covers the admin's organization.
+type AdminStats struct {
+	TotalStorage   int64 `json:"total_storage"`
+	TotalUsers     int64 `json:"total_users"`
+	TotalDownloads int64 `json:"total_downloads"`
+}
+
+type AuditLog struct {
+	Action     string    `json:"action"`
+	ObjectType string    `json:"object_type"`
+	ObjectID   int64     `json:"object_id"`
+	Meta       *string   `json:"meta"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AuditLogList struct {
+	Logs []AuditLog `json:"logs"`
+}
@@ -0,0 +1,325 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+func (c *Client) Health(ctx context.Context) (*api.Health, error) {
+	return fetch[api.Health](ctx, c, "GET", "/health", nil)
+}
+
+func (c *Client) Signup(ctx context.Context, in api.SignupRequest) (*api.SignupResponse, error) {
+	return fetch[api.SignupResponse](ctx, c, "POST", "/signup", in)
+}
+
+// Login exchanges a username and password for a token pair, which the
+// client uses from then on. For users with two-factor authentication it
+// returns a *TwoFactorRequired error; finish with LoginTwoFactor.
+func (c *Client) Login(ctx context.Context, username, password string) (*api.LoginResponse, error) {
+	raw, err := fetch[json.RawMessage](ctx, c, "POST", "/login", api.LoginRequest{Username: username, Password: password})
+	if err != nil {
+		return nil, err
+	}
+	var challenge api.TwoFactorChallenge
+	if err := json.Unmarshal(*raw, &challenge); err != nil {
+		return nil, err
+	}
+	if challenge.TwoFactorRequired {
+		return nil, &TwoFactorRequired{Challenge: challenge}
+	}
+	var out api.LoginResponse
+	if err := json.Unmarshal(*raw, &out); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.setTokens(out.TokenPair)
+	c.mu.Unlock()
+	return &out, nil
+}
+
+// TwoFactorRequired is returned by Login when the password was right but
+// the user also needs a code from their authenticator app.
+type TwoFactorRequired struct {
+	Challenge api.TwoFactorChallenge
+}
+
+func (e *TwoFactorRequired) Error() string { return "two-factor authentication code required" }
+
+// LoginTwoFactor finishes a login with the challenge from
+// TwoFactorRequired and a code from the app or a recovery code.
+func (c *Client) LoginTwoFactor(ctx context.Context, challenge api.TwoFactorChallenge, code string) (*api.LoginResponse, error) {
+	out, err := fetch[api.LoginResponse](ctx, c, "POST", "/login/2fa",
+		api.TwoFactorLoginRequest{TwoFactorToken: challenge.TwoFactorToken, Code: code})
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.setTokens(out.TokenPair)
+	c.mu.Unlock()
+	return out, nil
+}
+
+// Refresh exchanges the client's refresh token for a new token pair. Do
+// calls it as needed, so it is rarely called directly.
+func (c *Client) Refresh(ctx context.Context) (*api.TokenPair, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refresh(ctx)
+}
+
+// refreshFrom refreshes the token unless another request already replaced
+// used, and returns the current token.
+func (c *Client) refreshFrom(ctx context.Context, used string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Token != used {
+		return c.Token, nil
+	}
+	if _, err := c.refresh(ctx); err != nil {
+		return "", err
+	}
+	return c.Token, nil
+}
+
+// refresh is called with c.mu held. It bypasses Do, which would try to
+// refresh again.
+func (c *Client) refresh(ctx context.Context) (*api.TokenPair, error) {
+	if c.RefreshToken == "" {
+		return nil, errors.New("client: no refresh token")
+	}
+	data, err := json.Marshal(api.RefreshRequest{RefreshToken: c.RefreshToken})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/refresh", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out api.TokenPair
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	c.setTokens(out)
+	return &out, nil
+}
+
+func (c *Client) setTokens(t api.TokenPair) {
+	c.Token, c.RefreshToken, c.Expiry = t.Token, t.RefreshToken, t.ExpiresAt
+	if c.OnRefresh != nil {
+		c.OnRefresh(t)
+	}
+}
+
+// Logout revokes the client's token and its session, then forgets them.
+func (c *Client) Logout(ctx context.Context) (*api.Message, error) {
+	out, err := fetch[api.Message](ctx, c, "POST", "/logout", api.LogoutRequest{RefreshToken: c.RefreshToken})
+	if err != nil {
+		return nil, err
+	}
+	c.forget()
+	return out, nil
+}
+
+// LogoutAll revokes every token of the caller, on all devices.
+func (c *Client) LogoutAll(ctx context.Context) (*api.Message, error) {
+	out, err := fetch[api.Message](ctx, c, "POST", "/logout-all", nil)
+	if err != nil {
+		return nil, err
+	}
+	c.forget()
+	return out, nil
+}
+
+func (c *Client) forget() {
+	c.mu.Lock()
+	c.Token, c.RefreshToken, c.Expiry = "", "", time.Time{}
+	c.mu.Unlock()
+}
+
+func (c *Client) VerifyToken(ctx context.Context) (*api.TokenInfo, error) {
+	return fetch[api.TokenInfo](ctx, c, "GET", "/verify-token", nil)
+}
+
+func (c *Client) Stats(ctx context.Context) (*api.Stats, error) {
+	return fetch[api.Stats](ctx, c, "GET", "/stats", nil)
+}
+
+// AuditLogs returns the caller's 100 most recent audit entries.
+func (c *Client) AuditLogs(ctx context.Context) ([]api.AuditLog, error) {
+	out, err := fetch[api.AuditLogList](ctx, c, "GET", "/audit-logs", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Logs, nil
+}
+
+// AdminFiles lists the files of everyone in the caller's organization. It
+// needs the admin role.
+func (c *Client) AdminFiles(ctx context.Context) ([]api.File, error) {
+	out, err := fetch[api.FileList](ctx, c, "GET", "/admin/files", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Files, nil
+}
+
+// AdminStats needs the admin role.
+func (c *Client) AdminStats(ctx context.Context) (*api.AdminStats, error) {
+	return fetch[api.AdminStats](ctx, c, "GET", "/admin/stats", nil)
+}
+
+// CreateAccessKey creates an S3 access key; its secret is only returned
+// here.
+func (c *Client) CreateAccessKey(ctx context.Context) (*api.NewAccessKey, error) {
+	return fetch[api.NewAccessKey](ctx, c, "POST", "/access-keys", nil)
+}
+
+func (c *Client) AccessKeys(ctx context.Context) ([]api.AccessKey, error) {
+	out, err := fetch[api.AccessKeyList](ctx, c, "GET", "/access-keys", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.AccessKeys, nil
+}
+
+func (c *Client) DeleteAccessKey(ctx context.Context, id int64) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", fmt.Sprintf("/access-keys/%d", id), nil)
+}
+
+// VerifyEmail redeems the token from a verification email.
+func (c *Client) VerifyEmail(ctx context.Context, token string) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "POST", "/verify-email", api.EmailTokenRequest{Token: token})
+}
+
+func (c *Client) ResendVerificationEmail(ctx context.Context) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "POST", "/verify-email/resend", nil)
+}
+
+// RequestPasswordReset has a reset link mailed to the account with email.
+func (c *Client) RequestPasswordReset(ctx context.Context, email string) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "POST", "/password-reset", api.PasswordResetRequest{Email: email})
+}
+
+// ResetPassword sets a new password with the token from a reset email. It
+// ends every session, so log in again afterwards.
+func (c *Client) ResetPassword(ctx context.Context, token, password string) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "POST", "/password-reset/confirm",
+		api.PasswordResetConfirmRequest{Token: token, Password: password})
+}
+
+func (c *Client) TwoFactorStatus(ctx context.Context) (*api.TwoFactorStatus, error) {
+	return fetch[api.TwoFactorStatus](ctx, c, "GET", "/2fa", nil)
+}
+
+// EnrollTwoFactor starts turning on two-factor authentication; confirm
+// with a code from the secret using EnableTwoFactor.
+func (c *Client) EnrollTwoFactor(ctx context.Context) (*api.TwoFactorEnrollment, error) {
+	return fetch[api.TwoFactorEnrollment](ctx, c, "POST", "/2fa/enroll", nil)
+}
+
+// EnableTwoFactor confirms enrollment and returns the recovery codes, which
+// are only returned here.
+func (c *Client) EnableTwoFactor(ctx context.Context, code string) (*api.RecoveryCodes, error) {
+	return fetch[api.RecoveryCodes](ctx, c, "POST", "/2fa/enable", api.TwoFactorCodeRequest{Code: code})
+}
+
+func (c *Client) DisableTwoFactor(ctx context.Context, in api.DisableTwoFactorRequest) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "POST", "/2fa/disable", in)
+}
+
+func (c *Client) RegenerateRecoveryCodes(ctx context.Context, code string) (*api.RecoveryCodes, error) {
+	return fetch[api.RecoveryCodes](ctx, c, "POST", "/2fa/recovery-codes", api.TwoFactorCodeRequest{Code: code})
+}
+
+// AdminLockouts lists usernames and IPs blocked after failed logins. It
+// needs the admin role.
+func (c *Client) AdminLockouts(ctx context.Context) ([]api.Lockout, error) {
+	out, err := fetch[api.LockoutList](ctx, c, "GET", "/admin/lockouts", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Lockouts, nil
+}
+
+// AdminUnlock clears the failed logins of a username, an IP or both.
+func (c *Client) AdminUnlock(ctx context.Context, in api.UnlockRequest) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "POST", "/admin/unlock", in)
+}
+
+// SecuritySettings needs the admin role.
+func (c *Client) SecuritySettings(ctx context.Context) (*api.SecuritySettings, error) {
+	return fetch[api.SecuritySettings](ctx, c, "GET", "/admin/security", nil)
+}
+
+func (c *Client) UpdateSecuritySettings(ctx context.Context, in api.SecuritySettings) (*api.SecuritySettings, error) {
+	return fetch[api.SecuritySettings](ctx, c, "PUT", "/admin/security", in)
+}
+
+// CreateToken creates a personal access token; the token is only returned
+// this once. Use it as the client's Token, without a RefreshToken.
+func (c *Client) CreateToken(ctx context.Context, in api.CreateTokenRequest) (*api.NewPersonalAccessToken, error) {
+	return fetch[api.NewPersonalAccessToken](ctx, c, "POST", "/tokens", in)
+}
+
+// Tokens lists the caller's personal access tokens, revoked ones included.
+func (c *Client) Tokens(ctx context.Context) ([]api.PersonalAccessToken, error) {
+	out, err := fetch[api.PersonalAccessTokenList](ctx, c, "GET", "/tokens", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Tokens, nil
+}
+
+func (c *Client) RevokeToken(ctx context.Context, id int64) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", fmt.Sprintf("/tokens/%d", id), nil)
+}
+
+// Events is the stream of change notifications from GET /ws/stats.
+type Events struct {
+	conn *websocket.Conn
+}
+
+// Events connects to the change notifications of the caller's
+// organization.
+func (c *Client) Events(ctx context.Context) (*Events, error) {
+	u := "ws" + strings.TrimPrefix(c.BaseURL, "http") + "/ws/stats"
+	header := http.Header{}
+	c.mu.Lock()
+	if c.Token != "" {
+		header.Set("Authorization", "Bearer "+c.Token)
+	}
+	c.mu.Unlock()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u, header)
+	if err != nil {
+		return nil, err
+	}
+	return &Events{conn: conn}, nil
+}
+
+// Next blocks until the next event arrives.
+func (e *Events) Next() (api.Event, error) {
+	var ev api.Event
+	if err := e.conn.ReadJSON(&ev); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+func (e *Events) Close() error { return e.conn.Close() }
@@ -0,0 +1,230 @@
+// Package client is a Go client for the HTTP API. Requests and responses
+// are the types of package api, which the server encodes with, so a
+// change to either side that breaks the other fails to compile.
+//
+// There is a method for every route of the main listener; /dav and the S3
+// gateway speak WebDAV and S3 and are meant for clients of those
+// protocols.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// ErrVersion is returned when the server speaks another api.Version.
+var ErrVersion = errors.New("client: server speaks another API version")
+
+// Client calls the API on BaseURL. Its fields may be changed between
+// calls but not during one.
+//
+// Access tokens are short-lived. With a RefreshToken, the client refreshes
+// the token shortly before Expiry, and once more when the server rejects
+// it, retrying the request when its body can be replayed.
+type Client struct {
+	// BaseURL is the server's address, e.g. http://localhost:8080.
+	BaseURL string
+	// Token is sent as a bearer token with every request; Login sets it.
+	Token string
+	// RefreshToken and Expiry are set by Login and by each refresh.
+	RefreshToken string
+	Expiry       time.Time
+	// OnRefresh, when set, is called with every new token pair, e.g. to
+	// store it. The previous refresh token no longer works by then.
+	OnRefresh func(api.TokenPair)
+	// HTTPClient sends the requests. Transfers take as long as they need,
+	// so it should not have an overall timeout.
+	HTTPClient *http.Client
+
+	// mu serializes refreshes, since a refresh token works only once.
+	mu sync.Mutex
+}
+
+// refreshMargin is how long before Expiry the token is refreshed.
+const refreshMargin = 30 * time.Second
+
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Error is a 4xx or 5xx response.
+type Error struct {
+	StatusCode int
+	Body       api.Error
+}
+
+func (e *Error) Error() string {
+	msg := e.Body.Error
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Body.Path != "" {
+		msg = e.Body.Path + ": " + msg
+	}
+	return fmt.Sprintf("%s (HTTP %d)", msg, e.StatusCode)
+}
+
+// StatusCode returns the HTTP status of an *Error in err's chain, or 0.
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+func IsNotFound(err error) bool { return StatusCode(err) == http.StatusNotFound }
+
+// NewRequest builds a request for path on the server, authenticated with
+// the client's token.
+func (c *Client) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	token := c.Token
+	c.mu.Unlock()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// Do sends req, turning error statuses into an *Error. The caller closes
+// the body of a successful response.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	used := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if used == "" || c.RefreshToken == "" {
+		return c.send(req)
+	}
+
+	c.mu.Lock()
+	stale := !c.Expiry.IsZero() && time.Until(c.Expiry) < refreshMargin
+	c.mu.Unlock()
+	if stale {
+		if token, err := c.refreshFrom(req.Context(), used); err == nil {
+			req.Header.Set("Authorization", "Bearer "+token)
+			used = token
+		}
+	}
+
+	resp, err := c.send(req)
+	if StatusCode(err) != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+	token, rerr := c.refreshFrom(req.Context(), used)
+	if rerr != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, rerr = req.GetBody(); rerr != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return c.send(retry)
+}
+
+// send is Do without refreshing.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if v := resp.Header.Get(api.VersionHeader); v != "" && v != api.Version {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s, client %s", ErrVersion, v, api.Version)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		e := &Error{StatusCode: resp.StatusCode}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		_ = json.Unmarshal(data, &e.Body)
+		return nil, e
+	}
+	return resp, nil
+}
+
+// call sends in as a JSON body (when not nil) and decodes the response
+// into out (when not nil).
+func (c *Client) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := c.NewRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.decode(req, out)
+}
+
+// decode sends req and decodes the response into out (when not nil).
+func (c *Client) decode(req *http.Request, out any) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// fetch calls the API and decodes the response into a new T.
+func fetch[T any](ctx context.Context, c *Client, method, path string, in any) (*T, error) {
+	out := new(T)
+	if err := c.call(ctx, method, path, in, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// open GETs path, asking for the content from offset on. It returns the
+// body and the offset the body actually starts at, which is 0 when the
+// server sent the whole content.
+func (c *Client) open(ctx context.Context, path string, offset int64) (io.ReadCloser, int64, error) {
+	req, err := c.NewRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		offset = 0
+	}
+	return resp.Body, offset, nil
+}
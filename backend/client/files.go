@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// UploadFile is one file of a form upload.
+type UploadFile struct {
+	Name    string
+	Content io.Reader
+	// ContentType is checked against the detected type when set.
+	ContentType string
+}
+
+// UploadOptions apply to every file of an upload.
+type UploadOptions struct {
+	// FolderID is the destination folder, nil for the root.
+	FolderID *int64
+	Tags     []string
+}
+
+// Upload sends one file as a form upload. A live file of the same name in
+// the folder gets it as a new version.
+func (c *Client) Upload(ctx context.Context, file UploadFile, opts *UploadOptions) (*api.UploadResult, error) {
+	var out api.UploadResult
+	if err := c.upload(ctx, "/upload", "file", []UploadFile{file}, opts, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MultiUpload sends several files in one request. Files the server rejects
+// are reported in the result rather than failing the call.
+func (c *Client) MultiUpload(ctx context.Context, files []UploadFile, opts *UploadOptions) ([]api.UploadResult, error) {
+	var out api.MultiUploadResult
+	if err := c.upload(ctx, "/multi-upload", "files", files, opts, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// upload streams a multipart form, so files are never held in memory.
+func (c *Client) upload(ctx context.Context, path, field string, files []UploadFile, opts *UploadOptions, out any) error {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeUploadForm(mw, field, files, opts))
+	}()
+	defer pr.Close()
+
+	req, err := c.NewRequest(ctx, "POST", path, pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return c.decode(req, out)
+}
+
+func writeUploadForm(mw *multipart.Writer, field string, files []UploadFile, opts *UploadOptions) error {
+	if opts != nil {
+		if opts.FolderID != nil {
+			if err := mw.WriteField("folder_id", strconv.FormatInt(*opts.FolderID, 10)); err != nil {
+				return err
+			}
+		}
+		if len(opts.Tags) > 0 {
+			if err := mw.WriteField("tags", strings.Join(opts.Tags, ",")); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range files {
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, field, f.Name))
+		if f.ContentType != "" {
+			h.Set("Content-Type", f.ContentType)
+		}
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, f.Content); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func (c *Client) ListFiles(ctx context.Context) ([]api.File, error) {
+	out, err := fetch[api.FileList](ctx, c, "GET", "/files", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Files, nil
+}
+
+// SearchQuery filters GET /files/search; zero fields do not filter.
+type SearchQuery struct {
+	// Name matches part of the filename, case-insensitively.
+	Name     string
+	MIMEType string
+	From, To time.Time
+	MinSize  int64
+	MaxSize  int64
+	// Tags must each match part of one of the file's tags.
+	Tags     []string
+	FolderID *int64
+	// SortBy is "date" (the default), "name", "size" or "mime"; Order is
+	// "asc" or "desc" (the default).
+	SortBy string
+	Order  string
+	Page   int
+	Limit  int
+}
+
+func (q *SearchQuery) values() url.Values {
+	v := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	set("name", q.Name)
+	set("mime", q.MIMEType)
+	if !q.From.IsZero() {
+		v.Set("from", q.From.Format(time.RFC3339))
+	}
+	if !q.To.IsZero() {
+		v.Set("to", q.To.Format(time.RFC3339))
+	}
+	if q.MinSize > 0 {
+		v.Set("min_size", strconv.FormatInt(q.MinSize, 10))
+	}
+	if q.MaxSize > 0 {
+		v.Set("max_size", strconv.FormatInt(q.MaxSize, 10))
+	}
+	set("tags", strings.Join(q.Tags, ","))
+	if q.FolderID != nil {
+		v.Set("folder_id", strconv.FormatInt(*q.FolderID, 10))
+	}
+	set("sort_by", q.SortBy)
+	set("order", q.Order)
+	if q.Page > 0 {
+		v.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v
+}
+
+func (c *Client) SearchFiles(ctx context.Context, q SearchQuery) (*api.SearchResult, error) {
+	return fetch[api.SearchResult](ctx, c, "GET", "/files/search?"+q.values().Encode(), nil)
+}
+
+// Download fetches a file's content from offset on. The returned offset
+// is where the body starts, 0 when the server sent everything.
+func (c *Client) Download(ctx context.Context, fileID, offset int64) (io.ReadCloser, int64, error) {
+	return c.open(ctx, fmt.Sprintf("/files/%d/download", fileID), offset)
+}
+
+// Preview fetches a file for display, when the server can preview it.
+func (c *Client) Preview(ctx context.Context, fileID int64) (io.ReadCloser, error) {
+	body, _, err := c.open(ctx, fmt.Sprintf("/files/%d/preview", fileID), 0)
+	return body, err
+}
+
+// MoveFile moves a file to folderID, nil being the root.
+func (c *Client) MoveFile(ctx context.Context, fileID int64, folderID *int64) (*api.FileMoved, error) {
+	return fetch[api.FileMoved](ctx, c, "PATCH", fmt.Sprintf("/files/%d/move", fileID), api.MoveFileRequest{FolderID: folderID})
+}
+
+// MoveFiles moves several files at once; either all move or none do.
+func (c *Client) MoveFiles(ctx context.Context, fileIDs []int64, folderID *int64) (*api.FilesMoved, error) {
+	return fetch[api.FilesMoved](ctx, c, "PATCH", "/files/move-bulk", api.BulkMoveRequest{FileIDs: fileIDs, FolderID: folderID})
+}
+
+func (c *Client) Tags(ctx context.Context, fileID int64) ([]string, error) {
+	out, err := fetch[api.FileTags](ctx, c, "GET", fmt.Sprintf("/files/%d/tags", fileID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Tags, nil
+}
+
+// SetTags replaces a file's tags.
+func (c *Client) SetTags(ctx context.Context, fileID int64, tags []string) ([]string, error) {
+	out, err := fetch[api.FileTags](ctx, c, "PATCH", fmt.Sprintf("/files/%d/tags", fileID), api.TagsRequest{Tags: tags})
+	if err != nil {
+		return nil, err
+	}
+	return out.Tags, nil
+}
+
+// AddEditor lets the user with email edit a file the caller owns.
+func (c *Client) AddEditor(ctx context.Context, fileID int64, email string) (*api.EditorStatus, error) {
+	return fetch[api.EditorStatus](ctx, c, "POST", fmt.Sprintf("/files/%d/add-editor", fileID), api.EditorRequest{Email: email})
+}
+
+func (c *Client) RemoveEditor(ctx context.Context, fileID int64, email string) (*api.EditorStatus, error) {
+	return fetch[api.EditorStatus](ctx, c, "DELETE", fmt.Sprintf("/files/%d/remove-editor", fileID), api.EditorRequest{Email: email})
+}
+
+// FileAccess lists the owner of a file and the roles granted on it.
+func (c *Client) FileAccess(ctx context.Context, fileID int64) (*api.FileAccess, error) {
+	return fetch[api.FileAccess](ctx, c, "GET", fmt.Sprintf("/files/%d/access", fileID), nil)
+}
+
+// GrantAccess gives the user with email a role on a file, one of
+// api.FileRoles, replacing the role they had.
+func (c *Client) GrantAccess(ctx context.Context, fileID int64, email, role string) (*api.AccessEntry, error) {
+	return fetch[api.AccessEntry](ctx, c, "PUT", fmt.Sprintf("/files/%d/access", fileID), api.GrantAccessRequest{Email: email, Role: role})
+}
+
+// RevokeAccess takes away a user's role on a file.
+func (c *Client) RevokeAccess(ctx context.Context, fileID, userID int64) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", fmt.Sprintf("/files/%d/access/%d", fileID, userID), nil)
+}
+
+// GrantGroupAccess gives a group the caller belongs to a role on a file.
+func (c *Client) GrantGroupAccess(ctx context.Context, fileID, groupID int64, role string) (*api.AccessEntry, error) {
+	return fetch[api.AccessEntry](ctx, c, "PUT", fmt.Sprintf("/files/%d/access", fileID), api.GrantAccessRequest{GroupID: groupID, Role: role})
+}
+
+func (c *Client) RevokeGroupAccess(ctx context.Context, fileID, groupID int64) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", fmt.Sprintf("/files/%d/access/groups/%d", fileID, groupID), nil)
+}
+
+// TransferFile hands a file the caller owns to the user with email. With
+// keepRole set, the caller keeps that role on it.
+func (c *Client) TransferFile(ctx context.Context, fileID int64, email, keepRole string) (*api.Transfer, error) {
+	return fetch[api.Transfer](ctx, c, "POST", fmt.Sprintf("/files/%d/transfer", fileID), api.TransferRequest{Email: email, KeepRole: keepRole})
+}
+
+// Versions lists a file's versions, newest first.
+func (c *Client) Versions(ctx context.Context, fileID int64) ([]api.FileVersion, error) {
+	out, err := fetch[api.FileVersions](ctx, c, "GET", fmt.Sprintf("/files/%d/versions", fileID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Versions, nil
+}
+
+// RestoreVersion makes an earlier version the file's content again.
+func (c *Client) RestoreVersion(ctx context.Context, fileID int64, version int) (*api.VersionRestored, error) {
+	return fetch[api.VersionRestored](ctx, c, "POST", fmt.Sprintf("/files/%d/restore-version/%d", fileID, version), nil)
+}
+
+func (c *Client) TrashFile(ctx context.Context, fileID int64) (*api.FileStatus, error) {
+	return fetch[api.FileStatus](ctx, c, "PATCH", fmt.Sprintf("/files/%d/trash", fileID), nil)
+}
+
+func (c *Client) RestoreFile(ctx context.Context, fileID int64) (*api.FileStatus, error) {
+	return fetch[api.FileStatus](ctx, c, "PATCH", fmt.Sprintf("/files/%d/restore", fileID), nil)
+}
+
+// DeleteFile permanently deletes a file from the trash.
+func (c *Client) DeleteFile(ctx context.Context, fileID int64) (*api.FileStatus, error) {
+	return fetch[api.FileStatus](ctx, c, "DELETE", fmt.Sprintf("/trash/%d", fileID), nil)
+}
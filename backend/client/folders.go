@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// CreateFolder creates a folder in parentID, nil being the root.
+func (c *Client) CreateFolder(ctx context.Context, name string, parentID *int64) (*api.FolderStatus, error) {
+	return fetch[api.FolderStatus](ctx, c, "POST", "/folders", api.CreateFolderRequest{Name: name, ParentID: parentID})
+}
+
+// ListFolders lists all of the caller's folders, most recent first.
+func (c *Client) ListFolders(ctx context.Context) ([]api.Folder, error) {
+	out, err := fetch[api.FolderList](ctx, c, "GET", "/folders", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Folders, nil
+}
+
+func (c *Client) FolderTree(ctx context.Context) ([]*api.FolderNode, error) {
+	out, err := fetch[api.FolderTree](ctx, c, "GET", "/folders/tree", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Tree, nil
+}
+
+// FolderFiles lists the live files directly in a folder.
+func (c *Client) FolderFiles(ctx context.Context, folderID int64) ([]api.File, error) {
+	out, err := fetch[api.FileList](ctx, c, "GET", fmt.Sprintf("/folders/%d/files", folderID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Files, nil
+}
+
+func (c *Client) RenameFolder(ctx context.Context, folderID int64, name string) (*api.FolderStatus, error) {
+	return fetch[api.FolderStatus](ctx, c, "PATCH", fmt.Sprintf("/folders/%d", folderID), api.RenameFolderRequest{Name: name})
+}
+
+// MoveFolder moves a folder into parentID, nil being the root.
+func (c *Client) MoveFolder(ctx context.Context, folderID int64, parentID *int64) (*api.FolderMoved, error) {
+	return fetch[api.FolderMoved](ctx, c, "PATCH", fmt.Sprintf("/folders/%d/move", folderID), api.MoveFolderRequest{NewParentID: parentID})
+}
+
+func (c *Client) TrashFolder(ctx context.Context, folderID int64) (*api.FolderStatus, error) {
+	return fetch[api.FolderStatus](ctx, c, "PATCH", fmt.Sprintf("/folders/%d/trash", folderID), nil)
+}
+
+func (c *Client) RestoreFolder(ctx context.Context, folderID int64) (*api.FolderStatus, error) {
+	return fetch[api.FolderStatus](ctx, c, "PATCH", fmt.Sprintf("/folders/%d/restore", folderID), nil)
+}
+
+// DeleteFolder permanently deletes a trashed folder and its trashed files.
+func (c *Client) DeleteFolder(ctx context.Context, folderID int64) (*api.FolderStatus, error) {
+	return fetch[api.FolderStatus](ctx, c, "DELETE", fmt.Sprintf("/trash/folders/%d", folderID), nil)
+}
+
+func (c *Client) Trash(ctx context.Context) (*api.Trash, error) {
+	return fetch[api.Trash](ctx, c, "GET", "/trash", nil)
+}
+
+// EmptyTrash permanently deletes everything in the trash.
+func (c *Client) EmptyTrash(ctx context.Context) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", "/trash/empty", nil)
+}
+
+// FolderAccess lists the owner of a folder and the roles granted on it and
+// the folders above it.
+func (c *Client) FolderAccess(ctx context.Context, folderID int64) (*api.FolderAccess, error) {
+	return fetch[api.FolderAccess](ctx, c, "GET", fmt.Sprintf("/folders/%d/access", folderID), nil)
+}
+
+// GrantFolderAccess gives the user with email a role on a folder and
+// everything in it.
+func (c *Client) GrantFolderAccess(ctx context.Context, folderID int64, email, role string) (*api.AccessEntry, error) {
+	return fetch[api.AccessEntry](ctx, c, "PUT", fmt.Sprintf("/folders/%d/access", folderID), api.GrantAccessRequest{Email: email, Role: role})
+}
+
+func (c *Client) RevokeFolderAccess(ctx context.Context, folderID, userID int64) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", fmt.Sprintf("/folders/%d/access/%d", folderID, userID), nil)
+}
+
+// GrantFolderGroupAccess gives a group the caller belongs to a role on a
+// folder and everything in it.
+func (c *Client) GrantFolderGroupAccess(ctx context.Context, folderID, groupID int64, role string) (*api.AccessEntry, error) {
+	return fetch[api.AccessEntry](ctx, c, "PUT", fmt.Sprintf("/folders/%d/access", folderID), api.GrantAccessRequest{GroupID: groupID, Role: role})
+}
+
+func (c *Client) RevokeFolderGroupAccess(ctx context.Context, folderID, groupID int64) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", fmt.Sprintf("/folders/%d/access/groups/%d", folderID, groupID), nil)
+}
+
+// TransferFolder hands a folder the caller owns, with everything under it,
+// to the user with email.
+func (c *Client) TransferFolder(ctx context.Context, folderID int64, email, keepRole string) (*api.Transfer, error) {
+	return fetch[api.Transfer](ctx, c, "POST", fmt.Sprintf("/folders/%d/transfer", folderID), api.TransferRequest{Email: email, KeepRole: keepRole})
+}
+
+// SharedWithMe lists the files and folders other users shared with the
+// caller. Files in a shared folder are listed by FolderFiles.
+func (c *Client) SharedWithMe(ctx context.Context) (*api.SharedWithMe, error) {
+	return fetch[api.SharedWithMe](ctx, c, "GET", "/shared", nil)
+}
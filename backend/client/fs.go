@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// CleanPath cleans a path in the caller's storage. Every path is taken
+// from the root, with or without a leading slash.
+func CleanPath(p string) string {
+	parts := strings.FieldsFunc(p, func(r rune) bool { return r == '/' })
+	return "/" + strings.Join(parts, "/")
+}
+
+func fsURL(p string, query url.Values) string {
+	parts := strings.Split(strings.TrimPrefix(CleanPath(p), "/"), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	u := "/fs/" + strings.Join(parts, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// Stat describes the file or folder at p.
+func (c *Client) Stat(ctx context.Context, p string) (*api.Entry, error) {
+	return fetch[api.Entry](ctx, c, "GET", fsURL(p, nil), nil)
+}
+
+// List lists the folder at p, folders first, each sorted by name.
+func (c *Client) List(ctx context.Context, p string) ([]api.Entry, error) {
+	out, err := fetch[api.EntryList](ctx, c, "GET", fsURL(p, url.Values{"op": {"list"}}), nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Entries, nil
+}
+
+// Mkdir creates the folder p; with parents, missing parents are created
+// and an existing folder is not an error.
+func (c *Client) Mkdir(ctx context.Context, p string, parents bool) (*api.FolderStatus, error) {
+	q := url.Values{"op": {"mkdir"}}
+	if parents {
+		q.Set("recursive", "true")
+	}
+	return fetch[api.FolderStatus](ctx, c, "POST", fsURL(p, q), nil)
+}
+
+// Move moves or renames the file or folder at from to the path to.
+func (c *Client) Move(ctx context.Context, from, to string) (*api.PathMoved, error) {
+	return fetch[api.PathMoved](ctx, c, "POST", fsURL(from, url.Values{"op": {"move"}}), api.MoveRequest{To: to})
+}
+
+// Remove moves the file or folder at p to the trash.
+func (c *Client) Remove(ctx context.Context, p string) (*api.PathStatus, error) {
+	return fetch[api.PathStatus](ctx, c, "DELETE", fsURL(p, nil), nil)
+}
+
+type PutOptions struct {
+	// Parents creates missing parent folders.
+	Parents bool
+	Tags    []string
+	// ContentType is checked against the detected type when set.
+	ContentType string
+}
+
+// Put uploads r to the file at p, adding a version when it exists.
+func (c *Client) Put(ctx context.Context, p string, r io.Reader, opts *PutOptions) (*api.UploadResult, error) {
+	q := url.Values{}
+	contentType := "application/octet-stream"
+	if opts != nil {
+		if opts.Parents {
+			q.Set("parents", "true")
+		}
+		if len(opts.Tags) > 0 {
+			q.Set("tags", strings.Join(opts.Tags, ","))
+		}
+		if opts.ContentType != "" {
+			contentType = opts.ContentType
+		}
+	}
+	req, err := c.NewRequest(ctx, "PUT", fsURL(p, q), r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	var out api.UploadResult
+	if err := c.decode(req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Get fetches the content of the file at p from offset on; see Download
+// for the returned offset.
+func (c *Client) Get(ctx context.Context, p string, offset int64) (io.ReadCloser, int64, error) {
+	return c.open(ctx, fsURL(p, url.Values{"op": {"download"}}), offset)
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// Groups lists the groups the caller belongs to.
+func (c *Client) Groups(ctx context.Context) ([]api.Group, error) {
+	out, err := fetch[api.GroupList](ctx, c, "GET", "/groups", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Groups, nil
+}
+
+// Group returns a group the caller belongs to, with its members.
+func (c *Client) Group(ctx context.Context, groupID int64) (*api.GroupDetail, error) {
+	return fetch[api.GroupDetail](ctx, c, "GET", fmt.Sprintf("/groups/%d", groupID), nil)
+}
+
+// CreateGroup creates a group with the caller as its admin.
+func (c *Client) CreateGroup(ctx context.Context, name, description string) (*api.Group, error) {
+	return fetch[api.Group](ctx, c, "POST", "/groups", api.GroupRequest{Name: name, Description: &description})
+}
+
+// UpdateGroup renames a group or changes its description, leaving the
+// fields that are empty or nil.
+func (c *Client) UpdateGroup(ctx context.Context, groupID int64, in api.GroupRequest) (*api.Group, error) {
+	return fetch[api.Group](ctx, c, "PATCH", fmt.Sprintf("/groups/%d", groupID), in)
+}
+
+// DeleteGroup deletes a group, taking away every role granted to it.
+func (c *Client) DeleteGroup(ctx context.Context, groupID int64) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", fmt.Sprintf("/groups/%d", groupID), nil)
+}
+
+// AddGroupMember adds the user with email to a group, or changes whether
+// they administer it.
+func (c *Client) AddGroupMember(ctx context.Context, groupID int64, email string, admin bool) (*api.GroupMember, error) {
+	return fetch[api.GroupMember](ctx, c, "PUT", fmt.Sprintf("/groups/%d/members", groupID), api.GroupMemberRequest{Email: email, IsAdmin: admin})
+}
+
+// RemoveGroupMember removes a member from a group; members can remove
+// themselves to leave.
+func (c *Client) RemoveGroupMember(ctx context.Context, groupID, userID int64) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", fmt.Sprintf("/groups/%d/members/%d", groupID, userID), nil)
+}
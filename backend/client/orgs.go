@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// Organization returns the caller's organization.
+func (c *Client) Organization(ctx context.Context) (*api.Organization, error) {
+	return fetch[api.Organization](ctx, c, "GET", "/org", nil)
+}
+
+// UpdateOrganization renames the caller's organization or changes its
+// quotas, leaving the fields that are nil. It needs the admin role.
+func (c *Client) UpdateOrganization(ctx context.Context, in api.UpdateOrganizationRequest) (*api.Organization, error) {
+	return fetch[api.Organization](ctx, c, "PATCH", "/admin/org", in)
+}
+
+// Members lists the users of the caller's organization. It needs the
+// admin role.
+func (c *Client) Members(ctx context.Context) ([]api.Member, error) {
+	out, err := fetch[api.MemberList](ctx, c, "GET", "/admin/members", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Members, nil
+}
+
+// UpdateMember changes a member's role or quota, leaving the fields that
+// are nil.
+func (c *Client) UpdateMember(ctx context.Context, userID int64, in api.UpdateMemberRequest) (*api.Member, error) {
+	return fetch[api.Member](ctx, c, "PATCH", fmt.Sprintf("/admin/members/%d", userID), in)
+}
+
+// TransferMemberFiles hands everything a member owns to the member with
+// email. It needs the admin role.
+func (c *Client) TransferMemberFiles(ctx context.Context, userID int64, email string) (*api.Transfer, error) {
+	return fetch[api.Transfer](ctx, c, "POST", fmt.Sprintf("/admin/members/%d/transfer", userID), api.TransferRequest{Email: email})
+}
+
+// Invites lists the organization's pending invites.
+func (c *Client) Invites(ctx context.Context) ([]api.Invite, error) {
+	out, err := fetch[api.InviteList](ctx, c, "GET", "/admin/invites", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Invites, nil
+}
+
+// Invite emails email a link to sign up into the caller's organization
+// with role.
+func (c *Client) Invite(ctx context.Context, email, role string) (*api.Invite, error) {
+	return fetch[api.Invite](ctx, c, "POST", "/admin/invites", api.InviteRequest{Email: email, Role: role})
+}
+
+func (c *Client) RevokeInvite(ctx context.Context, inviteID int64) (*api.Message, error) {
+	return fetch[api.Message](ctx, c, "DELETE", fmt.Sprintf("/admin/invites/%d", inviteID), nil)
+}
+
+// Organizations lists every organization. It needs an instance admin.
+func (c *Client) Organizations(ctx context.Context) ([]api.Organization, error) {
+	out, err := fetch[api.OrganizationList](ctx, c, "GET", "/admin/orgs", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Organizations, nil
+}
+
+// CreateOrganization creates an organization and invites its first admin.
+// It needs an instance admin.
+func (c *Client) CreateOrganization(ctx context.Context, in api.CreateOrganizationRequest) (*api.NewOrganization, error) {
+	return fetch[api.NewOrganization](ctx, c, "POST", "/admin/orgs", in)
+}
+
+// UpdateOrganizationAdmin changes any organization. It needs an instance
+// admin.
+func (c *Client) UpdateOrganizationAdmin(ctx context.Context, orgID int64, in api.UpdateOrganizationRequest) (*api.Organization, error) {
+	return fetch[api.Organization](ctx, c, "PATCH", fmt.Sprintf("/admin/orgs/%d", orgID), in)
+}
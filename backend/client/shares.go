@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+type ShareOptions struct {
+	// Expiry is how long the link works, zero for no expiry.
+	Expiry time.Duration
+	// AllowDownload lets the link download the file, not just preview it.
+	AllowDownload bool
+	// Password, MaxDownloads (0 for no cap) and RequireEmail protect the
+	// link; see UnlockShare.
+	Password     string
+	MaxDownloads int
+	RequireEmail bool
+}
+
+// CreateShare creates a public link to a file the caller may share.
+func (c *Client) CreateShare(ctx context.Context, fileID int64, opts ShareOptions) (*api.Share, error) {
+	q, in := opts.request()
+	return fetch[api.Share](ctx, c, "POST", fmt.Sprintf("/share/%d?%s", fileID, q.Encode()), in)
+}
+
+// CreateFolderShare creates a public link to a folder the caller may
+// share, and everything under it.
+func (c *Client) CreateFolderShare(ctx context.Context, folderID int64, opts ShareOptions) (*api.Share, error) {
+	q, in := opts.request()
+	return fetch[api.Share](ctx, c, "POST", fmt.Sprintf("/folders/%d/share?%s", folderID, q.Encode()), in)
+}
+
+// request is the query and body that create a link with opts.
+func (opts ShareOptions) request() (url.Values, any) {
+	q := url.Values{"download": {strconv.FormatBool(opts.AllowDownload)}}
+	if opts.Expiry > 0 {
+		q.Set("expiry", strconv.FormatFloat(opts.Expiry.Hours(), 'f', -1, 64))
+	}
+	var in any
+	if opts.Password != "" || opts.MaxDownloads > 0 || opts.RequireEmail {
+		req := api.CreateShareRequest{Password: opts.Password, RequireEmail: opts.RequireEmail}
+		if opts.MaxDownloads > 0 {
+			req.MaxDownloads = &opts.MaxDownloads
+		}
+		in = req
+	}
+	return q, in
+}
+
+// ListShares lists the links to the caller's files.
+func (c *Client) ListShares(ctx context.Context) ([]api.Share, error) {
+	out, err := fetch[api.ShareList](ctx, c, "GET", "/shares", nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Shares, nil
+}
+
+// FileShares lists the links to a file.
+func (c *Client) FileShares(ctx context.Context, fileID int64) ([]api.Share, error) {
+	out, err := fetch[api.ShareList](ctx, c, "GET", fmt.Sprintf("/files/%d/shares", fileID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Shares, nil
+}
+
+// FolderShares lists the links to a folder.
+func (c *Client) FolderShares(ctx context.Context, folderID int64) ([]api.Share, error) {
+	out, err := fetch[api.ShareList](ctx, c, "GET", fmt.Sprintf("/folders/%d/shares", folderID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Shares, nil
+}
+
+func (c *Client) Share(ctx context.Context, shareID int64) (*api.Share, error) {
+	return fetch[api.Share](ctx, c, "GET", fmt.Sprintf("/shares/%d", shareID), nil)
+}
+
+// UpdateShare changes the fields of a link that are set in in.
+func (c *Client) UpdateShare(ctx context.Context, shareID int64, in api.UpdateShareRequest) (*api.Share, error) {
+	return fetch[api.Share](ctx, c, "PATCH", fmt.Sprintf("/shares/%d", shareID), in)
+}
+
+// ShareStats sums up the visits to a link.
+func (c *Client) ShareStats(ctx context.Context, shareID int64) (*api.ShareStats, error) {
+	return fetch[api.ShareStats](ctx, c, "GET", fmt.Sprintf("/shares/%d/stats", shareID), nil)
+}
+
+func (c *Client) RevokeShare(ctx context.Context, shareID int64) (*api.ShareStatus, error) {
+	return fetch[api.ShareStatus](ctx, c, "DELETE", fmt.Sprintf("/shares/%d", shareID), nil)
+}
+
+// ShareViewers lists the emails a link was unlocked with.
+func (c *Client) ShareViewers(ctx context.Context, shareID int64) ([]api.ShareViewer, error) {
+	out, err := fetch[api.ShareViewerList](ctx, c, "GET", fmt.Sprintf("/shares/%d/viewers", shareID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.Viewers, nil
+}
+
+// UnlockShare passes a protected link's password and the visitor's email,
+// whichever it asks for. The link then opens through a cookie, so
+// HTTPClient needs a cookie jar.
+func (c *Client) UnlockShare(ctx context.Context, token string, in api.UnlockShareRequest) (*api.ShareUnlocked, error) {
+	return fetch[api.ShareUnlocked](ctx, c, "POST", "/s/"+url.PathEscape(token)+"/unlock", in)
+}
+
+// SharedFile describes the file behind a share token. It needs no login.
+func (c *Client) SharedFile(ctx context.Context, token string) (*api.SharedFile, error) {
+	return fetch[api.SharedFile](ctx, c, "GET", "/s/"+url.PathEscape(token), nil)
+}
+
+// DownloadShared fetches a shared file from offset on, when the link
+// allows downloading; see Download for the returned offset.
+func (c *Client) DownloadShared(ctx context.Context, token string, offset int64) (io.ReadCloser, int64, error) {
+	return c.open(ctx, "/s/"+url.PathEscape(token)+"/download", offset)
+}
+
+// SharedFolder lists everything under the folder behind a share token. It
+// needs no login.
+func (c *Client) SharedFolder(ctx context.Context, token string) (*api.SharedFolder, error) {
+	return fetch[api.SharedFolder](ctx, c, "GET", "/s/"+url.PathEscape(token)+"/tree", nil)
+}
+
+// DownloadSharedFolder streams a shared folder as a ZIP, when the link
+// allows downloading. With fileIDs or folderIDs, the ZIP holds just those
+// files and folders.
+func (c *Client) DownloadSharedFolder(ctx context.Context, token string, fileIDs, folderIDs []int64) (io.ReadCloser, error) {
+	q := url.Values{}
+	for _, id := range fileIDs {
+		q.Add("file", strconv.FormatInt(id, 10))
+	}
+	for _, id := range folderIDs {
+		q.Add("folder", strconv.FormatInt(id, 10))
+	}
+	body, _, err := c.open(ctx, "/s/"+url.PathEscape(token)+"/zip?"+q.Encode(), 0)
+	return body, err
+}
+
+func (c *Client) PreviewShared(ctx context.Context, token string) (io.ReadCloser, error) {
+	body, _, err := c.open(ctx, "/s/"+url.PathEscape(token)+"/preview", 0)
+	return body, err
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Resumable uploads go through the server's tus 1.0.0 endpoint: TusCreate
+// returns an upload location, TusPatch appends to it and TusOffset tells
+// where to resume after an interruption. The file is recorded when the
+// last byte arrives.
+
+const tusVersion = "1.0.0"
+
+// TusInfo is what the server's tus endpoint supports.
+type TusInfo struct {
+	Version    string
+	Extensions []string
+	// MaxSize is the largest upload accepted, 0 when unlimited.
+	MaxSize int64
+}
+
+func (c *Client) TusOptions(ctx context.Context) (*TusInfo, error) {
+	req, err := c.NewRequest(ctx, "OPTIONS", "/tus", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	info := &TusInfo{Version: resp.Header.Get("Tus-Version")}
+	if ext := resp.Header.Get("Tus-Extension"); ext != "" {
+		info.Extensions = strings.Split(ext, ",")
+	}
+	info.MaxSize, _ = strconv.ParseInt(resp.Header.Get("Tus-Max-Size"), 10, 64)
+	return info, nil
+}
+
+func (c *Client) tusRequest(ctx context.Context, method, location string, body io.Reader) (*http.Request, error) {
+	req, err := c.NewRequest(ctx, method, location, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Tus-Resumable", tusVersion)
+	return req, nil
+}
+
+// TusCreate starts an upload of size bytes named name and returns its
+// location, a path on the server.
+func (c *Client) TusCreate(ctx context.Context, name string, size int64, opts *UploadOptions) (string, error) {
+	enc := base64.StdEncoding.EncodeToString
+	meta := []string{"filename " + enc([]byte(name))}
+	if opts != nil && opts.FolderID != nil {
+		meta = append(meta, "folder_id "+enc([]byte(strconv.FormatInt(*opts.FolderID, 10))))
+	}
+	if opts != nil && len(opts.Tags) > 0 {
+		meta = append(meta, "tags "+enc([]byte(strings.Join(opts.Tags, ","))))
+	}
+
+	req, err := c.tusRequest(ctx, "POST", "/tus", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Upload-Length", strconv.FormatInt(size, 10))
+	req.Header.Set("Upload-Metadata", strings.Join(meta, ","))
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", errors.New("client: server did not return an upload location")
+	}
+	if u, err := url.Parse(location); err == nil && u.IsAbs() {
+		location = u.RequestURI()
+	}
+	return location, nil
+}
+
+// TusOffset returns how much of an upload the server holds.
+func (c *Client) TusOffset(ctx context.Context, location string) (int64, error) {
+	req, err := c.tusRequest(ctx, "HEAD", location, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+}
+
+// TusPatch sends n bytes of body at offset and returns the offset the
+// server has acknowledged. When the server holds a different offset than
+// the client assumed, that offset is returned instead, so the caller can
+// resume from it.
+func (c *Client) TusPatch(ctx context.Context, location string, offset int64, body io.Reader, n int64) (int64, error) {
+	req, err := c.tusRequest(ctx, "PATCH", location, body)
+	if err != nil {
+		return offset, err
+	}
+	req.ContentLength = n
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	resp, err := c.Do(req)
+	if err != nil {
+		if StatusCode(err) == http.StatusConflict {
+			return c.TusOffset(ctx, location)
+		}
+		return offset, err
+	}
+	resp.Body.Close()
+	return strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+}
+
+// TusCancel abandons an upload.
+func (c *Client) TusCancel(ctx context.Context, location string) error {
+	req, err := c.tusRequest(ctx, "DELETE", location, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
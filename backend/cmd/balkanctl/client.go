@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/client"
+)
+
+func newClient() (*client.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("not logged in, run: balkanctl login")
+	}
+	c := client.New(cfg.Server, cfg.Token)
+	c.HTTPClient = newHTTPClient()
+	c.RefreshToken, c.Expiry = cfg.RefreshToken, cfg.Expiry
+	c.OnRefresh = saveTokens
+	return c, nil
+}
+
+// saveTokens stores a refreshed token pair. It rereads the stored config
+// so that overrides from the environment are not saved with it.
+func saveTokens(t api.TokenPair) {
+	cfg := &config{Server: defaultServer}
+	if err := readState("config.json", cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "balkanctl: could not store the refreshed token:", err)
+		return
+	}
+	cfg.Token, cfg.RefreshToken, cfg.Expiry = t.Token, t.RefreshToken, t.ExpiresAt
+	if err := cfg.save(); err != nil {
+		fmt.Fprintln(os.Stderr, "balkanctl: could not store the refreshed token:", err)
+	}
+}
+
+// newHTTPClient has no overall timeout, since transfers take as long as
+// they need, but gives up on a server that stops answering.
+func newHTTPClient() *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: 5 * time.Minute,
+	}}
+}
+
+// folderID resolves p to a folder id, nil being the root.
+func folderID(ctx context.Context, c *client.Client, p string) (*int64, error) {
+	e, err := c.Stat(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsDir() {
+		return nil, fmt.Errorf("%s is not a folder", e.Path)
+	}
+	return e.ID, nil
+}
+
+func fileID(ctx context.Context, c *client.Client, p string) (int64, error) {
+	e, err := c.Stat(ctx, p)
+	if err != nil {
+		return 0, err
+	}
+	if e.IsDir() {
+		return 0, fmt.Errorf("%s is a folder", e.Path)
+	}
+	return *e.ID, nil
+}
+
+// mkdir creates p and any missing parents.
+func mkdir(ctx context.Context, c *client.Client, p string) error {
+	if client.CleanPath(p) == "/" {
+		return nil
+	}
+	_, err := c.Mkdir(ctx, p, true)
+	return err
+}
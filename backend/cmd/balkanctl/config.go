@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// config is what login stores in the user's config directory. BALKAN_SERVER
+// and BALKAN_TOKEN override it, e.g. for scripts; a token from the
+// environment is used as is, without refreshing.
+type config struct {
+	Server       string    `json:"server"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+	Username     string    `json:"username"`
+}
+
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "balkanctl"), nil
+}
+
+func loadConfig() (*config, error) {
+	cfg := &config{Server: defaultServer}
+	if err := readState("config.json", cfg); err != nil {
+		return nil, err
+	}
+	if v := os.Getenv("BALKAN_SERVER"); v != "" {
+		cfg.Server = v
+	}
+	if v := os.Getenv("BALKAN_TOKEN"); v != "" {
+		cfg.Token, cfg.RefreshToken, cfg.Expiry = v, "", time.Time{}
+	}
+	return cfg, nil
+}
+
+func (c *config) save() error {
+	return writeState("config.json", c)
+}
+
+// readState decodes a JSON file from the config directory into v, leaving v
+// alone when the file does not exist yet.
+func readState(name string, v any) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeState stores v as JSON in the config directory, readable only by
+// the user since it holds the token.
+func writeState(name string, v any) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, name))
+}
@@ -0,0 +1,1268 @@
+// Command balkanctl is a command-line client for the file vault API.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/client"
+)
+
+const usage = `usage: balkanctl <command> [flags] [args]
+
+Remote paths start at your root folder, e.g. /reports/2026/q1.pdf.
+
+commands:
+  login [-server URL] [-u USER] [-p PASSWORD] [-code CODE]
+                                                log in and store the token
+  logout [-all]                                 end the session (-all: on every device)
+  ls [-l] [PATH]                                list a folder
+  tree [PATH]                                   list a folder recursively
+  put [-parents] [-tags a,b] LOCAL [REMOTE]     upload a file, resuming if interrupted
+  get REMOTE [LOCAL]                            download a file, resuming if interrupted
+  mv SRC DST                                    move or rename
+  rm PATH                                       move to the trash
+  restore [-folder] [ID|NAME]                   restore from the trash (lists it without an argument)
+  tag PATH [TAG...]                             show or replace a file's tags
+  share create [-expiry HOURS] [-download] [-password P] [-max-downloads N] [-require-email] PATH
+                                                create a share link to a file or folder
+  share list [PATH]                             list your share links, or a file's or folder's
+  share update [-expiry HOURS] [-download=BOOL] [-password P] [-max-downloads N] [-require-email=BOOL] ID
+                                                change a share link; -password "" removes it
+  share revoke ID                               revoke a share link
+  share viewers ID                              list the emails a share link was unlocked with
+  share stats ID                                show a share link's views, downloads and visitors
+  access list PATH                              show who has access to a file or folder
+  access grant PATH EMAIL|group:ID ROLE         give a user or group a role on a file or folder (viewer, commenter, editor, co_owner)
+  access revoke PATH USER_ID|group:ID           take away a user's or group's access to a file or folder
+  shared                                        list files and folders others shared with you
+  transfer [-keep ROLE] PATH EMAIL              give a file or folder you own to someone else (-keep: role you keep)
+  group list                                    list your groups
+  group show ID                                 list a group's members
+  group create [-description TEXT] NAME         create a group you administer
+  group add [-admin] ID EMAIL                   add a user to a group, or change whether they are an admin
+  group remove ID USER_ID                       remove a member from a group, or leave it
+  group delete ID                               delete a group
+  org                                           show your organization and its usage
+  org members                                   list your organization's members (admins)
+  org invite [-admin] EMAIL                     invite someone to your organization (admins)
+  org invites                                   list pending invites (admins)
+  org uninvite ID                               revoke an invite (admins)
+  org offboard USER_ID EMAIL                    give everything a member owns to another member (admins)
+  versions [-restore N] PATH                    list a file's versions or restore one
+  token create [-scopes S,...] [-expiry DAYS] [-folder PATH] NAME
+                                                create a personal access token, e.g. for CI
+  token list                                    list your personal access tokens
+  token revoke ID                               revoke a personal access token
+  sync [-delete] [-dry-run] LOCALDIR REMOTEDIR  mirror a local directory, skipping unchanged files
+  2fa status                                    show whether two-factor authentication is on
+  2fa enable                                    turn on two-factor authentication
+  2fa disable [-p PASSWORD] CODE                turn off two-factor authentication
+  2fa recovery-codes CODE                       replace your recovery codes
+`
+
+var commands = map[string]func(context.Context, []string) error{
+	"login":    cmdLogin,
+	"logout":   cmdLogout,
+	"ls":       cmdLs,
+	"tree":     cmdTree,
+	"put":      cmdPut,
+	"get":      cmdGet,
+	"mv":       cmdMv,
+	"rm":       cmdRm,
+	"restore":  cmdRestore,
+	"tag":      cmdTag,
+	"share":    cmdShare,
+	"access":   cmdAccess,
+	"shared":   cmdShared,
+	"transfer": cmdTransfer,
+	"group":    cmdGroup,
+	"org":      cmdOrg,
+	"versions": cmdVersions,
+	"token":    cmdToken,
+	"sync":     cmdSync,
+	"2fa":      cmdTwoFactor,
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "balkanctl: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	// An interrupt cancels the running request; put and get resume from
+	// where it stopped the next time.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "balkanctl:", err)
+		os.Exit(1)
+	}
+}
+
+func cmdLogin(ctx context.Context, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	server := flags.String("server", cfg.Server, "server URL")
+	username := flags.String("u", cfg.Username, "username")
+	password := flags.String("p", os.Getenv("BALKAN_PASSWORD"), "password (prompted for when empty)")
+	code := flags.String("code", "", "two-factor code (prompted for when needed)")
+	flags.Parse(args)
+
+	in := bufio.NewReader(os.Stdin)
+	if *username == "" {
+		fmt.Fprint(os.Stderr, "Username: ")
+		line, _ := in.ReadString('\n')
+		*username = strings.TrimSpace(line)
+	}
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, _ := in.ReadString('\n')
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	c := client.New(*server, "")
+	c.HTTPClient = newHTTPClient()
+	_, err = c.Login(ctx, *username, *password)
+	var twoFactor *client.TwoFactorRequired
+	if errors.As(err, &twoFactor) {
+		if *code == "" {
+			fmt.Fprint(os.Stderr, "Two-factor code (or recovery code): ")
+			line, _ := in.ReadString('\n')
+			*code = strings.TrimSpace(line)
+		}
+		_, err = c.LoginTwoFactor(ctx, twoFactor.Challenge, *code)
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg.Server, cfg.Username = c.BaseURL, *username
+	cfg.Token, cfg.RefreshToken, cfg.Expiry = c.Token, c.RefreshToken, c.Expiry
+	if err := cfg.save(); err != nil {
+		return err
+	}
+	fmt.Printf("logged in to %s as %s\n", cfg.Server, cfg.Username)
+	return nil
+}
+
+func cmdLogout(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("logout", flag.ExitOnError)
+	all := flags.Bool("all", false, "revoke every session of the account, on all devices")
+	flags.Parse(args)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Token != "" {
+		c, err := newClient()
+		if err != nil {
+			return err
+		}
+		if *all {
+			_, err = c.LogoutAll(ctx)
+		} else {
+			_, err = c.Logout(ctx)
+		}
+		// An expired or revoked session needs no revoking, and the
+		// stored tokens go either way.
+		if err != nil && client.StatusCode(err) != http.StatusUnauthorized {
+			if *all {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "balkanctl: could not revoke the session:", err)
+		}
+	}
+	cfg, err = loadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.Token, cfg.RefreshToken, cfg.Expiry = "", "", time.Time{}
+	return cfg.save()
+}
+
+func cmdLs(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("ls", flag.ExitOnError)
+	long := flags.Bool("l", false, "show size, date and id")
+	flags.Parse(args)
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	p := client.CleanPath(flags.Arg(0))
+	e, err := c.Stat(ctx, p)
+	if err != nil {
+		return err
+	}
+	entries := []api.Entry{*e}
+	if e.IsDir() {
+		if entries, err = c.List(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, e := range entries {
+		name := e.Name
+		if e.IsDir() {
+			name += "/"
+		}
+		if !*long {
+			fmt.Fprintln(w, name)
+			continue
+		}
+		size := "-"
+		if !e.IsDir() {
+			size = formatSize(e.Size)
+		}
+		id, created := "-", "-"
+		if e.ID != nil {
+			id = strconv.FormatInt(*e.ID, 10)
+		}
+		if e.CreatedAt != nil {
+			created = e.CreatedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", id, size, created, name)
+	}
+	return w.Flush()
+}
+
+func cmdTree(ctx context.Context, args []string) error {
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	root := client.CleanPath(firstArg(args))
+	fmt.Println(root)
+	return walkRemote(ctx, c, root, func(rel string, e api.Entry) {
+		depth := strings.Count(rel, "/")
+		name := e.Name
+		if e.IsDir() {
+			name += "/"
+		}
+		fmt.Printf("%s%s\n", strings.Repeat("  ", depth+1), name)
+	})
+}
+
+func cmdPut(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("put", flag.ExitOnError)
+	parents := flags.Bool("parents", false, "create missing remote folders")
+	tagList := flags.String("tags", "", "comma separated tags")
+	flags.Parse(args)
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return errors.New("usage: balkanctl put [-parents] [-tags a,b] LOCAL [REMOTE]")
+	}
+	local := flags.Arg(0)
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	// Like cp, a remote folder (or a trailing slash) means "into it".
+	remote := flags.Arg(1)
+	if remote == "" || strings.HasSuffix(remote, "/") {
+		remote = path.Join(client.CleanPath(remote), filepath.Base(local))
+	} else if e, err := c.Stat(ctx, remote); err == nil && e.IsDir() {
+		remote = path.Join(e.Path, filepath.Base(local))
+	}
+	remote = client.CleanPath(remote)
+
+	if *parents {
+		if err := mkdir(ctx, c, path.Dir(remote)); err != nil {
+			return err
+		}
+	}
+	var tags []string
+	if *tagList != "" {
+		tags = strings.Split(*tagList, ",")
+	}
+	if err := upload(ctx, c, local, remote, tags); err != nil {
+		return err
+	}
+	fmt.Println(remote)
+	return nil
+}
+
+func cmdGet(ctx context.Context, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: balkanctl get REMOTE [LOCAL]")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	e, err := c.Stat(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if e.IsDir() {
+		return fmt.Errorf("%s is a folder", e.Path)
+	}
+
+	local := e.Name
+	if len(args) == 2 {
+		local = args[1]
+		if fi, err := os.Stat(local); err == nil && fi.IsDir() {
+			local = filepath.Join(local, e.Name)
+		}
+	}
+	return download(ctx, c, e, local)
+}
+
+func cmdMv(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: balkanctl mv SRC DST")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	src, dst := client.CleanPath(args[0]), client.CleanPath(args[1])
+	if e, err := c.Stat(ctx, dst); err == nil && e.IsDir() {
+		dst = path.Join(e.Path, path.Base(src))
+	}
+	if _, err := c.Move(ctx, src, dst); err != nil {
+		return err
+	}
+	fmt.Printf("%s -> %s\n", src, dst)
+	return nil
+}
+
+func cmdRm(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: balkanctl rm PATH...")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	for _, p := range args {
+		if _, err := c.Remove(ctx, p); err != nil {
+			return fmt.Errorf("%s: %w", client.CleanPath(p), err)
+		}
+	}
+	return nil
+}
+
+// trashEntry is a trashed file or folder, as restore lists and matches them.
+type trashEntry struct {
+	ID        int64
+	Name      string
+	Folder    bool
+	TrashedAt time.Time
+}
+
+func (t trashEntry) label() string {
+	if t.Folder {
+		return t.Name + "/"
+	}
+	return t.Name
+}
+
+func (t trashEntry) kind() string {
+	if t.Folder {
+		return "folder"
+	}
+	return "file"
+}
+
+func cmdRestore(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	folderOnly := flags.Bool("folder", false, "only match trashed folders")
+	flags.Parse(args)
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	trash, err := c.Trash(ctx)
+	if err != nil {
+		return err
+	}
+	var all []trashEntry
+	for _, f := range trash.Folders {
+		all = append(all, trashEntry{ID: f.ID, Name: f.Name, Folder: true, TrashedAt: derefTime(f.TrashedAt)})
+	}
+	for _, f := range trash.Files {
+		all = append(all, trashEntry{ID: f.ID, Name: f.Filename, TrashedAt: derefTime(f.TrashedAt)})
+	}
+
+	if flags.NArg() == 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, t := range all {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", t.ID, t.TrashedAt.Local().Format("2006-01-02 15:04"), t.label())
+		}
+		return w.Flush()
+	}
+
+	arg := flags.Arg(0)
+	id, idErr := strconv.ParseInt(arg, 10, 64)
+	var matches []trashEntry
+	for _, t := range all {
+		if *folderOnly && !t.Folder {
+			continue
+		}
+		if (idErr == nil && t.ID == id) || t.Name == arg {
+			matches = append(matches, t)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("%s is not in the trash", arg)
+	case 1:
+	default:
+		var ids []string
+		for _, m := range matches {
+			ids = append(ids, fmt.Sprintf("%s %d", m.kind(), m.ID))
+		}
+		return fmt.Errorf("%s matches several trashed entries (%s); use the id, with -folder for a folder", arg, strings.Join(ids, ", "))
+	}
+
+	t := matches[0]
+	if t.Folder {
+		_, err = c.RestoreFolder(ctx, t.ID)
+	} else {
+		_, err = c.RestoreFile(ctx, t.ID)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("restored %s\n", t.label())
+	return nil
+}
+
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func cmdTag(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: balkanctl tag PATH [TAG...]")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	id, err := fileID(ctx, c, args[0])
+	if err != nil {
+		return err
+	}
+
+	var tags []string
+	if len(args) == 1 {
+		tags, err = c.Tags(ctx, id)
+	} else {
+		var set []string
+		for _, a := range args[1:] {
+			set = append(set, strings.Split(a, ",")...)
+		}
+		tags, err = c.SetTags(ctx, id, set)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(strings.Join(tags, ", "))
+	return nil
+}
+
+func cmdShare(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: balkanctl share create|list|update|revoke|viewers|stats")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("share create", flag.ExitOnError)
+		expiry := flags.Int("expiry", 0, "hours until the link expires (0 never)")
+		download := flags.Bool("download", false, "allow downloading, not just previewing")
+		password := flags.String("password", "", "password visitors must enter")
+		maxDownloads := flags.Int("max-downloads", 0, "downloads allowed (0 no cap)")
+		requireEmail := flags.Bool("require-email", false, "have visitors leave their email")
+		flags.Parse(args[1:])
+		if flags.NArg() != 1 {
+			return errors.New("usage: balkanctl share create [-expiry HOURS] [-download] [-password P] [-max-downloads N] [-require-email] PATH")
+		}
+		e, err := c.Stat(ctx, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		if e.ID == nil {
+			return errors.New("your root folder cannot be shared")
+		}
+		opts := client.ShareOptions{
+			Expiry:        time.Duration(*expiry) * time.Hour,
+			AllowDownload: *download,
+			Password:      *password,
+			MaxDownloads:  *maxDownloads,
+			RequireEmail:  *requireEmail,
+		}
+		var out *api.Share
+		if e.IsDir() {
+			out, err = c.CreateFolderShare(ctx, *e.ID, opts)
+		} else {
+			out, err = c.CreateShare(ctx, *e.ID, opts)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d\t%s\n", out.ID, out.ShareURL)
+		return nil
+
+	case "list":
+		var shares []api.Share
+		switch len(args) {
+		case 1:
+			shares, err = c.ListShares(ctx)
+		case 2:
+			var e *api.Entry
+			if e, err = c.Stat(ctx, args[1]); err != nil {
+				return err
+			}
+			switch {
+			case e.ID == nil:
+			case e.IsDir():
+				shares, err = c.FolderShares(ctx, *e.ID)
+			default:
+				shares, err = c.FileShares(ctx, *e.ID)
+			}
+		default:
+			return errors.New("usage: balkanctl share list [PATH]")
+		}
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, s := range shares {
+			expires := "never"
+			if s.ExpiresAt != nil {
+				expires = s.ExpiresAt.Local().Format("2006-01-02 15:04")
+			}
+			if s.Expired {
+				expires += " (expired)"
+			}
+			access := "preview"
+			if s.AllowDownload {
+				access = "download"
+				if s.MaxDownloads != nil {
+					access += fmt.Sprintf(" %d/%d", s.Downloads, *s.MaxDownloads)
+				}
+			}
+			if s.HasPassword {
+				access += ", password"
+			}
+			if s.RequireEmail {
+				access += ", email"
+			}
+			name := s.Filename
+			if s.FolderID != nil {
+				name = s.FolderName + "/"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", s.ID, name, access, expires, s.ShareURL)
+		}
+		return w.Flush()
+
+	case "update":
+		flags := flag.NewFlagSet("share update", flag.ExitOnError)
+		expiry := flags.Float64("expiry", 0, "hours from now until the link expires (0 never)")
+		download := flags.Bool("download", false, "allow downloading, not just previewing")
+		password := flags.String("password", "", "password visitors must enter (\"\" removes it)")
+		maxDownloads := flags.Int("max-downloads", 0, "downloads allowed (0 no cap)")
+		requireEmail := flags.Bool("require-email", false, "have visitors leave their email")
+		flags.Parse(args[1:])
+		if flags.NArg() != 1 {
+			return errors.New("usage: balkanctl share update [-expiry HOURS] [-download=BOOL] [-password P] [-max-downloads N] [-require-email=BOOL] ID")
+		}
+		id, err := strconv.ParseInt(flags.Arg(0), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid share id %q", flags.Arg(0))
+		}
+		// Only the flags given are sent, so the rest stay as they are.
+		var in api.UpdateShareRequest
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "expiry":
+				in.Expiry = expiry
+			case "download":
+				in.AllowDownload = download
+			case "password":
+				in.Password = password
+			case "max-downloads":
+				in.MaxDownloads = maxDownloads
+			case "require-email":
+				in.RequireEmail = requireEmail
+			}
+		})
+		out, err := c.UpdateShare(ctx, id, in)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d\t%s\n", out.ID, out.ShareURL)
+		return nil
+
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New("usage: balkanctl share revoke ID")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid share id %q", args[1])
+		}
+		_, err = c.RevokeShare(ctx, id)
+		return err
+
+	case "viewers":
+		if len(args) != 2 {
+			return errors.New("usage: balkanctl share viewers ID")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid share id %q", args[1])
+		}
+		viewers, err := c.ShareViewers(ctx, id)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, v := range viewers {
+			fmt.Fprintf(w, "%s\t%d views\tlast %s\n", v.Email, v.Views, v.LastViewedAt.Local().Format("2006-01-02 15:04"))
+		}
+		return w.Flush()
+
+	case "stats":
+		if len(args) != 2 {
+			return errors.New("usage: balkanctl share stats ID")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid share id %q", args[1])
+		}
+		s, err := c.ShareStats(ctx, id)
+		if err != nil {
+			return err
+		}
+		last := "never"
+		if s.LastAccessAt != nil {
+			last = s.LastAccessAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("views %d, previews %d, downloads %d, unlocks %d, last access %s\n",
+			s.Views, s.Previews, s.Downloads, s.Unlocks, last)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, r := range s.Referrers {
+			fmt.Fprintf(w, "referrer\t%s\t%d\n", r.Value, r.Count)
+		}
+		for _, ip := range s.IPs {
+			fmt.Fprintf(w, "ip\t%s\t%d\n", ip.Value, ip.Count)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown share command %q", args[0])
+}
+
+func cmdAccess(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: balkanctl access list|grant|revoke PATH ...")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	e, err := c.Stat(ctx, args[1])
+	if err != nil {
+		return err
+	}
+	if e.ID == nil {
+		return errors.New("your root folder cannot be shared")
+	}
+	id, isDir := *e.ID, e.IsDir()
+
+	switch args[0] {
+	case "list":
+		var entries []api.AccessEntry
+		public := false
+		if isDir {
+			out, err := c.FolderAccess(ctx, id)
+			if err != nil {
+				return err
+			}
+			entries = out.Access
+		} else {
+			out, err := c.FileAccess(ctx, id)
+			if err != nil {
+				return err
+			}
+			entries, public = out.Access, out.Public
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, e := range entries {
+			via := ""
+			if e.ViaFolderID != nil {
+				via = fmt.Sprintf("from folder %d", *e.ViaFolderID)
+			}
+			if e.GroupID != 0 {
+				fmt.Fprintf(w, "group:%d\t%s\t\t%s\t%s\n", e.GroupID, e.Group, e.Role, via)
+				continue
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", e.UserID, e.Username, e.Email, e.Role, via)
+		}
+		if public {
+			fmt.Fprintln(w, "-\teveryone\t\tviewer\tpublic")
+		}
+		return w.Flush()
+
+	case "grant":
+		if len(args) != 4 {
+			return errors.New("usage: balkanctl access grant PATH EMAIL|group:ID ROLE")
+		}
+		groupID, isGroup, err := parseGroupRef(args[2])
+		if err != nil {
+			return err
+		}
+		switch {
+		case isGroup && isDir:
+			_, err = c.GrantFolderGroupAccess(ctx, id, groupID, args[3])
+		case isGroup:
+			_, err = c.GrantGroupAccess(ctx, id, groupID, args[3])
+		case isDir:
+			_, err = c.GrantFolderAccess(ctx, id, args[2], args[3])
+		default:
+			_, err = c.GrantAccess(ctx, id, args[2], args[3])
+		}
+		return err
+
+	case "revoke":
+		if len(args) != 3 {
+			return errors.New("usage: balkanctl access revoke PATH USER_ID|group:ID")
+		}
+		groupID, isGroup, err := parseGroupRef(args[2])
+		if err != nil {
+			return err
+		}
+		if isGroup {
+			if isDir {
+				_, err = c.RevokeFolderGroupAccess(ctx, id, groupID)
+			} else {
+				_, err = c.RevokeGroupAccess(ctx, id, groupID)
+			}
+			return err
+		}
+		userID, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid user id %q", args[2])
+		}
+		if isDir {
+			_, err = c.RevokeFolderAccess(ctx, id, userID)
+		} else {
+			_, err = c.RevokeAccess(ctx, id, userID)
+		}
+		return err
+	}
+	return fmt.Errorf("unknown access command %q", args[0])
+}
+
+func cmdTransfer(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("transfer", flag.ExitOnError)
+	keep := flags.String("keep", "", "role to keep on it (viewer, commenter, editor, co_owner)")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return errors.New("usage: balkanctl transfer [-keep ROLE] PATH EMAIL")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	e, err := c.Stat(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if e.ID == nil {
+		return errors.New("your root folder cannot be transferred")
+	}
+	var t *api.Transfer
+	if e.IsDir() {
+		t, err = c.TransferFolder(ctx, *e.ID, flags.Arg(1), *keep)
+	} else {
+		t, err = c.TransferFile(ctx, *e.ID, flags.Arg(1), *keep)
+	}
+	if err != nil {
+		return err
+	}
+	printTransfer(t)
+	return nil
+}
+
+func printTransfer(t *api.Transfer) {
+	fmt.Printf("moved %d files and %d folders (%s)\n", t.Files, t.Folders, formatSize(t.Bytes))
+	for _, u := range []api.Usage{t.From, t.To} {
+		quota := "unlimited"
+		if u.Quota > 0 {
+			quota = formatSize(u.Quota)
+		}
+		fmt.Printf("%s: %s of %s used\n", u.Username, formatSize(u.Used), quota)
+	}
+}
+
+// parseGroupRef parses a "group:ID" argument, reporting false for anything
+// else.
+func parseGroupRef(s string) (int64, bool, error) {
+	ref, ok := strings.CutPrefix(s, "group:")
+	if !ok {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid group id %q", ref)
+	}
+	return id, true, nil
+}
+
+func cmdShared(ctx context.Context, args []string) error {
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	out, err := c.SharedWithMe(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, f := range out.Folders {
+		fmt.Fprintf(w, "folder %d\t%s/\t%s\t%s\n", f.ID, f.Name, f.Owner, f.Role)
+	}
+	for _, f := range out.Files {
+		fmt.Fprintf(w, "file %d\t%s\t%s\t%s\n", f.ID, f.Filename, f.Owner, f.Role)
+	}
+	return w.Flush()
+}
+
+func cmdGroup(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: balkanctl group list|show|create|add|remove|delete")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	// groupArg parses the group id in args[i].
+	groupArg := func(i int) (int64, error) {
+		id, err := strconv.ParseInt(args[i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid group id %q", args[i])
+		}
+		return id, nil
+	}
+
+	switch args[0] {
+	case "list":
+		groups, err := c.Groups(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, g := range groups {
+			role := "member"
+			if g.IsAdmin {
+				role = "admin"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%d members\t%s\t%s\n", g.ID, g.Name, g.Members, role, g.Description)
+		}
+		return w.Flush()
+
+	case "show":
+		if len(args) != 2 {
+			return errors.New("usage: balkanctl group show ID")
+		}
+		id, err := groupArg(1)
+		if err != nil {
+			return err
+		}
+		g, err := c.Group(ctx, id)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, m := range g.MemberList {
+			role := "member"
+			if m.IsAdmin {
+				role = "admin"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", m.UserID, m.Username, m.Email, role)
+		}
+		return w.Flush()
+
+	case "create":
+		flags := flag.NewFlagSet("group create", flag.ExitOnError)
+		description := flags.String("description", "", "what the group is for")
+		flags.Parse(args[1:])
+		if flags.NArg() != 1 {
+			return errors.New("usage: balkanctl group create [-description TEXT] NAME")
+		}
+		g, err := c.CreateGroup(ctx, flags.Arg(0), *description)
+		if err != nil {
+			return err
+		}
+		fmt.Println(g.ID)
+		return nil
+
+	case "add":
+		flags := flag.NewFlagSet("group add", flag.ExitOnError)
+		admin := flags.Bool("admin", false, "make the user an admin of the group")
+		flags.Parse(args[1:])
+		if flags.NArg() != 2 {
+			return errors.New("usage: balkanctl group add [-admin] ID EMAIL")
+		}
+		args = flags.Args()
+		id, err := groupArg(0)
+		if err != nil {
+			return err
+		}
+		_, err = c.AddGroupMember(ctx, id, args[1], *admin)
+		return err
+
+	case "remove":
+		if len(args) != 3 {
+			return errors.New("usage: balkanctl group remove ID USER_ID")
+		}
+		id, err := groupArg(1)
+		if err != nil {
+			return err
+		}
+		userID, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid user id %q", args[2])
+		}
+		_, err = c.RemoveGroupMember(ctx, id, userID)
+		return err
+
+	case "delete":
+		if len(args) != 2 {
+			return errors.New("usage: balkanctl group delete ID")
+		}
+		id, err := groupArg(1)
+		if err != nil {
+			return err
+		}
+		_, err = c.DeleteGroup(ctx, id)
+		return err
+	}
+	return fmt.Errorf("unknown group command %q", args[0])
+}
+
+func cmdOrg(ctx context.Context, args []string) error {
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		o, err := c.Organization(ctx)
+		if err != nil {
+			return err
+		}
+		quota := "unlimited"
+		if o.StorageQuota > 0 {
+			quota = formatSize(o.StorageQuota)
+		}
+		fmt.Printf("%s (%s), your role: %s\n", o.Name, o.Slug, o.Role)
+		fmt.Printf("%d members, %s of %s used\n", o.Members, formatSize(o.Used), quota)
+		return nil
+	}
+
+	switch args[0] {
+	case "members":
+		members, err := c.Members(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, m := range members {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s of %s\n", m.ID, m.Username, m.Email, m.Role, formatSize(m.Used), formatSize(m.Quota))
+		}
+		return w.Flush()
+
+	case "invite":
+		flags := flag.NewFlagSet("org invite", flag.ExitOnError)
+		admin := flags.Bool("admin", false, "invite them as an admin")
+		flags.Parse(args[1:])
+		if flags.NArg() != 1 {
+			return errors.New("usage: balkanctl org invite [-admin] EMAIL")
+		}
+		role := api.OrgRoleMember
+		if *admin {
+			role = api.OrgRoleAdmin
+		}
+		inv, err := c.Invite(ctx, flags.Arg(0), role)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("invited %s, expires %s\n", inv.Email, inv.ExpiresAt.Local().Format("2006-01-02 15:04"))
+		return nil
+
+	case "invites":
+		invites, err := c.Invites(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, inv := range invites {
+			fmt.Fprintf(w, "%d\t%s\t%s\texpires %s\n", inv.ID, inv.Email, inv.Role, inv.ExpiresAt.Local().Format("2006-01-02 15:04"))
+		}
+		return w.Flush()
+
+	case "offboard":
+		if len(args) != 3 {
+			return errors.New("usage: balkanctl org offboard USER_ID EMAIL")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid user id %q", args[1])
+		}
+		t, err := c.TransferMemberFiles(ctx, id, args[2])
+		if err != nil {
+			return err
+		}
+		printTransfer(t)
+		return nil
+
+	case "uninvite":
+		if len(args) != 2 {
+			return errors.New("usage: balkanctl org uninvite ID")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid invite id %q", args[1])
+		}
+		_, err = c.RevokeInvite(ctx, id)
+		return err
+	}
+	return fmt.Errorf("unknown org command %q", args[0])
+}
+
+func cmdToken(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: balkanctl token create|list|revoke")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("token create", flag.ExitOnError)
+		scopes := flags.String("scopes", api.ScopeFilesRead, "comma-separated scopes: "+strings.Join(api.Scopes, ", "))
+		expiry := flags.Int("expiry", 0, "days until the token expires (0 never)")
+		folder := flags.String("folder", "", "restrict the token to this folder")
+		flags.Parse(args[1:])
+		if flags.NArg() != 1 {
+			return errors.New("usage: balkanctl token create [-scopes S,...] [-expiry DAYS] [-folder PATH] NAME")
+		}
+		in := api.CreateTokenRequest{Name: flags.Arg(0), Scopes: strings.Split(*scopes, ",")}
+		if *expiry > 0 {
+			t := time.Now().AddDate(0, 0, *expiry)
+			in.ExpiresAt = &t
+		}
+		if *folder != "" {
+			if in.FolderID, err = folderID(ctx, c, *folder); err != nil {
+				return err
+			}
+			if in.FolderID == nil {
+				return errors.New("the root is not a folder restriction; leave out -folder")
+			}
+		}
+		out, err := c.CreateToken(ctx, in)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out.Token)
+		fmt.Fprintln(os.Stderr, out.Message)
+		return nil
+
+	case "list":
+		tokens, err := c.Tokens(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, t := range tokens {
+			status := "never used"
+			if t.LastUsedAt != nil {
+				status = "used " + t.LastUsedAt.Local().Format("2006-01-02 15:04")
+			}
+			switch {
+			case t.RevokedAt != nil:
+				status = "revoked"
+			case t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()):
+				status = "expired"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s…\t%s\t%s\n", t.ID, t.Name, t.Prefix, strings.Join(t.Scopes, ","), status)
+		}
+		return w.Flush()
+
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New("usage: balkanctl token revoke ID")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid token id %q", args[1])
+		}
+		_, err = c.RevokeToken(ctx, id)
+		return err
+	}
+	return fmt.Errorf("unknown token command %q", args[0])
+}
+
+func cmdTwoFactor(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: balkanctl 2fa status|enable|disable|recovery-codes")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		s, err := c.TwoFactorStatus(ctx)
+		if err != nil {
+			return err
+		}
+		switch {
+		case s.Enabled:
+			fmt.Printf("on since %s, %d recovery codes left\n", s.EnabledAt.Local().Format("2006-01-02 15:04"), s.RecoveryCodesLeft)
+		case s.Required:
+			fmt.Println("off, but required for your role: run balkanctl 2fa enable")
+		default:
+			fmt.Println("off")
+		}
+		return nil
+
+	case "enable":
+		e, err := c.EnrollTwoFactor(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Add this account to your authenticator app:\n\n  %s\n\nor enter the secret %s\n\n", e.ProvisioningURI, e.Secret)
+		fmt.Fprint(os.Stderr, "Code from the app: ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		codes, err := c.EnableTwoFactor(ctx, strings.TrimSpace(line))
+		if err != nil {
+			return err
+		}
+		printRecoveryCodes(codes)
+		return nil
+
+	case "disable":
+		flags := flag.NewFlagSet("2fa disable", flag.ExitOnError)
+		password := flags.String("p", os.Getenv("BALKAN_PASSWORD"), "password (prompted for when empty)")
+		flags.Parse(args[1:])
+		if flags.NArg() != 1 {
+			return errors.New("usage: balkanctl 2fa disable [-p PASSWORD] CODE")
+		}
+		if *password == "" {
+			fmt.Fprint(os.Stderr, "Password (empty for single sign-on accounts): ")
+			line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			*password = strings.TrimRight(line, "\r\n")
+		}
+		out, err := c.DisableTwoFactor(ctx, api.DisableTwoFactorRequest{Password: *password, Code: flags.Arg(0)})
+		if err != nil {
+			return err
+		}
+		fmt.Println(out.Message)
+		return nil
+
+	case "recovery-codes":
+		if len(args) != 2 {
+			return errors.New("usage: balkanctl 2fa recovery-codes CODE")
+		}
+		codes, err := c.RegenerateRecoveryCodes(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		printRecoveryCodes(codes)
+		return nil
+	}
+	return fmt.Errorf("unknown 2fa command %q", args[0])
+}
+
+func printRecoveryCodes(codes *api.RecoveryCodes) {
+	for _, code := range codes.Codes {
+		fmt.Println(code)
+	}
+	fmt.Fprintln(os.Stderr, codes.Message)
+}
+
+func cmdVersions(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("versions", flag.ExitOnError)
+	restore := flags.Int("restore", 0, "make version N current again")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("usage: balkanctl versions [-restore N] PATH")
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	id, err := fileID(ctx, c, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *restore > 0 {
+		_, err := c.RestoreVersion(ctx, id, *restore)
+		return err
+	}
+
+	versions, err := c.Versions(ctx, id)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, v := range versions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", v.Version, v.CreatedAt.Local().Format("2006-01-02 15:04"), formatSize(v.Size), trimHash(v.Hash))
+	}
+	return w.Flush()
+}
+
+func trimHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
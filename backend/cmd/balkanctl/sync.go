@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/client"
+)
+
+// walkRemote lists everything under root, keyed by the path relative to it.
+func walkRemote(ctx context.Context, c *client.Client, root string, visit func(rel string, e api.Entry)) error {
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		entries, err := c.List(ctx, dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			r := path.Join(rel, e.Name)
+			visit(r, e)
+			if e.IsDir() {
+				if err := walk(e.Path, r); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(root, "")
+}
+
+// cmdSync mirrors a local directory into a remote folder. Files whose
+// content hash already matches the remote copy are skipped; with -delete,
+// remote entries that no longer exist locally go to the trash.
+func cmdSync(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	del := flags.Bool("delete", false, "move remote entries missing locally to the trash")
+	dryRun := flags.Bool("dry-run", false, "print what would change without changing anything")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: balkanctl sync [-delete] [-dry-run] LOCALDIR REMOTEDIR")
+	}
+	localRoot, remoteRoot := flags.Arg(0), client.CleanPath(flags.Arg(1))
+
+	if fi, err := os.Stat(localRoot); err != nil {
+		return err
+	} else if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", localRoot)
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	remote := map[string]api.Entry{}
+	if _, err := c.Stat(ctx, remoteRoot); err == nil {
+		err := walkRemote(ctx, c, remoteRoot, func(rel string, e api.Entry) { remote[rel] = e })
+		if err != nil {
+			return err
+		}
+	} else if !client.IsNotFound(err) {
+		return err
+	} else if !*dryRun {
+		if err := mkdir(ctx, c, remoteRoot); err != nil {
+			return err
+		}
+	}
+
+	var uploaded, unchanged, deleted, failed int
+	seen := map[string]bool{}
+	err = filepath.WalkDir(localRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localRoot, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+		target := path.Join(remoteRoot, rel)
+		existing, exists := remote[rel]
+
+		if d.IsDir() {
+			if exists && existing.IsDir() {
+				return nil
+			}
+			if exists {
+				fmt.Fprintf(os.Stderr, "skip %s: a file of that name exists remotely\n", rel)
+				failed++
+				return fs.SkipDir
+			}
+			fmt.Printf("mkdir  %s\n", target)
+			if !*dryRun {
+				if err := mkdir(ctx, c, target); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if fi.Size() == 0 {
+			fmt.Fprintf(os.Stderr, "skip %s: empty files are not supported\n", rel)
+			return nil
+		}
+		if exists && existing.IsDir() {
+			fmt.Fprintf(os.Stderr, "skip %s: a folder of that name exists remotely\n", rel)
+			failed++
+			return nil
+		}
+		if exists {
+			sum, err := hashFile(p)
+			if err != nil {
+				return err
+			}
+			if sum == existing.Hash {
+				unchanged++
+				return nil
+			}
+		}
+
+		fmt.Printf("upload %s\n", target)
+		if *dryRun {
+			uploaded++
+			return nil
+		}
+		if err := upload(ctx, c, p, target, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "upload %s: %v\n", rel, err)
+			failed++
+			return nil
+		}
+		uploaded++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if *del {
+		var missing []string
+		for rel := range remote {
+			if !seen[rel] {
+				missing = append(missing, rel)
+			}
+		}
+		sort.Strings(missing)
+		var trashed []string
+		for _, rel := range missing {
+			// Trashing a folder takes its contents along.
+			covered := false
+			for _, t := range trashed {
+				if strings.HasPrefix(rel, t+"/") {
+					covered = true
+					break
+				}
+			}
+			if covered {
+				continue
+			}
+			fmt.Printf("delete %s\n", path.Join(remoteRoot, rel))
+			if !*dryRun {
+				if _, err := c.Remove(ctx, path.Join(remoteRoot, rel)); err != nil {
+					fmt.Fprintf(os.Stderr, "delete %s: %v\n", rel, err)
+					failed++
+					continue
+				}
+			}
+			trashed = append(trashed, rel)
+			deleted++
+		}
+	}
+
+	fmt.Printf("%d uploaded, %d unchanged, %d deleted\n", uploaded, unchanged, deleted)
+	if failed > 0 {
+		return fmt.Errorf("%d entries could not be synced", failed)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/client"
+)
+
+// fakeServer keeps a tree of folders and files in memory and serves the
+// /fs and tus routes sync uses.
+type fakeServer struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries map[string]*api.Entry // by path; the root is "/"
+	content map[string]string
+	uploads map[string]*fakeUpload
+	// uploaded and trashed record the paths written and removed.
+	uploaded, trashed []string
+}
+
+type fakeUpload struct {
+	path   string
+	length int64
+	data   []byte
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	s := &fakeServer{
+		entries: map[string]*api.Entry{"/": {Path: "/", Type: api.EntryFolder}},
+		content: map[string]string{},
+		uploads: map[string]*fakeUpload{},
+	}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BALKAN_SERVER", srv.URL)
+	t.Setenv("BALKAN_TOKEN", "token")
+	return s
+}
+
+func (s *fakeServer) add(p, typ, content string) {
+	s.nextID++
+	id := s.nextID
+	e := &api.Entry{Path: p, Type: typ, ID: &id, Name: path.Base(p)}
+	if typ == api.EntryFile {
+		sum := sha256.Sum256([]byte(content))
+		e.Hash, e.Size = hex.EncodeToString(sum[:]), int64(len(content))
+		s.content[p] = content
+	}
+	s.entries[p] = e
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply := func(status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	notFound := func() { reply(404, api.Error{Error: "path not found"}) }
+
+	if id, ok := strings.CutPrefix(r.URL.Path, "/tus"); ok {
+		s.serveTus(w, r, strings.TrimPrefix(id, "/"), reply)
+		return
+	}
+	p := client.CleanPath(strings.TrimPrefix(r.URL.Path, "/fs"))
+	e := s.entries[p]
+
+	switch {
+	case r.Method == "GET" && r.URL.Query().Get("op") == "list":
+		if e == nil {
+			notFound()
+			return
+		}
+		list := api.EntryList{Path: p, Entries: []api.Entry{}}
+		for q, child := range s.entries {
+			if q != "/" && path.Dir(q) == p {
+				list.Entries = append(list.Entries, *child)
+			}
+		}
+		reply(200, list)
+	case r.Method == "GET":
+		if e == nil {
+			notFound()
+			return
+		}
+		reply(200, e)
+	case r.Method == "POST" && r.URL.Query().Get("op") == "mkdir":
+		for dir := p; dir != "/"; dir = path.Dir(dir) {
+			if s.entries[dir] == nil {
+				s.add(dir, api.EntryFolder, "")
+			}
+		}
+		reply(200, api.FolderStatus{Message: "folder created", FolderID: *s.entries[p].ID, Path: p})
+	case r.Method == "DELETE":
+		if e == nil {
+			notFound()
+			return
+		}
+		for q := range s.entries {
+			if q == p || strings.HasPrefix(q, p+"/") {
+				delete(s.entries, q)
+			}
+		}
+		s.trashed = append(s.trashed, p)
+		reply(200, api.PathStatus{Message: "moved to trash", Path: p})
+	default:
+		reply(400, api.Error{Error: "unexpected request"})
+	}
+}
+
+func (s *fakeServer) serveTus(w http.ResponseWriter, r *http.Request, id string, reply func(int, any)) {
+	switch r.Method {
+	case "POST":
+		meta := map[string]string{}
+		for _, pair := range strings.Split(r.Header.Get("Upload-Metadata"), ",") {
+			k, v, _ := strings.Cut(pair, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(v)
+			meta[k] = string(decoded)
+		}
+		dir := "/"
+		for q, e := range s.entries {
+			if e.ID != nil && strconv.FormatInt(*e.ID, 10) == meta["folder_id"] {
+				dir = q
+			}
+		}
+		length, _ := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		id := strconv.Itoa(len(s.uploads) + 1)
+		s.uploads[id] = &fakeUpload{path: path.Join(dir, meta["filename"]), length: length}
+		w.Header().Set("Location", "/tus/"+id)
+		w.WriteHeader(201)
+	case "HEAD", "PATCH":
+		u := s.uploads[id]
+		if u == nil {
+			w.WriteHeader(404)
+			return
+		}
+		if r.Method == "PATCH" {
+			data, _ := io.ReadAll(r.Body)
+			u.data = append(u.data, data...)
+			if int64(len(u.data)) == u.length {
+				s.add(u.path, api.EntryFile, string(u.data))
+				s.uploaded = append(s.uploaded, u.path)
+			}
+		}
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(u.data)))
+		w.WriteHeader(204)
+	default:
+		reply(400, api.Error{Error: "unexpected request"})
+	}
+}
+
+// writeFiles creates files under dir from a map of relative paths to
+// content.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	s := newFakeServer(t)
+	s.add("/backup", api.EntryFolder, "")
+	s.add("/backup/same.txt", api.EntryFile, "same")
+	s.add("/backup/changed.txt", api.EntryFile, "old")
+	s.add("/backup/gone", api.EntryFolder, "")
+	s.add("/backup/gone/a.txt", api.EntryFile, "a")
+	s.add("/backup/stale.txt", api.EntryFile, "stale")
+
+	local := t.TempDir()
+	writeFiles(t, local, map[string]string{
+		"same.txt":       "same",
+		"changed.txt":    "new",
+		"docs/notes.txt": "notes",
+		"empty.txt":      "",
+	})
+
+	if err := cmdSync(context.Background(), []string{"-delete", local, "/backup"}); err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(s.uploaded)
+	if want := []string{"/backup/changed.txt", "/backup/docs/notes.txt"}; !slices.Equal(s.uploaded, want) {
+		t.Errorf("uploaded %v, want %v", s.uploaded, want)
+	}
+	// The folder is trashed once, taking its file along.
+	slices.Sort(s.trashed)
+	if want := []string{"/backup/gone", "/backup/stale.txt"}; !slices.Equal(s.trashed, want) {
+		t.Errorf("trashed %v, want %v", s.trashed, want)
+	}
+	if s.content["/backup/changed.txt"] != "new" {
+		t.Errorf("changed.txt holds %q, want %q", s.content["/backup/changed.txt"], "new")
+	}
+	if s.entries["/backup/empty.txt"] != nil {
+		t.Error("the empty file was uploaded")
+	}
+}
+
+func TestSyncDryRun(t *testing.T) {
+	s := newFakeServer(t)
+	s.add("/backup", api.EntryFolder, "")
+	s.add("/backup/stale.txt", api.EntryFile, "stale")
+	local := t.TempDir()
+	writeFiles(t, local, map[string]string{"docs/new.txt": "new"})
+
+	if err := cmdSync(context.Background(), []string{"-delete", "-dry-run", local, "/backup"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.uploaded) != 0 || len(s.trashed) != 0 || s.entries["/backup/docs"] != nil {
+		t.Errorf("a dry run changed the server: uploaded %v, trashed %v", s.uploaded, s.trashed)
+	}
+}
+
+// TestSyncConflict checks that a name that is a folder on one side and a
+// file on the other is reported, without touching either.
+func TestSyncConflict(t *testing.T) {
+	s := newFakeServer(t)
+	s.add("/backup", api.EntryFolder, "")
+	s.add("/backup/report", api.EntryFile, "a file")
+	local := t.TempDir()
+	writeFiles(t, local, map[string]string{"report/q1.txt": "q1"})
+
+	if err := cmdSync(context.Background(), []string{local, "/backup"}); err == nil {
+		t.Error("syncing over a conflicting name succeeded")
+	}
+	if len(s.uploaded) != 0 || s.content["/backup/report"] != "a file" {
+		t.Errorf("the conflict changed the server: uploaded %v", s.uploaded)
+	}
+}
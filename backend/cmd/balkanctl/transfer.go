@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/client"
+)
+
+// Uploads go through the server's tus endpoint in chunks, so an interrupted
+// put picks up from the last acknowledged offset. The tus upload URL of an
+// unfinished upload is kept in uploads.json, keyed by the local file's
+// identity and its destination.
+
+const chunkSize = 8 << 20
+
+// progress draws a single status line on stderr while a transfer runs,
+// when stderr is a terminal.
+type progress struct {
+	name  string
+	total int64
+	done  int64
+	start time.Time
+	last  time.Time
+	tty   bool
+}
+
+func newProgress(name string, total, done int64) *progress {
+	fi, err := os.Stderr.Stat()
+	return &progress{
+		name:  name,
+		total: total,
+		done:  done,
+		start: time.Now(),
+		tty:   err == nil && fi.Mode()&os.ModeCharDevice != 0,
+	}
+}
+
+func (p *progress) add(n int64) {
+	p.done += n
+	if time.Since(p.last) > 100*time.Millisecond {
+		p.draw()
+	}
+}
+
+func (p *progress) draw() {
+	if !p.tty {
+		return
+	}
+	p.last = time.Now()
+	pct := 100
+	if p.total > 0 {
+		pct = int(p.done * 100 / p.total)
+	}
+	fmt.Fprintf(os.Stderr, "\r%-40s %10s / %-10s %3d%%", trimName(p.name, 40), formatSize(p.done), formatSize(p.total), pct)
+}
+
+func (p *progress) finish() {
+	if !p.tty {
+		return
+	}
+	p.draw()
+	fmt.Fprintln(os.Stderr)
+}
+
+// progressReader counts what passes through it towards p.
+type progressReader struct {
+	r io.Reader
+	p *progress
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	pr.p.add(int64(n))
+	return n, err
+}
+
+func trimName(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n+3:]
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func uploadKey(server, local string, fi os.FileInfo, remote string) string {
+	return fmt.Sprintf("%s|%s|%d|%d|%s", server, local, fi.Size(), fi.ModTime().UnixNano(), remote)
+}
+
+func setPendingUpload(key, location string) error {
+	pending := map[string]string{}
+	if err := readState("uploads.json", &pending); err != nil {
+		return err
+	}
+	if location == "" {
+		delete(pending, key)
+	} else {
+		pending[key] = location
+	}
+	return writeState("uploads.json", pending)
+}
+
+func pendingUpload(key string) string {
+	pending := map[string]string{}
+	_ = readState("uploads.json", &pending)
+	return pending[key]
+}
+
+// upload sends the local file to the remote path remote, whose parent
+// folder must exist, resuming an earlier attempt at the same upload.
+func upload(ctx context.Context, c *client.Client, local, remote string, tags []string) error {
+	abs, err := filepath.Abs(local)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return err
+	}
+	if fi.Size() == 0 {
+		return fmt.Errorf("%s: empty files are not supported", local)
+	}
+
+	remote = client.CleanPath(remote)
+	parentID, err := folderID(ctx, c, path.Dir(remote))
+	if err != nil {
+		return err
+	}
+
+	key := uploadKey(c.BaseURL, abs, fi, remote)
+	location := pendingUpload(key)
+	var offset int64
+	if location != "" {
+		offset, err = c.TusOffset(ctx, location)
+		if err != nil {
+			location = ""
+		}
+	}
+	if location == "" {
+		opts := &client.UploadOptions{FolderID: parentID, Tags: tags}
+		location, err = c.TusCreate(ctx, path.Base(remote), fi.Size(), opts)
+		if err != nil {
+			return err
+		}
+		if err := setPendingUpload(key, location); err != nil {
+			return err
+		}
+		offset = 0
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	prog := newProgress(remote, fi.Size(), offset)
+	defer prog.finish()
+	for offset < fi.Size() {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		n := min(int64(chunkSize), fi.Size()-offset)
+		offset, err = c.TusPatch(ctx, location, offset, &progressReader{r: io.LimitReader(f, n), p: prog}, n)
+		if err != nil {
+			return fmt.Errorf("%s: %w (run the same put again to resume)", local, err)
+		}
+		prog.done = offset
+	}
+	return setPendingUpload(key, "")
+}
+
+// download fetches the remote file e into local. Data goes to local+".part"
+// first, so an interrupted get resumes with a range request, and the result
+// is checked against the file's hash before it is renamed into place.
+func download(ctx context.Context, c *client.Client, e *api.Entry, local string) error {
+	part := local + ".part"
+	var offset int64
+	if fi, err := os.Stat(part); err == nil && fi.Size() <= e.Size {
+		offset = fi.Size()
+	}
+
+	if offset < e.Size {
+		body, start, err := c.Get(ctx, e.Path, offset)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+
+		flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		if start == 0 {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			offset = 0
+		}
+		f, err := os.OpenFile(part, flags, 0o644)
+		if err != nil {
+			return err
+		}
+		prog := newProgress(e.Path, e.Size, offset)
+		_, err = io.Copy(f, &progressReader{r: body, p: prog})
+		prog.finish()
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w (run the same get again to resume)", e.Path, err)
+		}
+	}
+
+	sum, err := hashFile(part)
+	if err != nil {
+		return err
+	}
+	if e.Hash != "" && sum != e.Hash {
+		os.Remove(part)
+		return fmt.Errorf("%s: downloaded content does not match its hash", e.Path)
+	}
+	return os.Rename(part, local)
+}
+
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
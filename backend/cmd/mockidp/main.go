@@ -0,0 +1,311 @@
+// Command mockidp is a minimal OpenID Connect provider for trying out and
+// testing single sign-on locally. It serves discovery, keys, an authorize
+// page where you pick a user, and a token endpoint that checks PKCE. Keys
+// and codes live in memory; do not use it for anything real.
+//
+//	go run ./cmd/mockidp -users 'alice:alice@example.com:vault-admins;bob:bob@example.com:staff'
+//
+// and run the server with
+//
+//	OIDC_ISSUER=http://localhost:9000 OIDC_CLIENT_ID=vault OIDC_CLIENT_SECRET=secret \
+//	OIDC_REDIRECT_URL=http://localhost:8080/auth/oidc/callback
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type user struct {
+	Name     string
+	Email    string
+	Verified bool
+	Groups   []string
+}
+
+// parseUsers reads "name:email[:group,group...]" entries separated by
+// semicolons; a "!" before the email marks it unverified.
+func parseUsers(spec string) []user {
+	var users []user
+	for _, entry := range strings.Split(spec, ";") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		u := user{Name: parts[0], Email: parts[1], Verified: true}
+		if strings.HasPrefix(u.Email, "!") {
+			u.Email, u.Verified = u.Email[1:], false
+		}
+		if len(parts) == 3 && parts[2] != "" {
+			u.Groups = strings.Split(parts[2], ",")
+		}
+		users = append(users, u)
+	}
+	return users
+}
+
+type grant struct {
+	user      user
+	clientID  string
+	redirect  string
+	nonce     string
+	challenge string
+	expires   time.Time
+}
+
+type idp struct {
+	issuer, clientID, secret string
+	redirects                []string
+	users                    []user
+
+	key *rsa.PrivateKey
+	kid string
+
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer URL")
+	clientID := flag.String("client-id", "vault", "client id")
+	secret := flag.String("client-secret", "secret", "client secret (empty for a public client)")
+	redirects := flag.String("redirect", "http://localhost:8080/auth/oidc/callback", "allowed redirect URIs, comma-separated")
+	users := flag.String("users", "alice:alice@example.com:vault-admins;bob:bob@example.com:staff;eve:!eve@example.com",
+		`users as "name:email:group,group" separated by ";"; "!" before the email marks it unverified`)
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p := &idp{
+		issuer:    strings.TrimSuffix(*issuer, "/"),
+		clientID:  *clientID,
+		secret:    *secret,
+		redirects: strings.Split(*redirects, ","),
+		users:     parseUsers(*users),
+		key:       key,
+		kid:       randomString(8),
+		codes:     map[string]grant{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("GET /authorize", p.authorizeForm)
+	mux.HandleFunc("POST /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+
+	log.Printf("mock OIDC provider %s on %s", p.issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (p *idp) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 200, map[string]any{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile", "groups"},
+	})
+}
+
+func (p *idp) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, 200, map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": p.kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+var form = template.Must(template.New("authorize").Parse(`<!doctype html>
+<title>Mock IdP</title>
+<h1>Log in to {{.ClientID}}</h1>
+<form method="post">
+{{range $k, $v := .Query}}<input type="hidden" name="{{$k}}" value="{{index $v 0}}">
+{{end}}{{range .Users}}<p><button name="user" value="{{.Name}}">{{.Name}}</button> {{.Email}}{{if not .Verified}} (unverified){{end}} {{.Groups}}</p>
+{{end}}<p><button name="deny" value="1">Deny</button></p>
+</form>`))
+
+// checkRequest validates an authorization request; errors that cannot be
+// sent back to the client are answered directly.
+func (p *idp) checkRequest(w http.ResponseWriter, q url.Values) bool {
+	if q.Get("client_id") != p.clientID {
+		http.Error(w, "unknown client_id", 400)
+		return false
+	}
+	redirect := q.Get("redirect_uri")
+	allowed := false
+	for _, r := range p.redirects {
+		allowed = allowed || strings.TrimSpace(r) == redirect
+	}
+	if !allowed {
+		http.Error(w, "redirect_uri is not registered", 400)
+		return false
+	}
+	if q.Get("response_type") != "code" || q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		p.redirectBack(w, q, url.Values{"error": {"invalid_request"}, "error_description": {"code flow with S256 PKCE required"}})
+		return false
+	}
+	return true
+}
+
+func (p *idp) authorizeForm(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if !p.checkRequest(w, q) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = form.Execute(w, map[string]any{"ClientID": p.clientID, "Query": q, "Users": p.users})
+}
+
+func (p *idp) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", 400)
+		return
+	}
+	q := r.PostForm
+	if !p.checkRequest(w, q) {
+		return
+	}
+	if q.Get("deny") != "" {
+		p.redirectBack(w, q, url.Values{"error": {"access_denied"}})
+		return
+	}
+	var u *user
+	for i := range p.users {
+		if p.users[i].Name == q.Get("user") {
+			u = &p.users[i]
+		}
+	}
+	if u == nil {
+		http.Error(w, "unknown user", 400)
+		return
+	}
+
+	code := randomString(24)
+	p.mu.Lock()
+	p.codes[code] = grant{
+		user:      *u,
+		clientID:  q.Get("client_id"),
+		redirect:  q.Get("redirect_uri"),
+		nonce:     q.Get("nonce"),
+		challenge: q.Get("code_challenge"),
+		expires:   time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+	p.redirectBack(w, q, url.Values{"code": {code}})
+}
+
+func (p *idp) redirectBack(w http.ResponseWriter, q, params url.Values) {
+	if state := q.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	w.Header().Set("Location", q.Get("redirect_uri")+"?"+params.Encode())
+	w.WriteHeader(http.StatusFound)
+}
+
+func (p *idp) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+	clientID, secret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.clientID || subtle.ConstantTimeCompare([]byte(secret), []byte(p.secret)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="mockidp"`)
+		writeJSON(w, 401, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	g, found := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+	if !found || time.Now().After(g.expires) || g.clientID != clientID || g.redirect != r.PostForm.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                p.issuer,
+		"sub":                "mock|" + g.user.Name,
+		"aud":                clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              g.nonce,
+		"email":              g.user.Email,
+		"email_verified":     g.user.Verified,
+		"preferred_username": g.user.Name,
+		"name":               g.user.Name,
+		"groups":             append([]string{}, g.user.Groups...),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, 500, map[string]string{"error": "server_error"})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, 200, map[string]any{
+		"access_token": randomString(24),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, 400, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -1,18 +1,44 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// AccessTokenTTL is how long an access token is valid. ACCESS_TOKEN_TTL
+// overrides it, e.g. "5m".
+var AccessTokenTTL = durationEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+
+// RefreshTokenTTL is how long a refresh token can be used. Each refresh
+// issues a new one, so a session lasts as long as it is used at least
+// this often. REFRESH_TOKEN_TTL overrides it.
+var RefreshTokenTTL = durationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+
+func durationEnv(name string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
 
+// Claims are carried by access tokens. ID (the jti) lets a single token be
+// revoked; Generation is compared with users.token_generation, which is
+// bumped to revoke all of a user's tokens at once.
 type Claims struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
+	UserID     int64  `json:"user_id"`
+	Username   string `json:"username"`
+	Generation int64  `json:"gen"`
 	jwt.RegisteredClaims
 }
 
@@ -26,25 +52,90 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func GenerateJWT(userID int64, username string) (string, error) {
+// GenerateJWT issues an access token valid for AccessTokenTTL.
+func GenerateJWT(userID int64, username string, generation int64) (string, *Claims, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:     userID,
+		Username:   username,
+		Generation: generation,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	if signingKey == nil {
+		return "", nil, errors.New("auth: no signing key loaded")
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(signingKey.Alg), claims)
+	token.Header["kid"] = signingKey.ID
+	signed, err := token.SignedString(signingKey.Signer)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
-func ParseJWT(tokenStr string) (int64, string, error) {
+// ParseJWT checks an access token's signature, against the key its kid
+// names, and its expiry. Whether it has been revoked is up to the caller.
+func ParseJWT(tokenStr string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
-	if err != nil || !token.Valid {
-		return 0, "", err
+		kid, _ := token.Header["kid"].(string)
+		key, ok := verifyKeys[kid]
+		// The algorithm must be the key's, not whatever the token says.
+		if !ok || key.Alg != token.Method.Alg() {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key.Public, nil
+	}, jwt.WithValidMethods([]string{"EdDSA", "RS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
 	}
-	return claims.UserID, claims.Username, nil
-}
\ No newline at end of file
+	return claims, nil
+}
+
+// NewRefreshToken returns an opaque refresh token and the hash to store
+// for it. Only the hash is kept, so a database leak does not leak
+// sessions.
+func NewRefreshToken() (token, hash string, err error) {
+	token, err = randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashToken(token), nil
+}
+
+// HashToken hashes a refresh or personal access token for lookup. Tokens
+// are random, so a plain SHA-256 is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewPersonalAccessToken returns a personal access token, the hash to store
+// for it (see HashToken) and the prefix to show for it.
+func NewPersonalAccessToken() (token, hash, prefix string, err error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+	token = api.PersonalAccessTokenPrefix + secret
+	return token, HashToken(token), token[:len(api.PersonalAccessTokenPrefix)+8], nil
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
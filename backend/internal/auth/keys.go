@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// Access tokens are signed with an Ed25519 (EdDSA) or RSA (RS256) key and
+// name it in the kid header. Tokens are accepted from the signing key and
+// from any verification key, so a key can be rotated by making a new one
+// the signing key and keeping the old one for verification until the
+// tokens it signed have expired. The public keys are published as a JWK
+// set for other services.
+
+// Key is a key for access tokens. Signer is nil for keys that only verify.
+type Key struct {
+	// ID is the key's RFC 7638 thumbprint, so it needs no configuring.
+	ID     string
+	Alg    string
+	Public crypto.PublicKey
+	Signer crypto.Signer
+}
+
+var (
+	signingKey *Key
+	verifyKeys = map[string]*Key{}
+)
+
+// LoadKeysFromEnv loads the signing key from JWT_SIGNING_KEY and further
+// verification keys from JWT_VERIFY_KEYS. Each is PEM text or the path of
+// a PEM file; JWT_VERIFY_KEYS takes several, separated by commas, and a
+// file may hold several keys. It fails without a signing key.
+func LoadKeysFromEnv() error {
+	signing := os.Getenv("JWT_SIGNING_KEY")
+	if signing == "" {
+		return errors.New("JWT_SIGNING_KEY is not set; generate a key with `openssl genpkey -algorithm ed25519 -out jwt.pem`")
+	}
+	signingPEM, err := readPEM(signing)
+	if err != nil {
+		return fmt.Errorf("JWT_SIGNING_KEY: %w", err)
+	}
+	var verifyPEMs [][]byte
+	for _, v := range strings.Split(os.Getenv("JWT_VERIFY_KEYS"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		data, err := readPEM(v)
+		if err != nil {
+			return fmt.Errorf("JWT_VERIFY_KEYS: %w", err)
+		}
+		verifyPEMs = append(verifyPEMs, data)
+	}
+	return LoadKeys(signingPEM, verifyPEMs...)
+}
+
+// LoadKeys sets the signing key, which must be a private key, and the
+// extra verification keys, public or private.
+func LoadKeys(signing []byte, verify ...[]byte) error {
+	keys, err := parseKeys(signing)
+	if err != nil {
+		return err
+	}
+	if len(keys) != 1 || keys[0].Signer == nil {
+		return errors.New("signing key must be a single private key")
+	}
+
+	all := map[string]*Key{keys[0].ID: keys[0]}
+	for _, data := range verify {
+		more, err := parseKeys(data)
+		if err != nil {
+			return err
+		}
+		for _, k := range more {
+			if _, dup := all[k.ID]; !dup {
+				// Only the signing key signs.
+				all[k.ID] = &Key{ID: k.ID, Alg: k.Alg, Public: k.Public}
+			}
+		}
+	}
+	signingKey, verifyKeys = keys[0], all
+	return nil
+}
+
+func readPEM(v string) ([]byte, error) {
+	if strings.Contains(v, "-----BEGIN") {
+		return []byte(v), nil
+	}
+	return os.ReadFile(v)
+}
+
+func parseKeys(data []byte) ([]*Key, error) {
+	var keys []*Key
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		var parsed any
+		var err error
+		switch block.Type {
+		case "PRIVATE KEY":
+			parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "PUBLIC KEY":
+			parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		default:
+			return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+		k, err := newKey(parsed)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no PEM key found")
+	}
+	return keys, nil
+}
+
+func newKey(parsed any) (*Key, error) {
+	k := &Key{}
+	switch key := parsed.(type) {
+	case ed25519.PrivateKey:
+		k.Alg, k.Public, k.Signer = "EdDSA", key.Public(), key
+	case ed25519.PublicKey:
+		k.Alg, k.Public = "EdDSA", key
+	case *rsa.PrivateKey:
+		k.Alg, k.Public, k.Signer = "RS256", &key.PublicKey, key
+	case *rsa.PublicKey:
+		k.Alg, k.Public = "RS256", key
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use Ed25519 or RSA", parsed)
+	}
+	if pub, ok := k.Public.(*rsa.PublicKey); ok && pub.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys must have at least 2048 bits")
+	}
+	k.ID = thumbprint(k.JWK())
+	return k, nil
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k *Key) JWK() JWK {
+	enc := base64.RawURLEncoding.EncodeToString
+	j := JWK{Kid: k.ID, Use: "sig", Alg: k.Alg}
+	switch pub := k.Public.(type) {
+	case ed25519.PublicKey:
+		j.Kty, j.Crv, j.X = "OKP", "Ed25519", enc(pub)
+	case *rsa.PublicKey:
+		j.Kty, j.N, j.E = "RSA", enc(pub.N.Bytes()), enc(big.NewInt(int64(pub.E)).Bytes())
+	}
+	return j
+}
+
+// thumbprint is the RFC 7638 thumbprint: the SHA-256 of the required
+// members, in lexicographic order and without whitespace.
+func thumbprint(j JWK) string {
+	var members any
+	if j.Kty == "OKP" {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	} else {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// PublicKeys returns the verification keys as a JWK set, signing key
+// first.
+func PublicKeys() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if signingKey != nil {
+		set.Keys = append(set.Keys, signingKey.JWK())
+	}
+	for id, k := range verifyKeys {
+		if signingKey == nil || id != signingKey.ID {
+			set.Keys = append(set.Keys, k.JWK())
+		}
+	}
+	return set
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func ed25519PEM(t *testing.T) (private, public []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pemBlock(t, "PRIVATE KEY", priv), pemBlock(t, "PUBLIC KEY", pub)
+}
+
+func pemBlock(t *testing.T, typ string, key any) []byte {
+	t.Helper()
+	var der []byte
+	var err error
+	if typ == "PRIVATE KEY" {
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	} else {
+		der, err = x509.MarshalPKIXPublicKey(key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func mustLoad(t *testing.T, signing []byte, verify ...[]byte) {
+	t.Helper()
+	if err := LoadKeys(signing, verify...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func issue(t *testing.T) string {
+	t.Helper()
+	token, _, err := GenerateJWT(1, "alice", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldPriv, oldPub := ed25519PEM(t)
+	newPriv, _ := ed25519PEM(t)
+
+	mustLoad(t, oldPriv)
+	oldToken := issue(t)
+	oldID := signingKey.ID
+
+	// Rotate: the new key signs, the old one still verifies.
+	mustLoad(t, newPriv, oldPub)
+	if _, err := ParseJWT(oldToken); err != nil {
+		t.Errorf("a token of the previous key was refused during rotation: %v", err)
+	}
+	newToken := issue(t)
+	parsed, _, _ := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	if kid := parsed.Header["kid"]; kid != signingKey.ID || kid == oldID {
+		t.Errorf("a new token names key %v, want the new signing key", kid)
+	}
+	if set := PublicKeys(); len(set.Keys) != 2 || set.Keys[0].Kid != signingKey.ID {
+		t.Errorf("JWK set during rotation is %+v, want the signing key first and the old one", set)
+	}
+
+	// Retire the old key.
+	mustLoad(t, newPriv)
+	if _, err := ParseJWT(oldToken); err == nil {
+		t.Error("a token of a retired key was accepted")
+	}
+	if _, err := ParseJWT(newToken); err != nil {
+		t.Errorf("a token of the signing key was refused: %v", err)
+	}
+}
+
+func TestRSAKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustLoad(t, pemBlock(t, "PRIVATE KEY", key))
+	claims, err := ParseJWT(issue(t))
+	if err != nil || claims.UserID != 1 || claims.Username != "alice" {
+		t.Errorf("RS256 round trip gave %+v, %v", claims, err)
+	}
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadKeys(pemBlock(t, "PRIVATE KEY", weak)); err == nil {
+		t.Error("a 1024-bit RSA key was accepted")
+	}
+	_, pub := ed25519PEM(t)
+	if err := LoadKeys(pub); err == nil {
+		t.Error("a public key was accepted as the signing key")
+	}
+}
+
+func TestRejectedTokens(t *testing.T) {
+	priv, _ := ed25519PEM(t)
+	mustLoad(t, priv)
+	pub := signingKey.Public.(ed25519.PublicKey)
+
+	// HS256 keyed with the public key, naming the Ed25519 key.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}})
+	forged.Header["kid"] = signingKey.ID
+	token, err := forged.SignedString([]byte(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseJWT(token); err == nil {
+		t.Error("an HS256 token keyed with the public key was accepted")
+	}
+
+	noExpiry := jwt.NewWithClaims(jwt.SigningMethodEdDSA, &Claims{UserID: 1})
+	noExpiry.Header["kid"] = signingKey.ID
+	token, err = noExpiry.SignedString(signingKey.Signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseJWT(token); err == nil {
+		t.Error("a token without expiry was accepted")
+	}
+
+	ttl := AccessTokenTTL
+	AccessTokenTTL = -time.Minute
+	expired := issue(t)
+	AccessTokenTTL = ttl
+	if _, err := ParseJWT(expired); err == nil {
+		t.Error("an expired token was accepted")
+	}
+}
+
+// TestThumbprint checks the RFC 8037 Ed25519 thumbprint example.
+func TestThumbprint(t *testing.T) {
+	j := JWK{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+	if got, want := thumbprint(j), "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"; got != want {
+		t.Errorf("thumbprint is %s, want %s", got, want)
+	}
+}
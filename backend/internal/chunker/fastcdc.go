@@ -0,0 +1,104 @@
+package chunker
+
+import (
+	"io"
+)
+
+// FastCDC content-defined chunking (Xia et al., USENIX ATC '16) with
+// normalized chunking. Boundaries depend only on the content, so an edit
+// in the middle of a file only changes the chunks around it.
+
+const (
+	MinSize = 16 * 1024
+	AvgSize = 64 * 1024
+	MaxSize = 256 * 1024
+)
+
+var (
+	// avg is 2^16; the small-chunk mask is harder to match (2 extra bits)
+	// and the large-chunk mask easier (2 fewer bits).
+	maskS = topBits(18)
+	maskL = topBits(14)
+	gear  [256]uint64
+)
+
+func init() {
+	// The table must never change: stored chunk boundaries depend on it.
+	seed := uint64(0x42616c6b616e4344)
+	for i := range gear {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		gear[i] = z ^ (z >> 31)
+	}
+}
+
+func topBits(n uint) uint64 {
+	return ((uint64(1) << n) - 1) << (64 - n)
+}
+
+// Cut returns the length of the first chunk in data.
+func Cut(data []byte) int {
+	n := len(data)
+	if n <= MinSize {
+		return n
+	}
+	if n > MaxSize {
+		n = MaxSize
+	}
+	normal := AvgSize
+	if n < normal {
+		normal = n
+	}
+
+	var fp uint64
+	i := MinSize
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gear[data[i]]
+		if fp&maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// Chunker splits a stream into content-defined chunks.
+type Chunker struct {
+	r   io.Reader
+	buf []byte
+	n   int
+	eof bool
+}
+
+func New(r io.Reader) *Chunker {
+	return &Chunker{r: r, buf: make([]byte, MaxSize)}
+}
+
+// Next returns the next chunk, or io.EOF once the stream is exhausted.
+func (c *Chunker) Next() ([]byte, error) {
+	for !c.eof && c.n < MaxSize {
+		m, err := c.r.Read(c.buf[c.n:])
+		c.n += m
+		if err == io.EOF {
+			c.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if c.n == 0 {
+		return nil, io.EOF
+	}
+
+	size := Cut(c.buf[:c.n])
+	chunk := make([]byte, size)
+	copy(chunk, c.buf[:size])
+	c.n = copy(c.buf, c.buf[size:c.n])
+	return chunk, nil
+}
@@ -0,0 +1,78 @@
+package chunker
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func chunks(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+	c := New(bytes.NewReader(data))
+	var out [][]byte
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, chunk)
+	}
+}
+
+func TestSmallInputs(t *testing.T) {
+	if got := chunks(t, nil); len(got) != 0 {
+		t.Errorf("empty input gave %d chunks, want none", len(got))
+	}
+	got := chunks(t, []byte{7})
+	if len(got) != 1 || !bytes.Equal(got[0], []byte{7}) {
+		t.Errorf("one byte gave %v, want one chunk of it", got)
+	}
+}
+
+func TestBoundaries(t *testing.T) {
+	data := make([]byte, 4<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	got := chunks(t, data)
+	if !bytes.Equal(bytes.Join(got, nil), data) {
+		t.Fatal("chunks do not add up to the input")
+	}
+	for i, chunk := range got {
+		if len(chunk) > MaxSize {
+			t.Errorf("chunk %d is %d bytes, over MaxSize", i, len(chunk))
+		}
+		if len(chunk) < MinSize && i != len(got)-1 {
+			t.Errorf("chunk %d is %d bytes, under MinSize", i, len(chunk))
+		}
+	}
+	if again := chunks(t, data); len(again) != len(got) {
+		t.Errorf("chunking the same input twice gave %d and %d chunks", len(got), len(again))
+	}
+}
+
+// TestLocality checks that an edit in the middle of the input leaves most
+// chunks unchanged.
+func TestLocality(t *testing.T) {
+	data := make([]byte, 4<<20)
+	rand.New(rand.NewSource(2)).Read(data)
+	edited := append(append(append([]byte{}, data[:2<<20]...), "inserted"...), data[2<<20:]...)
+
+	seen := map[string]bool{}
+	for _, chunk := range chunks(t, data) {
+		seen[string(chunk)] = true
+	}
+	got := chunks(t, edited)
+	var shared int
+	for _, chunk := range got {
+		if seen[string(chunk)] {
+			shared++
+		}
+	}
+	if shared < len(got)-3 {
+		t.Errorf("only %d of %d chunks survived a small edit", shared, len(got))
+	}
+}
@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
+)
+
+// ChunkIndex is the Postgres-backed storage.ChunkIndex (chunks and
+// blob_chunks tables).
+type ChunkIndex struct{}
+
+// RefChunk upserts the chunk row, so it waits on a RemoveChunk holding the
+// row and then finds it gone.
+func (ChunkIndex) RefChunk(ctx context.Context, hash string, size int64) (bool, error) {
+	var refs int64
+	err := Pool.QueryRow(ctx,
+		`INSERT INTO chunks (hash, size, ref_count) VALUES ($1,$2,1)
+		 ON CONFLICT (hash) DO UPDATE SET ref_count = GREATEST(chunks.ref_count, 0) + 1
+		 RETURNING ref_count`,
+		hash, size,
+	).Scan(&refs)
+	return refs == 1, err
+}
+
+func (ChunkIndex) UnrefChunks(ctx context.Context, hashes []string) ([]string, error) {
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, hash := range hashes {
+		if _, err := tx.Exec(ctx, "UPDATE chunks SET ref_count = ref_count - 1 WHERE hash=$1", hash); err != nil {
+			return nil, err
+		}
+	}
+	orphans, err := collectStrings(tx.Query(ctx,
+		"SELECT DISTINCT hash FROM chunks WHERE hash = ANY($1) AND ref_count <= 0",
+		hashes,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return orphans, tx.Commit(ctx)
+}
+
+// RemoveChunk holds the chunk row locked while remove runs, so the file is
+// only removed if the row's deletion commits with no reference taken.
+func (ChunkIndex) RemoveChunk(ctx context.Context, hash string, remove func() error) error {
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "DELETE FROM chunks WHERE hash=$1 AND ref_count <= 0", hash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+	if err := remove(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (ChunkIndex) SaveManifest(ctx context.Context, blobKey string, refs []storage.ChunkRef) (bool, error) {
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock out a concurrent save of the same blob until this one commits.
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", blobKey); err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM blob_chunks WHERE blob_hash=$1)", blobKey).Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists {
+		return false, tx.Commit(ctx)
+	}
+
+	for seq, ref := range refs {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO blob_chunks (blob_hash, seq, chunk_id, chunk_offset)
+			 SELECT $1, $2, id, $4 FROM chunks WHERE hash=$3`,
+			blobKey, seq, ref.Hash, ref.Offset,
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit(ctx)
+}
+
+func (ChunkIndex) Manifest(ctx context.Context, blobKey string) ([]storage.ChunkRef, error) {
+	rows, err := Pool.Query(ctx,
+		`SELECT c.hash, bc.chunk_offset, c.size
+		 FROM blob_chunks bc
+		 JOIN chunks c ON bc.chunk_id = c.id
+		 WHERE bc.blob_hash=$1
+		 ORDER BY bc.seq`,
+		blobKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []storage.ChunkRef
+	for rows.Next() {
+		var ref storage.ChunkRef
+		if err := rows.Scan(&ref.Hash, &ref.Offset, &ref.Size); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+func (ChunkIndex) DropManifest(ctx context.Context, blobKey string) (bool, []string, error) {
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE chunks c SET ref_count = c.ref_count - d.n
+		 FROM (SELECT chunk_id, COUNT(*) AS n FROM blob_chunks WHERE blob_hash=$1 GROUP BY chunk_id) d
+		 WHERE c.id = d.chunk_id`,
+		blobKey,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil, nil
+	}
+
+	// The chunk rows stay; storage.ChunkedStore removes the orphans through
+	// RemoveChunk, which only deletes a row nothing has referenced since.
+	orphans, err := collectStrings(tx.Query(ctx,
+		`WITH gone AS (DELETE FROM blob_chunks WHERE blob_hash=$1 RETURNING chunk_id)
+		 SELECT DISTINCT c.hash FROM chunks c JOIN gone ON gone.chunk_id = c.id
+		 WHERE c.ref_count <= 0`,
+		blobKey,
+	))
+	if err != nil {
+		return false, nil, err
+	}
+	return true, orphans, tx.Commit(ctx)
+}
+
+// RepairEmptyBlobs gives every empty blob that has no manifest the empty
+// chunk it gets on upload now. store.RepairEmpty leaves blobs that were
+// stored whole alone, so this is safe to run on every start.
+func RepairEmptyBlobs(ctx context.Context, store *storage.ChunkedStore) error {
+	rows, err := Pool.Query(ctx,
+		`SELECT b.path, COALESCE(b.key_id, 0) FROM blobs b
+		 WHERE b.size = 0 AND NOT EXISTS (SELECT 1 FROM blob_chunks bc WHERE bc.blob_hash = b.path)`,
+	)
+	if err != nil {
+		return err
+	}
+	type blob struct {
+		key   string
+		keyID int64
+	}
+	var blobs []blob
+	for rows.Next() {
+		var b blob
+		if err := rows.Scan(&b.key, &b.keyID); err != nil {
+			rows.Close()
+			return err
+		}
+		blobs = append(blobs, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, b := range blobs {
+		blobCtx := ctx
+		if b.keyID != 0 {
+			blobCtx = storage.WithKeyID(ctx, b.keyID)
+		}
+		if err := store.RepairEmpty(blobCtx, b.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectStrings(rows pgx.Rows, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// KeyIndex is the Postgres-backed encryption.KeyIndex (data_keys table).
+type KeyIndex struct{}
+
+func (KeyIndex) UserKeyID(ctx context.Context, userID int64) (int64, bool, error) {
+	var id int64
+	err := Pool.QueryRow(ctx, "SELECT id FROM data_keys WHERE user_id=$1", userID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	return id, err == nil, err
+}
+
+func (KeyIndex) CreateUserKey(ctx context.Context, userID int64, wrapped []byte) (int64, error) {
+	var id int64
+	err := Pool.QueryRow(ctx,
+		`INSERT INTO data_keys (user_id, wrapped_key) VALUES ($1,$2)
+		 ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		 RETURNING id`,
+		userID, wrapped,
+	).Scan(&id)
+	return id, err
+}
+
+func (KeyIndex) WrappedKey(ctx context.Context, keyID int64) ([]byte, error) {
+	var wrapped []byte
+	err := Pool.QueryRow(ctx, "SELECT wrapped_key FROM data_keys WHERE id=$1", keyID).Scan(&wrapped)
+	return wrapped, err
+}
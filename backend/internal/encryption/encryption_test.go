@@ -0,0 +1,215 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
+)
+
+// memKeys is an in-memory KeyIndex.
+type memKeys struct {
+	byUser map[int64]int64
+	keys   map[int64][]byte
+}
+
+func (m *memKeys) UserKeyID(ctx context.Context, userID int64) (int64, bool, error) {
+	id, ok := m.byUser[userID]
+	return id, ok, nil
+}
+
+func (m *memKeys) CreateUserKey(ctx context.Context, userID int64, wrapped []byte) (int64, error) {
+	id := int64(len(m.keys) + 1)
+	m.keys[id] = wrapped
+	m.byUser[userID] = id
+	return id, nil
+}
+
+func (m *memKeys) WrappedKey(ctx context.Context, keyID int64) ([]byte, error) {
+	return m.keys[keyID], nil
+}
+
+func newTestStore(t *testing.T, convergent bool) (*Store, string) {
+	t.Helper()
+	root := t.TempDir()
+	inner, err := storage.NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master := make([]byte, 32)
+	rand.Read(master)
+	s, err := NewStore(inner, &memKeys{byUser: map[int64]int64{}, keys: map[int64][]byte{}}, master, convergent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, root
+}
+
+// userCtx returns a context carrying the data key of user 1.
+func userCtx(t *testing.T, s *Store) context.Context {
+	t.Helper()
+	id, err := s.UserKeyID(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return storage.WithKeyID(context.Background(), id)
+}
+
+func random(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// read returns a function that reads what Get or GetRange returned.
+func read(t *testing.T) func(io.ReadCloser, error) []byte {
+	return func(rc io.ReadCloser, err error) []byte {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, SegmentSize - 1, SegmentSize, SegmentSize + 1, 3*SegmentSize + 5} {
+		s, root := newTestStore(t, false)
+		ctx := userCtx(t, s)
+		data := random(size)
+		if err := s.Put(ctx, "blob", bytes.NewReader(data), int64(size)); err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+
+		stored, err := os.ReadFile(filepath.Join(root, "blob"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(stored)) != cipherSize(int64(size), SegmentSize) {
+			t.Errorf("size %d: stored %d bytes, want %d", size, len(stored), cipherSize(int64(size), SegmentSize))
+		}
+		if h, ok := parseHeader(stored); !ok || h.size != int64(size) || h.mode != modeUserKey {
+			t.Errorf("size %d: bad header %+v", size, h)
+		}
+		if size > 16 && bytes.Contains(stored, data[:16]) {
+			t.Errorf("size %d: plaintext stored as-is", size)
+		}
+
+		if got := read(t)(s.Get(ctx, "blob")); !bytes.Equal(got, data) {
+			t.Errorf("size %d: Get returned %d different bytes", size, len(got))
+		}
+		info, err := s.Stat(ctx, "blob")
+		if err != nil || info.Size != int64(size) {
+			t.Errorf("size %d: Stat gave %d, %v", size, info.Size, err)
+		}
+	}
+}
+
+func TestGetRange(t *testing.T) {
+	s, _ := newTestStore(t, false)
+	ctx := userCtx(t, s)
+	data := random(3*SegmentSize + 100)
+	if err := s.Put(ctx, "blob", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []struct{ offset, length int64 }{
+		{0, 1},
+		{SegmentSize - 1, 2},
+		{SegmentSize, SegmentSize},
+		{SegmentSize - 10, SegmentSize + 20},
+		{5, 3 * SegmentSize},
+		{int64(len(data)) - 1, 1},
+		{int64(len(data)) - 50, 1000}, // past the end
+	} {
+		end := r.offset + r.length
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		got := read(t)(s.GetRange(ctx, "blob", r.offset, r.length))
+		if !bytes.Equal(got, data[r.offset:end]) {
+			t.Errorf("GetRange(%d, %d) returned %d wrong bytes", r.offset, r.length, len(got))
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	s, root := newTestStore(t, false)
+	ctx := userCtx(t, s)
+	data := random(2 * SegmentSize)
+	if err := s.Put(ctx, "blob", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "blob")
+	stored, _ := os.ReadFile(path)
+
+	for name, at := range map[string]int{
+		"second segment": headerSize + SegmentSize + tagSize + 10,
+		"header":         headerSize - 1, // the plaintext size, which is AAD
+	} {
+		tampered := append([]byte{}, stored...)
+		tampered[at] ^= 1
+		os.WriteFile(path, tampered, 0o644)
+
+		rc, err := s.Get(ctx, "blob")
+		if err == nil {
+			_, err = io.ReadAll(rc)
+			rc.Close()
+		}
+		if err == nil {
+			t.Errorf("tampering with the %s went unnoticed", name)
+		}
+	}
+}
+
+func TestConvergent(t *testing.T) {
+	s, root := newTestStore(t, true)
+	data := random(SegmentSize + 1)
+	if err := s.Put(context.Background(), "same", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t)(s.Get(context.Background(), "same")); !bytes.Equal(got, data) {
+		t.Error("convergent round trip failed")
+	}
+	first, _ := os.ReadFile(filepath.Join(root, "same"))
+	if err := s.Put(context.Background(), "same", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := os.ReadFile(filepath.Join(root, "same"))
+	if !bytes.Equal(first, second) {
+		t.Error("convergent encryption of equal content differs")
+	}
+
+	if _, err := NewStore(nil, nil, make([]byte, 16), false); err == nil {
+		t.Error("a 16-byte master key was accepted")
+	}
+}
+
+// TestPlainObjects checks that objects written before encryption was
+// enabled are still read as they are.
+func TestPlainObjects(t *testing.T) {
+	s, root := newTestStore(t, false)
+	data := []byte("written before encryption")
+	if err := os.WriteFile(filepath.Join(root, "old"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t)(s.Get(context.Background(), "old")); !bytes.Equal(got, data) {
+		t.Errorf("Get returned %q", got)
+	}
+	if got := read(t)(s.GetRange(context.Background(), "old", 8, 6)); string(got) != "before" {
+		t.Errorf("GetRange returned %q", got)
+	}
+	if err := s.Put(context.Background(), "new", bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("a put without a data key was stored")
+	}
+}
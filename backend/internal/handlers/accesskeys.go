@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// Access keys are the credentials for the S3 gateway (see s3gateway.go).
+
+const accessKeyAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+
+func generateAccessKey() (string, string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	id := []byte("BK")
+	for _, v := range b {
+		id = append(id, accessKeyAlphabet[int(v)%len(accessKeyAlphabet)])
+	}
+
+	secret := make([]byte, 30)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	return string(id), base64.StdEncoding.EncodeToString(secret), nil
+}
+
+func (h *Handler) CreateAccessKeyHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	accessKey, secretKey, err := generateAccessKey()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate access key"})
+		return
+	}
+
+	key := api.NewAccessKey{
+		AccessKey: api.AccessKey{AccessKey: accessKey},
+		SecretKey: secretKey,
+		Message:   "store the secret key now, it is not shown again",
+	}
+	err = db.Pool.QueryRow(c,
+		"INSERT INTO access_keys (user_id, access_key, secret_key) VALUES ($1,$2,$3) RETURNING id, created_at",
+		userID, accessKey, secretKey,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create access key"})
+		return
+	}
+
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "create_access_key", "access_key", key.ID,
+	)
+
+	c.JSON(200, key)
+}
+
+func (h *Handler) ListAccessKeysHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	rows, err := db.Pool.Query(c,
+		"SELECT id, access_key, created_at, last_used_at FROM access_keys WHERE user_id=$1 ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list access keys"})
+		return
+	}
+	defer rows.Close()
+
+	keys := []api.AccessKey{}
+	for rows.Next() {
+		var k api.AccessKey
+		if err := rows.Scan(&k.ID, &k.AccessKey, &k.CreatedAt, &k.LastUsedAt); err != nil {
+			continue
+		}
+		keys = append(keys, k)
+	}
+
+	c.JSON(200, api.AccessKeyList{AccessKeys: keys})
+}
+
+func (h *Handler) DeleteAccessKeyHandler(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetInt64("user_id")
+
+	res, err := db.Pool.Exec(c, "DELETE FROM access_keys WHERE id=$1 AND user_id=$2", id, userID)
+	if err != nil || res.RowsAffected() == 0 {
+		c.JSON(404, gin.H{"error": "access key not found"})
+		return
+	}
+
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "delete_access_key", "access_key", id,
+	)
+
+	c.JSON(200, api.Message{Message: "access key deleted"})
+}
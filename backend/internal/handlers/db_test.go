@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
+)
+
+// The tests that need Postgres run against the database in
+// TEST_DATABASE_URL, each in a schema of its own that every migration is
+// applied to and that is dropped afterwards. Without it they are skipped.
+
+func testDB(t *testing.T) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connecting to the test database: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := db.Pool
+	db.Pool = pool
+	t.Cleanup(func() {
+		db.Pool = previous
+		pool.Close()
+		_, _ = admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close(ctx)
+	})
+
+	migrations, err := filepath.Glob("../../migrations/*.up.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	number := func(path string) int {
+		n, _ := strconv.Atoi(strings.SplitN(filepath.Base(path), "_", 2)[0])
+		return n
+	}
+	sort.Slice(migrations, func(i, j int) bool { return number(migrations[i]) < number(migrations[j]) })
+	for _, m := range migrations {
+		sql, err := os.ReadFile(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("applying %s: %v", filepath.Base(m), err)
+		}
+	}
+}
+
+// insertID runs an INSERT … RETURNING id.
+func insertID(t *testing.T, sql string, args ...any) int64 {
+	t.Helper()
+	var id int64
+	if err := db.Pool.QueryRow(context.Background(), sql, args...).Scan(&id); err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+	return id
+}
+
+func testOrg(t *testing.T, slug string) int64 {
+	return insertID(t, "INSERT INTO organizations (name, slug) VALUES ($1,$1) RETURNING id", slug)
+}
+
+func testUser(t *testing.T, orgID int64, name string) int64 {
+	return insertID(t,
+		"INSERT INTO users (username, email, password_hash, org_id, email_verified_at) VALUES ($1,$2,'',$3,now()) RETURNING id",
+		name, name+"@example.com", orgID,
+	)
+}
+
+// testFolder creates a folder owned by ownerID, under parentID unless it
+// is 0.
+func testFolder(t *testing.T, ownerID int64, name string, parentID int64) int64 {
+	var parent *int64
+	if parentID != 0 {
+		parent = &parentID
+	}
+	return insertID(t, "INSERT INTO folders (owner_id, name, parent_id) VALUES ($1,$2,$3) RETURNING id", ownerID, name, parent)
+}
+
+// testFile creates an empty file owned by ownerID, in folderID unless it
+// is 0.
+func testFile(t *testing.T, ownerID int64, name string, folderID int64) int64 {
+	var folder *int64
+	if folderID != 0 {
+		folder = &folderID
+	}
+	return insertID(t, "INSERT INTO files (owner_id, filename, size, folder_id) VALUES ($1,$2,0,$3) RETURNING id", ownerID, name, folder)
+}
+
+// testHandler returns a Handler storing blobs in a temporary directory.
+func testHandler(t *testing.T) *Handler {
+	t.Helper()
+	dir := t.TempDir()
+	blobs, err := storage.NewLocalStore(dir + "/blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHandler(dir, blobs)
+}
+
+// serve sends a JSON request with body, as userID, to handler mounted at
+// route.
+func serve(userID int64, handler gin.HandlerFunc, method, route, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	return serveRequest(userID, handler, route, req)
+}
+
+// serveRequest sends req, as userID, to handler mounted at route.
+func serveRequest(userID int64, handler gin.HandlerFunc, route string, req *http.Request) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Handle(req.Method, route, func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	}, handler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// upload uploads a text file through UploadHandler as userID, into folderID
+// unless it is 0.
+func upload(t *testing.T, h *Handler, userID, folderID int64, name, content string) api.UploadResult {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if folderID != 0 {
+		_ = form.WriteField("folder_id", strconv.FormatInt(folderID, 10))
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, name))
+	header.Set("Content-Type", "text/plain")
+	part, err := form.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	form.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := serveRequest(userID, h.UploadHandler, "/upload", req)
+	if w.Code != 200 {
+		t.Fatalf("uploading %s: got %d: %s", name, w.Code, w.Body)
+	}
+	var res api.UploadResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func count(t *testing.T, sql string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.Pool.QueryRow(context.Background(), sql, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+	return n
+}
+
+func execSQL(t *testing.T, sql string, args ...any) {
+	t.Helper()
+	if _, err := db.Pool.Exec(context.Background(), sql, args...); err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+}
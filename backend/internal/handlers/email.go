@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/mailer"
+)
+
+// Email verification and password resets. Both send a link with a
+// single-use token (stored hashed in email_tokens) to the frontend at
+// APP_URL, which posts the token back.
+
+const (
+	purposeVerifyEmail   = "verify_email"
+	purposeResetPassword = "reset_password"
+
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+
+	// emailResendInterval limits how often a user can have the same kind of
+	// email sent, so the endpoints cannot be used to flood an inbox.
+	emailResendInterval = time.Minute
+)
+
+func appURL() string {
+	if u := os.Getenv("APP_URL"); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	return "http://localhost:3000"
+}
+
+// sendMail sends in the background: a slow relay should not hold up the
+// request, nor should its timing tell whether an account exists.
+func (h *Handler) sendMail(msg mailer.Message) {
+	if h.Mailer == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := h.Mailer.Send(ctx, msg); err != nil {
+			log.Printf("sending %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
+
+// newEmailToken stores a token for purpose and returns it. It returns ""
+// without error when one was sent less than emailResendInterval ago.
+func newEmailToken(c *gin.Context, userID int64, purpose, email string, ttl time.Duration) (string, error) {
+	var recent bool
+	err := db.Pool.QueryRow(c,
+		`SELECT EXISTS (SELECT 1 FROM email_tokens
+		   WHERE user_id=$1 AND purpose=$2 AND created_at > now() - make_interval(secs => $3))`,
+		userID, purpose, emailResendInterval.Seconds(),
+	).Scan(&recent)
+	if err != nil || recent {
+		return "", err
+	}
+
+	token, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	_, _ = db.Pool.Exec(c, "DELETE FROM email_tokens WHERE user_id=$1 AND expires_at < now()", userID)
+	_, err = db.Pool.Exec(c,
+		"INSERT INTO email_tokens (user_id, purpose, token_hash, email, expires_at) VALUES ($1,$2,$3,$4,$5)",
+		userID, purpose, hash, email, time.Now().Add(ttl),
+	)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendVerificationEmail mails a link that verifies email for the user.
+func (h *Handler) sendVerificationEmail(c *gin.Context, userID int64, username, email string) error {
+	token, err := newEmailToken(c, userID, purposeVerifyEmail, email, verifyEmailTTL)
+	if err != nil || token == "" {
+		return err
+	}
+	h.sendMail(mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+please confirm that this is your email address by opening the link below
+within %d hours:
+
+%s/verify-email?token=%s
+
+If you did not sign up, you can ignore this email.
+`, username, int(verifyEmailTTL.Hours()), appURL(), token),
+	})
+	return nil
+}
+
+// emailVerified reports whether the user has verified their email.
+func emailVerified(c *gin.Context, userID int64) (bool, error) {
+	var verified bool
+	err := db.Pool.QueryRow(c,
+		"SELECT email_verified_at IS NOT NULL FROM users WHERE id=$1", userID,
+	).Scan(&verified)
+	return verified, err
+}
+
+// VerifyEmailHandler redeems a token from a verification email.
+func (h *Handler) VerifyEmailHandler(c *gin.Context) {
+	var body api.EmailTokenRequest
+	if err := c.BindJSON(&body); err != nil || body.Token == "" {
+		c.JSON(400, gin.H{"error": "token is required"})
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to verify email"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	var userID int64
+	err = tx.QueryRow(c,
+		`UPDATE email_tokens t SET used_at=now()
+		 FROM users u
+		 WHERE t.token_hash=$1 AND t.purpose=$2 AND t.used_at IS NULL AND t.expires_at > now()
+		   AND u.id = t.user_id AND u.email = t.email
+		 RETURNING t.user_id`,
+		auth.HashToken(body.Token), purposeVerifyEmail,
+	).Scan(&userID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "link is invalid or expired, request a new one"})
+		return
+	}
+	_, err = tx.Exec(c,
+		"UPDATE users SET email_verified_at=COALESCE(email_verified_at, now()) WHERE id=$1",
+		userID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to verify email"})
+		return
+	}
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "verify_email", "user", userID,
+	)
+	if err := tx.Commit(c); err != nil {
+		c.JSON(500, gin.H{"error": "failed to verify email"})
+		return
+	}
+
+	c.JSON(200, api.Message{Message: "email verified"})
+}
+
+// ResendVerificationHandler sends the caller a new verification email.
+func (h *Handler) ResendVerificationHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var username, email string
+	var verified bool
+	err := db.Pool.QueryRow(c,
+		"SELECT username, COALESCE(email, ''), email_verified_at IS NOT NULL FROM users WHERE id=$1",
+		userID,
+	).Scan(&username, &email, &verified)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to send email"})
+		return
+	}
+	if verified {
+		c.JSON(409, gin.H{"error": "email is already verified"})
+		return
+	}
+	if email == "" {
+		c.JSON(409, gin.H{"error": "account has no email"})
+		return
+	}
+	if err := h.sendVerificationEmail(c, userID, username, email); err != nil {
+		c.JSON(500, gin.H{"error": "failed to send email"})
+		return
+	}
+
+	c.JSON(200, api.Message{Message: "verification email sent to " + email})
+}
+
+// RequestPasswordResetHandler mails a reset link to the account with the
+// email, if there is one. It answers the same either way.
+func (h *Handler) RequestPasswordResetHandler(c *gin.Context) {
+	var body api.PasswordResetRequest
+	if err := c.BindJSON(&body); err != nil || !validateEmail(strings.TrimSpace(body.Email)) {
+		c.JSON(400, gin.H{"error": "a valid email is required"})
+		return
+	}
+	const sent = "if an account uses this email, a reset link is on its way"
+
+	var userID int64
+	var username, email string
+	err := db.Pool.QueryRow(c,
+		"SELECT id, username, email FROM users WHERE lower(email)=lower($1)",
+		strings.TrimSpace(body.Email),
+	).Scan(&userID, &username, &email)
+	if err != nil {
+		c.JSON(200, api.Message{Message: sent})
+		return
+	}
+
+	token, err := newEmailToken(c, userID, purposeResetPassword, email, resetPasswordTTL)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to send email"})
+		return
+	}
+	if token != "" {
+		h.sendMail(mailer.Message{
+			To:      email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf(`Hi %s,
+
+someone asked to reset the password of your account. To choose a new
+password, open the link below within %d minutes:
+
+%s/reset-password?token=%s
+
+The link works once. Resetting the password logs you out on every device.
+If you did not ask for this, you can ignore this email.
+`, username, int(resetPasswordTTL.Minutes()), appURL(), token),
+		})
+		_, _ = db.Pool.Exec(c,
+			"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+			userID, "request_password_reset", "user", userID,
+		)
+	}
+
+	c.JSON(200, api.Message{Message: sent})
+}
+
+// ResetPasswordHandler sets a new password with a token from a reset
+// email. The link proves the user reads the email, so it also verifies
+// it. All sessions end.
+func (h *Handler) ResetPasswordHandler(c *gin.Context) {
+	var body api.PasswordResetConfirmRequest
+	if err := c.BindJSON(&body); err != nil || body.Token == "" {
+		c.JSON(400, gin.H{"error": "token and password are required"})
+		return
+	}
+	if msg := checkPassword(body.Password); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+	hash, err := auth.HashPassword(body.Password)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to reset password"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	var userID int64
+	err = tx.QueryRow(c,
+		`UPDATE email_tokens t SET used_at=now()
+		 FROM users u
+		 WHERE t.token_hash=$1 AND t.purpose=$2 AND t.used_at IS NULL AND t.expires_at > now()
+		   AND u.id = t.user_id AND u.email = t.email
+		 RETURNING t.user_id`,
+		auth.HashToken(body.Token), purposeResetPassword,
+	).Scan(&userID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "link is invalid or expired, request a new one"})
+		return
+	}
+
+	_, err = tx.Exec(c,
+		"UPDATE users SET password_hash=$2, email_verified_at=COALESCE(email_verified_at, now()) WHERE id=$1",
+		userID, hash,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to reset password"})
+		return
+	}
+	// Other reset links sent before stop working too.
+	_, _ = tx.Exec(c,
+		"UPDATE email_tokens SET used_at=now() WHERE user_id=$1 AND purpose=$2 AND used_at IS NULL",
+		userID, purposeResetPassword,
+	)
+	if err := revokeSessions(c, tx, userID); err != nil {
+		c.JSON(500, gin.H{"error": "failed to reset password"})
+		return
+	}
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "reset_password", "user", userID,
+	)
+	if err := tx.Commit(c); err != nil {
+		c.JSON(500, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	c.JSON(200, api.Message{Message: "password changed, log in with the new password"})
+}
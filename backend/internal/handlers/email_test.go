@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/mailer"
+)
+
+// inbox is a Mailer that hands the messages it is sent to the test.
+type inbox chan mailer.Message
+
+func (in inbox) Send(ctx context.Context, msg mailer.Message) error {
+	in <- msg
+	return nil
+}
+
+var mailToken = regexp.MustCompile(`token=(\S+)`)
+
+// receive waits for a message and returns the token in its link.
+func (in inbox) receive(t *testing.T) (mailer.Message, string) {
+	t.Helper()
+	select {
+	case msg := <-in:
+		m := mailToken.FindStringSubmatch(msg.Body)
+		if m == nil {
+			t.Fatalf("no link in %q", msg.Body)
+		}
+		return msg, m[1]
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email sent")
+		return mailer.Message{}, ""
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	testDB(t)
+	mail := make(inbox, 10)
+	h := &Handler{Mailer: mail}
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	execSQL(t, "UPDATE users SET email_verified_at=NULL WHERE id=$1", alice)
+
+	resend := func() int {
+		return serve(alice, h.ResendVerificationHandler, "POST", "/verify-email/resend", "/verify-email/resend", "").Code
+	}
+	verify := func(token string) int {
+		return serve(0, h.VerifyEmailHandler, "POST", "/verify-email", "/verify-email", fmt.Sprintf(`{"token":%q}`, token)).Code
+	}
+
+	if code := resend(); code != 200 {
+		t.Fatalf("resending: got %d", code)
+	}
+	msg, token := mail.receive(t)
+	if msg.To != "alice@example.com" {
+		t.Errorf("sent to %s, want alice@example.com", msg.To)
+	}
+	if code := resend(); code != 200 {
+		t.Errorf("resending again: got %d", code)
+	}
+	if n := count(t, "SELECT count(*) FROM email_tokens WHERE user_id=$1", alice); n != 1 {
+		t.Errorf("resending within a minute stored %d tokens, want 1", n)
+	}
+
+	if code := verify("wrong"); code != 400 {
+		t.Errorf("verifying with a wrong token: got %d, want 400", code)
+	}
+	if code := verify(token); code != 200 {
+		t.Fatalf("verifying: got %d", code)
+	}
+	if code := verify(token); code != 400 {
+		t.Errorf("verifying twice: got %d, want 400", code)
+	}
+	if n := count(t, "SELECT count(*) FROM users WHERE id=$1 AND email_verified_at IS NOT NULL", alice); n != 1 {
+		t.Error("the email is not verified")
+	}
+	if code := resend(); code != 409 {
+		t.Errorf("resending once verified: got %d, want 409", code)
+	}
+}
+
+// TestVerifyChangedEmail checks that a link stops working once the account
+// uses another email.
+func TestVerifyChangedEmail(t *testing.T) {
+	testDB(t)
+	mail := make(inbox, 10)
+	h := &Handler{Mailer: mail}
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	execSQL(t, "UPDATE users SET email_verified_at=NULL WHERE id=$1", alice)
+
+	serve(alice, h.ResendVerificationHandler, "POST", "/verify-email/resend", "/verify-email/resend", "")
+	_, token := mail.receive(t)
+	execSQL(t, "UPDATE users SET email='alice@elsewhere.com' WHERE id=$1", alice)
+	w := serve(0, h.VerifyEmailHandler, "POST", "/verify-email", "/verify-email", fmt.Sprintf(`{"token":%q}`, token))
+	if w.Code != 400 {
+		t.Errorf("verifying an old email: got %d, want 400", w.Code)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	testDB(t)
+	mail := make(inbox, 10)
+	h := &Handler{Mailer: mail}
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	insertID(t, "INSERT INTO refresh_tokens (user_id, family_id, token_hash, generation, expires_at) VALUES ($1,1,'hash',0,now() + interval '1 day') RETURNING id", alice)
+
+	request := func(email string) int {
+		return serve(0, h.RequestPasswordResetHandler, "POST", "/password-reset", "/password-reset", fmt.Sprintf(`{"email":%q}`, email)).Code
+	}
+	reset := func(token, password string) int {
+		return serve(0, h.ResetPasswordHandler, "POST", "/password-reset/confirm", "/password-reset/confirm",
+			fmt.Sprintf(`{"token":%q,"password":%q}`, token, password)).Code
+	}
+
+	if code := request("nobody@example.com"); code != 200 {
+		t.Errorf("requesting for an unknown email: got %d, want 200", code)
+	}
+	if code := request("not an email"); code != 400 {
+		t.Errorf("requesting for an invalid email: got %d, want 400", code)
+	}
+	if code := request("ALICE@example.com"); code != 200 {
+		t.Fatalf("requesting: got %d", code)
+	}
+	_, token := mail.receive(t)
+
+	if code := reset(token, "short"); code != 400 {
+		t.Errorf("resetting to a short password: got %d, want 400", code)
+	}
+	if code := reset(token, "a new password"); code != 200 {
+		t.Fatalf("resetting: got %d", code)
+	}
+	if code := reset(token, "another password"); code != 400 {
+		t.Errorf("reusing the link: got %d, want 400", code)
+	}
+
+	var hash string
+	var generation int
+	err := db.Pool.QueryRow(context.Background(),
+		"SELECT password_hash, token_generation FROM users WHERE id=$1", alice,
+	).Scan(&hash, &generation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !auth.CheckPasswordHash("a new password", hash) {
+		t.Error("the password did not change")
+	}
+	if generation == 0 {
+		t.Error("access tokens were not revoked")
+	}
+	if n := count(t, "SELECT count(*) FROM refresh_tokens WHERE user_id=$1 AND revoked_at IS NULL", alice); n != 0 {
+		t.Errorf("%d refresh tokens left, want 0", n)
+	}
+}
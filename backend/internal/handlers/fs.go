@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// The /fs API addresses a user's folders and files by path, e.g.
+// /fs/reports/2026/q1.pdf, resolving each component through parent_id from
+// the root. It uses the same helpers as WebDAV and the S3 gateway (see
+// paths.go). For a token restricted to a folder, that folder is the root
+// (see fsRoot).
+
+// fsNode is a resolved path: the root, a folder or a file.
+type fsNode struct {
+	path     string
+	parentID *int64
+	folder   *pathFolder
+	file     *pathFile
+}
+
+func (n *fsNode) isDir() bool { return n.file == nil }
+
+// dirID is the id of the folder n names; nil is the root.
+func (n *fsNode) dirID() *int64 {
+	if n.folder == nil {
+		return nil
+	}
+	return &n.folder.ID
+}
+
+func fsPath(parts []string) string {
+	return "/" + strings.Join(parts, "/")
+}
+
+// resolveFSPath looks up the entry at parts. A folder and a file sharing the
+// last name is errAmbiguousPath rather than a guess.
+func resolveFSPath(c *gin.Context, userID int64, parts []string) (*fsNode, error) {
+	n := &fsNode{path: fsPath(parts)}
+	if len(parts) == 0 {
+		if root := fsRoot(c); root != nil {
+			folder, err := lookupFolderID(c, userID, *root)
+			if err != nil {
+				return nil, err
+			}
+			n.folder = folder
+		}
+		return n, nil
+	}
+	parentID, err := resolveFolderPath(c, userID, fsRoot(c), parts[:len(parts)-1])
+	if err != nil {
+		return nil, err
+	}
+	n.parentID = parentID
+	name := parts[len(parts)-1]
+
+	folder, ferr := lookupFolder(c, userID, parentID, name)
+	file, err := lookupFile(c, userID, parentID, name)
+	switch {
+	case ferr == nil && err == nil:
+		return nil, errAmbiguousPath
+	case ferr == nil:
+		n.folder = folder
+	case err == nil:
+		n.file = file
+	default:
+		return nil, os.ErrNotExist
+	}
+	return n, nil
+}
+
+// fsFail reports a path error with the status the /fs API uses for it.
+func fsFail(c *gin.Context, p string, err error, message string) {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		c.JSON(404, gin.H{"error": "path not found", "path": p})
+	case errors.Is(err, errAmbiguousPath):
+		c.JSON(409, gin.H{"error": "path is ambiguous: a folder and a file share its name", "path": p})
+	case errors.Is(err, os.ErrExist):
+		c.JSON(409, gin.H{"error": "path already exists", "path": p})
+	case errors.Is(err, os.ErrInvalid):
+		c.JSON(400, gin.H{"error": "invalid name", "path": p})
+	case errors.Is(err, os.ErrPermission):
+		c.JSON(403, gin.H{"error": "permission denied", "path": p})
+	default:
+		c.JSON(500, gin.H{"error": message, "path": p})
+	}
+}
+
+func fsEntry(n *fsNode) api.Entry {
+	switch {
+	case n.file != nil:
+		return api.Entry{
+			Path:      n.path,
+			Type:      api.EntryFile,
+			ID:        &n.file.ID,
+			Name:      n.file.Name,
+			ParentID:  n.file.FolderID,
+			Size:      n.file.Size,
+			MIMEType:  n.file.MIME,
+			Hash:      n.file.Hash,
+			CreatedAt: &n.file.Created,
+		}
+	case n.folder != nil:
+		return api.Entry{
+			Path:      n.path,
+			Type:      api.EntryFolder,
+			ID:        &n.folder.ID,
+			Name:      n.folder.Name,
+			ParentID:  n.parentID,
+			CreatedAt: &n.folder.Created,
+		}
+	default:
+		return api.Entry{Path: "/", Type: api.EntryFolder}
+	}
+}
+
+// FSGetHandler stats a path, or with ?op=list lists a folder and with
+// ?op=download downloads a file.
+func (h *Handler) FSGetHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	parts := splitPath(c.Param("path"))
+
+	n, err := resolveFSPath(c, userID, parts)
+	if err != nil {
+		fsFail(c, fsPath(parts), err, "failed to resolve path")
+		return
+	}
+
+	switch c.DefaultQuery("op", "stat") {
+	case "stat":
+		c.JSON(200, fsEntry(n))
+	case "list":
+		h.fsList(c, userID, n)
+	case "download":
+		h.fsDownload(c, userID, n)
+	default:
+		c.JSON(400, gin.H{"error": "unknown op"})
+	}
+}
+
+func (h *Handler) fsList(c *gin.Context, userID int64, n *fsNode) {
+	if !n.isDir() {
+		c.JSON(400, gin.H{"error": "path is not a folder", "path": n.path})
+		return
+	}
+	folders, files, err := listFolder(c, userID, n.dirID())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list folder", "path": n.path})
+		return
+	}
+
+	prefix := strings.TrimSuffix(n.path, "/") + "/"
+	entries := []api.Entry{}
+	for i := range folders {
+		entries = append(entries, fsEntry(&fsNode{path: prefix + folders[i].Name, parentID: n.dirID(), folder: &folders[i]}))
+	}
+	for i := range files {
+		entries = append(entries, fsEntry(&fsNode{path: prefix + files[i].Name, parentID: n.dirID(), file: &files[i]}))
+	}
+	c.JSON(200, api.EntryList{Path: n.path, Entries: entries})
+}
+
+func (h *Handler) fsDownload(c *gin.Context, userID int64, n *fsNode) {
+	if n.file == nil {
+		c.JSON(400, gin.H{"error": "path is not a file", "path": n.path})
+		return
+	}
+	f := n.file
+
+	_, _ = db.Pool.Exec(c, "UPDATE files SET download_count = download_count + 1 WHERE id=$1", f.ID)
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "download_file", "file", f.ID, fmt.Sprintf(`{"filename":%q}`, f.Name),
+	)
+	broadcastUpdate(c, gin.H{
+		"event":    "download",
+		"file_id":  f.ID,
+		"filename": f.Name,
+		"user_id":  userID,
+		"ts":       time.Now(),
+	})
+
+	serveFileWithRange(c, h.Blobs, f.BlobKey, f.Name, f.MIME, true)
+}
+
+// FSPutHandler uploads the raw request body to a path. An existing file
+// there gets a new version; ?parents=true creates missing folders and
+// ?tags=a,b tags the file.
+func (h *Handler) FSPutHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	parts := splitPath(c.Param("path"))
+	p := fsPath(parts)
+	if len(parts) == 0 {
+		c.JSON(400, gin.H{"error": "cannot upload to the root", "path": p})
+		return
+	}
+	name := parts[len(parts)-1]
+
+	tags, ok := parseUploadTags(c.Query("tags"))
+	if !ok {
+		c.JSON(400, gin.H{"error": "invalid tag length"})
+		return
+	}
+
+	var folderID *int64
+	var err error
+	if c.Query("parents") == "true" {
+		folderID, err = ensureFolderPath(c, userID, fsRoot(c), parts[:len(parts)-1])
+	} else {
+		folderID, err = resolveFolderPath(c, userID, fsRoot(c), parts[:len(parts)-1])
+	}
+	if err != nil {
+		fsFail(c, fsPath(parts[:len(parts)-1]), err, "failed to create parent folders")
+		return
+	}
+	if _, err := lookupFolder(c, userID, folderID, name); err == nil {
+		c.JSON(409, gin.H{"error": "path is a folder", "path": p})
+		return
+	}
+
+	maxSizeMB, _ := strconv.ParseInt(os.Getenv("MAX_FILE_SIZE_MB"), 10, 64)
+	if maxSizeMB > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSizeMB*1024*1024)
+	}
+
+	staged, err := h.stageUpload(c.Request.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(413, gin.H{"error": "file too large"})
+			return
+		}
+		c.JSON(400, gin.H{"error": "failed to read request body"})
+		return
+	}
+	defer os.Remove(staged.Path)
+	if staged.Size == 0 {
+		c.JSON(400, gin.H{"error": "empty files are not supported"})
+		return
+	}
+
+	// A bare octet-stream says nothing about the content, so only a more
+	// specific type is checked against what is detected.
+	declared := c.ContentType()
+	if declared == "application/octet-stream" {
+		declared = ""
+	}
+
+	res, uerr := h.saveUpload(c, userID, staged, name, declared, tags, folderID)
+	if uerr != nil {
+		if uerr.Detected != "" {
+			c.JSON(uerr.Status, gin.H{
+				"error":    uerr.Message,
+				"declared": declared,
+				"detected": uerr.Detected,
+				"status":   "rejected",
+			})
+			return
+		}
+		c.JSON(uerr.Status, gin.H{"error": uerr.Message})
+		return
+	}
+
+	out := res.response(tags, folderID)
+	out.Path = p
+	c.JSON(200, out)
+}
+
+// FSPostHandler runs ?op=mkdir (with &recursive=true for missing parents)
+// or ?op=move with a JSON body {"to": "/new/path"}.
+func (h *Handler) FSPostHandler(c *gin.Context) {
+	switch c.Query("op") {
+	case "mkdir":
+		h.fsMkdir(c)
+	case "move":
+		h.fsMove(c)
+	default:
+		c.JSON(400, gin.H{"error": "unknown op"})
+	}
+}
+
+func (h *Handler) fsMkdir(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	parts := splitPath(c.Param("path"))
+	p := fsPath(parts)
+	if len(parts) == 0 {
+		c.JSON(409, gin.H{"error": "path already exists", "path": p})
+		return
+	}
+
+	var folderID *int64
+	var err error
+	if c.Query("recursive") == "true" {
+		folderID, err = ensureFolderPath(c, userID, fsRoot(c), parts)
+	} else {
+		var parentID *int64
+		parentID, err = resolveFolderPath(c, userID, fsRoot(c), parts[:len(parts)-1])
+		if err != nil {
+			fsFail(c, fsPath(parts[:len(parts)-1]), err, "failed to resolve path")
+			return
+		}
+		var id int64
+		id, err = createFolder(c, userID, parentID, parts[len(parts)-1])
+		folderID = &id
+	}
+	if err != nil {
+		fsFail(c, p, err, "failed to create folder")
+		return
+	}
+
+	c.JSON(200, api.FolderStatus{Message: "folder created", FolderID: *folderID, Path: p})
+}
+
+func (h *Handler) fsMove(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	parts := splitPath(c.Param("path"))
+
+	var body api.MoveRequest
+	if err := c.BindJSON(&body); err != nil || body.To == "" {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if len(parts) == 0 {
+		c.JSON(400, gin.H{"error": "cannot move the root"})
+		return
+	}
+
+	n, err := resolveFSPath(c, userID, parts)
+	if err != nil {
+		fsFail(c, fsPath(parts), err, "failed to resolve path")
+		return
+	}
+
+	dest := splitPath(body.To)
+	to := fsPath(dest)
+	if len(dest) == 0 {
+		c.JSON(409, gin.H{"error": "path already exists", "path": to})
+		return
+	}
+	parentID, err := resolveFolderPath(c, userID, fsRoot(c), dest[:len(dest)-1])
+	if err != nil {
+		fsFail(c, fsPath(dest[:len(dest)-1]), err, "failed to resolve path")
+		return
+	}
+	name := dest[len(dest)-1]
+	if !validateFilename(name) {
+		c.JSON(400, gin.H{"error": "invalid name", "path": to})
+		return
+	}
+	if taken, err := nameTaken(c, userID, parentID, name); err != nil {
+		c.JSON(500, gin.H{"error": "failed to move", "path": to})
+		return
+	} else if taken {
+		c.JSON(409, gin.H{"error": "path already exists", "path": to})
+		return
+	}
+
+	if n.file != nil {
+		err = moveFile(c, userID, n.file, parentID, name)
+	} else {
+		err = moveFolder(c, userID, n.folder.ID, parentID, name)
+		if errors.Is(err, os.ErrInvalid) {
+			c.JSON(400, gin.H{"error": "cannot move a folder into its own subtree", "path": to})
+			return
+		}
+	}
+	if err != nil {
+		fsFail(c, to, err, "failed to move")
+		return
+	}
+
+	c.JSON(200, api.PathMoved{Message: "moved", From: n.path, To: to})
+}
+
+// FSDeleteHandler moves the file or folder at a path to the trash.
+func (h *Handler) FSDeleteHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	parts := splitPath(c.Param("path"))
+	if len(parts) == 0 {
+		c.JSON(400, gin.H{"error": "cannot delete the root"})
+		return
+	}
+
+	n, err := resolveFSPath(c, userID, parts)
+	if err != nil {
+		fsFail(c, fsPath(parts), err, "failed to resolve path")
+		return
+	}
+
+	if n.file != nil {
+		err = trashFile(c, userID, n.file)
+	} else {
+		err = trashFolder(c, userID, n.folder.ID)
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to move to trash", "path": n.path})
+		return
+	}
+
+	c.JSON(200, api.PathStatus{Message: "moved to trash", Path: n.path})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// fsRequest sends a /fs request as userID, with the access of pat unless
+// it is nil.
+func fsRequest(h *Handler, userID int64, pat *patAccess, method, path, body string) *httptest.ResponseRecorder {
+	handler := map[string]gin.HandlerFunc{
+		"GET":    h.FSGetHandler,
+		"PUT":    h.FSPutHandler,
+		"POST":   h.FSPostHandler,
+		"DELETE": h.FSDeleteHandler,
+	}[method]
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Handle(method, "/fs/*path", func(c *gin.Context) {
+		c.Set("user_id", userID)
+		if pat != nil {
+			c.Set("pat", pat)
+		}
+	}, handler)
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if method == "PUT" {
+		req.Header.Set("Content-Type", "text/plain")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestFSPaths(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	fs := func(method, path, body string) *httptest.ResponseRecorder {
+		return fsRequest(h, alice, nil, method, path, body)
+	}
+
+	if w := fs("PUT", "/fs/reports/q1.txt", "first"); w.Code != 404 {
+		t.Errorf("PUT into a missing folder: got %d, want 404", w.Code)
+	}
+	if w := fs("PUT", "/fs/reports/q1.txt?parents=true", "first"); w.Code != 200 {
+		t.Fatalf("PUT creating the folder: got %d %s", w.Code, w.Body)
+	}
+	if w := fs("PUT", "/fs/reports/q1.txt", "second"); w.Code != 200 {
+		t.Fatalf("PUT over the file: got %d %s", w.Code, w.Body)
+	}
+	if n := count(t, "SELECT count(*) FROM file_versions v JOIN files f ON v.file_id = f.id WHERE f.filename='q1.txt'"); n != 1 {
+		t.Errorf("overwriting made %d versions, want 1", n)
+	}
+	if w := fs("PUT", "/fs/reports", "x"); w.Code != 409 {
+		t.Errorf("PUT over a folder: got %d, want 409", w.Code)
+	}
+
+	var entry api.Entry
+	w := fs("GET", "/fs/reports/q1.txt", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil || entry.Type != api.EntryFile || entry.Size != 6 {
+		t.Errorf("stat: got %d %s", w.Code, w.Body)
+	}
+	if w := fs("GET", "/fs/reports/q1.txt?op=download", ""); w.Code != 200 || w.Body.String() != "second" {
+		t.Errorf("download: got %d %q", w.Code, w.Body)
+	}
+
+	if w := fs("POST", "/fs/reports/2026?op=mkdir", ""); w.Code != 200 {
+		t.Fatalf("mkdir: got %d %s", w.Code, w.Body)
+	}
+	if w := fs("POST", "/fs/reports/2026?op=mkdir", ""); w.Code != 409 {
+		t.Errorf("mkdir over a folder: got %d, want 409", w.Code)
+	}
+	if w := fs("POST", "/fs/a/b/c?op=mkdir&recursive=true", ""); w.Code != 200 {
+		t.Errorf("mkdir with parents: got %d %s", w.Code, w.Body)
+	}
+
+	if w := fs("POST", "/fs/reports/q1.txt?op=move", `{"to":"/reports/2026/q1.txt"}`); w.Code != 200 {
+		t.Fatalf("move: got %d %s", w.Code, w.Body)
+	}
+	if w := fs("GET", "/fs/reports/q1.txt", ""); w.Code != 404 {
+		t.Errorf("stat of the old path: got %d, want 404", w.Code)
+	}
+	if w := fs("POST", "/fs/reports?op=move", `{"to":"/reports/2026/reports"}`); w.Code != 400 {
+		t.Errorf("moving a folder into itself: got %d, want 400", w.Code)
+	}
+
+	var list api.EntryList
+	w = fs("GET", "/fs/reports?op=list", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Entries) != 1 || list.Entries[0].Path != "/reports/2026" {
+		t.Errorf("list: got %d %s", w.Code, w.Body)
+	}
+
+	testFolder(t, alice, "both", 0)
+	testFile(t, alice, "both", 0)
+	if w := fs("GET", "/fs/both", ""); w.Code != 409 {
+		t.Errorf("stat of a name both a folder and a file have: got %d, want 409", w.Code)
+	}
+
+	if w := fs("DELETE", "/fs/reports", ""); w.Code != 200 {
+		t.Fatalf("delete: got %d %s", w.Code, w.Body)
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE filename='q1.txt' AND trashed=true"); n != 1 {
+		t.Error("deleting the folder did not trash the file in it")
+	}
+}
+
+// TestFSRestrictedRoot checks that a token restricted to a folder sees it
+// as the root and cannot reach outside it.
+func TestFSRestrictedRoot(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	builds := testFolder(t, alice, "builds", 0)
+	testFile(t, alice, "secret.txt", 0)
+	pat := &patAccess{Scopes: []string{api.ScopeFilesRead, api.ScopeFilesWrite}, FolderID: &builds}
+
+	if w := fsRequest(h, alice, pat, "PUT", "/fs/out.txt", "built"); w.Code != 200 {
+		t.Fatalf("PUT at the root: got %d %s", w.Code, w.Body)
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE filename='out.txt' AND folder_id=$1", builds); n != 1 {
+		t.Error("the upload did not go into the token's folder")
+	}
+	for _, path := range []string{"/fs/secret.txt", "/fs/../secret.txt", "/fs/builds"} {
+		if w := fsRequest(h, alice, pat, "GET", path, ""); w.Code != 404 {
+			t.Errorf("stat of %s: got %d, want 404", path, w.Code)
+		}
+	}
+}
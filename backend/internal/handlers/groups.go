@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// Groups are teams that file and folder roles can be granted to (see
+// permissions.go). Whoever creates a group administers it; admins add and
+// remove members, make other admins, and rename or delete the group, which
+// takes away every role granted to it. A group always keeps an admin.
+// Groups belong to the creator's organization and only take its members,
+// and they are only visible to their members.
+
+// groupColumns are the columns scanGroup reads, from groups g joined to
+// the caller's membership m.
+const groupColumns = `g.id, g.name, g.description, g.created_at, m.is_admin,
+	(SELECT count(*) FROM group_members WHERE group_id = g.id)`
+
+func scanGroup(row pgx.Row) (api.Group, error) {
+	var g api.Group
+	err := row.Scan(&g.ID, &g.Name, &g.Description, &g.CreatedAt, &g.IsAdmin, &g.Members)
+	return g, err
+}
+
+// loadGroup returns group id as userID sees it, answering 404 unless they
+// are a member, and 403 when admin is set and they are not an admin.
+func loadGroup(c *gin.Context, userID, id int64, admin bool) (api.Group, bool) {
+	g, err := scanGroup(db.Pool.QueryRow(c,
+		`SELECT `+groupColumns+`
+		 FROM groups g JOIN group_members m ON m.group_id = g.id AND m.user_id=$2
+		 WHERE g.id=$1`,
+		id, userID,
+	))
+	if err == pgx.ErrNoRows {
+		c.JSON(404, gin.H{"error": "group not found"})
+		return g, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to load group"})
+		return g, false
+	}
+	if admin && !g.IsAdmin {
+		c.JSON(403, gin.H{"error": "only group admins can do that"})
+		return g, false
+	}
+	return g, true
+}
+
+func auditGroup(c *gin.Context, userID int64, action string, groupID int64, meta map[string]any) {
+	data, _ := json.Marshal(meta)
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, action, "group", groupID, string(data),
+	)
+}
+
+func validGroupName(name string) bool {
+	return name != "" && len([]rune(name)) <= 100
+}
+
+// CreateGroupHandler creates a group with the caller as its admin.
+func (h *Handler) CreateGroupHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	var body api.GroupRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	g := api.Group{Name: strings.TrimSpace(body.Name), Members: 1, IsAdmin: true}
+	if body.Description != nil {
+		g.Description = strings.TrimSpace(*body.Description)
+	}
+	if !validGroupName(g.Name) {
+		c.JSON(400, gin.H{"error": "name must be 1 to 100 characters"})
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create group"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	err = tx.QueryRow(c,
+		`INSERT INTO groups (org_id, name, description, created_by)
+		 SELECT org_id, $1, $2, id FROM users WHERE id=$3
+		 RETURNING id, created_at`,
+		g.Name, g.Description, userID,
+	).Scan(&g.ID, &g.CreatedAt)
+	if isUniqueViolation(err) {
+		c.JSON(409, gin.H{"error": "a group with that name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create group"})
+		return
+	}
+	_, err = tx.Exec(c,
+		"INSERT INTO group_members (group_id, user_id, is_admin, added_by) VALUES ($1,$2,true,$2)",
+		g.ID, userID,
+	)
+	if err != nil || tx.Commit(c) != nil {
+		c.JSON(500, gin.H{"error": "failed to create group"})
+		return
+	}
+
+	auditGroup(c, userID, "create_group", g.ID, map[string]any{"name": g.Name})
+	c.JSON(200, g)
+}
+
+// ListGroupsHandler lists the groups the caller belongs to.
+func (h *Handler) ListGroupsHandler(c *gin.Context) {
+	rows, err := db.Pool.Query(c,
+		`SELECT `+groupColumns+`
+		 FROM groups g JOIN group_members m ON m.group_id = g.id
+		 WHERE m.user_id=$1
+		 ORDER BY lower(g.name)`,
+		c.GetInt64("user_id"),
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list groups"})
+		return
+	}
+	defer rows.Close()
+
+	groups := []api.Group{}
+	for rows.Next() {
+		g, err := scanGroup(rows)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to list groups"})
+			return
+		}
+		groups = append(groups, g)
+	}
+	c.JSON(200, api.GroupList{Groups: groups})
+}
+
+// GetGroupHandler returns a group and its members, to its members.
+func (h *Handler) GetGroupHandler(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	g, ok := loadGroup(c, c.GetInt64("user_id"), id, false)
+	if !ok {
+		return
+	}
+
+	rows, err := db.Pool.Query(c,
+		`SELECT u.id, u.username, COALESCE(u.email, ''), m.is_admin, m.created_at
+		 FROM group_members m JOIN users u ON u.id = m.user_id
+		 WHERE m.group_id=$1
+		 ORDER BY m.is_admin DESC, u.username`,
+		id,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list members"})
+		return
+	}
+	defer rows.Close()
+
+	detail := api.GroupDetail{Group: g, MemberList: []api.GroupMember{}}
+	for rows.Next() {
+		var m api.GroupMember
+		if err := rows.Scan(&m.UserID, &m.Username, &m.Email, &m.IsAdmin, &m.AddedAt); err != nil {
+			c.JSON(500, gin.H{"error": "failed to list members"})
+			return
+		}
+		detail.MemberList = append(detail.MemberList, m)
+	}
+	c.JSON(200, detail)
+}
+
+// UpdateGroupHandler renames a group or changes its description.
+func (h *Handler) UpdateGroupHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var body api.GroupRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	g, ok := loadGroup(c, userID, id, true)
+	if !ok {
+		return
+	}
+	previous := g.Name
+	if name := strings.TrimSpace(body.Name); name != "" {
+		g.Name = name
+	}
+	if body.Description != nil {
+		g.Description = strings.TrimSpace(*body.Description)
+	}
+	if !validGroupName(g.Name) {
+		c.JSON(400, gin.H{"error": "name must be 1 to 100 characters"})
+		return
+	}
+
+	_, err := db.Pool.Exec(c, "UPDATE groups SET name=$1, description=$2 WHERE id=$3", g.Name, g.Description, id)
+	if isUniqueViolation(err) {
+		c.JSON(409, gin.H{"error": "a group with that name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to update group"})
+		return
+	}
+
+	auditGroup(c, userID, "update_group", id, map[string]any{"name": g.Name, "previous_name": previous})
+	c.JSON(200, g)
+}
+
+// DeleteGroupHandler deletes a group and every role granted to it.
+func (h *Handler) DeleteGroupHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	g, ok := loadGroup(c, userID, id, true)
+	if !ok {
+		return
+	}
+	if _, err := db.Pool.Exec(c, "DELETE FROM groups WHERE id=$1", id); err != nil {
+		c.JSON(500, gin.H{"error": "failed to delete group"})
+		return
+	}
+
+	auditGroup(c, userID, "delete_group", id, map[string]any{"name": g.Name})
+	c.JSON(200, api.Message{Message: "group deleted"})
+}
+
+// AddGroupMemberHandler adds a user to a group, or makes a member an admin
+// or no longer one.
+func (h *Handler) AddGroupMemberHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var body api.GroupMemberRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if _, ok := loadGroup(c, userID, id, true); !ok {
+		return
+	}
+
+	var m api.GroupMember
+	err := db.Pool.QueryRow(c,
+		"SELECT id, username, email FROM users WHERE lower(email)=lower($1) AND org_id=(SELECT org_id FROM users WHERE id=$2)",
+		strings.TrimSpace(body.Email), userID,
+	).Scan(&m.UserID, &m.Username, &m.Email)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "user not found"})
+		return
+	}
+
+	// Demoting an admin only goes ahead while another admin remains.
+	err = db.Pool.QueryRow(c,
+		`INSERT INTO group_members (group_id, user_id, is_admin, added_by) VALUES ($1,$2,$3,$4)
+		 ON CONFLICT (group_id, user_id) DO UPDATE SET is_admin=EXCLUDED.is_admin
+		 WHERE EXCLUDED.is_admin OR EXISTS (
+		   SELECT 1 FROM group_members WHERE group_id=$1 AND is_admin AND user_id<>$2
+		 )
+		 RETURNING is_admin, created_at`,
+		id, m.UserID, body.IsAdmin, userID,
+	).Scan(&m.IsAdmin, &m.AddedAt)
+	if err == pgx.ErrNoRows {
+		c.JSON(409, gin.H{"error": "a group needs at least one admin"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to add member"})
+		return
+	}
+
+	auditGroup(c, userID, "add_group_member", id, map[string]any{"user_id": m.UserID, "is_admin": m.IsAdmin})
+	c.JSON(200, m)
+}
+
+// RemoveGroupMemberHandler removes a member from a group. Admins remove
+// anyone; members can leave. The last admin cannot leave.
+func (h *Handler) RemoveGroupMemberHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := paramID(c, "user_id")
+	if !ok {
+		return
+	}
+	if _, ok := loadGroup(c, userID, id, memberID != userID); !ok {
+		return
+	}
+
+	var wasAdmin bool
+	err := db.Pool.QueryRow(c,
+		`DELETE FROM group_members
+		 WHERE group_id=$1 AND user_id=$2 AND (NOT is_admin OR EXISTS (
+		   SELECT 1 FROM group_members WHERE group_id=$1 AND is_admin AND user_id<>$2
+		 ))
+		 RETURNING is_admin`,
+		id, memberID,
+	).Scan(&wasAdmin)
+	if err == pgx.ErrNoRows {
+		var exists bool
+		_ = db.Pool.QueryRow(c,
+			"SELECT EXISTS (SELECT 1 FROM group_members WHERE group_id=$1 AND user_id=$2)", id, memberID,
+		).Scan(&exists)
+		if exists {
+			c.JSON(409, gin.H{"error": "a group needs at least one admin"})
+		} else {
+			c.JSON(404, gin.H{"error": "user is not a member of this group"})
+		}
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to remove member"})
+		return
+	}
+
+	auditGroup(c, userID, "remove_group_member", id, map[string]any{"user_id": memberID, "was_admin": wasAdmin})
+	c.JSON(200, api.Message{Message: "member removed"})
+}
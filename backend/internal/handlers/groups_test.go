@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// TestGroupGrants checks that members get the roles granted to their
+// groups and that, of equal roles, one granted to the user is preferred.
+func TestGroupGrants(t *testing.T) {
+	testDB(t)
+	org := testOrg(t, "org")
+	alice, bob := testUser(t, org, "alice"), testUser(t, org, "bob")
+	group := insertID(t, "INSERT INTO groups (name, created_by, org_id) VALUES ('team',$1,$2) RETURNING id", alice, org)
+	execSQL(t, "INSERT INTO group_members (group_id, user_id) VALUES ($1,$2)", group, bob)
+	folder := testFolder(t, alice, "shared", 0)
+	file := testFile(t, alice, "plan.txt", folder)
+	insertID(t, "INSERT INTO folder_permissions (folder_id, group_id, role) VALUES ($1,$2,'editor') RETURNING id", folder, group)
+
+	ctx := context.Background()
+	lookup := func() access {
+		t.Helper()
+		a, err := fileGrants.lookup(ctx, bob, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	if a := lookup(); a.Role != api.RoleEditor || a.GroupID == nil || *a.GroupID != group {
+		t.Errorf("a member has %+v, want editor through the group", a)
+	}
+	insertID(t, "INSERT INTO file_permissions (file_id, user_id, role) VALUES ($1,$2,'editor') RETURNING id", file, bob)
+	if a := lookup(); a.Role != api.RoleEditor || a.GroupID != nil {
+		t.Errorf("with equal user and group grants a member has %+v, want the user grant", a)
+	}
+	execSQL(t, "UPDATE file_permissions SET role='viewer' WHERE file_id=$1 AND user_id=$2", file, bob)
+	if a := lookup(); a.Role != api.RoleEditor || a.GroupID == nil {
+		t.Errorf("with a higher group grant a member has %+v, want editor through the group", a)
+	}
+
+	execSQL(t, "DELETE FROM group_members WHERE group_id=$1 AND user_id=$2", group, bob)
+	if a := lookup(); a.Role != api.RoleViewer {
+		t.Errorf("after leaving the group the role is %q, want viewer", a.Role)
+	}
+}
@@ -5,10 +5,8 @@ import (
   "encoding/hex"
   "fmt"
   "io"
-  "mime/multipart"
   "net/http"
   "os"
-  "path/filepath"
   "strings"
   "time"
   "strconv"
@@ -17,12 +15,24 @@ import (
   "github.com/gin-gonic/gin"
   "github.com/lib/pq"
 
+  "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
   "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
   "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+  "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/encryption"
+  "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/mailer"
+  "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/oidc"
+  "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
 )
 
 type Handler struct {
   StoragePath string
+  Blobs       storage.BlobStore
+  // Encryption is set when blobs are encrypted at rest.
+  Encryption  *encryption.Store
+  // OIDC is set when single sign-on is configured.
+  OIDC        *oidc.Provider
+  // Mailer sends verification and password reset emails.
+  Mailer      mailer.Mailer
 }
 
 // upload file
@@ -33,169 +43,46 @@ func (h *Handler) UploadHandler(c *gin.Context) {
         c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
     }
 
-    file, header, err := c.Request.FormFile("file")
-    if err != nil {
-        c.JSON(400, gin.H{"error": "failed to read file"})
-        return
-    }
-    defer file.Close()
-
-    filename := filepath.Base(header.Filename)
-    filename = strings.ReplaceAll(filename, "..", "")
-    if !validateFilename(filename) {
-        c.JSON(400, gin.H{"error": "invalid filename"})
-        return
-    }
-
-    detected, valid := detectAndValidateMIME(file, header.Header.Get("Content-Type"))
-    if !valid {
-        c.JSON(400, gin.H{
-            "error":    "MIME type mismatch",
-            "declared": header.Header.Get("Content-Type"),
-            "detected": detected,
-            "status":   "rejected",
-        })
-        return
-    }
-    file.Seek(0, 0)
-
-    hash, size, err := computeSHA256(file)
-    if err != nil {
-        c.JSON(500, gin.H{"error": "failed to compute hash"})
-        return
-    }
-    file.Seek(0, 0)
-
     userID := c.GetInt64("user_id")
     if userID == 0 {
         c.JSON(401, gin.H{"error": "unauthenticated"})
         return
     }
 
-    var quota int64
-    err = db.Pool.QueryRow(c, "SELECT quota FROM users WHERE id=$1", userID).Scan(&quota)
-    if err != nil {
-        c.JSON(500, gin.H{"error": "failed to get quota"})
+    form, err := h.readUploadForm(c, "file")
+    if err != nil || len(form.Files) == 0 {
+        c.JSON(400, gin.H{"error": "failed to read file"})
         return
     }
-    var used int64
-    err = db.Pool.QueryRow(c, "SELECT COALESCE(SUM(size),0) FROM files WHERE owner_id=$1 AND trashed=false", userID).Scan(&used)
-    if err != nil {
-        c.JSON(500, gin.H{"error": "failed to calculate usage"})
+    defer form.cleanup()
+    file := form.Files[0]
+
+    tagArray, ok := parseUploadTags(form.Fields["tags"])
+    if !ok {
+        c.JSON(400, gin.H{"error": "invalid tag length"})
         return
     }
-    if quota > 0 && used+size > quota {
-        c.JSON(413, gin.H{"error": "quota exceeded"})
+    folderID, ok := targetFolder(c, parseUploadFolder(form.Fields["folder_id"]))
+    if !ok {
         return
     }
 
-    blobPath := filepath.Join(h.StoragePath, hash)
-    var blobID int64
-    err = db.Pool.QueryRow(c, "SELECT id FROM blobs WHERE hash=$1", hash).Scan(&blobID)
-    if err == nil {
-        _, _ = db.Pool.Exec(c, "UPDATE blobs SET ref_count = ref_count + 1 WHERE id=$1", blobID)
-    } else {
-        out, err := os.Create(blobPath)
-        if err != nil {
-            c.JSON(500, gin.H{"error": "failed to save file"})
-            return
-        }
-        defer out.Close()
-        _, _ = io.Copy(out, file)
-
-        err = db.Pool.QueryRow(
-            c,
-            "INSERT INTO blobs (hash, size, path, ref_count, created_at) VALUES ($1,$2,$3,$4,$5) RETURNING id",
-            hash, size, blobPath, 1, time.Now(),
-        ).Scan(&blobID)
-        if err != nil {
-            c.JSON(500, gin.H{"error": "failed to insert blob"})
+    res, uerr := h.saveUpload(c, userID, file.stagedUpload, file.Filename, file.Declared, tagArray, folderID)
+    if uerr != nil {
+        if uerr.Detected != "" {
+            c.JSON(uerr.Status, gin.H{
+                "error":    uerr.Message,
+                "declared": file.Declared,
+                "detected": uerr.Detected,
+                "status":   "rejected",
+            })
             return
         }
-    }
-
-    tagStr := c.PostForm("tags")
-    var tagArray []string
-    if tagStr != "" {
-        tagArray = strings.Split(tagStr, ",")
-        for _, t := range tagArray {
-            if !validateTag(t) {
-                c.JSON(400, gin.H{"error": "invalid tag length"})
-                return
-            }
-        }
-    }
-
-    folderIDStr := c.PostForm("folder_id")
-    var folderID *int64
-    if folderIDStr != "" {
-        if fid, errConv := strconv.ParseInt(folderIDStr, 10, 64); errConv == nil {
-            folderID = &fid
-        }
-    }
-
-		previewAvailable := false
-    if strings.HasPrefix(detected, "image/") ||
-      detected == "application/pdf" ||
-      strings.HasPrefix(detected, "text/") {
-      previewAvailable = true
-	  }
-
-    var fileID int64
-    err = db.Pool.QueryRow(
-    c,
-    "INSERT INTO files (blob_id, owner_id, filename, mime_type, size, created_at, tags, folder_id, preview_available) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING id",
-    blobID, userID, filename, detected, size, time.Now(), pq.Array(tagArray), folderID, previewAvailable,
-    ).Scan(&fileID)
-    if err != nil {
-        c.JSON(500, gin.H{"error": "failed to insert file"})
+        c.JSON(uerr.Status, gin.H{"error": uerr.Message})
         return
     }
 
-    var existingFileID int64
-    err = db.Pool.QueryRow(
-        c,
-        "SELECT id FROM files WHERE owner_id=$1 AND filename=$2 AND folder_id IS NOT DISTINCT FROM $3 AND trashed=false LIMIT 1",
-        userID, filename, folderID,
-    ).Scan(&existingFileID)
-
-    if err == nil && existingFileID != 0 && existingFileID != fileID {
-        var maxVersion int
-        _ = db.Pool.QueryRow(c, "SELECT COALESCE(MAX(version),0) FROM file_versions WHERE file_id=$1", existingFileID).Scan(&maxVersion)
-        _, _ = db.Pool.Exec(c,
-            "INSERT INTO file_versions (file_id, version, blob_id, created_at) VALUES ($1,$2,$3,$4)",
-            existingFileID, maxVersion+1, blobID, time.Now(),
-        )
-    }
-
-    _, _ = db.Pool.Exec(c,
-        "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-        userID, "upload_file", "file", fileID, fmt.Sprintf(`{\"filename\":\"%s\"}`, filename),
-    )
-
-    broadcastUpdate(gin.H{
-        "event":     "file_uploaded",
-        "file_id":   fileID,
-        "filename":  filename,
-        "size":      size,
-        "mime":      detected,
-        "tags":      tagArray,
-        "folder_id": folderID,
-        "user":      userID,
-        "timestamp": time.Now(),
-    })
-
-    c.JSON(200, gin.H{
-        "file_id":   fileID,
-        "blob_id":   blobID,
-        "filename":  filename,
-        "size":      size,
-        "hash":      hash,
-        "mime":      detected,
-        "tags":      tagArray,
-        "folder_id": folderID,
-        "status":    "uploaded",
-    })
+    c.JSON(200, res.response(tagArray, folderID))
 }
 
 func (h *Handler) MultiUploadHandler(c *gin.Context) {
@@ -205,186 +92,47 @@ func (h *Handler) MultiUploadHandler(c *gin.Context) {
         c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
     }
 
-    form, err := c.MultipartForm()
+    form, err := h.readUploadForm(c, "files")
     if err != nil {
         c.JSON(400, gin.H{"error": "failed to parse form"})
         return
     }
-    files := form.File["files"]
+    defer form.cleanup()
     userID := c.GetInt64("user_id")
 
-    tagStr := c.PostForm("tags")
-    var tagArray []string
-    if tagStr != "" {
-        tagArray = strings.Split(tagStr, ",")
-        for _, t := range tagArray {
-            if !validateTag(t) {
-                c.JSON(400, gin.H{"error": "invalid tag length"})
-                return
-            }
-        }
-    }
-
-    folderIDStr := c.PostForm("folder_id")
-    var folderID *int64
-    if folderIDStr != "" {
-        if fid, errConv := strconv.ParseInt(folderIDStr, 10, 64); errConv == nil {
-            folderID = &fid
-        }
+    tagArray, ok := parseUploadTags(form.Fields["tags"])
+    if !ok {
+        c.JSON(400, gin.H{"error": "invalid tag length"})
+        return
     }
-
-    var quota int64
-    err = db.Pool.QueryRow(c, "SELECT quota FROM users WHERE id=$1", userID).Scan(&quota)
-    if err != nil {
-        c.JSON(500, gin.H{"error": "failed to get quota"})
+    folderID, ok := targetFolder(c, parseUploadFolder(form.Fields["folder_id"]))
+    if !ok {
         return
     }
 
-    var results []gin.H
-    for _, f := range files {
-        file, err := f.Open()
-        if err != nil {
-            results = append(results, gin.H{
-                "filename": f.Filename,
-                "status":   "rejected",
-                "reason":   "failed to open file",
+    results := []api.UploadResult{}
+    for _, f := range form.Files {
+        res, uerr := h.saveUpload(c, userID, f.stagedUpload, f.Filename, f.Declared, tagArray, folderID)
+        if uerr != nil {
+            results = append(results, api.UploadResult{
+                Filename: f.Filename,
+                Status:   api.UploadStatusRejected,
+                Reason:   uerr.Message,
             })
             continue
         }
 
-        func() {
-            defer file.Close()
-
-            safeName := filepath.Base(f.Filename)
-            safeName = strings.ReplaceAll(safeName, "..", "")
-            if !validateFilename(safeName) {
-                results = append(results, gin.H{"filename": safeName, "status": "rejected", "reason": "invalid filename"})
-                return
-            }
-
-            detected, valid := detectAndValidateMIME(file, f.Header.Get("Content-Type"))
-            if !valid {
-                results = append(results, gin.H{
-                    "filename": safeName,
-                    "status":   "rejected",
-                    "reason":   "MIME mismatch",
-                })
-                return
-            }
-            file.Seek(0, 0)
-
-            hash, size, err := computeSHA256(file)
-            if err != nil {
-                results = append(results, gin.H{
-                    "filename": safeName,
-                    "status":   "rejected",
-                    "reason":   "failed to hash file",
-                })
-                return
-            }
-            file.Seek(0, 0)
-
-            var usedNow int64
-            _ = db.Pool.QueryRow(c, "SELECT COALESCE(SUM(size),0) FROM files WHERE owner_id=$1 AND trashed=false", userID).Scan(&usedNow)
-            if quota > 0 && usedNow+size > quota {
-                results = append(results, gin.H{
-                    "filename": safeName,
-                    "status":   "rejected",
-                    "reason":   "quota exceeded",
-                })
-                return
-            }
-
-            blobPath := filepath.Join(h.StoragePath, hash)
-            var blobID int64
-            err = db.Pool.QueryRow(c, "SELECT id FROM blobs WHERE hash=$1", hash).Scan(&blobID)
-            if err == nil {
-                _, _ = db.Pool.Exec(c, "UPDATE blobs SET ref_count = ref_count + 1 WHERE id=$1", blobID)
-            } else {
-                out, err := os.Create(blobPath)
-                if err != nil {
-                    results = append(results, gin.H{"filename": safeName, "status": "rejected", "reason": "failed to save file"})
-                    return
-                }
-                io.Copy(out, file)
-                out.Close()
-
-                _ = db.Pool.QueryRow(c,
-                    "INSERT INTO blobs (hash, size, path, ref_count, created_at) VALUES ($1,$2,$3,$4,$5) RETURNING id",
-                    hash, size, blobPath, 1, time.Now(),
-                ).Scan(&blobID)
-            }
-
-            previewAvailable := false
-            if strings.HasPrefix(detected, "image/") ||
-                detected == "application/pdf" ||
-                strings.HasPrefix(detected, "text/") {
-                previewAvailable = true
-            }
-
-            var fileID int64
-            _ = db.Pool.QueryRow(c,
-                "INSERT INTO files (blob_id, owner_id, filename, mime_type, size, created_at, tags, folder_id, preview_available) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING id",
-                blobID, userID, safeName, detected, size, time.Now(), pq.Array(tagArray), folderID, previewAvailable,
-            ).Scan(&fileID)
-
-            var existingFileID int64
-            _ = db.Pool.QueryRow(c,
-                "SELECT id FROM files WHERE owner_id=$1 AND filename=$2 AND folder_id IS NOT DISTINCT FROM $3 AND trashed=false LIMIT 1",
-                userID, safeName, folderID,
-            ).Scan(&existingFileID)
-
-            if existingFileID != 0 && existingFileID != fileID {
-                var maxVersion int
-                _ = db.Pool.QueryRow(c, "SELECT COALESCE(MAX(version),0) FROM file_versions WHERE file_id=$1", existingFileID).Scan(&maxVersion)
-                _, _ = db.Pool.Exec(c,
-                    "INSERT INTO file_versions (file_id, version, blob_id, created_at) VALUES ($1,$2,$3,$4)",
-                    existingFileID, maxVersion+1, blobID, time.Now(),
-                )
-            }
-
-            results = append(results, gin.H{
-                "file_id":   fileID,
-                "filename":  safeName,
-                "hash":      hash,
-                "size":      size,
-                "mime":      detected,
-                "tags":      tagArray,
-                "folder_id": folderID,
-                "status":    "uploaded",
-            })
-
-            _, _ = db.Pool.Exec(c,
-                "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-                userID, "upload_file", "file", fileID, fmt.Sprintf(`{\"filename\":\"%s\"}`, safeName),
-            )
-
-            broadcastUpdate(gin.H{
-                "event":     "file_uploaded",
-                "file_id":   fileID,
-                "filename":  safeName,
-                "size":      size,
-                "mime":      detected,
-                "tags":      tagArray,
-                "folder_id": folderID,
-                "user":      userID,
-                "timestamp": time.Now(),
-            })
-        }()
+        results = append(results, res.response(tagArray, folderID))
     }
 
-    c.JSON(200, gin.H{"results": results})
+    c.JSON(200, api.MultiUploadResult{Results: results})
 }
 
-func detectAndValidateMIME(file multipart.File, declared string) (string, bool) {
-	buf := make([]byte, 512)
-	n, _ := file.Read(buf)
-	file.Seek(0, 0)
-
-	detected := http.DetectContentType(buf[:n])
+func detectAndValidateMIME(sniff []byte, declared string) (string, bool) {
+	detected := http.DetectContentType(sniff)
 
 	if detected == "application/octet-stream" {
-		if isTextFile(buf[:n]) {
+		if isTextFile(sniff) {
 			detected = "text/plain; charset=utf-8"
 		}
 	}
@@ -416,7 +164,7 @@ func (h *Handler) ListFilesHandler(c *gin.Context) {
 
 	rows, err := db.Pool.Query(
     c,
-    `SELECT f.id, f.filename, f.size, f.created_at, f.download_count, f.is_public, f.preview_available, b.hash
+    `SELECT `+fileColumns+`
      FROM files f
      JOIN blobs b ON f.blob_id = b.id
      WHERE f.owner_id = $1 AND f.trashed=false
@@ -429,80 +177,48 @@ func (h *Handler) ListFilesHandler(c *gin.Context) {
 	}
 	defer rows.Close()
 
-	var files []gin.H
+	files := []api.File{}
 	for rows.Next() {
-		var (
-    id            int64
-    filename      string
-    size          int64
-    createdAt     time.Time
-    downloadCount int64
-    isPublic      bool
-    previewAvail  bool
-    hash          string
-)
-if err := rows.Scan(&id, &filename, &size, &createdAt, &downloadCount, &isPublic, &previewAvail, &hash); err != nil {
-    c.JSON(500, gin.H{"error": "failed to scan row"})
-    return
-}
-files = append(files, gin.H{
-    "id":               id,
-    "filename":         filename,
-    "size":             size,
-    "created_at":       createdAt,
-    "download_count":   downloadCount,
-    "is_public":        isPublic,
-    "preview_available": previewAvail,
-    "hash":             hash,
-})
+		f, err := scanFile(rows)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to scan row"})
+			return
+		}
+		files = append(files, f)
 	}
 
-	c.JSON(200, gin.H{"files": files})
+	c.JSON(200, api.FileList{Files: files})
 }
 
 func (h *Handler) DownloadHandler(c *gin.Context) {
-    id := c.Param("id")
+    id, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
     userID := c.GetInt64("user_id")
+    if _, ok := authorize(c, userID, id, actionRead); !ok {
+        return
+    }
 
     var (
         filename string
-        blobPath string
-        ownerID  int64
+        blobKey  string
         mimeType string
     )
     err := db.Pool.QueryRow(
         c,
-        `SELECT f.filename, b.path, f.owner_id, f.mime_type
+        `SELECT f.filename, b.path, f.mime_type
          FROM files f
          JOIN blobs b ON f.blob_id = b.id
          WHERE f.id=$1`,
         id,
-    ).Scan(&filename, &blobPath, &ownerID, &mimeType)
+    ).Scan(&filename, &blobKey, &mimeType)
 
     if err != nil {
         c.JSON(404, gin.H{"error": "file not found"})
         return
     }
 
-    var isEditor bool
-    _ = db.Pool.QueryRow(
-        c,
-        "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2",
-        id, userID,
-    ).Scan(&isEditor)
-
-    var isPublic bool
-    _ = db.Pool.QueryRow(
-        c,
-        "SELECT is_public FROM files WHERE id=$1",
-        id,
-    ).Scan(&isPublic)
-
-    if userID != ownerID && !isEditor && !isPublic {
-        c.JSON(403, gin.H{"error": "permission denied"})
-        return
-    }
-
     _, _ = db.Pool.Exec(c,
         "UPDATE files SET download_count = download_count + 1 WHERE id=$1",
         id,
@@ -510,10 +226,10 @@ func (h *Handler) DownloadHandler(c *gin.Context) {
 
     _, _ = db.Pool.Exec(c,
         "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-        userID, "download_file", "file", id, fmt.Sprintf(`{"filename":"%s"}`, filename),
+        userID, "download_file", "file", id, auditMeta(c, fmt.Sprintf(`{"filename":"%s"}`, filename)),
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":    "download",
         "file_id":  id,
         "filename": filename,
@@ -521,36 +237,20 @@ func (h *Handler) DownloadHandler(c *gin.Context) {
         "ts":       time.Now(),
     })
 
-    serveFileWithRange(c, blobPath, filename, mimeType, true)
+    serveFileWithRange(c, h.Blobs, blobKey, filename, mimeType, true)
 }
 
 func (h *Handler) DeleteHandler(c *gin.Context) {
-	id := c.Param("id")
-	userID := c.GetInt64("user_id")
-
-	var ownerID int64
-	err := db.Pool.QueryRow(
-		c,
-		"SELECT owner_id FROM files WHERE id=$1",
-		id,
-	).Scan(&ownerID)
-
-	if err != nil {
-		c.JSON(404, gin.H{"error": "file not found"})
+	id, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
-
-
-	if ownerID != userID {
-		var canEdit bool
-		_ = db.Pool.QueryRow(c, "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2", id, userID).Scan(&canEdit)
-		if !canEdit {
-			c.JSON(403, gin.H{"error": "not authorized"})
-			return
-		}
+	userID := c.GetInt64("user_id")
+	if _, ok := authorize(c, userID, id, actionEdit); !ok {
+		return
 	}
 
-	_, err = db.Pool.Exec(
+	_, err := db.Pool.Exec(
 		c,
 		"UPDATE files SET trashed=true, trashed_at=$1 WHERE id=$2",
 		time.Now(), id,
@@ -565,10 +265,10 @@ func (h *Handler) DeleteHandler(c *gin.Context) {
 	_, _ = db.Pool.Exec(
 		c,
 		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-		userID, "trash_file", "file", id, string(metaJson),
+		userID, "trash_file", "file", id, auditMeta(c, string(metaJson)),
 	)
 
-	broadcastUpdate(gin.H{
+	broadcastUpdate(c, gin.H{
         "event":   "file_trashed",
         "file_id": id,
         "user":    userID,
@@ -620,41 +320,68 @@ func (h *Handler) StatsHandler(c *gin.Context) {
 		userID,
 	).Scan(&trashSize)
 
-	var totalBlobSize, totalFileSize int64
-	_ = db.Pool.QueryRow(c, "SELECT COALESCE(SUM(size),0) FROM blobs").Scan(&totalBlobSize)
-	_ = db.Pool.QueryRow(c, "SELECT COALESCE(SUM(size),0) FROM files").Scan(&totalFileSize)
+	// Savings cover the caller's organization: its files, and the blobs
+	// and chunks those files are stored in. Stored bytes are the unique
+	// chunks plus any blob kept whole (written before chunking or with
+	// chunking off).
+	var storedSize, totalFileSize int64
+	_ = db.Pool.QueryRow(c,
+		`WITH org_blobs AS (
+		   SELECT DISTINCT b.id, b.path, b.size
+		   FROM files f
+		   JOIN users u ON u.id = f.owner_id
+		   JOIN blobs b ON b.id = f.blob_id
+		   WHERE u.org_id = (SELECT org_id FROM users WHERE id=$1)
+		 )
+		 SELECT COALESCE((SELECT SUM(ch.size) FROM chunks ch
+		                  WHERE ch.id IN (SELECT bc.chunk_id FROM blob_chunks bc JOIN org_blobs ob ON bc.blob_hash = ob.path)),0) +
+		        COALESCE((SELECT SUM(ob.size) FROM org_blobs ob
+		                  WHERE NOT EXISTS (SELECT 1 FROM blob_chunks bc WHERE bc.blob_hash = ob.path)),0)`,
+		userID,
+	).Scan(&storedSize)
+	_ = db.Pool.QueryRow(c,
+		`SELECT COALESCE(SUM(f.size),0) FROM files f
+		 JOIN users u ON u.id = f.owner_id
+		 WHERE u.org_id = (SELECT org_id FROM users WHERE id=$1)`,
+		userID,
+	).Scan(&totalFileSize)
 
-	savings := totalFileSize - totalBlobSize
+	savings := totalFileSize - storedSize
 
 	usedPercent := 0.0
 	if quota > 0 {
 		usedPercent = float64(totalUsed) / float64(quota) * 100
 	}
 
-	c.JSON(200, gin.H{
-		"total_used":   totalUsed,
-		"quota":        quota,
-		"used_percent": usedPercent,
-		"breakdown":    breakdown,
-		"trash_size":   trashSize,
-		"savings":      savings,
+	c.JSON(200, api.Stats{
+		TotalUsed:   totalUsed,
+		Quota:       quota,
+		UsedPercent: usedPercent,
+		Breakdown:   breakdown,
+		TrashSize:   trashSize,
+		Savings:     savings,
 	})
 }
 
 func (h *Handler) SignupHandler(c *gin.Context) {
-	var body struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
+	var body api.SignupRequest
 
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
 
-	if strings.TrimSpace(body.Username) == "" || strings.TrimSpace(body.Password) == "" {
-		c.JSON(400, gin.H{"error": "username and password required"})
+	body.Email = strings.TrimSpace(body.Email)
+	if !validateUsername(body.Username) {
+		c.JSON(400, gin.H{"error": "username must be 3-64 letters, digits, '.', '_' or '-'"})
+		return
+	}
+	if !validateEmail(body.Email) {
+		c.JSON(400, gin.H{"error": "a valid email is required"})
+		return
+	}
+	if msg := checkPassword(body.Password); msg != "" {
+		c.JSON(400, gin.H{"error": msg})
 		return
 	}
 
@@ -664,97 +391,137 @@ func (h *Handler) SignupHandler(c *gin.Context) {
 		return
 	}
 
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create user"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	invite, ok := redeemInvite(c, tx, body.Invite, body.Email)
+	if !ok {
+		return
+	}
+	// An invite reached the address, which verifies it.
 	var userID int64
-	err = db.Pool.QueryRow(
+	err = tx.QueryRow(
 		c,
-		"INSERT INTO users (username, email, password_hash) VALUES ($1,$2,$3) RETURNING id",
-		body.Username, body.Email, hash,
+		`INSERT INTO users (username, email, password_hash, org_id, role, quota, email_verified_at)
+		 VALUES ($1,$2,$3,$4,$5,$6, CASE WHEN $7 THEN now() END) RETURNING id`,
+		body.Username, body.Email, hash, invite.OrgID, invite.Role, invite.Quota, invite.ID != 0,
 	).Scan(&userID)
 
-	if err != nil {
+	if isUniqueViolation(err) {
+		c.JSON(409, gin.H{"error": "username or email is already taken"})
+		return
+	}
+	if err != nil || tx.Commit(c) != nil {
 		c.JSON(500, gin.H{"error": "failed to create user"})
 		return
 	}
+	meta, _ := json.Marshal(map[string]any{"org_id": invite.OrgID, "invite_id": invite.ID, "role": invite.Role})
 	_, _ = db.Pool.Exec(c,
 		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-		userID, "signup", "user", userID, nil,
+		userID, "signup", "user", userID, string(meta),
 	)
-
-	c.JSON(200, gin.H{"message": "signup successful", "user_id": userID})
+	if invite.ID == 0 {
+		// The account works without verifying; sharing publicly waits for it.
+		_ = h.sendVerificationEmail(c, userID, body.Username, body.Email)
+		c.JSON(200, api.SignupResponse{Message: "signup successful, check your email to verify it", UserID: userID})
+		return
+	}
+	c.JSON(200, api.SignupResponse{Message: "signup successful", UserID: userID})
 }
 
 func (h *Handler) LoginHandler(c *gin.Context) {
-	var body struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
+	var body api.LoginRequest
 
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
 
-	var userID int64
-	var hash, email string
-	err := db.Pool.QueryRow(
-		c,
-		"SELECT id, password_hash, email FROM users WHERE username=$1",
-		body.Username,
-	).Scan(&userID, &hash, &email)
-
-	if err != nil {
-		c.JSON(401, gin.H{"error": "invalid credentials"})
+	if loginBlocked(c, body.Username) {
 		return
 	}
-
-	if !auth.CheckPasswordHash(body.Password, hash) {
+	userID, email, ok := checkCredentials(c, body.Username, body.Password)
+	if !ok {
+		loginFailed(c, body.Username)
 		c.JSON(401, gin.H{"error": "invalid credentials"})
 		return
 	}
 
-	token, err := auth.GenerateJWT(userID, body.Username)
+	twoFactor, err := twoFactorEnabled(c, userID)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to generate token"})
 		return
 	}
+	if twoFactor {
+		// The failures are only cleared once the second step passes.
+		startTwoFactorLogin(c, userID)
+		return
+	}
 
-	_, _ = db.Pool.Exec(c,
-		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
-		userID, "login", "user", userID,
-	)
-
-	c.JSON(200, gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":       userID,
-			"username": body.Username,
-			"email":    email,
-		},
-	})
+	loginSucceeded(c, body.Username)
+	finishLogin(c, userID, body.Username, email, nil)
 }
 
-func AuthMiddleware(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.JSON(401, gin.H{"error": "missing token"})
-		c.Abort()
+// finishLogin issues tokens to a user who has proven who they are. meta,
+// if not nil, is recorded with the login in the audit log.
+func finishLogin(c *gin.Context, userID int64, username, email string, meta any) {
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate token"})
 		return
 	}
+	defer tx.Rollback(c)
 
-	tokenStr := authHeader
-	if strings.HasPrefix(strings.ToLower(authHeader), "bearer ") {
-		tokenStr = strings.TrimSpace(authHeader[7:])
-	}
-
-	userID, _, err := auth.ParseJWT(tokenStr)
+	tokens, err := issueTokens(c, tx, userID, username, nil)
 	if err != nil {
-		c.JSON(401, gin.H{"error": "invalid token"})
-		c.Abort()
+		c.JSON(500, gin.H{"error": "failed to generate token"})
+		return
+	}
+	var metaJSON *string
+	if meta != nil {
+		b, _ := json.Marshal(meta)
+		metaJSON = nullIfEmpty(string(b))
+	}
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "login", "user", userID, metaJSON,
+	)
+	if err := tx.Commit(c); err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate token"})
 		return
 	}
 
-	c.Set("user_id", userID)
-	c.Next()
+	c.JSON(200, api.LoginResponse{
+		TokenPair: tokens,
+		User: api.User{
+			ID:       userID,
+			Username: username,
+			Email:    email,
+		},
+	})
+}
+
+// checkCredentials verifies a username and password, returning the user's
+// id and email.
+func checkCredentials(c *gin.Context, username, password string) (int64, string, bool) {
+	var userID int64
+	var hash, email string
+	err := db.Pool.QueryRow(
+		c,
+		"SELECT id, COALESCE(password_hash, ''), COALESCE(email, '') FROM users WHERE username=$1",
+		username,
+	).Scan(&userID, &hash, &email)
+	if err != nil {
+		return 0, "", false
+	}
+	if !auth.CheckPasswordHash(password, hash) {
+		return 0, "", false
+	}
+	return userID, email, true
 }
 
 func generateToken() (string, error) {
@@ -767,110 +534,123 @@ func generateToken() (string, error) {
 }
 
 func (h *Handler) CreateShareHandler(c *gin.Context) {
-    id := c.Param("id")
-    var expiresAt *time.Time
-
-    expiry := c.Query("expiry")
-    if expiry != "" {
-        hrs, _ := time.ParseDuration(expiry + "h")
-        t := time.Now().Add(hrs)
-        expiresAt = &t
+    id, ok := paramID(c, "id")
+    if !ok {
+        return
     }
-
-    allowDownload := c.Query("download") == "true"
-
-    var fileID int64
-    err := db.Pool.QueryRow(c,
-        "SELECT id FROM files WHERE id=$1 AND owner_id=$2",
-        id, c.GetInt64("user_id"),
-    ).Scan(&fileID)
-    if err != nil {
-        c.JSON(404, gin.H{"error": "file not found"})
+    opts, ok := readShareOptions(c)
+    if !ok {
         return
     }
 
-    token, err := generateToken()
-    if err != nil {
-        c.JSON(500, gin.H{"error": "failed to generate token"})
+    if !requireVerifiedEmail(c) {
+        return
+    }
+    if _, ok := authorize(c, c.GetInt64("user_id"), id, actionShare); !ok {
         return
     }
 
-    _, err = db.Pool.Exec(c,
-        "INSERT INTO shares (file_id, token, expires_at, allow_download) VALUES ($1,$2,$3,$4)",
-        fileID, token, expiresAt, allowDownload,
-    )
+    var filename string
+    err := db.Pool.QueryRow(c,
+        "SELECT filename FROM files WHERE id=$1",
+        id,
+    ).Scan(&filename)
     if err != nil {
-        c.JSON(500, gin.H{"error": "failed to create share"})
+        c.JSON(404, gin.H{"error": "file not found"})
         return
     }
 
-    shareURL := "http://localhost:8080/s/" + token
+    createShare(c, shareTarget{ID: id, Name: filename}, opts)
+}
 
-    _, _ = db.Pool.Exec(c,
-        "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-        c.GetInt64("user_id"), "create_share", "file", fileID,
-        fmt.Sprintf(`{"share_url":"%s"}`, shareURL),
-    )
+// ListSharesHandler lists the share links on the caller's files and
+// folders.
+func (h *Handler) ListSharesHandler(c *gin.Context) {
+	listShares(c, "COALESCE(f.owner_id, d.owner_id)=$1", c.GetInt64("user_id"))
+}
 
-    broadcastUpdate(gin.H{
-        "event":          "file_shared",
-        "file_id":        fileID,
-        "share_url":      shareURL,
-        "allow_download": allowDownload,
-        "expires_at":     expiresAt,
-        "timestamp":      time.Now(),
-    })
+// RevokeShareHandler deletes a share link on a file or folder the caller
+// may share.
+func (h *Handler) RevokeShareHandler(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	userID := c.GetInt64("user_id")
 
-    c.JSON(200, gin.H{
-        "share_url":      shareURL,
-        "expires_at":     expiresAt,
-        "allow_download": allowDownload,
-    })
+	s, ok := manageShare(c, userID, id)
+	if !ok {
+		return
+	}
+	if _, err := db.Pool.Exec(c, "DELETE FROM shares WHERE id=$1", id); err != nil {
+		c.JSON(500, gin.H{"error": "failed to revoke share"})
+		return
+	}
+
+	objectType, objectID := shareObject(s)
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "revoke_share", objectType, objectID, auditMeta(c, fmt.Sprintf(`{"share_id":%d}`, id)),
+	)
+
+	c.JSON(200, api.ShareStatus{Message: "share revoked", ShareID: id})
 }
 
 // Download shared file
 func (h *Handler) DownloadShareHandler(c *gin.Context) {
-    token := c.Param("token")
+    s, email, ok := openFileShare(c, c.Param("token"))
+    if !ok {
+        return
+    }
+    if !s.AllowDownload {
+        c.JSON(403, gin.H{"error": "download not allowed"})
+        return
+    }
 
     var (
-        fileID        int64
-        blobPath      string
-        filename      string
-        expiresAt     *time.Time
-        allowDownload bool
-        mimeType      string
+        fileID   = s.FileID
+        blobKey  string
+        filename string
+        mimeType string
     )
 
     err := db.Pool.QueryRow(
         c,
-        `SELECT f.id, b.path, f.filename, s.expires_at, s.allow_download, f.mime_type
-         FROM shares s
-         JOIN files f ON s.file_id = f.id
+        `SELECT b.path, f.filename, f.mime_type
+         FROM files f
          JOIN blobs b ON f.blob_id = b.id
-         WHERE s.token=$1`,
-        token,
-    ).Scan(&fileID, &blobPath, &filename, &expiresAt, &allowDownload, &mimeType)
+         WHERE f.id=$1`,
+        fileID,
+    ).Scan(&blobKey, &filename, &mimeType)
 
     if err != nil {
         c.JSON(404, gin.H{"error": "invalid or expired link"})
         return
     }
-    if expiresAt != nil && time.Now().After(*expiresAt) {
-        c.JSON(410, gin.H{"error": "link expired"})
+
+    // Every request takes a download, a resumed one included; the check
+    // and the count are one statement.
+    res, err := db.Pool.Exec(c,
+        `UPDATE shares SET download_count = download_count + 1
+         WHERE id=$1 AND (max_downloads IS NULL OR download_count < max_downloads)`,
+        s.ID,
+    )
+    if err != nil {
+        c.JSON(500, gin.H{"error": "failed to count download"})
         return
     }
-    if !allowDownload {
-        c.JSON(403, gin.H{"error": "download not allowed"})
+    if res.RowsAffected() == 0 {
+        c.JSON(410, gin.H{"error": "download limit reached"})
         return
     }
 
     _, _ = db.Pool.Exec(c, "UPDATE files SET download_count = download_count + 1 WHERE id=$1", fileID)
     _, _ = db.Pool.Exec(c,
         "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-        0, "download_file_public", "file", fileID, fmt.Sprintf(`{"filename":"%s"}`, filename),
+        nil, "download_file_public", "file", fileID, publicAuditMeta(c, s, email, map[string]any{"filename": filename}),
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":    "download",
         "file_id":  fileID,
         "filename": filename,
@@ -878,40 +658,37 @@ func (h *Handler) DownloadShareHandler(c *gin.Context) {
         "ts":       time.Now(),
     })
 
-    serveFileWithRange(c, blobPath, filename, mimeType, true)
+    serveFileWithRange(c, h.Blobs, blobKey, filename, mimeType, true)
 }
 
 // Preview shared file
 func (h *Handler) PreviewShareHandler(c *gin.Context) {
-    token := c.Param("token")
+    s, email, ok := openFileShare(c, c.Param("token"))
+    if !ok {
+        return
+    }
 
     var (
-        fileID           int64
-        blobPath         string
+        fileID           = s.FileID
+        blobKey           string
         filename         string
         mimeType         string
-        expiresAt        *time.Time
         previewAvailable bool
     )
 
     err := db.Pool.QueryRow(
         c,
-        `SELECT f.id, b.path, f.filename, f.mime_type, s.expires_at, f.preview_available
-         FROM shares s
-         JOIN files f ON s.file_id = f.id
+        `SELECT b.path, f.filename, f.mime_type, f.preview_available
+         FROM files f
          JOIN blobs b ON f.blob_id = b.id
-         WHERE s.token=$1`,
-        token,
-    ).Scan(&fileID, &blobPath, &filename, &mimeType, &expiresAt, &previewAvailable)
+         WHERE f.id=$1`,
+        fileID,
+    ).Scan(&blobKey, &filename, &mimeType, &previewAvailable)
 
     if err != nil {
         c.JSON(404, gin.H{"error": "invalid or expired link"})
         return
     }
-    if expiresAt != nil && time.Now().After(*expiresAt) {
-        c.JSON(410, gin.H{"error": "link expired"})
-        return
-    }
     if !previewAvailable {
         c.JSON(403, gin.H{"error": "preview not available"})
         return
@@ -919,10 +696,10 @@ func (h *Handler) PreviewShareHandler(c *gin.Context) {
 
     _, _ = db.Pool.Exec(c,
         "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-        0, "preview_file_public", "file", fileID, fmt.Sprintf(`{"filename":"%s"}`, filename),
+        nil, "preview_file_public", "file", fileID, publicAuditMeta(c, s, email, map[string]any{"filename": filename}),
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":    "preview",
         "file_id":  fileID,
         "filename": filename,
@@ -930,87 +707,64 @@ func (h *Handler) PreviewShareHandler(c *gin.Context) {
         "ts":       time.Now(),
     })
 
-    serveFileWithRange(c, blobPath, filename, mimeType, false)
+    serveFileWithRange(c, h.Blobs, blobKey, filename, mimeType, false)
 }
 
+// AddEditorHandler and RemoveEditorHandler grant and take away the editor
+// role by email. They predate roles; GrantAccessHandler and
+// RevokeAccessHandler handle every role.
 func (h *Handler) AddEditorHandler(c *gin.Context) {
-	fileID := c.Param("id")
-	userID := c.GetInt64("user_id")
-
-	var ownerID int64
-	err := db.Pool.QueryRow(c, "SELECT owner_id FROM files WHERE id=$1", fileID).Scan(&ownerID)
-	if err != nil || ownerID != userID {
-		c.JSON(403, gin.H{"error": "only owner can add editors"})
+	fileID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	var body struct {
-		Email string `json:"email"`
-	}
+	var body api.EditorRequest
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
 
-	var editorID int64
-	err = db.Pool.QueryRow(c, "SELECT id FROM users WHERE email=$1", body.Email).Scan(&editorID)
-	if err != nil {
-		c.JSON(404, gin.H{"error": "user not found"})
+	entry, ok := fileGrants.grant(c, c.GetInt64("user_id"), fileID, api.GrantAccessRequest{Email: body.Email, Role: api.RoleEditor})
+	if !ok {
 		return
 	}
 
-	_, err = db.Pool.Exec(c, "INSERT INTO file_permissions (file_id, user_id, can_edit) VALUES ($1,$2,$3)", fileID, editorID, true)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to add editor"})
-		return
-	}
-
-	_, _ = db.Pool.Exec(c,
-		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-		userID, "add_editor", "file", fileID, fmt.Sprintf(`{"editor_id":%d}`, editorID),
-	)
-
-	c.JSON(200, gin.H{"message": "editor added", "file_id": fileID, "editor_id": editorID})
+	c.JSON(200, api.EditorStatus{Message: "editor added", FileID: fileID, EditorID: entry.UserID})
 }
 
 func (h *Handler) RemoveEditorHandler(c *gin.Context) {
-	fileID := c.Param("id")
-	userID := c.GetInt64("user_id")
-
-	var ownerID int64
-	err := db.Pool.QueryRow(c, "SELECT owner_id FROM files WHERE id=$1", fileID).Scan(&ownerID)
-	if err != nil || ownerID != userID {
-		c.JSON(403, gin.H{"error": "only owner can remove editors"})
+	fileID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	var body struct {
-		Email string `json:"email"`
-	}
+	var body api.EditorRequest
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
 
+	userID := c.GetInt64("user_id")
+	if _, ok := authorize(c, userID, fileID, actionShare); !ok {
+		return
+	}
+
 	var editorID int64
-	err = db.Pool.QueryRow(c, "SELECT id FROM users WHERE email=$1", body.Email).Scan(&editorID)
+	err := db.Pool.QueryRow(c,
+		"SELECT id FROM users WHERE lower(email)=lower($1) AND org_id=(SELECT org_id FROM users WHERE id=$2)",
+		strings.TrimSpace(body.Email), userID,
+	).Scan(&editorID)
 	if err != nil {
 		c.JSON(404, gin.H{"error": "user not found"})
 		return
 	}
 
-	_, err = db.Pool.Exec(c, "DELETE FROM file_permissions WHERE file_id=$1 AND user_id=$2", fileID, editorID)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to remove editor"})
+	if !fileGrants.revoke(c, userID, fileID, principal{"user_id", editorID}) {
 		return
 	}
 
-	_, _ = db.Pool.Exec(c,
-		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-		userID, "remove_editor", "file", fileID, fmt.Sprintf(`{"editor_id":%d}`, editorID),
-	)
-
-	c.JSON(200, gin.H{"message": "editor removed", "file_id": fileID, "editor_id": editorID})
+	c.JSON(200, api.EditorStatus{Message: "editor removed", FileID: fileID, EditorID: editorID})
 }
 
 func (h *Handler) SearchFilesHandler(c *gin.Context) {
@@ -1099,7 +853,7 @@ func (h *Handler) SearchFilesHandler(c *gin.Context) {
 		return
 	}
 
-	query := `SELECT f.id, f.filename, f.size, f.created_at, b.hash, f.mime_type, f.preview_available, COALESCE(f.tags, '{}')
+	query := `SELECT ` + fileColumns + `
           FROM files f
           JOIN blobs b ON f.blob_id = b.id ` + where
 
@@ -1122,49 +876,32 @@ func (h *Handler) SearchFilesHandler(c *gin.Context) {
 	}
 	defer rows.Close()
 
-	var results []gin.H
+	results := []api.File{}
 	for rows.Next() {
-		var id, size int64
-var filename, hash, mimeType string
-var created time.Time
-var previewAvail bool
-var fileTags []string
-rows.Scan(&id, &filename, &size, &created, &hash, &mimeType, &previewAvail, pq.Array(&fileTags))
-
-		var tags interface{}
-		if len(fileTags) == 0 {
-			tags = nil
-		} else {
-			tags = fileTags
+		f, err := scanFile(rows)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to scan row"})
+			return
 		}
-
-		results = append(results, gin.H{
-    "id":               id,
-    "filename":         filename,
-    "size":             size,
-    "created_at":       created,
-    "hash":             hash,
-    "mime_type":        mimeType,
-    "tags":             tags,
-    "preview_available": previewAvail,
-})
+		results = append(results, f)
 	}
 
-	c.JSON(200, gin.H{
-		"page":        page,
-		"limit":       limit,
-		"total_count": totalCount,
-		"results":     results,
+	c.JSON(200, api.SearchResult{
+		Page:       page,
+		Limit:      limit,
+		TotalCount: totalCount,
+		Results:    results,
 	})
 }
 
 func (h *Handler) UpdateTagsHandler(c *gin.Context) {
-	fileID := c.Param("id")
+	fileID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
 	userID := c.GetInt64("user_id")
 
-	var body struct {
-		Tags []string `json:"tags"`
-	}
+	var body api.TagsRequest
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(400, gin.H{"error": "invalid request"})
 		return
@@ -1176,70 +913,62 @@ func (h *Handler) UpdateTagsHandler(c *gin.Context) {
 		}
 	}
 
-	var ownerID int64
-	err := db.Pool.QueryRow(c, "SELECT owner_id FROM files WHERE id=$1", fileID).Scan(&ownerID)
-	if err != nil {
-		c.JSON(404, gin.H{"error": "file not found"})
+	if _, ok := authorize(c, userID, fileID, actionEdit); !ok {
 		return
 	}
 
-	if ownerID != userID {
-		var canEdit bool
-		err = db.Pool.QueryRow(c, "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2", fileID, userID).Scan(&canEdit)
-		if err != nil || !canEdit {
-			c.JSON(403, gin.H{"error": "not authorized"})
-			return
-		}
-	}
-
-	_, err = db.Pool.Exec(c, "UPDATE files SET tags=$1 WHERE id=$2", pq.Array(body.Tags), fileID)
+	_, err := db.Pool.Exec(c, "UPDATE files SET tags=$1 WHERE id=$2", pq.Array(body.Tags), fileID)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to update tags"})
 		return
 	}
 
+	meta, _ := json.Marshal(map[string]any{"tags": body.Tags})
 	_, _ = db.Pool.Exec(c,
 		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-		userID, "update_tags", "file", fileID, fmt.Sprintf(`{"tags": %q}`, body.Tags),
+		userID, "update_tags", "file", fileID, auditMeta(c, string(meta)),
 	)
 
-	c.JSON(200, gin.H{"message": "tags updated", "file_id": fileID, "tags": body.Tags})
+	if body.Tags == nil {
+		body.Tags = []string{}
+	}
+	c.JSON(200, api.FileTags{FileID: fileID, Tags: body.Tags})
 }
 
 func (h *Handler) GetTagsHandler(c *gin.Context) {
-	fileID := c.Param("id")
-	userID := c.GetInt64("user_id")
+	fileID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := authorize(c, c.GetInt64("user_id"), fileID, actionRead); !ok {
+		return
+	}
 
-	var ownerID int64
 	var tags []string
-	err := db.Pool.QueryRow(c, "SELECT owner_id, tags FROM files WHERE id=$1", fileID).Scan(&ownerID, pq.Array(&tags))
+	err := db.Pool.QueryRow(c, "SELECT tags FROM files WHERE id=$1", fileID).Scan(pq.Array(&tags))
 	if err != nil {
 		c.JSON(404, gin.H{"error": "file not found"})
 		return
 	}
 
-	if ownerID != userID {
-		var canEdit bool
-		err = db.Pool.QueryRow(c, "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2", fileID, userID).Scan(&canEdit)
-		if err != nil {
-			c.JSON(403, gin.H{"error": "not authorized"})
-			return
-		}
+	if tags == nil {
+		tags = []string{}
 	}
-
-	c.JSON(200, gin.H{"file_id": fileID, "tags": tags})
+	c.JSON(200, api.FileTags{FileID: fileID, Tags: tags})
 }
 
 func (h *Handler) CreateFolderHandler(c *gin.Context) {
     userID := c.GetInt64("user_id")
-    var body struct {
-        Name     string `json:"name"`
-        ParentID *int64 `json:"parent_id"`
-    }
+    var body api.CreateFolderRequest
     if err := c.BindJSON(&body); err != nil || body.Name == "" {
         c.JSON(400, gin.H{"error": "invalid request"})
         return
     }
+    parentID, ok := targetFolder(c, body.ParentID)
+    if !ok {
+        return
+    }
+    body.ParentID = parentID
 
     var folderID int64
     err := db.Pool.QueryRow(
@@ -1247,12 +976,16 @@ func (h *Handler) CreateFolderHandler(c *gin.Context) {
         "INSERT INTO folders (owner_id, name, parent_id) VALUES ($1,$2,$3) RETURNING id",
         userID, body.Name, body.ParentID,
     ).Scan(&folderID)
+    if isUniqueViolation(err) {
+        c.JSON(409, gin.H{"error": "a folder with that name already exists"})
+        return
+    }
     if err != nil {
         c.JSON(500, gin.H{"error": "failed to create folder"})
         return
     }
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":     "folder_created",
         "folder_id": folderID,
         "name":      body.Name,
@@ -1260,7 +993,7 @@ func (h *Handler) CreateFolderHandler(c *gin.Context) {
         "user":      userID,
     })
 
-    c.JSON(200, gin.H{"message": "folder created", "folder_id": folderID})
+    c.JSON(200, api.FolderStatus{Message: "folder created", FolderID: folderID})
 }
 
 func (h *Handler) ListFoldersHandler(c *gin.Context) {
@@ -1273,26 +1006,27 @@ func (h *Handler) ListFoldersHandler(c *gin.Context) {
 	}
 	defer rows.Close()
 
-	var folders []gin.H
+	folders := []api.Folder{}
 	for rows.Next() {
-		var id int64
-		var name string
-		var parentID *int64
-		var created time.Time
-		rows.Scan(&id, &name, &parentID, &created)
-		folders = append(folders, gin.H{"id": id, "name": name, "parent_id": parentID, "created": created})
+		var f api.Folder
+		if err := rows.Scan(&f.ID, &f.Name, &f.ParentID, &f.CreatedAt); err != nil {
+			c.JSON(500, gin.H{"error": "failed to list folders"})
+			return
+		}
+		folders = append(folders, f)
 	}
 
-	c.JSON(200, gin.H{"folders": folders})
+	c.JSON(200, api.FolderList{Folders: folders})
 }
 
 func (h *Handler) RenameFolderHandler(c *gin.Context) {
-    folderID := c.Param("id")
+    folderID, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
     userID := c.GetInt64("user_id")
 
-    var body struct {
-        Name string `json:"name"`
-    }
+    var body api.RenameFolderRequest
     if err := c.BindJSON(&body); err != nil || body.Name == "" {
         c.JSON(400, gin.H{"error": "invalid request"})
         return
@@ -1302,19 +1036,23 @@ func (h *Handler) RenameFolderHandler(c *gin.Context) {
         "UPDATE folders SET name=$1 WHERE id=$2 AND owner_id=$3",
         body.Name, folderID, userID,
     )
+    if isUniqueViolation(err) {
+        c.JSON(409, gin.H{"error": "a folder with that name already exists"})
+        return
+    }
     if err != nil || res.RowsAffected() == 0 {
         c.JSON(404, gin.H{"error": "folder not found or not owned"})
         return
     }
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":     "folder_renamed",
         "folder_id": folderID,
         "new_name":  body.Name,
         "user":      userID,
     })
 
-    c.JSON(200, gin.H{"message": "folder renamed"})
+    c.JSON(200, api.FolderStatus{Message: "folder renamed", FolderID: folderID})
 }
 
 func (h *Handler) DeleteFolderHandler(c *gin.Context) {
@@ -1333,18 +1071,26 @@ func (h *Handler) DeleteFolderHandler(c *gin.Context) {
 }
 
 func (h *Handler) MoveFileHandler(c *gin.Context) {
-	fileID := c.Param("id")
+	fileID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
 	userID := c.GetInt64("user_id")
 
-	var body struct {
-		FolderID *int64 `json:"folder_id"`
-	}
+	var body api.MoveFileRequest
 	if err := c.BindJSON(&body); err != nil {
 		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
+	if body.FolderID, ok = targetFolder(c, body.FolderID); !ok {
+		return
+	}
 
 	res, err := db.Pool.Exec(c, "UPDATE files SET folder_id=$1 WHERE id=$2 AND owner_id=$3", body.FolderID, fileID, userID)
+	if isUniqueViolation(err) {
+		c.JSON(409, gin.H{"error": "a file with that name already exists in the folder"})
+		return
+	}
 	if err != nil || res.RowsAffected() == 0 {
 		c.JSON(404, gin.H{"error": "file not found or not owned"})
 		return
@@ -1352,19 +1098,25 @@ func (h *Handler) MoveFileHandler(c *gin.Context) {
 
 	_, _ = db.Pool.Exec(c, "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)", userID, "move_file", "file", fileID, fmt.Sprintf(`{"folder_id":%v}`, body.FolderID))
 
-	c.JSON(200, gin.H{"message": "file moved", "folder_id": body.FolderID})
+	c.JSON(200, api.FileMoved{Message: "file moved", FileID: fileID, FolderID: body.FolderID})
 }
 
 func (h *Handler) ListFolderFilesHandler(c *gin.Context) {
-    folderID := c.Param("id")
-    userID := c.GetInt64("user_id")
+    folderID, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
+    if _, ok := authorizeFolder(c, c.GetInt64("user_id"), folderID, actionRead); !ok {
+        return
+    }
 
     rows, err := db.Pool.Query(c,
-        `SELECT f.id, f.filename, f.size, f.mime_type, f.created_at
+        `SELECT `+fileColumns+`
          FROM files f
-         WHERE f.owner_id=$1 AND f.folder_id=$2 AND f.trashed=false
+         JOIN blobs b ON f.blob_id = b.id
+         WHERE f.folder_id=$1 AND f.trashed=false
          ORDER BY f.created_at DESC`,
-        userID, folderID,
+        folderID,
     )
     if err != nil {
         c.JSON(500, gin.H{"error": "failed to query files"})
@@ -1372,25 +1124,17 @@ func (h *Handler) ListFolderFilesHandler(c *gin.Context) {
     }
     defer rows.Close()
 
-    var files []gin.H
+    files := []api.File{}
     for rows.Next() {
-        var id, size int64
-        var filename, mime string
-        var created time.Time
-        if err := rows.Scan(&id, &filename, &size, &mime, &created); err != nil {
+        f, err := scanFile(rows)
+        if err != nil {
             c.JSON(500, gin.H{"error": "failed to scan file row"})
             return
         }
-        files = append(files, gin.H{
-            "id":         id,
-            "filename":   filename,
-            "size":       size,
-            "mime":       mime,
-            "created_at": created,
-        })
+        files = append(files, f)
     }
 
-    c.JSON(200, gin.H{"files": files})
+    c.JSON(200, api.FileList{Files: files})
 }
 
 func (h *Handler) GetFolderTreeHandler(c *gin.Context) {
@@ -1403,15 +1147,8 @@ func (h *Handler) GetFolderTreeHandler(c *gin.Context) {
 	}
 	defer rows.Close()
 
-	type Folder struct {
-		ID       int64     `json:"id"`
-		Name     string    `json:"name"`
-		ParentID *int64    `json:"parent_id"`
-		Children []*Folder `json:"children"`
-	}
-
-	folderMap := make(map[int64]*Folder)
-	var roots []*Folder
+	folderMap := make(map[int64]*api.FolderNode)
+	roots := []*api.FolderNode{}
 
 	for rows.Next() {
 		var id int64
@@ -1419,7 +1156,7 @@ func (h *Handler) GetFolderTreeHandler(c *gin.Context) {
 		var parentID *int64
 		rows.Scan(&id, &name, &parentID)
 
-		f := &Folder{ID: id, Name: name, ParentID: parentID}
+		f := &api.FolderNode{ID: id, Name: name, ParentID: parentID, Children: []*api.FolderNode{}}
 		folderMap[id] = f
 	}
 
@@ -1433,42 +1170,37 @@ func (h *Handler) GetFolderTreeHandler(c *gin.Context) {
 		}
 	}
 
-	c.JSON(200, gin.H{"tree": roots})
+	c.JSON(200, api.FolderTree{Tree: roots})
 }
 
 func (h *Handler) MoveFolderHandler(c *gin.Context) {
     userID := c.GetInt64("user_id")
-    folderID := c.Param("id")
-
-    var body struct {
-        NewParentID *int64 `json:"new_parent_id"`
+    folderID, ok := paramID(c, "id")
+    if !ok {
+        return
     }
+
+    var body api.MoveFolderRequest
     if err := c.BindJSON(&body); err != nil {
         c.JSON(400, gin.H{"error": "invalid request"})
         return
     }
 
-    var ownerID int64
-    err := db.Pool.QueryRow(
-        c,
-        "SELECT owner_id FROM folders WHERE id=$1",
-        folderID,
-    ).Scan(&ownerID)
+    if _, ok := authorizeFolder(c, userID, folderID, actionEdit); !ok {
+        return
+    }
 
-    if err != nil {
-        c.JSON(404, gin.H{"error": "folder not found"})
+    if body.NewParentID, ok = targetFolder(c, body.NewParentID); !ok {
         return
     }
-    if ownerID != userID {
-        c.JSON(403, gin.H{"error": "not authorized"})
+    inside, err := folderWithin(c, body.NewParentID, folderID)
+    if err != nil {
+        c.JSON(500, gin.H{"error": "failed to move folder"})
         return
     }
-
-    if body.NewParentID != nil {
-        if strconv.FormatInt(*body.NewParentID, 10) == folderID {
-            c.JSON(400, gin.H{"error": "cannot move folder into itself"})
-            return
-        }
+    if inside {
+        c.JSON(400, gin.H{"error": "cannot move a folder into itself or its subfolders"})
+        return
     }
 
     _, err = db.Pool.Exec(
@@ -1476,139 +1208,142 @@ func (h *Handler) MoveFolderHandler(c *gin.Context) {
         "UPDATE folders SET parent_id=$1 WHERE id=$2",
         body.NewParentID, folderID,
     )
+    if isUniqueViolation(err) {
+        c.JSON(409, gin.H{"error": "a folder with that name already exists"})
+        return
+    }
     if err != nil {
         c.JSON(500, gin.H{"error": "failed to move folder"})
         return
     }
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":       "folder_moved",
         "folder_id":   folderID,
         "new_parent":  body.NewParentID,
         "user":        userID,
     })
 
-    c.JSON(200, gin.H{
-        "message":       "folder moved",
-        "folder_id":     folderID,
-        "new_parent_id": body.NewParentID,
+    c.JSON(200, api.FolderMoved{
+        Message:     "folder moved",
+        FolderID:    folderID,
+        NewParentID: body.NewParentID,
     })
 }
 
 func (h *Handler) TrashFolderHandler(c *gin.Context) {
-    folderID := c.Param("id")
-    userID := c.GetInt64("user_id")
-
-    var ownerID int64
-    err := db.Pool.QueryRow(c, "SELECT owner_id FROM folders WHERE id=$1", folderID).Scan(&ownerID)
-    if err != nil {
-        c.JSON(404, gin.H{"error": "folder not found"})
+    folderID, ok := paramID(c, "id")
+    if !ok {
         return
     }
-    if ownerID != userID {
-        c.JSON(403, gin.H{"error": "not authorized"})
+    userID := c.GetInt64("user_id")
+
+    a, ok := authorizeFolder(c, userID, folderID, actionEdit)
+    if !ok {
         return
     }
 
-    _, err = db.Pool.Exec(c, "UPDATE folders SET trashed=true, trashed_at=$1 WHERE id=$2", time.Now(), folderID)
+    _, err := db.Pool.Exec(c, "UPDATE folders SET trashed=true, trashed_at=$1 WHERE id=$2", time.Now(), folderID)
     if err != nil {
         c.JSON(500, gin.H{"error": "failed to trash folder"})
         return
     }
 
-    _, _ = db.Pool.Exec(c, "UPDATE files SET trashed=true, trashed_at=$1 WHERE folder_id=$2 AND owner_id=$3", time.Now(), folderID, userID)
+    _, _ = db.Pool.Exec(c, "UPDATE files SET trashed=true, trashed_at=$1 WHERE folder_id=$2 AND owner_id=$3", time.Now(), folderID, a.OwnerID)
 
     _, _ = db.Pool.Exec(c,
         "INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
         userID, "trash_folder", "folder", folderID,
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
     "event":     "folder_trashed",
     "folder_id": folderID,
     "user":      userID,
     "timestamp": time.Now(),
 })
 
-    c.JSON(200, gin.H{"message": "folder moved to trash", "folder_id": folderID})
+    c.JSON(200, api.FolderStatus{Message: "folder moved to trash", FolderID: folderID})
 }
 
 func (h *Handler) RestoreFolderHandler(c *gin.Context) {
-    folderID := c.Param("id")
+    folderID, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
     userID := c.GetInt64("user_id")
 
-    var ownerID int64
-    err := db.Pool.QueryRow(c, "SELECT owner_id FROM folders WHERE id=$1", folderID).Scan(&ownerID)
-    if err != nil {
-        c.JSON(404, gin.H{"error": "folder not found"})
+    a, ok := authorizeFolder(c, userID, folderID, actionEdit)
+    if !ok {
         return
     }
-    if ownerID != userID {
-        c.JSON(403, gin.H{"error": "not authorized"})
+
+    tx, err := db.Pool.Begin(c)
+    if err != nil {
+        c.JSON(500, gin.H{"error": "failed to restore folder"})
         return
     }
+    defer tx.Rollback(c)
 
-    _, err = db.Pool.Exec(c, "UPDATE folders SET trashed=false, trashed_at=NULL WHERE id=$1", folderID)
+    _, err = tx.Exec(c, "UPDATE folders SET trashed=false, trashed_at=NULL WHERE id=$1", folderID)
+    if err == nil {
+        _, err = tx.Exec(c, "UPDATE files SET trashed=false, trashed_at=NULL WHERE folder_id=$1 AND owner_id=$2", folderID, a.OwnerID)
+    }
+    if err == nil {
+        err = tx.Commit(c)
+    }
+    if isUniqueViolation(err) {
+        c.JSON(409, gin.H{"error": "a folder or file with the same name already exists"})
+        return
+    }
     if err != nil {
         c.JSON(500, gin.H{"error": "failed to restore folder"})
         return
     }
 
-    _, _ = db.Pool.Exec(c, "UPDATE files SET trashed=false, trashed_at=NULL WHERE folder_id=$1 AND owner_id=$2", folderID, userID)
-
     _, _ = db.Pool.Exec(c,
         "INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
         userID, "restore_folder", "folder", folderID,
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
     "event":     "folder_restored",
     "folder_id": folderID,
     "user":      userID,
     "timestamp": time.Now(),
 })
 
-    c.JSON(200, gin.H{"message": "folder restored", "folder_id": folderID})
+    c.JSON(200, api.FolderStatus{Message: "folder restored", FolderID: folderID})
 }
 
 func (h *Handler) PermanentlyDeleteFolderHandler(c *gin.Context) {
-    folderID := c.Param("id")
-    userID := c.GetInt64("user_id")
-
-    var ownerID int64
-    err := db.Pool.QueryRow(c, "SELECT owner_id FROM folders WHERE id=$1", folderID).Scan(&ownerID)
-    if err != nil {
-        c.JSON(404, gin.H{"error": "folder not found"})
+    folderID, ok := paramID(c, "id")
+    if !ok {
         return
     }
-    if ownerID != userID {
-        c.JSON(403, gin.H{"error": "not authorized"})
+    userID := c.GetInt64("user_id")
+
+    if _, ok := authorizeFolder(c, userID, folderID, actionOwn); !ok {
         return
     }
 
     tx, _ := db.Pool.Begin(c)
 
-    rows, _ := tx.Query(c, `SELECT f.id, b.id, b.path 
-        FROM files f 
-        JOIN blobs b ON f.blob_id=b.id 
-        WHERE f.folder_id=$1 AND f.owner_id=$2 AND f.trashed=true`, folderID, userID)
+    rows, _ := tx.Query(c, "SELECT id FROM files WHERE folder_id=$1 AND owner_id=$2 AND trashed=true", folderID, userID)
 
+    var fileIDs []int64
     for rows.Next() {
-        var fid, bid int64
-        var bpath string
-        rows.Scan(&fid, &bid, &bpath)
-
-        _, _ = tx.Exec(c, "DELETE FROM files WHERE id=$1", fid)
-        var refCount int
-        _ = tx.QueryRow(c, "UPDATE blobs SET ref_count = ref_count - 1 WHERE id=$1 RETURNING ref_count", bid).Scan(&refCount)
-        if refCount <= 0 {
-            _, _ = tx.Exec(c, "DELETE FROM blobs WHERE id=$1", bid)
-            os.Remove(bpath)
-        }
+        var fid int64
+        rows.Scan(&fid)
+        fileIDs = append(fileIDs, fid)
     }
     rows.Close()
 
-    _, err = tx.Exec(c, "DELETE FROM folders WHERE id=$1 AND owner_id=$2 AND trashed=true", folderID, userID)
+    for _, fid := range fileIDs {
+        _ = h.purgeFile(c, tx, fid)
+    }
+
+    _, err := tx.Exec(c, "DELETE FROM folders WHERE id=$1 AND owner_id=$2 AND trashed=true", folderID, userID)
     if err != nil {
         _ = tx.Rollback(c)
         c.JSON(500, gin.H{"error": "failed to permanently delete folder"})
@@ -1622,21 +1357,21 @@ func (h *Handler) PermanentlyDeleteFolderHandler(c *gin.Context) {
         userID, "permanent_delete_folder", "folder", folderID,
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
     "event":     "folder_deleted",
     "folder_id": folderID,
     "user":      userID,
     "timestamp": time.Now(),
 })
 
-    c.JSON(200, gin.H{"message": "folder permanently deleted", "folder_id": folderID})
+    c.JSON(200, api.FolderStatus{Message: "folder permanently deleted", FolderID: folderID})
 }
 
 func (h *Handler) ListTrashHandler(c *gin.Context) {
 	userID := c.GetInt64("user_id")
 
 	filesRows, err := db.Pool.Query(c,
-		`SELECT f.id, f.filename, f.size, f.mime_type, f.trashed_at, b.hash
+		`SELECT `+fileColumns+`
 		 FROM files f
 		 JOIN blobs b ON f.blob_id = b.id
 		 WHERE f.owner_id=$1 AND f.trashed=true
@@ -1649,36 +1384,32 @@ func (h *Handler) ListTrashHandler(c *gin.Context) {
 	}
 	defer filesRows.Close()
 
-	var trashedFiles []gin.H
+	trashedFiles := []api.File{}
 	for filesRows.Next() {
-		var id, size int64
-		var filename, mime, hash string
-		var trashedAt time.Time
-		if err := filesRows.Scan(&id, &filename, &size, &mime, &trashedAt, &hash); err != nil {
+		f, err := scanFile(filesRows)
+		if err != nil {
 			continue
 		}
-		trashedFiles = append(trashedFiles, gin.H{"id": id, "filename": filename, "size": size, "mime_type": mime, "hash": hash, "trashed_at": trashedAt, "type": "file"})
+		trashedFiles = append(trashedFiles, f)
 	}
 
-	folderRows, err := db.Pool.Query(c, `SELECT id, name, trashed_at FROM folders WHERE owner_id=$1 AND trashed=true ORDER BY trashed_at DESC`, userID)
+	folderRows, err := db.Pool.Query(c, `SELECT id, name, parent_id, created_at, trashed_at FROM folders WHERE owner_id=$1 AND trashed=true ORDER BY trashed_at DESC`, userID)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to fetch trashed folders"})
 		return
 	}
 	defer folderRows.Close()
 
-	var trashedFolders []gin.H
+	trashedFolders := []api.Folder{}
 	for folderRows.Next() {
-		var id int64
-		var name string
-		var trashedAt time.Time
-		if err := folderRows.Scan(&id, &name, &trashedAt); err != nil {
+		var f api.Folder
+		if err := folderRows.Scan(&f.ID, &f.Name, &f.ParentID, &f.CreatedAt, &f.TrashedAt); err != nil {
 			continue
 		}
-		trashedFolders = append(trashedFolders, gin.H{"id": id, "name": name, "trashed_at": trashedAt, "type": "folder"})
+		trashedFolders = append(trashedFolders, f)
 	}
 
-	c.JSON(200, gin.H{"files": trashedFiles, "folders": trashedFolders})
+	c.JSON(200, api.Trash{Files: trashedFiles, Folders: trashedFolders})
 }
 
 // empty trash
@@ -1692,35 +1423,29 @@ func (h *Handler) EmptyTrashHandler(c *gin.Context) {
     }
     defer tx.Rollback(c)
 
-    fileRows, err := tx.Query(c,
-        `SELECT f.id, b.id, b.path 
-         FROM files f 
-         JOIN blobs b ON f.blob_id=b.id
-         WHERE f.owner_id=$1 AND f.trashed=true`, userID)
+    fileRows, err := tx.Query(c, "SELECT id FROM files WHERE owner_id=$1 AND trashed=true", userID)
     if err != nil {
         c.JSON(500, gin.H{"error": "failed to fetch trashed files"})
         return
     }
-    defer fileRows.Close()
 
-    var deletedFiles []int64
+    var trashed []int64
     for fileRows.Next() {
-        var fid, bid int64
-        var bpath string
-        if err := fileRows.Scan(&fid, &bid, &bpath); err != nil {
+        var fid int64
+        if err := fileRows.Scan(&fid); err != nil {
             continue
         }
+        trashed = append(trashed, fid)
+    }
+    fileRows.Close()
 
-        deletedFiles = append(deletedFiles, fid)
-
-        _, _ = tx.Exec(c, "DELETE FROM files WHERE id=$1", fid)
-
-        var refCount int
-        _ = tx.QueryRow(c, "UPDATE blobs SET ref_count=ref_count-1 WHERE id=$1 RETURNING ref_count", bid).Scan(&refCount)
-        if refCount <= 0 {
-            _, _ = tx.Exec(c, "DELETE FROM blobs WHERE id=$1", bid)
-            os.Remove(bpath)
+    var deletedFiles []int64
+    for _, fid := range trashed {
+        if err := h.purgeFile(c, tx, fid); err != nil {
+            c.JSON(500, gin.H{"error": "failed to empty trash"})
+            return
         }
+        deletedFiles = append(deletedFiles, fid)
     }
 
     _, _ = tx.Exec(c, "DELETE FROM folders WHERE owner_id=$1 AND trashed=true", userID)
@@ -1735,67 +1460,72 @@ func (h *Handler) EmptyTrashHandler(c *gin.Context) {
         userID, "empty_trash", "system", userID, fmt.Sprintf(`{"deleted_files":%v}`, deletedFiles),
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event": "trash_emptied",
         "user":  userID,
     })
 
-    c.JSON(200, gin.H{"message": "trash emptied"})
+    c.JSON(200, api.Message{Message: "trash emptied"})
 }
 
+// AdminListFiles lists the files of the admin's organization.
 func (h *Handler) AdminListFiles(c *gin.Context) {
-	if !requireAdmin(c) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
 		return
 	}
 
 	rows, err := db.Pool.Query(c,
-		`SELECT f.id, f.filename, f.size, u.username, f.created_at, f.download_count
+		`SELECT `+fileColumns+`, u.username
      FROM files f
+     JOIN blobs b ON f.blob_id = b.id
      JOIN users u ON f.owner_id=u.id
-     ORDER BY f.created_at DESC`)
+     WHERE u.org_id=$1
+     ORDER BY f.created_at DESC`, orgID)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to query files"})
 		return
 	}
 	defer rows.Close()
 
-	var results []gin.H
+	results := []api.File{}
 	for rows.Next() {
-		var id, size, downloadCount int64
-		var filename, username string
-		var created time.Time
-		rows.Scan(&id, &filename, &size, &username, &created, &downloadCount)
-		results = append(results, gin.H{
-			"id":             id,
-			"filename":       filename,
-			"size":           size,
-			"owner":          username,
-			"created_at":     created,
-			"download_count": downloadCount,
-		})
-	}
-
-	c.JSON(200, gin.H{"files": results})
+		var owner string
+		f, err := scanFile(rows, &owner)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to scan row"})
+			return
+		}
+		f.Owner = owner
+		results = append(results, f)
+	}
+
+	c.JSON(200, api.FileList{Files: results})
 }
 
 func (h *Handler) AdminStats(c *gin.Context) {
-	if !requireAdmin(c) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
 		return
 	}
 
 	var totalStorage int64
-	_ = db.Pool.QueryRow(c, "SELECT COALESCE(SUM(size),0) FROM files").Scan(&totalStorage)
+	_ = db.Pool.QueryRow(c,
+		"SELECT COALESCE(SUM(f.size),0) FROM files f JOIN users u ON u.id = f.owner_id WHERE u.org_id=$1", orgID,
+	).Scan(&totalStorage)
 
 	var totalUsers int64
-	_ = db.Pool.QueryRow(c, "SELECT COUNT(*) FROM users").Scan(&totalUsers)
+	_ = db.Pool.QueryRow(c, "SELECT COUNT(*) FROM users WHERE org_id=$1", orgID).Scan(&totalUsers)
 
 	var totalDownloads int64
-	_ = db.Pool.QueryRow(c, "SELECT COALESCE(SUM(download_count),0) FROM files").Scan(&totalDownloads)
-
-	c.JSON(200, gin.H{
-		"total_storage":   totalStorage,
-		"total_users":     totalUsers,
-		"total_downloads": totalDownloads,
+	_ = db.Pool.QueryRow(c,
+		"SELECT COALESCE(SUM(f.download_count),0) FROM files f JOIN users u ON u.id = f.owner_id WHERE u.org_id=$1", orgID,
+	).Scan(&totalDownloads)
+
+	c.JSON(200, api.AdminStats{
+		TotalStorage:   totalStorage,
+		TotalUsers:     totalUsers,
+		TotalDownloads: totalDownloads,
 	})
 }
 
@@ -1817,86 +1547,66 @@ func (h *Handler) GetAuditLogsHandler(c *gin.Context) {
 	}
 	defer rows.Close()
 
-	var logs []gin.H
+	logs := []api.AuditLog{}
 	for rows.Next() {
-		var action, objectType string
-		var objectID int64
-		var meta *string
-		var created time.Time
-		rows.Scan(&action, &objectType, &objectID, &meta, &created)
-
-		logs = append(logs, gin.H{
-			"action":      action,
-			"object_type": objectType,
-			"object_id":   objectID,
-			"meta":        meta,
-			"created_at":  created,
-		})
-	}
-
-	c.JSON(200, gin.H{"logs": logs})
+		var l api.AuditLog
+		rows.Scan(&l.Action, &l.ObjectType, &l.ObjectID, &l.Meta, &l.CreatedAt)
+		logs = append(logs, l)
+	}
+
+	c.JSON(200, api.AuditLogList{Logs: logs})
 }
 
 // Move file to trash
 func (h *Handler) TrashFileHandler(c *gin.Context) {
-    id := c.Param("id")
-    userID := c.GetInt64("user_id")
-
-    var ownerID int64
-    err := db.Pool.QueryRow(c, "SELECT owner_id FROM files WHERE id=$1", id).Scan(&ownerID)
-    if err != nil {
-        c.JSON(404, gin.H{"error": "file not found"})
+    id, ok := paramID(c, "id")
+    if !ok {
         return
     }
+    userID := c.GetInt64("user_id")
 
-    if ownerID != userID {
-        var canEdit bool
-        _ = db.Pool.QueryRow(c, "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2", id, userID).Scan(&canEdit)
-        if !canEdit {
-            c.JSON(403, gin.H{"error": "not authorized"})
-            return
-        }
+    if _, ok := authorize(c, userID, id, actionEdit); !ok {
+        return
     }
 
-    _, err = db.Pool.Exec(c, "UPDATE files SET trashed=true, trashed_at=$1 WHERE id=$2", time.Now(), id)
+    _, err := db.Pool.Exec(c, "UPDATE files SET trashed=true, trashed_at=$1 WHERE id=$2", time.Now(), id)
     if err != nil {
         c.JSON(500, gin.H{"error": "failed to trash file"})
         return
     }
 
     _, _ = db.Pool.Exec(c,
-        "INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
-        userID, "trash_file", "file", id,
+        "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+        userID, "trash_file", "file", id, auditMeta(c, ""),
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":     "file_trashed",
         "file_id":   id,
         "user":      userID,
         "timestamp": time.Now(),
     })
 
-    c.JSON(200, gin.H{"message": "file moved to trash", "file_id": id})
+    c.JSON(200, api.FileStatus{Message: "file moved to trash", FileID: id})
 }
 
 // Restore file from trash
 func (h *Handler) RestoreFileHandler(c *gin.Context) {
-    id := c.Param("id")
+    id, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
     userID := c.GetInt64("user_id")
 
-    var ownerID int64
-    err := db.Pool.QueryRow(c, "SELECT owner_id FROM files WHERE id=$1", id).Scan(&ownerID)
-    if err != nil {
-        c.JSON(404, gin.H{"error": "file not found"})
+    if _, ok := authorize(c, userID, id, actionOwn); !ok {
         return
     }
 
-    if ownerID != userID {
-        c.JSON(403, gin.H{"error": "not authorized"})
+    _, err := db.Pool.Exec(c, "UPDATE files SET trashed=false, trashed_at=NULL WHERE id=$1", id)
+    if isUniqueViolation(err) {
+        c.JSON(409, gin.H{"error": "a file with that name already exists in the folder"})
         return
     }
-
-    _, err = db.Pool.Exec(c, "UPDATE files SET trashed=false, trashed_at=NULL WHERE id=$1", id)
     if err != nil {
         c.JSON(500, gin.H{"error": "failed to restore file"})
         return
@@ -1907,47 +1617,38 @@ func (h *Handler) RestoreFileHandler(c *gin.Context) {
         userID, "restore_file", "file", id,
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":     "file_restored",
         "file_id":   id,
         "user":      userID,
         "timestamp": time.Now(),
     })
 
-    c.JSON(200, gin.H{"message": "file restored", "file_id": id})
+    c.JSON(200, api.FileStatus{Message: "file restored", FileID: id})
 }
 
 // Permanently delete file
 func (h *Handler) PermanentlyDeleteFileHandler(c *gin.Context) {
-    id := c.Param("id")
+    id, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
     userID := c.GetInt64("user_id")
 
-    var ownerID int64
-    var blobID int64
-    var blobPath string
-
-    err := db.Pool.QueryRow(c, "SELECT owner_id, blob_id FROM files WHERE id=$1", id).Scan(&ownerID, &blobID)
-    if err != nil {
-        c.JSON(404, gin.H{"error": "file not found"})
+    if _, ok := authorize(c, userID, id, actionOwn); !ok {
         return
     }
 
-    if ownerID != userID {
-        c.JSON(403, gin.H{"error": "not authorized"})
+    tx, err := db.Pool.Begin(c)
+    if err != nil {
+        c.JSON(500, gin.H{"error": "failed to start transaction"})
         return
     }
+    defer tx.Rollback(c)
 
-    _ = db.Pool.QueryRow(c, "SELECT path FROM blobs WHERE id=$1", blobID).Scan(&blobPath)
-
-    _, _ = db.Pool.Exec(c, "DELETE FROM files WHERE id=$1", id)
-
-    var refCount int
-    _ = db.Pool.QueryRow(c, "UPDATE blobs SET ref_count=ref_count-1 WHERE id=$1 RETURNING ref_count", blobID).Scan(&refCount)
-    if refCount <= 0 {
-        _, _ = db.Pool.Exec(c, "DELETE FROM blobs WHERE id=$1", blobID)
-        if blobPath != "" {
-            _ = os.Remove(blobPath)
-        }
+    if err := h.purgeFile(c, tx, id); err != nil || tx.Commit(c) != nil {
+        c.JSON(500, gin.H{"error": "failed to delete file"})
+        return
     }
 
     _, _ = db.Pool.Exec(c,
@@ -1955,37 +1656,25 @@ func (h *Handler) PermanentlyDeleteFileHandler(c *gin.Context) {
         userID, "delete_file", "file", id,
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":     "file_deleted",
         "file_id":   id,
         "user":      userID,
         "timestamp": time.Now(),
     })
 
-    c.JSON(200, gin.H{"message": "file permanently deleted", "file_id": id})
+    c.JSON(200, api.FileStatus{Message: "file permanently deleted", FileID: id})
 }
 
 func (h *Handler) ListFileVersionsHandler(c *gin.Context) {
-    fileID := c.Param("id")
-    userID := c.GetInt64("user_id")
-
-    var ownerID int64
-    err := db.Pool.QueryRow(c, "SELECT owner_id FROM files WHERE id=$1", fileID).Scan(&ownerID)
-    if err != nil {
-        c.JSON(404, gin.H{"error": "file not found"})
+    fileID, ok := paramID(c, "id")
+    if !ok {
         return
     }
+    userID := c.GetInt64("user_id")
 
-    if ownerID != userID {
-        var canEdit bool
-        _ = db.Pool.QueryRow(c,
-            "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2",
-            fileID, userID,
-        ).Scan(&canEdit)
-        if !canEdit {
-            c.JSON(403, gin.H{"error": "not authorized"})
-            return
-        }
+    if _, ok := authorize(c, userID, fileID, actionRead); !ok {
+        return
     }
 
     rows, err := db.Pool.Query(c,
@@ -2000,31 +1689,26 @@ func (h *Handler) ListFileVersionsHandler(c *gin.Context) {
     }
     defer rows.Close()
 
-    var versions []gin.H
+    versions := []api.FileVersion{}
     for rows.Next() {
-        var version int
-        var created time.Time
-        var hash string
-        var size int64
-        if err := rows.Scan(&version, &created, &hash, &size); err != nil {
+        var v api.FileVersion
+        if err := rows.Scan(&v.Version, &v.CreatedAt, &v.Hash, &v.Size); err != nil {
             continue
         }
-        versions = append(versions, gin.H{
-            "version":    version,
-            "created_at": created,
-            "hash":       hash,
-            "size":       size,
-        })
+        versions = append(versions, v)
     }
 
-    c.JSON(200, gin.H{
-        "file_id":  fileID,
-        "versions": versions,
+    c.JSON(200, api.FileVersions{
+        FileID:   fileID,
+        Versions: versions,
     })
 }
 
 func (h *Handler) RestoreFileVersionHandler(c *gin.Context) {
-    fileID := c.Param("id")
+    fileID, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
     versionStr := c.Param("version")
     userID := c.GetInt64("user_id")
 
@@ -2034,98 +1718,106 @@ func (h *Handler) RestoreFileVersionHandler(c *gin.Context) {
         return
     }
 
-    var ownerID int64
-    err = db.Pool.QueryRow(c, "SELECT owner_id FROM files WHERE id=$1", fileID).Scan(&ownerID)
-    if err != nil {
-        c.JSON(404, gin.H{"error": "file not found"})
+    if _, ok := authorize(c, userID, fileID, actionEdit); !ok {
         return
     }
 
-    if ownerID != userID {
-        var canEdit bool
-        _ = db.Pool.QueryRow(c,
-            "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2",
-            fileID, userID,
-        ).Scan(&canEdit)
-        if !canEdit {
-            c.JSON(403, gin.H{"error": "not authorized"})
-            return
-        }
-    }
-
-    var blobID int64
+    var id, blobID, size, currentBlobID int64
     err = db.Pool.QueryRow(c,
-        "SELECT blob_id FROM file_versions WHERE file_id=$1 AND version=$2",
+        `SELECT f.id, v.blob_id, b.size, f.blob_id
+         FROM file_versions v
+         JOIN blobs b ON v.blob_id = b.id
+         JOIN files f ON v.file_id = f.id
+         WHERE v.file_id=$1 AND v.version=$2`,
         fileID, version,
-    ).Scan(&blobID)
+    ).Scan(&id, &blobID, &size, &currentBlobID)
     if err != nil {
         c.JSON(404, gin.H{"error": "version not found"})
         return
     }
 
-    _, err = db.Pool.Exec(c, "UPDATE files SET blob_id=$1 WHERE id=$2", blobID, fileID)
-    if err != nil {
-        c.JSON(500, gin.H{"error": "failed to restore version"})
-        return
+    // The files row moves its blob reference from the current content to
+    // the restored one.
+    if blobID != currentBlobID {
+        tx, err := db.Pool.Begin(c)
+        if err != nil {
+            c.JSON(500, gin.H{"error": "failed to restore version"})
+            return
+        }
+        defer tx.Rollback(c)
+
+        err = retireBlob(c, tx, id, currentBlobID)
+        if err == nil {
+            _, err = tx.Exec(c, "UPDATE blobs SET ref_count = ref_count + 1 WHERE id=$1", blobID)
+        }
+        if err == nil {
+            _, err = tx.Exec(c, "UPDATE files SET blob_id=$1, size=$2 WHERE id=$3", blobID, size, id)
+        }
+        if err == nil {
+            err = tx.Commit(c)
+        }
+        if err != nil {
+            c.JSON(500, gin.H{"error": "failed to restore version"})
+            return
+        }
     }
 
     _, _ = db.Pool.Exec(c,
         "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-        userID, "restore_version", "file", fileID, fmt.Sprintf(`{"version":%d}`, version),
+        userID, "restore_version", "file", fileID, auditMeta(c, fmt.Sprintf(`{"version":%d}`, version)),
     )
 
-    c.JSON(200, gin.H{
-        "message": "version restored",
-        "file_id": fileID,
-        "version": version,
+    c.JSON(200, api.VersionRestored{
+        Message: "version restored",
+        FileID:  fileID,
+        Version: version,
     })
 }
 
 func (h *Handler) GetPreviewHandler(c *gin.Context) {
-    id := c.Param("id")
+    id, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
     userID := c.GetInt64("user_id")
+    if _, ok := authorize(c, userID, id, actionRead); !ok {
+        return
+    }
 
     var (
         filename   string
-        blobPath   string
-        ownerID    int64
+        blobKey    string
         mimeType   string
-        isPublic   bool
     )
     err := db.Pool.QueryRow(c,
-        `SELECT f.filename, b.path, f.owner_id, f.mime_type, f.is_public
+        `SELECT f.filename, b.path, f.mime_type
          FROM files f
          JOIN blobs b ON f.blob_id = b.id
          WHERE f.id=$1`,
         id,
-    ).Scan(&filename, &blobPath, &ownerID, &mimeType, &isPublic)
+    ).Scan(&filename, &blobKey, &mimeType)
 
     if err != nil {
         c.JSON(404, gin.H{"error": "file not found"})
         return
     }
 
-    var isEditor bool
-    _ = db.Pool.QueryRow(c,
-        "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2",
-        id, userID,
-    ).Scan(&isEditor)
-
-    if userID != ownerID && !isEditor && !isPublic {
-        c.JSON(403, gin.H{"error": "permission denied"})
-        return
-    }
-
     if strings.HasPrefix(mimeType, "image/") {
-        c.File(blobPath)
+        serveFileWithRange(c, h.Blobs, blobKey, filename, mimeType, false)
         return
     }
     if mimeType == "application/pdf" {
-        c.File(blobPath)
+        serveFileWithRange(c, h.Blobs, blobKey, filename, mimeType, false)
         return
     }
     if strings.HasPrefix(mimeType, "text/") {
-        data, err := os.ReadFile(blobPath)
+        rc, err := h.Blobs.GetRange(c, blobKey, 0, 2048)
+        if err != nil {
+            c.JSON(500, gin.H{"error": "failed to read file"})
+            return
+        }
+        data, err := io.ReadAll(rc)
+        rc.Close()
         if err != nil {
             c.JSON(500, gin.H{"error": "failed to read file"})
             return
@@ -2143,49 +1835,35 @@ func (h *Handler) GetPreviewHandler(c *gin.Context) {
 
 
 func (h *Handler) PreviewFileHandler(c *gin.Context) {
-    id := c.Param("id")
+    id, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
     userID := c.GetInt64("user_id")
+    if _, ok := authorize(c, userID, id, actionRead); !ok {
+        return
+    }
 
     var (
         filename         string
-        blobPath         string
-        ownerID          int64
+        blobKey           string
         mimeType         string
         previewAvailable bool
     )
 
     err := db.Pool.QueryRow(
         c,
-        `SELECT f.filename, b.path, f.owner_id, f.mime_type, f.preview_available
+        `SELECT f.filename, b.path, f.mime_type, f.preview_available
          FROM files f
          JOIN blobs b ON f.blob_id = b.id
          WHERE f.id=$1 AND f.trashed=false`,
         id,
-    ).Scan(&filename, &blobPath, &ownerID, &mimeType, &previewAvailable)
+    ).Scan(&filename, &blobKey, &mimeType, &previewAvailable)
     if err != nil {
         c.JSON(404, gin.H{"error": "file not found"})
         return
     }
 
-    if userID != ownerID {
-        var canEdit bool
-        _ = db.Pool.QueryRow(c,
-            "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2",
-            id, userID,
-        ).Scan(&canEdit)
-
-        var isPublic bool
-        _ = db.Pool.QueryRow(c,
-            "SELECT is_public FROM files WHERE id=$1",
-            id,
-        ).Scan(&isPublic)
-
-        if !canEdit && !isPublic {
-            c.JSON(403, gin.H{"error": "permission denied"})
-            return
-        }
-    }
-
     if !previewAvailable {
         c.JSON(400, gin.H{"error": "preview not available for this file"})
         return
@@ -2194,10 +1872,10 @@ func (h *Handler) PreviewFileHandler(c *gin.Context) {
 
     _, _ = db.Pool.Exec(c,
         "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-        userID, "preview_file", "file", id, fmt.Sprintf(`{"filename":"%s"}`, filename),
+        userID, "preview_file", "file", id, auditMeta(c, fmt.Sprintf(`{"filename":"%s"}`, filename)),
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":    "preview",
         "file_id":  id,
         "filename": filename,
@@ -2205,67 +1883,62 @@ func (h *Handler) PreviewFileHandler(c *gin.Context) {
         "ts":       time.Now(),
     })
 
-    serveFileWithRange(c, blobPath, filename, mimeType, false)
+    serveFileWithRange(c, h.Blobs, blobKey, filename, mimeType, false)
 }
 
 func (h *Handler) AccessShareHandler(c *gin.Context) {
     token := c.Param("token")
+    s, email, ok := openFileShare(c, token)
+    if !ok {
+        return
+    }
 
     var (
-        fileID           int64
+        fileID           = s.FileID
         filename         string
-        expiresAt        *time.Time
-        allowDownload    bool
         mimeType         string
         previewAvailable bool
     )
 
     err := db.Pool.QueryRow(
         c,
-        `SELECT f.id, f.filename, s.expires_at, s.allow_download, f.mime_type, f.preview_available
-         FROM shares s
-         JOIN files f ON s.file_id = f.id
-         WHERE s.token=$1`,
-        token,
-    ).Scan(&fileID, &filename, &expiresAt, &allowDownload, &mimeType, &previewAvailable)
+        "SELECT filename, mime_type, preview_available FROM files WHERE id=$1",
+        fileID,
+    ).Scan(&filename, &mimeType, &previewAvailable)
 
     if err != nil {
         c.JSON(404, gin.H{"error": "invalid or expired link"})
         return
     }
 
-    if expiresAt != nil && time.Now().After(*expiresAt) {
-        c.JSON(410, gin.H{"error": "link expired"})
-        return
-    }
-
-    baseURL := "http://localhost:8080/s/" + token
+    _, _ = db.Pool.Exec(c,
+        "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+        nil, "view_share", "share", s.ID, publicAuditMeta(c, s, email, map[string]any{}),
+    )
 
-    c.JSON(200, gin.H{
-        "file_id":           fileID,
-        "filename":          filename,
-        "mime_type":         mimeType,
-        "allow_download":    allowDownload,
-        "preview_available": previewAvailable,
-        "download_url":      baseURL + "/download",
-        "preview_url":       baseURL + "/preview",
+    baseURL := shareURL(token)
+
+    c.JSON(200, api.SharedFile{
+        FileID:           fileID,
+        Filename:         filename,
+        MIMEType:         mimeType,
+        AllowDownload:    s.AllowDownload,
+        PreviewAvailable: previewAvailable,
+        DownloadURL:      baseURL + "/download",
+        PreviewURL:       baseURL + "/preview",
+        DownloadsLeft:    s.downloadsLeft(),
     })
 }
 
-func serveFileWithRange(c *gin.Context, filePath, filename, mimeType string, asAttachment bool) {
-    file, err := os.Open(filePath)
+func serveFileWithRange(c *gin.Context, blobs storage.BlobStore, key, filename, mimeType string, asAttachment bool) {
+    file, err := storage.Open(c, blobs, key)
     if err != nil {
         c.JSON(500, gin.H{"error": "failed to open file"})
         return
     }
     defer file.Close()
 
-    stat, err := file.Stat()
-    if err != nil {
-        c.JSON(500, gin.H{"error": "failed to stat file"})
-        return
-    }
-    size := stat.Size()
+    size := file.Size()
 
     c.Header("Accept-Ranges", "bytes")
     rangeHeader := c.GetHeader("Range")
@@ -2280,7 +1953,7 @@ func serveFileWithRange(c *gin.Context, filePath, filename, mimeType string, asA
             disposition = "attachment"
         }
         c.Header("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, filename))
-        http.ServeContent(c.Writer, c.Request, filename, stat.ModTime(), file)
+        http.ServeContent(c.Writer, c.Request, filename, file.ModTime(), file)
         return
     }
 
@@ -2313,54 +1986,44 @@ func serveFileWithRange(c *gin.Context, filePath, filename, mimeType string, asA
     }
     c.Header("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, filename))
 
-    file.Seek(start, io.SeekStart)
-    io.CopyN(c.Writer, file, length)
+    body, err := blobs.GetRange(c, key, start, length)
+    if err != nil {
+        return
+    }
+    defer body.Close()
+    io.CopyN(c.Writer, body, length)
 }
 
 func (h *Handler) PreviewHandler(c *gin.Context) {
-    id := c.Param("id")
+    id, ok := paramID(c, "id")
+    if !ok {
+        return
+    }
     userID := c.GetInt64("user_id")
+    if _, ok := authorize(c, userID, id, actionRead); !ok {
+        return
+    }
 
     var (
         filename         string
-        blobPath         string
-        ownerID          int64
+        blobKey           string
         mimeType         string
         previewAvailable bool
     )
     err := db.Pool.QueryRow(
         c,
-        `SELECT f.filename, b.path, f.owner_id, f.mime_type, f.preview_available
+        `SELECT f.filename, b.path, f.mime_type, f.preview_available
          FROM files f
          JOIN blobs b ON f.blob_id = b.id
          WHERE f.id=$1`,
         id,
-    ).Scan(&filename, &blobPath, &ownerID, &mimeType, &previewAvailable)
+    ).Scan(&filename, &blobKey, &mimeType, &previewAvailable)
 
     if err != nil {
         c.JSON(404, gin.H{"error": "file not found"})
         return
     }
 
-    var isEditor bool
-    _ = db.Pool.QueryRow(
-        c,
-        "SELECT can_edit FROM file_permissions WHERE file_id=$1 AND user_id=$2",
-        id, userID,
-    ).Scan(&isEditor)
-
-    var isPublic bool
-    _ = db.Pool.QueryRow(
-        c,
-        "SELECT is_public FROM files WHERE id=$1",
-        id,
-    ).Scan(&isPublic)
-
-    if userID != ownerID && !isEditor && !isPublic {
-        c.JSON(403, gin.H{"error": "permission denied"})
-        return
-    }
-
     if !previewAvailable {
         c.JSON(400, gin.H{"error": "preview not available for this file"})
         return
@@ -2368,10 +2031,10 @@ func (h *Handler) PreviewHandler(c *gin.Context) {
 
     _, _ = db.Pool.Exec(c,
         "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-        userID, "preview_file", "file", id, fmt.Sprintf(`{"filename":"%s"}`, filename),
+        userID, "preview_file", "file", id, auditMeta(c, fmt.Sprintf(`{"filename":"%s"}`, filename)),
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":    "preview",
         "file_id":  id,
         "filename": filename,
@@ -2379,42 +2042,38 @@ func (h *Handler) PreviewHandler(c *gin.Context) {
         "ts":       time.Now(),
     })
 
-    serveFileWithRange(c, blobPath, filename, mimeType, false)
+    serveFileWithRange(c, h.Blobs, blobKey, filename, mimeType, false)
 }
 
 
 func (h *Handler) AccessSharePreviewHandler(c *gin.Context) {
-    token := c.Param("token")
+    s, email, ok := openFileShare(c, c.Param("token"))
+    if !ok {
+        return
+    }
 
     var (
-        fileID           int64
-        blobPath         string
+        fileID           = s.FileID
+        blobKey           string
         filename         string
-        expiresAt        *time.Time
         mimeType         string
         previewAvailable bool
     )
 
     err := db.Pool.QueryRow(
         c,
-        `SELECT f.id, b.path, f.filename, s.expires_at, f.mime_type, f.preview_available
-         FROM shares s
-         JOIN files f ON s.file_id = f.id
+        `SELECT b.path, f.filename, f.mime_type, f.preview_available
+         FROM files f
          JOIN blobs b ON f.blob_id = b.id
-         WHERE s.token=$1`,
-        token,
-    ).Scan(&fileID, &blobPath, &filename, &expiresAt, &mimeType, &previewAvailable)
+         WHERE f.id=$1`,
+        fileID,
+    ).Scan(&blobKey, &filename, &mimeType, &previewAvailable)
 
     if err != nil {
         c.JSON(404, gin.H{"error": "invalid or expired link"})
         return
     }
 
-    if expiresAt != nil && time.Now().After(*expiresAt) {
-        c.JSON(410, gin.H{"error": "link expired"})
-        return
-    }
-
     if !previewAvailable {
         c.JSON(403, gin.H{"error": "preview not available for this file"})
         return
@@ -2422,11 +2081,11 @@ func (h *Handler) AccessSharePreviewHandler(c *gin.Context) {
 
     _, _ = db.Pool.Exec(c,
         "INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
-        0, "preview_file_public", "file", fileID,
-        fmt.Sprintf(`{"filename":"%s"}`, filename),
+        nil, "preview_file_public", "file", fileID,
+        publicAuditMeta(c, s, email, map[string]any{"filename": filename}),
     )
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":     "preview",
         "file_id":   fileID,
         "filename":  filename,
@@ -2434,16 +2093,13 @@ func (h *Handler) AccessSharePreviewHandler(c *gin.Context) {
         "timestamp": time.Now(),
     })
 
-    serveFileWithRange(c, blobPath, filename, mimeType, false)
+    serveFileWithRange(c, h.Blobs, blobKey, filename, mimeType, false)
 }
 
 func (h *Handler) BulkMoveFilesHandler(c *gin.Context) {
     userID := c.GetInt64("user_id")
 
-    var body struct {
-        FileIDs   []int64 `json:"file_ids"`
-        FolderID  *int64  `json:"folder_id"`
-    }
+    var body api.BulkMoveRequest
     if err := c.BindJSON(&body); err != nil {
         c.JSON(400, gin.H{"error": "invalid request"})
         return
@@ -2453,6 +2109,32 @@ func (h *Handler) BulkMoveFilesHandler(c *gin.Context) {
         c.JSON(400, gin.H{"error": "no files provided"})
         return
     }
+    folderID, ok := targetFolder(c, body.FolderID)
+    if !ok {
+        return
+    }
+    body.FolderID = folderID
+    if pat := tokenAccess(c); pat != nil && pat.FolderID != nil {
+        var outside int64
+        err := db.Pool.QueryRow(c,
+            `WITH RECURSIVE scope AS (
+               SELECT id FROM folders WHERE id=$1
+               UNION
+               SELECT f.id FROM folders f JOIN scope ON f.parent_id = scope.id
+             )
+             SELECT COUNT(*) FROM files
+             WHERE id = ANY($2) AND owner_id=$3 AND (folder_id IS NULL OR folder_id NOT IN (SELECT id FROM scope))`,
+            *pat.FolderID, body.FileIDs, userID,
+        ).Scan(&outside)
+        if err != nil {
+            c.JSON(500, gin.H{"error": "failed to check token scope"})
+            return
+        }
+        if outside > 0 {
+            c.JSON(403, gin.H{"error": "outside the folder this token is restricted to"})
+            return
+        }
+    }
 
     tx, err := db.Pool.Begin(c)
     if err != nil {
@@ -2466,6 +2148,10 @@ func (h *Handler) BulkMoveFilesHandler(c *gin.Context) {
             "UPDATE files SET folder_id=$1 WHERE id=$2 AND owner_id=$3",
             body.FolderID, fid, userID,
         )
+        if isUniqueViolation(err) {
+            c.JSON(409, gin.H{"error": fmt.Sprintf("file %d: a file with that name already exists in the folder", fid)})
+            return
+        }
         if err != nil || res.RowsAffected() == 0 {
             c.JSON(404, gin.H{"error": fmt.Sprintf("file %d not found or not owned", fid)})
             return
@@ -2477,7 +2163,7 @@ func (h *Handler) BulkMoveFilesHandler(c *gin.Context) {
         return
     }
 
-    broadcastUpdate(gin.H{
+    broadcastUpdate(c, gin.H{
         "event":     "bulk_file_moved",
         "file_ids":  body.FileIDs,
         "folder_id": body.FolderID,
@@ -2485,5 +2171,5 @@ func (h *Handler) BulkMoveFilesHandler(c *gin.Context) {
         "timestamp": time.Now(),
     })
 
-    c.JSON(200, gin.H{"message": "files moved", "file_ids": body.FileIDs, "folder_id": body.FolderID})
+    c.JSON(200, api.FilesMoved{Message: "files moved", FileIDs: body.FileIDs, FolderID: body.FolderID})
 }
\ No newline at end of file
@@ -1,29 +1,60 @@
 package handlers
 
 import (
-    "crypto/sha256"
-    "encoding/hex"
-    "io"
-    "mime/multipart"
     "regexp"
+    "strconv"
 
     "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5"
+    "github.com/lib/pq"
+
+    "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
     "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+    "github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
 )
 
-func requireAdmin(c *gin.Context) bool {
+// requireAdmin checks that the caller administers their organization and
+// returns its id. Admin rights do not reach beyond it.
+func requireAdmin(c *gin.Context) (int64, bool) {
+	return checkAdmin(c, "role = 'admin'")
+}
+
+// requireInstanceAdmin checks that the caller operates the deployment,
+// which lets them manage organizations but not see into them.
+func requireInstanceAdmin(c *gin.Context) bool {
+	_, ok := checkAdmin(c, "instance_admin")
+	return ok
+}
+
+// checkAdmin checks the users column expression cond for the caller and
+// the two-factor rule of their organization, returning the organization.
+func checkAdmin(c *gin.Context, cond string) (int64, bool) {
 	userID := c.GetInt64("user_id")
 	if userID == 0 {
 		c.JSON(401, gin.H{"error": "unauthenticated"})
-		return false
+		return 0, false
 	}
-	var role string
-	err := db.Pool.QueryRow(c, "SELECT role FROM users WHERE id=$1", userID).Scan(&role)
-	if err != nil || role != "admin" {
+	var orgID int64
+	var admin, twoFactor bool
+	err := db.Pool.QueryRow(c,
+		"SELECT org_id, COALESCE("+cond+", false), totp_enabled_at IS NOT NULL FROM users WHERE id=$1", userID,
+	).Scan(&orgID, &admin, &twoFactor)
+	if err != nil || !admin {
 		c.JSON(403, gin.H{"error": "forbidden"})
-		return false
+		return 0, false
 	}
-	return true
+	if !twoFactor {
+		required, err := adminTwoFactorRequired(c, orgID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to check permissions"})
+			return 0, false
+		}
+		if required {
+			c.JSON(403, gin.H{"error": "two-factor authentication is required for admins, turn it on at /2fa"})
+			return 0, false
+		}
+	}
+	return orgID, true
 }
 func validateFilename(name string) bool {
 	if len(name) == 0 || len(name) > 255 {
@@ -48,15 +79,61 @@ func validateEmail(e string) bool {
 func validateUsername(u string) bool {
 	return usernameRegex.MatchString(u)
 }
-func computeSHA256(file multipart.File) (string, int64, error) {
-    h := sha256.New()
-    size, err := io.Copy(h, file)
-    if err != nil {
-        return "", 0, err
-    }
-    return hex.EncodeToString(h.Sum(nil)), size, nil
+
+// checkPassword returns why a new password is unacceptable, or "". bcrypt
+// ignores everything past 72 bytes.
+func checkPassword(p string) string {
+	if len(p) < 8 || len(p) > 72 {
+		return "password must be 8 to 72 bytes long"
+	}
+	return ""
 }
 
-func NewHandler(storagePath string) *Handler {
-    return &Handler{StoragePath: storagePath}
-}
\ No newline at end of file
+// requireVerifiedEmail answers 403 unless the caller has verified their
+// email, which public share links require.
+func requireVerifiedEmail(c *gin.Context) bool {
+	verified, err := emailVerified(c, c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to check email verification"})
+		return false
+	}
+	if !verified {
+		c.JSON(403, gin.H{"error": "verify your email before creating public share links, see POST /verify-email/resend"})
+		return false
+	}
+	return true
+}
+func NewHandler(storagePath string, blobs storage.BlobStore) *Handler {
+    return &Handler{StoragePath: storagePath, Blobs: blobs}
+}
+
+// paramID parses the id in path parameter name, answering 400 when it is
+// not one.
+func paramID(c *gin.Context, name string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+// fileColumns are the columns scanFile reads, from files f joined to
+// blobs b.
+const fileColumns = `f.id, f.filename, f.folder_id, f.size, COALESCE(f.mime_type, ''), b.hash,
+	COALESCE(f.tags, '{}'), COALESCE(f.preview_available, false), COALESCE(f.is_public, false),
+	COALESCE(f.download_count, 0), f.created_at, f.trashed_at`
+
+// scanFile reads a row selected with fileColumns, so that every listing
+// reports files the same way. Columns selected after them go to extra.
+func scanFile(row pgx.Row, extra ...any) (api.File, error) {
+	var f api.File
+	dest := []any{&f.ID, &f.Filename, &f.FolderID, &f.Size, &f.MIMEType, &f.Hash,
+		pq.Array(&f.Tags), &f.PreviewAvailable, &f.IsPublic,
+		&f.DownloadCount, &f.CreatedAt, &f.TrashedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if f.Tags == nil {
+		f.Tags = []string{}
+	}
+	return f, err
+}
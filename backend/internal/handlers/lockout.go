@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/middleware"
+)
+
+// Brute-force protection (see middleware/bruteforce.go) for everything that
+// checks a password or a second factor: /login, /login/2fa and WebDAV.
+
+// loginBlocked answers 429 when attempts for username or from the client's
+// IP are blocked. The answer is the same whether or not the user exists.
+func loginBlocked(c *gin.Context, username string) bool {
+	block, err := middleware.LoginBlocked(c, c.ClientIP(), username)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "rate limiter error"})
+		return true
+	}
+	if block == nil {
+		return false
+	}
+	secs := int(math.Ceil(block.RetryAfter.Seconds()))
+	c.Header("Retry-After", fmt.Sprint(secs))
+	if block.Locked {
+		c.JSON(429, gin.H{"error": fmt.Sprintf("too many failed attempts, login is locked for %d seconds", secs)})
+	} else {
+		c.JSON(429, gin.H{"error": fmt.Sprintf("too many failed attempts, try again in %d seconds", secs)})
+	}
+	return true
+}
+
+// loginFailed counts a failed attempt and records the lockouts it causes in
+// the audit log, under the locked user when there is one.
+func loginFailed(c *gin.Context, username string) {
+	lockouts, _ := middleware.LoginFailed(c, c.ClientIP(), username)
+	for _, l := range lockouts {
+		var userID *int64
+		action := "ip_locked"
+		if l.Kind == "user" {
+			action = "account_locked"
+			var id int64
+			if db.Pool.QueryRow(c, "SELECT id FROM users WHERE lower(username)=$1", l.Key).Scan(&id) == nil {
+				userID = &id
+			}
+		}
+		meta, _ := json.Marshal(map[string]any{
+			"username":   username,
+			"ip":         c.ClientIP(),
+			"failures":   l.Failures,
+			"locked_for": int(l.Duration.Seconds()),
+		})
+		_, _ = db.Pool.Exec(c,
+			"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+			userID, action, "user", userID, string(meta),
+		)
+	}
+}
+
+func loginSucceeded(c *gin.Context, username string) {
+	_ = middleware.LoginSucceeded(c, username)
+}
+
+// AdminLockoutsHandler lists the blocked usernames of the admin's
+// organization. IPs are shared by every organization, so only instance
+// admins see them.
+func (h *Handler) AdminLockoutsHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	entries, err := middleware.Blocked(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list lockouts"})
+		return
+	}
+	var usernames []string
+	for _, e := range entries {
+		if e.Kind == "user" {
+			usernames = append(usernames, e.Key)
+		}
+	}
+	var members []string
+	var instanceAdmin bool
+	err = db.Pool.QueryRow(c,
+		`SELECT COALESCE(array_agg(lower(username)) FILTER (WHERE org_id=$1 AND lower(username) = ANY($2::text[])), '{}'),
+		   bool_or(id=$3 AND instance_admin)
+		 FROM users WHERE id=$3 OR org_id=$1`,
+		orgID, usernames, c.GetInt64("user_id"),
+	).Scan(&members, &instanceAdmin)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list lockouts"})
+		return
+	}
+
+	out := api.LockoutList{Lockouts: []api.Lockout{}}
+	for _, e := range entries {
+		if (e.Kind == "user" && !slices.Contains(members, e.Key)) || (e.Kind != "user" && !instanceAdmin) {
+			continue
+		}
+		l := api.Lockout{
+			Failures:   e.Failures,
+			Locked:     e.Locked,
+			RetryAfter: int64(math.Ceil(e.RetryAfter.Seconds())),
+		}
+		if e.Kind == "user" {
+			l.Username = e.Key
+		} else {
+			l.IP = e.Key
+		}
+		out.Lockouts = append(out.Lockouts, l)
+	}
+	c.JSON(200, out)
+}
+
+// AdminUnlockHandler clears the failed attempts and blocks of a username
+// in the admin's organization, an IP, which takes an instance admin, or
+// both.
+func (h *Handler) AdminUnlockHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	var body api.UnlockRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	body.Username, body.IP = strings.TrimSpace(body.Username), strings.TrimSpace(body.IP)
+	if body.Username == "" && body.IP == "" {
+		c.JSON(400, gin.H{"error": "username or ip is required"})
+		return
+	}
+	if body.IP != "" && net.ParseIP(body.IP) == nil {
+		c.JSON(400, gin.H{"error": "invalid ip"})
+		return
+	}
+	if body.Username != "" {
+		var member bool
+		err := db.Pool.QueryRow(c,
+			"SELECT EXISTS (SELECT 1 FROM users WHERE lower(username)=lower($1) AND org_id=$2)",
+			body.Username, orgID,
+		).Scan(&member)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to unlock"})
+			return
+		}
+		if !member {
+			c.JSON(404, gin.H{"error": "user not found"})
+			return
+		}
+	}
+	if body.IP != "" && !requireInstanceAdmin(c) {
+		return
+	}
+
+	cleared := false
+	for kind, key := range map[string]string{"user": body.Username, "ip": body.IP} {
+		if key == "" {
+			continue
+		}
+		ok, err := middleware.Unlock(c, kind, key)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to unlock"})
+			return
+		}
+		cleared = cleared || ok
+	}
+	if !cleared {
+		c.JSON(404, gin.H{"error": "no failed attempts recorded"})
+		return
+	}
+
+	meta, _ := json.Marshal(body)
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		c.GetInt64("user_id"), "unlock_login", "user", nil, string(meta),
+	)
+	c.JSON(200, api.Message{Message: "unlocked"})
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/oidc"
+)
+
+// Single sign-on through an OpenID Connect provider (see package oidc).
+// GET /auth/oidc/login redirects to the provider; its callback finds the
+// user by their identity, links it to the account with the same verified
+// email, or creates an account, then issues the same tokens as /login.
+// With OIDC_GROUP_ROLES set, the groups in the ID token decide the role on
+// every login.
+
+const oidcStateCookie = "oidc_state"
+
+// oidcLoginTTL is how long a user has to finish logging in at the provider.
+const oidcLoginTTL = 600
+
+func (h *Handler) OIDCLoginHandler(c *gin.Context) {
+	if h.OIDC == nil {
+		c.JSON(404, gin.H{"error": "single sign-on is not configured"})
+		return
+	}
+
+	returnTo := c.Query("redirect_uri")
+	if returnTo != "" && !oidcReturnAllowed(returnTo) {
+		c.JSON(400, gin.H{"error": "redirect_uri is not allowed"})
+		return
+	}
+
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start login"})
+		return
+	}
+	target, err := h.OIDC.AuthURL(c, req)
+	if err != nil {
+		c.JSON(502, gin.H{"error": "identity provider unavailable"})
+		return
+	}
+
+	_, _ = db.Pool.Exec(c, "DELETE FROM oidc_logins WHERE created_at < now() - make_interval(secs => $1)", oidcLoginTTL)
+	_, err = db.Pool.Exec(c,
+		"INSERT INTO oidc_logins (state, nonce, code_verifier, return_to) VALUES ($1,$2,$3,$4)",
+		req.State, req.Nonce, req.Verifier, nullIfEmpty(returnTo),
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start login"})
+		return
+	}
+
+	// The cookie ties the callback to the browser that started the login,
+	// so nobody can finish their own login in someone else's browser.
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, req.State, oidcLoginTTL, "/auth/oidc", "", c.Request.TLS != nil, true)
+	c.Redirect(302, target)
+}
+
+func (h *Handler) OIDCCallbackHandler(c *gin.Context) {
+	if h.OIDC == nil {
+		c.JSON(404, gin.H{"error": "single sign-on is not configured"})
+		return
+	}
+
+	state := c.Query("state")
+	cookie, _ := c.Cookie(oidcStateCookie)
+	if state == "" || cookie != state {
+		c.JSON(400, gin.H{"error": "login state does not match, start again"})
+		return
+	}
+	c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc", "", c.Request.TLS != nil, true)
+
+	req := &oidc.AuthRequest{State: state}
+	var returnTo *string
+	err := db.Pool.QueryRow(c,
+		`DELETE FROM oidc_logins WHERE state=$1 AND created_at > now() - make_interval(secs => $2)
+		 RETURNING nonce, code_verifier, return_to`,
+		state, oidcLoginTTL,
+	).Scan(&req.Nonce, &req.Verifier, &returnTo)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "login expired, start again"})
+		return
+	}
+	fail := func(status int, message string) {
+		if returnTo != nil {
+			c.Redirect(302, *returnTo+"#"+url.Values{"error": {message}}.Encode())
+			return
+		}
+		c.JSON(status, gin.H{"error": message})
+	}
+
+	if e := c.Query("error"); e != "" {
+		fail(401, "identity provider refused the login: "+e)
+		return
+	}
+	claims, err := h.OIDC.Exchange(c, c.Query("code"), req)
+	if err != nil {
+		fail(401, "login with the identity provider failed")
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		fail(500, "failed to log in")
+		return
+	}
+	defer tx.Rollback(c)
+
+	user, status, err := provisionOIDCUser(c, tx, h.OIDC.Issuer, claims)
+	if err != nil {
+		fail(status, err.Error())
+		return
+	}
+	if err := applyGroupRole(c, tx, user.ID, claims.Groups); err != nil {
+		fail(500, "failed to log in")
+		return
+	}
+	tokens, err := issueTokens(c, tx, user.ID, user.Username, nil)
+	if err != nil {
+		fail(500, "failed to generate token")
+		return
+	}
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		user.ID, "login", "user", user.ID, `{"method":"oidc"}`,
+	)
+	if err := tx.Commit(c); err != nil {
+		fail(500, "failed to log in")
+		return
+	}
+
+	if returnTo == nil {
+		c.JSON(200, api.LoginResponse{TokenPair: tokens, User: *user})
+		return
+	}
+	// Tokens go in the fragment, which browsers do not send to servers.
+	fragment := url.Values{
+		"token":              {tokens.Token},
+		"expires_at":         {tokens.ExpiresAt.Format(time.RFC3339)},
+		"refresh_token":      {tokens.RefreshToken},
+		"refresh_expires_at": {tokens.RefreshExpiresAt.Format(time.RFC3339)},
+		"user_id":            {strconv.FormatInt(user.ID, 10)},
+		"username":           {user.Username},
+		"email":              {user.Email},
+	}
+	c.Redirect(302, *returnTo+"#"+fragment.Encode())
+}
+
+// provisionOIDCUser finds or creates the user behind an identity. On
+// failure it returns the status to answer with and an error to show.
+func provisionOIDCUser(c *gin.Context, tx pgx.Tx, issuer string, claims *oidc.Claims) (*api.User, int, error) {
+	u := &api.User{}
+	err := tx.QueryRow(c,
+		`SELECT u.id, u.username, COALESCE(u.email, '')
+		 FROM user_identities i JOIN users u ON u.id = i.user_id
+		 WHERE i.issuer=$1 AND i.subject=$2`,
+		issuer, claims.Subject,
+	).Scan(&u.ID, &u.Username, &u.Email)
+	if err == nil {
+		_, err = tx.Exec(c,
+			"UPDATE user_identities SET last_login_at=now(), email=$3 WHERE issuer=$1 AND subject=$2",
+			issuer, claims.Subject, nullIfEmpty(claims.Email),
+		)
+		if err != nil {
+			return nil, 500, fmt.Errorf("failed to log in")
+		}
+		return u, 0, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, 500, fmt.Errorf("failed to log in")
+	}
+
+	email := ""
+	if validateEmail(claims.Email) {
+		email = strings.ToLower(claims.Email)
+	}
+
+	action := "link_identity"
+	if email != "" {
+		err = tx.QueryRow(c,
+			"SELECT id, username, COALESCE(email, '') FROM users WHERE lower(email)=$1",
+			email,
+		).Scan(&u.ID, &u.Username, &u.Email)
+	} else {
+		err = pgx.ErrNoRows
+	}
+	switch {
+	case err == nil && !claims.EmailVerified:
+		// Linking on an email the provider has not checked would hand the
+		// account to whoever typed it in at the provider.
+		return nil, 409, fmt.Errorf("an account with this email exists, but the identity provider has not verified the email")
+	case err == nil:
+		_, err = tx.Exec(c, "UPDATE users SET email_verified_at=COALESCE(email_verified_at, now()) WHERE id=$1", u.ID)
+		if err != nil {
+			return nil, 500, fmt.Errorf("failed to log in")
+		}
+	case err == pgx.ErrNoRows:
+		action = "signup"
+		if u.ID, u.Username, err = createOIDCUser(c, tx, claims, email); err != nil {
+			return nil, 500, fmt.Errorf("failed to create user")
+		}
+		u.Email = email
+	case err != nil:
+		return nil, 500, fmt.Errorf("failed to log in")
+	}
+
+	_, err = tx.Exec(c,
+		"INSERT INTO user_identities (user_id, issuer, subject, email, last_login_at) VALUES ($1,$2,$3,$4,now())",
+		u.ID, issuer, claims.Subject, nullIfEmpty(claims.Email),
+	)
+	if err != nil {
+		return nil, 500, fmt.Errorf("failed to log in")
+	}
+	meta, _ := json.Marshal(map[string]any{"method": "oidc", "issuer": issuer, "subject": claims.Subject})
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		u.ID, action, "user", u.ID, string(meta),
+	)
+	return u, 0, nil
+}
+
+var usernameStrip = regexp.MustCompile(`[^A-Za-z0-9_.\-]+`)
+
+// createOIDCUser creates a passwordless user in the default organization,
+// deriving a free username from the claims.
+func createOIDCUser(c *gin.Context, tx pgx.Tx, claims *oidc.Claims, email string) (int64, string, error) {
+	base := claims.PreferredUsername
+	if base == "" && email != "" {
+		base = email[:strings.IndexByte(email, '@')]
+	}
+	base = usernameStrip.ReplaceAllString(base, "")
+	if len(base) > 56 {
+		base = base[:56]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	for i := 1; i <= 100; i++ {
+		username := base
+		if i > 1 {
+			username = fmt.Sprintf("%s-%d", base, i)
+		}
+		var id int64
+		err := tx.QueryRow(c,
+			`INSERT INTO users (username, email, email_verified_at, org_id, quota)
+			 SELECT $1, $2, CASE WHEN $3 AND $2::text IS NOT NULL THEN now() END, id, member_quota
+			 FROM organizations WHERE slug=$4
+			 ON CONFLICT (username) DO NOTHING RETURNING id`,
+			username, nullIfEmpty(email), claims.EmailVerified, defaultOrgSlug,
+		).Scan(&id)
+		if err == nil {
+			return id, username, nil
+		}
+		if err != pgx.ErrNoRows {
+			return 0, "", err
+		}
+	}
+	return 0, "", fmt.Errorf("no free username for %q", base)
+}
+
+// applyGroupRole sets the role that OIDC_GROUP_ROLES maps the user's groups
+// to (see groupRole). Without the mapping, or when the ID token has no
+// groups claim, the role is left alone.
+func applyGroupRole(c *gin.Context, tx pgx.Tx, userID int64, groups []string) error {
+	mapping := os.Getenv("OIDC_GROUP_ROLES")
+	if mapping == "" || groups == nil {
+		return nil
+	}
+	role := groupRole(mapping, groups)
+
+	var old string
+	err := tx.QueryRow(c, "SELECT COALESCE(role, 'user') FROM users WHERE id=$1", userID).Scan(&old)
+	if err != nil || old == role {
+		return err
+	}
+	if _, err := tx.Exec(c, "UPDATE users SET role=$1 WHERE id=$2", role, userID); err != nil {
+		return err
+	}
+	meta, _ := json.Marshal(map[string]any{"from": old, "to": role, "source": "oidc_groups"})
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "change_role", "user", userID, string(meta),
+	)
+	return nil
+}
+
+// groupRole picks the role for groups from a mapping such as
+// "vault-admins=admin,staff=user": the first pair whose group the user is in
+// wins, and "user" applies when none is. Pairs naming a role other than
+// "user" or "admin" are skipped, so a typo cannot store a role nothing
+// checks for.
+func groupRole(mapping string, groups []string) string {
+	for _, pair := range strings.Split(mapping, ",") {
+		group, role, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !slices.Contains(groups, group) {
+			continue
+		}
+		if role != api.OrgRoleMember && role != api.OrgRoleAdmin {
+			log.Printf("OIDC_GROUP_ROLES: ignoring unknown role %q for group %q", role, group)
+			continue
+		}
+		return role
+	}
+	return api.OrgRoleMember
+}
+
+// oidcReturnAllowed checks a redirect_uri against OIDC_ALLOWED_REDIRECTS, a
+// comma-separated list of URL prefixes, by default the frontend.
+func oidcReturnAllowed(target string) bool {
+	allowed := os.Getenv("OIDC_ALLOWED_REDIRECTS")
+	if allowed == "" {
+		allowed = "http://localhost:3000/"
+	}
+	for _, prefix := range strings.Split(allowed, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(target, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/oidc"
+)
+
+func TestGroupRole(t *testing.T) {
+	mapping := "vault-admins=admin, staff=user,ops=superuser,ops=admin"
+	for _, c := range []struct {
+		groups []string
+		want   string
+	}{
+		{[]string{"vault-admins"}, "admin"},
+		{[]string{"staff", "vault-admins"}, "admin"},
+		{[]string{"staff"}, "user"},
+		{[]string{"others"}, "user"},
+		{[]string{}, "user"},
+		// The unknown role is skipped, and the next pair for ops applies.
+		{[]string{"ops"}, "admin"},
+	} {
+		if got := groupRole(mapping, c.groups); got != c.want {
+			t.Errorf("groupRole(%v) = %q, want %q", c.groups, got, c.want)
+		}
+	}
+}
+
+// provision runs provisionOIDCUser and applyGroupRole for claims from the
+// issuer "https://idp", as the callback does.
+func provision(t *testing.T, claims oidc.Claims) (*api.User, int) {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/auth/oidc/callback", nil)
+	tx, err := db.Pool.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(context.Background())
+
+	u, status, err := provisionOIDCUser(c, tx, "https://idp", &claims)
+	if err != nil {
+		return nil, status
+	}
+	if err := applyGroupRole(c, tx, u.ID, claims.Groups); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return u, 0
+}
+
+func TestOIDCProvisioning(t *testing.T) {
+	testDB(t)
+	t.Setenv("OIDC_GROUP_ROLES", "vault-admins=admin")
+	alice := testUser(t, testOrg(t, "org"), "alice")
+
+	if _, status := provision(t, oidc.Claims{Subject: "s1", Email: "alice@example.com"}); status != 409 {
+		t.Errorf("linking on an unverified email: got %d, want 409", status)
+	}
+	u, _ := provision(t, oidc.Claims{Subject: "s1", Email: "Alice@Example.com", EmailVerified: true})
+	if u == nil || u.ID != alice {
+		t.Fatalf("linking on a verified email gave %+v, want alice", u)
+	}
+	// The identity now finds alice whatever email it reports.
+	if u, _ := provision(t, oidc.Claims{Subject: "s1", Email: "other@example.com"}); u == nil || u.ID != alice {
+		t.Errorf("logging in again gave %+v, want alice", u)
+	}
+	if n := count(t, "SELECT count(*) FROM user_identities WHERE user_id=$1 AND subject='s1'", alice); n != 1 {
+		t.Errorf("%d identities linked, want 1", n)
+	}
+
+	u, _ = provision(t, oidc.Claims{Subject: "s2", Email: "new@example.com", PreferredUsername: "alice", Groups: []string{"vault-admins"}})
+	if u == nil || u.ID == alice || u.Username != "alice-2" {
+		t.Fatalf("creating an account gave %+v, want alice-2", u)
+	}
+	if n := count(t,
+		`SELECT count(*) FROM users u JOIN organizations o ON o.id = u.org_id
+		 WHERE u.id=$1 AND o.slug='default' AND u.role='admin' AND u.email_verified_at IS NULL AND u.password_hash IS NULL`,
+		u.ID); n != 1 {
+		t.Error("the new account is not a passwordless, unverified admin in the default organization")
+	}
+
+	// Without groups the role is left alone; with other groups it is "user".
+	provision(t, oidc.Claims{Subject: "s2"})
+	if n := count(t, "SELECT count(*) FROM users WHERE id=$1 AND role='admin'", u.ID); n != 1 {
+		t.Error("a login without a groups claim changed the role")
+	}
+	provision(t, oidc.Claims{Subject: "s2", Groups: []string{"staff"}})
+	if n := count(t, "SELECT count(*) FROM users WHERE id=$1 AND role='user'", u.ID); n != 1 {
+		t.Error("a login outside the mapped groups kept the admin role")
+	}
+
+	if u, _ := provision(t, oidc.Claims{Subject: "s3", PreferredUsername: "x"}); u == nil || u.Username != "x__" {
+		t.Errorf("a short username gave %+v, want x__", u)
+	}
+}
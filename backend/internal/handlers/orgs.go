@@ -0,0 +1,528 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/mailer"
+)
+
+// Organizations are the tenants of a deployment. Every user belongs to
+// one, joining by invitation or, without one, the default organization.
+// Files and folders belong to their owner's organization, and grants,
+// groups and public files stay within it (see permissions.go). Admins
+// (users.role) administer their own organization: its members, invites,
+// settings and the admin routes. Instance admins create organizations and
+// set their storage quotas, without seeing into them.
+
+const (
+	defaultOrgSlug = "default"
+	inviteTTL      = 7 * 24 * time.Hour
+	// defaultMemberQuota is organizations.member_quota's default, 10 MiB.
+	defaultMemberQuota = 10 << 20
+)
+
+var slugRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// orgColumns are the columns scanOrg reads, from organizations o.
+const orgColumns = `o.id, o.name, o.slug, o.storage_quota, o.member_quota, o.created_at,
+	COALESCE((SELECT SUM(f.size) FROM files f JOIN users u ON u.id = f.owner_id
+	          WHERE u.org_id = o.id AND f.trashed=false), 0),
+	(SELECT count(*) FROM users WHERE org_id = o.id)`
+
+func scanOrg(row pgx.Row, extra ...any) (api.Organization, error) {
+	var o api.Organization
+	dest := append([]any{&o.ID, &o.Name, &o.Slug, &o.StorageQuota, &o.MemberQuota, &o.CreatedAt, &o.Used, &o.Members}, extra...)
+	err := row.Scan(dest...)
+	return o, err
+}
+
+// orgUsage returns an organization's storage quota and what its members
+// store outside the trash.
+func orgUsage(ctx context.Context, orgID int64) (int64, int64, error) {
+	var quota, used int64
+	err := db.Pool.QueryRow(ctx,
+		`SELECT o.storage_quota,
+		   COALESCE((SELECT SUM(f.size) FROM files f JOIN users u ON u.id = f.owner_id
+		             WHERE u.org_id = o.id AND f.trashed=false), 0)
+		 FROM organizations o WHERE o.id=$1`,
+		orgID,
+	).Scan(&quota, &used)
+	return quota, used, err
+}
+
+func auditOrg(c *gin.Context, action string, orgID int64, meta map[string]any) {
+	data, _ := json.Marshal(meta)
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		c.GetInt64("user_id"), action, "organization", orgID, string(data),
+	)
+}
+
+// orgInvite is what signing up gives a user: an organization, a role and a
+// quota. ID is the invitation redeemed, 0 for the default organization.
+type orgInvite struct {
+	ID    int64
+	OrgID int64
+	Role  string
+	Quota int64
+}
+
+// redeemInvite marks the invitation with token used, within tx, and
+// returns it. Without a token it returns the default organization. It
+// answers 400 for tokens that are unknown, used, expired, or were sent to
+// another address than email.
+func redeemInvite(c *gin.Context, tx pgx.Tx, token, email string) (orgInvite, bool) {
+	inv := orgInvite{Role: api.OrgRoleMember}
+	if token == "" {
+		err := tx.QueryRow(c,
+			"SELECT id, member_quota FROM organizations WHERE slug=$1", defaultOrgSlug,
+		).Scan(&inv.OrgID, &inv.Quota)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to create user"})
+			return inv, false
+		}
+		return inv, true
+	}
+
+	var invited string
+	err := tx.QueryRow(c,
+		`UPDATE org_invites i SET used_at=now()
+		 FROM organizations o
+		 WHERE i.token_hash=$1 AND i.used_at IS NULL AND i.expires_at > now() AND o.id = i.org_id
+		 RETURNING i.id, i.org_id, i.role, o.member_quota, i.email`,
+		auth.HashToken(token),
+	).Scan(&inv.ID, &inv.OrgID, &inv.Role, &inv.Quota, &invited)
+	if err == pgx.ErrNoRows {
+		c.JSON(400, gin.H{"error": "invalid or expired invite"})
+		return inv, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create user"})
+		return inv, false
+	}
+	if !strings.EqualFold(invited, email) {
+		c.JSON(400, gin.H{"error": "the invite was sent to another email address"})
+		return inv, false
+	}
+	return inv, true
+}
+
+// emailAvailable answers 409 when an account uses email. Accounts cannot
+// move between organizations, so its owner cannot be invited.
+func emailAvailable(c *gin.Context, email string) bool {
+	var taken bool
+	err := db.Pool.QueryRow(c,
+		"SELECT EXISTS (SELECT 1 FROM users WHERE lower(email)=lower($1))", email,
+	).Scan(&taken)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create invite"})
+		return false
+	}
+	if taken {
+		c.JSON(409, gin.H{"error": "an account already uses that email"})
+		return false
+	}
+	return true
+}
+
+// createInvite invites email to orgID as role and mails the link.
+func (h *Handler) createInvite(c *gin.Context, orgID int64, email, role string) (api.Invite, bool) {
+	inv := api.Invite{Email: strings.TrimSpace(email), Role: role}
+	if inv.Role == "" {
+		inv.Role = api.OrgRoleMember
+	}
+	if !validateEmail(inv.Email) {
+		c.JSON(400, gin.H{"error": "a valid email is required"})
+		return inv, false
+	}
+	if inv.Role != api.OrgRoleMember && inv.Role != api.OrgRoleAdmin {
+		c.JSON(400, gin.H{"error": "role must be user or admin"})
+		return inv, false
+	}
+	if !emailAvailable(c, inv.Email) {
+		return inv, false
+	}
+
+	token, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create invite"})
+		return inv, false
+	}
+	var orgName string
+	err = db.Pool.QueryRow(c,
+		`INSERT INTO org_invites (org_id, email, role, token_hash, invited_by, expires_at)
+		 VALUES ($1,$2,$3,$4,$5,$6)
+		 RETURNING id, created_at, expires_at, (SELECT name FROM organizations WHERE id=$1)`,
+		orgID, inv.Email, inv.Role, hash, c.GetInt64("user_id"), time.Now().Add(inviteTTL),
+	).Scan(&inv.ID, &inv.CreatedAt, &inv.ExpiresAt, &orgName)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create invite"})
+		return inv, false
+	}
+
+	h.sendMail(mailer.Message{
+		To:      inv.Email,
+		Subject: "You are invited to " + orgName,
+		Body: fmt.Sprintf(`Hi,
+
+you have been invited to join %s. Sign up with this email address
+through the link below within %d days:
+
+%s/signup?invite=%s
+
+If you did not expect this, you can ignore this email.
+`, orgName, int(inviteTTL.Hours()/24), appURL(), token),
+	})
+	auditOrg(c, "invite_member", orgID, map[string]any{"email": inv.Email, "role": inv.Role})
+	return inv, true
+}
+
+// GetOrganizationHandler returns the caller's organization.
+func (h *Handler) GetOrganizationHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	var role string
+	o, err := scanOrg(db.Pool.QueryRow(c,
+		`SELECT `+orgColumns+`, COALESCE(u.role, 'user')
+		 FROM organizations o JOIN users u ON u.org_id = o.id
+		 WHERE u.id=$1`,
+		userID,
+	), &role)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to load organization"})
+		return
+	}
+	o.Role = role
+	c.JSON(200, o)
+}
+
+// updateOrg applies body to organization orgID. Only instance admins
+// change the storage quota.
+func updateOrg(c *gin.Context, orgID int64, body api.UpdateOrganizationRequest, instanceAdmin bool) {
+	if body.StorageQuota != nil && !instanceAdmin {
+		c.JSON(403, gin.H{"error": "only instance admins change the storage quota"})
+		return
+	}
+	if body.Name != nil {
+		*body.Name = strings.TrimSpace(*body.Name)
+		if *body.Name == "" || len([]rune(*body.Name)) > 100 {
+			c.JSON(400, gin.H{"error": "name must be 1 to 100 characters"})
+			return
+		}
+	}
+	if (body.StorageQuota != nil && *body.StorageQuota < 0) || (body.MemberQuota != nil && *body.MemberQuota < 0) {
+		c.JSON(400, gin.H{"error": "quotas cannot be negative"})
+		return
+	}
+
+	o, err := scanOrg(db.Pool.QueryRow(c,
+		`UPDATE organizations o SET
+		   name = COALESCE($2, o.name),
+		   storage_quota = COALESCE($3, o.storage_quota),
+		   member_quota = COALESCE($4, o.member_quota)
+		 WHERE o.id=$1
+		 RETURNING `+orgColumns,
+		orgID, body.Name, body.StorageQuota, body.MemberQuota,
+	))
+	if err == pgx.ErrNoRows {
+		c.JSON(404, gin.H{"error": "organization not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to update organization"})
+		return
+	}
+
+	meta, _ := json.Marshal(body)
+	var m map[string]any
+	_ = json.Unmarshal(meta, &m)
+	auditOrg(c, "update_organization", orgID, m)
+	c.JSON(200, o)
+}
+
+// UpdateOrganizationHandler lets admins rename their organization and set
+// the quota new members get.
+func (h *Handler) UpdateOrganizationHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	var body api.UpdateOrganizationRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	updateOrg(c, orgID, body, false)
+}
+
+// ListMembersHandler lists the members of the admin's organization.
+func (h *Handler) ListMembersHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	rows, err := db.Pool.Query(c,
+		`SELECT u.id, u.username, COALESCE(u.email, ''), COALESCE(u.role, 'user'), COALESCE(u.quota, 0),
+		   COALESCE((SELECT SUM(size) FROM files WHERE owner_id = u.id AND trashed=false), 0),
+		   u.totp_enabled_at IS NOT NULL, u.created_at
+		 FROM users u
+		 WHERE u.org_id=$1
+		 ORDER BY u.username`,
+		orgID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list members"})
+		return
+	}
+	defer rows.Close()
+
+	members := []api.Member{}
+	for rows.Next() {
+		var m api.Member
+		if err := rows.Scan(&m.ID, &m.Username, &m.Email, &m.Role, &m.Quota, &m.Used, &m.TwoFactor, &m.CreatedAt); err != nil {
+			c.JSON(500, gin.H{"error": "failed to list members"})
+			return
+		}
+		members = append(members, m)
+	}
+	c.JSON(200, api.MemberList{Members: members})
+}
+
+// UpdateMemberHandler changes a member's role or quota. An organization
+// always keeps an admin.
+func (h *Handler) UpdateMemberHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	memberID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var body api.UpdateMemberRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if body.Role != nil && *body.Role != api.OrgRoleMember && *body.Role != api.OrgRoleAdmin {
+		c.JSON(400, gin.H{"error": "role must be user or admin"})
+		return
+	}
+	if body.Quota != nil && *body.Quota < 0 {
+		c.JSON(400, gin.H{"error": "quotas cannot be negative"})
+		return
+	}
+
+	// A demotion only goes ahead while another admin remains.
+	var m api.Member
+	err := db.Pool.QueryRow(c,
+		`UPDATE users u SET role = COALESCE($3, u.role), quota = COALESCE($4, u.quota)
+		 WHERE u.id=$1 AND u.org_id=$2
+		   AND ($3 IS NULL OR $3 = 'admin' OR EXISTS (
+		     SELECT 1 FROM users WHERE org_id=$2 AND role='admin' AND id<>$1
+		   ))
+		 RETURNING u.id, u.username, COALESCE(u.email, ''), COALESCE(u.role, 'user'), COALESCE(u.quota, 0),
+		   COALESCE((SELECT SUM(size) FROM files WHERE owner_id = u.id AND trashed=false), 0),
+		   u.totp_enabled_at IS NOT NULL, u.created_at`,
+		memberID, orgID, body.Role, body.Quota,
+	).Scan(&m.ID, &m.Username, &m.Email, &m.Role, &m.Quota, &m.Used, &m.TwoFactor, &m.CreatedAt)
+	if err == pgx.ErrNoRows {
+		var member bool
+		_ = db.Pool.QueryRow(c,
+			"SELECT EXISTS (SELECT 1 FROM users WHERE id=$1 AND org_id=$2)", memberID, orgID,
+		).Scan(&member)
+		if member {
+			c.JSON(409, gin.H{"error": "an organization needs at least one admin"})
+		} else {
+			c.JSON(404, gin.H{"error": "user not found"})
+		}
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to update member"})
+		return
+	}
+
+	auditOrg(c, "update_member", orgID, map[string]any{"user_id": m.ID, "role": body.Role, "quota": body.Quota})
+	c.JSON(200, m)
+}
+
+// CreateInviteHandler invites someone by email to the admin's
+// organization.
+func (h *Handler) CreateInviteHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	var body api.InviteRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	inv, ok := h.createInvite(c, orgID, body.Email, body.Role)
+	if !ok {
+		return
+	}
+	c.JSON(200, inv)
+}
+
+// ListInvitesHandler lists the invitations of the admin's organization
+// that are still open.
+func (h *Handler) ListInvitesHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	rows, err := db.Pool.Query(c,
+		`SELECT id, email, role, created_at, expires_at FROM org_invites
+		 WHERE org_id=$1 AND used_at IS NULL AND expires_at > now()
+		 ORDER BY created_at DESC`,
+		orgID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list invites"})
+		return
+	}
+	defer rows.Close()
+
+	invites := []api.Invite{}
+	for rows.Next() {
+		var inv api.Invite
+		if err := rows.Scan(&inv.ID, &inv.Email, &inv.Role, &inv.CreatedAt, &inv.ExpiresAt); err != nil {
+			c.JSON(500, gin.H{"error": "failed to list invites"})
+			return
+		}
+		invites = append(invites, inv)
+	}
+	c.JSON(200, api.InviteList{Invites: invites})
+}
+
+func (h *Handler) RevokeInviteHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	res, err := db.Pool.Exec(c, "DELETE FROM org_invites WHERE id=$1 AND org_id=$2 AND used_at IS NULL", id, orgID)
+	if err != nil || res.RowsAffected() == 0 {
+		c.JSON(404, gin.H{"error": "invite not found"})
+		return
+	}
+	auditOrg(c, "revoke_invite", orgID, map[string]any{"invite_id": id})
+	c.JSON(200, api.Message{Message: "invite revoked"})
+}
+
+// ListOrganizationsHandler lists every organization, for instance admins.
+func (h *Handler) ListOrganizationsHandler(c *gin.Context) {
+	if !requireInstanceAdmin(c) {
+		return
+	}
+	rows, err := db.Pool.Query(c, `SELECT `+orgColumns+` FROM organizations o ORDER BY o.name`)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list organizations"})
+		return
+	}
+	defer rows.Close()
+
+	orgs := []api.Organization{}
+	for rows.Next() {
+		o, err := scanOrg(rows)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to list organizations"})
+			return
+		}
+		orgs = append(orgs, o)
+	}
+	c.JSON(200, api.OrganizationList{Organizations: orgs})
+}
+
+// CreateOrganizationHandler creates an organization, for instance admins,
+// and invites its first admin.
+func (h *Handler) CreateOrganizationHandler(c *gin.Context) {
+	if !requireInstanceAdmin(c) {
+		return
+	}
+	var body api.CreateOrganizationRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	body.Name, body.Slug = strings.TrimSpace(body.Name), strings.TrimSpace(body.Slug)
+	if body.Name == "" || len([]rune(body.Name)) > 100 {
+		c.JSON(400, gin.H{"error": "name must be 1 to 100 characters"})
+		return
+	}
+	if !slugRegex.MatchString(body.Slug) {
+		c.JSON(400, gin.H{"error": "slug must be 1-63 lowercase letters, digits or '-'"})
+		return
+	}
+	if body.StorageQuota < 0 || (body.MemberQuota != nil && *body.MemberQuota < 0) {
+		c.JSON(400, gin.H{"error": "quotas cannot be negative"})
+		return
+	}
+	body.AdminEmail = strings.TrimSpace(body.AdminEmail)
+	if !validateEmail(body.AdminEmail) {
+		c.JSON(400, gin.H{"error": "a valid admin_email is required"})
+		return
+	}
+	if !emailAvailable(c, body.AdminEmail) {
+		return
+	}
+	memberQuota := int64(defaultMemberQuota)
+	if body.MemberQuota != nil {
+		memberQuota = *body.MemberQuota
+	}
+
+	o, err := scanOrg(db.Pool.QueryRow(c,
+		`INSERT INTO organizations (name, slug, storage_quota, member_quota)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, name, slug, storage_quota, member_quota, created_at, 0::bigint, 0::bigint`,
+		body.Name, body.Slug, body.StorageQuota, memberQuota,
+	))
+	if isUniqueViolation(err) {
+		c.JSON(409, gin.H{"error": "an organization with that slug already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create organization"})
+		return
+	}
+	auditOrg(c, "create_organization", o.ID, map[string]any{"name": o.Name, "slug": o.Slug})
+
+	inv, ok := h.createInvite(c, o.ID, body.AdminEmail, api.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+	c.JSON(200, api.NewOrganization{Organization: o, Invite: inv})
+}
+
+// UpdateOrganizationAdminHandler changes any organization, including its
+// storage quota, for instance admins.
+func (h *Handler) UpdateOrganizationAdminHandler(c *gin.Context) {
+	if !requireInstanceAdmin(c) {
+		return
+	}
+	orgID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var body api.UpdateOrganizationRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	updateOrg(c, orgID, body, true)
+}
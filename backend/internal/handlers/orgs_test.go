@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// TestTargetFolderOrganization checks that nothing can be created in or
+// moved into another organization's folder, not even through a grant on
+// it, and that within an organization it takes an editor role.
+func TestTargetFolderOrganization(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	orgA, orgB := testOrg(t, "org-a"), testOrg(t, "org-b")
+	alice, carol := testUser(t, orgA, "alice"), testUser(t, orgA, "carol")
+	bob := testUser(t, orgB, "bob")
+
+	bobs := testFolder(t, bob, "bobs", 0)
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'editor') RETURNING id", bobs, alice)
+	carols := testFolder(t, carol, "carols", 0)
+	mine := testFolder(t, alice, "mine", 0)
+
+	create := func(parent int64) int {
+		return serve(alice, h.CreateFolderHandler, "POST", "/folders", "/folders",
+			fmt.Sprintf(`{"name":"planted","parent_id":%d}`, parent)).Code
+	}
+	move := func(parent int64) int {
+		return serve(alice, h.MoveFolderHandler, "PATCH", "/folders/:id/move", fmt.Sprintf("/folders/%d/move", mine),
+			fmt.Sprintf(`{"new_parent_id":%d}`, parent)).Code
+	}
+
+	if code := create(bobs); code != 404 {
+		t.Errorf("creating in another organization's folder: got %d, want 404", code)
+	}
+	if code := move(bobs); code != 404 {
+		t.Errorf("moving into another organization's folder: got %d, want 404", code)
+	}
+	if n := count(t, "SELECT count(*) FROM folders WHERE parent_id=$1", bobs); n != 0 {
+		t.Errorf("another organization's folder holds %d planted folders", n)
+	}
+
+	if code := create(carols); code != 403 {
+		t.Errorf("creating in a folder without a role on it: got %d, want 403", code)
+	}
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'viewer') RETURNING id", carols, alice)
+	if code := create(carols); code != 403 {
+		t.Errorf("creating in a folder as a viewer: got %d, want 403", code)
+	}
+	execSQL(t, "UPDATE folder_permissions SET role='editor' WHERE folder_id=$1 AND user_id=$2", carols, alice)
+	if code := create(carols); code != 200 {
+		t.Errorf("creating in a folder as an editor: got %d, want 200", code)
+	}
+}
+
+// TestOrganizationIsolation checks that the files of another organization
+// do not exist for a user, whatever is granted on them.
+func TestOrganizationIsolation(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	alice, bob := testUser(t, testOrg(t, "org-a"), "alice"), testUser(t, testOrg(t, "org-b"), "bob")
+	folder := testFolder(t, alice, "a", 0)
+	file := testFile(t, alice, "a.txt", folder)
+	execSQL(t, "UPDATE files SET is_public=true WHERE id=$1", file)
+	insertID(t, "INSERT INTO file_permissions (file_id, user_id, role) VALUES ($1,$2,'co_owner') RETURNING id", file, bob)
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'co_owner') RETURNING id", folder, bob)
+
+	ctx := context.Background()
+	if _, err := fileGrants.lookup(ctx, bob, file); err != pgx.ErrNoRows {
+		t.Errorf("looking up another organization's file gave %v, want no rows", err)
+	}
+	if _, err := folderGrants.lookup(ctx, bob, folder); err != pgx.ErrNoRows {
+		t.Errorf("looking up another organization's folder gave %v, want no rows", err)
+	}
+	w := serve(bob, h.UpdateTagsHandler, "PATCH", "/files/:id/tags", fmt.Sprintf("/files/%d/tags", file), `{"tags":["x"]}`)
+	if w.Code != 404 {
+		t.Errorf("tagging another organization's file: got %d, want 404", w.Code)
+	}
+}
+
+// TestStatsSavingsOrganization checks that the deduplication savings only
+// count the caller's organization.
+func TestStatsSavingsOrganization(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	orgA, orgB := testOrg(t, "org-a"), testOrg(t, "org-b")
+	alice, bob := testUser(t, orgA, "alice"), testUser(t, orgB, "bob")
+
+	// alice stores 100 bytes twice, bob 500 bytes three times.
+	for _, f := range []struct {
+		owner, size int64
+		copies      int
+	}{{alice, 100, 2}, {bob, 500, 3}} {
+		hash := fmt.Sprintf("blob-%d", f.owner)
+		blob := insertID(t, "INSERT INTO blobs (hash, size, path, ref_count) VALUES ($1,$2,$1,$3) RETURNING id", hash, f.size, f.copies)
+		for i := 0; i < f.copies; i++ {
+			insertID(t, "INSERT INTO files (blob_id, owner_id, filename, size) VALUES ($1,$2,$3,$4) RETURNING id",
+				blob, f.owner, fmt.Sprintf("copy-%d", i), f.size)
+		}
+	}
+
+	w := serve(alice, h.StatsHandler, "GET", "/stats", "/stats", "")
+	var stats api.Stats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+	if stats.Savings != 100 {
+		t.Errorf("savings are %d, want 100 from alice's organization alone", stats.Savings)
+	}
+}
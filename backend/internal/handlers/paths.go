@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// Name-based lookups and changes over a user's folders and files, for the
+// front ends that address storage by path (/fs, WebDAV, the S3 gateway).
+// Live names are unique among the folders and among the files of a folder,
+// whoever added them, and among a user's own at the top of their tree
+// (migration 28). A folder and a file may still share a name, which path
+// resolution reports as errAmbiguousPath. Paths start at the top of
+// the user's tree, so every folder they reach is the user's or under one
+// of theirs, and is listed in full. Missing entries are os.ErrNotExist,
+// names that are taken os.ErrExist, and files the user may not move
+// os.ErrPermission.
+
+var errAmbiguousPath = errors.New("path names both a folder and a file")
+
+type pathFolder struct {
+	ID      int64
+	Name    string
+	Created time.Time
+}
+
+type pathFile struct {
+	ID       int64
+	FolderID *int64
+	Name     string
+	Size     int64
+	MIME     string
+	Created  time.Time
+	BlobID   int64
+	BlobKey  string
+	Hash     string
+}
+
+// splitPath cleans p and returns its components; the root has none.
+func splitPath(p string) []string {
+	clean := path.Clean("/" + p)
+	if clean == "/" {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(clean, "/"), "/")
+}
+
+func lookupFolder(c *gin.Context, userID int64, parentID *int64, name string) (*pathFolder, error) {
+	f := &pathFolder{Name: name}
+	err := db.Pool.QueryRow(c,
+		`SELECT id, created_at FROM folders
+		 WHERE parent_id IS NOT DISTINCT FROM $2 AND (parent_id IS NOT NULL OR owner_id=$1)
+		   AND name=$3 AND trashed=false`,
+		userID, parentID, name,
+	).Scan(&f.ID, &f.Created)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
+
+func lookupFolderID(c *gin.Context, userID, id int64) (*pathFolder, error) {
+	f := &pathFolder{ID: id}
+	err := db.Pool.QueryRow(c,
+		"SELECT name, created_at FROM folders WHERE id=$1 AND owner_id=$2 AND trashed=false",
+		id, userID,
+	).Scan(&f.Name, &f.Created)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
+
+// resolveFolderPath walks folder names down from parentID (nil is the root).
+func resolveFolderPath(c *gin.Context, userID int64, parentID *int64, parts []string) (*int64, error) {
+	folderID := parentID
+	for _, part := range parts {
+		f, err := lookupFolder(c, userID, folderID, part)
+		if err != nil {
+			return nil, err
+		}
+		folderID = &f.ID
+	}
+	return folderID, nil
+}
+
+// ensureFolderPath is resolveFolderPath creating any folder that is missing.
+func ensureFolderPath(c *gin.Context, userID int64, parentID *int64, parts []string) (*int64, error) {
+	folderID := parentID
+	for _, part := range parts {
+		if f, err := lookupFolder(c, userID, folderID, part); err == nil {
+			folderID = &f.ID
+			continue
+		}
+		id, err := createFolder(c, userID, folderID, part)
+		if err != nil {
+			return nil, err
+		}
+		folderID = &id
+	}
+	return folderID, nil
+}
+
+func lookupFile(c *gin.Context, userID int64, folderID *int64, name string) (*pathFile, error) {
+	f := &pathFile{FolderID: folderID, Name: name}
+	err := db.Pool.QueryRow(c,
+		`SELECT f.id, b.size, COALESCE(f.mime_type, ''), f.created_at, b.id, b.path, b.hash
+		 FROM files f
+		 JOIN blobs b ON f.blob_id = b.id
+		 WHERE f.folder_id IS NOT DISTINCT FROM $2 AND (f.folder_id IS NOT NULL OR f.owner_id=$1)
+		   AND f.filename=$3 AND f.trashed=false`,
+		userID, folderID, name,
+	).Scan(&f.ID, &f.Size, &f.MIME, &f.Created, &f.BlobID, &f.BlobKey, &f.Hash)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
+
+// nameTaken reports whether a live folder or file in parentID is called name.
+func nameTaken(c *gin.Context, userID int64, parentID *int64, name string) (bool, error) {
+	var taken bool
+	err := db.Pool.QueryRow(c,
+		`SELECT EXISTS (SELECT 1 FROM folders WHERE parent_id IS NOT DISTINCT FROM $2 AND (parent_id IS NOT NULL OR owner_id=$1)
+			   AND name=$3 AND trashed=false)
+			 OR EXISTS (SELECT 1 FROM files WHERE folder_id IS NOT DISTINCT FROM $2 AND (folder_id IS NOT NULL OR owner_id=$1)
+			   AND filename=$3 AND trashed=false)`,
+		userID, parentID, name,
+	).Scan(&taken)
+	return taken, err
+}
+
+// listFolder returns the folders and files directly inside folderID.
+func listFolder(c *gin.Context, userID int64, folderID *int64) ([]pathFolder, []pathFile, error) {
+	rows, err := db.Pool.Query(c,
+		`SELECT id, name, created_at FROM folders
+		 WHERE parent_id IS NOT DISTINCT FROM $2 AND (parent_id IS NOT NULL OR owner_id=$1) AND trashed=false
+		 ORDER BY name`,
+		userID, folderID,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	var folders []pathFolder
+	for rows.Next() {
+		var f pathFolder
+		if err := rows.Scan(&f.ID, &f.Name, &f.Created); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		folders = append(folders, f)
+	}
+	rows.Close()
+
+	rows, err = db.Pool.Query(c,
+		`SELECT f.id, f.filename, b.size, COALESCE(f.mime_type, ''), f.created_at, b.id, b.path, b.hash
+		 FROM files f
+		 JOIN blobs b ON f.blob_id = b.id
+		 WHERE f.folder_id IS NOT DISTINCT FROM $2 AND (f.folder_id IS NOT NULL OR f.owner_id=$1) AND f.trashed=false
+		 ORDER BY f.filename`,
+		userID, folderID,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var files []pathFile
+	for rows.Next() {
+		f := pathFile{FolderID: folderID}
+		if err := rows.Scan(&f.ID, &f.Name, &f.Size, &f.MIME, &f.Created, &f.BlobID, &f.BlobKey, &f.Hash); err != nil {
+			return nil, nil, err
+		}
+		files = append(files, f)
+	}
+	return folders, files, rows.Err()
+}
+
+// createFolder makes a folder called name in parentID. A name already used
+// by a folder or file there is os.ErrExist.
+func createFolder(c *gin.Context, userID int64, parentID *int64, name string) (int64, error) {
+	if !validateFilename(name) {
+		return 0, os.ErrInvalid
+	}
+	if taken, err := nameTaken(c, userID, parentID, name); err != nil {
+		return 0, err
+	} else if taken {
+		return 0, os.ErrExist
+	}
+	var folderID int64
+	err := db.Pool.QueryRow(c,
+		"INSERT INTO folders (owner_id, name, parent_id) VALUES ($1,$2,$3) RETURNING id",
+		userID, name, parentID,
+	).Scan(&folderID)
+	if isUniqueViolation(err) {
+		return 0, os.ErrExist
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	broadcastUpdate(c, gin.H{
+		"event":     "folder_created",
+		"folder_id": folderID,
+		"name":      name,
+		"parent_id": parentID,
+		"user":      userID,
+	})
+	return folderID, nil
+}
+
+// trashFolder moves a folder and the files its owner has directly in it
+// to the trash, as TrashFolderHandler does.
+func trashFolder(c *gin.Context, userID, folderID int64) error {
+	now := time.Now()
+	_, err := db.Pool.Exec(c, "UPDATE folders SET trashed=true, trashed_at=$1 WHERE id=$2", now, folderID)
+	if err != nil {
+		return err
+	}
+	_, _ = db.Pool.Exec(c,
+		"UPDATE files SET trashed=true, trashed_at=$1 WHERE folder_id=$2 AND owner_id=(SELECT owner_id FROM folders WHERE id=$2)",
+		now, folderID,
+	)
+
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "trash_folder", "folder", folderID,
+	)
+	broadcastUpdate(c, gin.H{
+		"event":     "folder_trashed",
+		"folder_id": folderID,
+		"user":      userID,
+		"timestamp": now,
+	})
+	return nil
+}
+
+// trashFile moves a file to the trash.
+func trashFile(c *gin.Context, userID int64, f *pathFile) error {
+	now := time.Now()
+	_, err := db.Pool.Exec(c, "UPDATE files SET trashed=true, trashed_at=$1 WHERE id=$2", now, f.ID)
+	if err != nil {
+		return err
+	}
+
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "trash_file", "file", f.ID,
+	)
+	broadcastUpdate(c, gin.H{
+		"event":     "file_trashed",
+		"file_id":   f.ID,
+		"user":      userID,
+		"timestamp": now,
+	})
+	return nil
+}
+
+// moveFolder renames and/or reparents a folder. Moving a folder into its
+// own subtree is refused with os.ErrInvalid, and onto a folder name in use
+// with os.ErrExist.
+func moveFolder(c *gin.Context, userID, folderID int64, parentID *int64, name string) error {
+	if !validateFilename(name) {
+		return os.ErrInvalid
+	}
+	inside, err := folderWithin(c, parentID, folderID)
+	if err != nil {
+		return err
+	}
+	if inside {
+		return os.ErrInvalid
+	}
+
+	_, err = db.Pool.Exec(c,
+		"UPDATE folders SET name=$1, parent_id=$2 WHERE id=$3",
+		name, parentID, folderID,
+	)
+	if isUniqueViolation(err) {
+		return os.ErrExist
+	}
+	if err != nil {
+		return err
+	}
+	broadcastUpdate(c, gin.H{
+		"event":      "folder_moved",
+		"folder_id":  folderID,
+		"new_parent": parentID,
+		"new_name":   name,
+		"user":       userID,
+	})
+	return nil
+}
+
+// moveFile renames and/or moves a file, which only its owner may do. A
+// name already used by another file in the destination is os.ErrExist.
+func moveFile(c *gin.Context, userID int64, f *pathFile, parentID *int64, name string) error {
+	if !validateFilename(name) {
+		return os.ErrInvalid
+	}
+	res, err := db.Pool.Exec(c,
+		"UPDATE files SET filename=$1, folder_id=$2 WHERE id=$3 AND owner_id=$4",
+		name, parentID, f.ID, userID,
+	)
+	if isUniqueViolation(err) {
+		return os.ErrExist
+	}
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return os.ErrPermission
+	}
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "move_file", "file", f.ID, fmt.Sprintf(`{"folder_id":%s,"filename":%q}`, folderIDJSON(parentID), name),
+	)
+	return nil
+}
+
+func folderIDJSON(id *int64) string {
+	if id == nil {
+		return "null"
+	}
+	return strconv.FormatInt(*id, 10)
+}
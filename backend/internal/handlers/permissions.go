@@ -0,0 +1,585 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// File and folder permissions. The owner may do anything with their files
+// and folders. Other users act through roles (see api.RoleViewer and the
+// rest) granted in file_permissions and folder_permissions, to them or to
+// a group they belong to; a role on a folder applies to everything under
+// it, found by walking up parent_id. The highest role that applies counts.
+// Anyone in the owner's organization may read a public file, and nothing
+// reaches across organizations: the files and folders of another one do
+// not exist as far as authorize is concerned. Handlers check with
+// authorize and authorizeFolder rather than querying these themselves.
+
+type action int
+
+const (
+	// actionRead is downloading, previewing and reading tags and versions,
+	// and listing a folder.
+	actionRead action = iota
+	// actionComment is for comments, which no handler offers yet.
+	actionComment
+	// actionEdit is changing tags, restoring versions and trashing, and
+	// moving folders and restoring them from the trash.
+	actionEdit
+	// actionShare is creating share links and managing who has access.
+	actionShare
+	// actionOwn is for the owner alone: restoring files from the trash,
+	// deleting for good, and moving files between the owner's folders.
+	actionOwn
+)
+
+// minRole is the least role that allows each action.
+var minRole = map[action]string{
+	actionRead:    api.RoleViewer,
+	actionComment: api.RoleCommenter,
+	actionEdit:    api.RoleEditor,
+	actionShare:   api.RoleCoOwner,
+}
+
+var roleRank = map[string]int{
+	api.RoleViewer:    1,
+	api.RoleCommenter: 2,
+	api.RoleEditor:    3,
+	api.RoleCoOwner:   4,
+	api.RoleOwner:     5,
+}
+
+// access is a user's standing on a file or folder.
+type access struct {
+	OwnerID int64
+	// Role is api.RoleOwner for the owner, the highest role that applies,
+	// or "" for none.
+	Role   string
+	Public bool
+	// GroupID is the group Role was granted to, nil when it was granted
+	// to the user.
+	GroupID *int64
+}
+
+func (a access) can(act action) bool {
+	if a.Role == api.RoleOwner {
+		return true
+	}
+	if act == actionRead && a.Public {
+		return true
+	}
+	min, ok := minRole[act]
+	return ok && roleRank[a.Role] >= roleRank[min]
+}
+
+// grantTarget is a kind of object roles are granted on.
+type grantTarget struct {
+	// kind is "file" or "folder", also the audit log's object_type.
+	kind    string
+	objects string
+	// table holds the roles granted on the object itself, by column.
+	table  string
+	column string
+	// parent selects the folder directly above object $1, where the walk
+	// up to inherited grants starts.
+	parent string
+	public string
+}
+
+var (
+	fileGrants = grantTarget{
+		kind:    "file",
+		objects: "files",
+		table:   "file_permissions",
+		column:  "file_id",
+		parent:  "SELECT folder_id FROM files WHERE id=$1",
+		public:  "COALESCE(o.is_public, false)",
+	}
+	folderGrants = grantTarget{
+		kind:    "folder",
+		objects: "folders",
+		table:   "folder_permissions",
+		column:  "folder_id",
+		parent:  "SELECT parent_id FROM folders WHERE id=$1",
+		public:  "false",
+	}
+)
+
+// ancestors is a recursive CTE of the folders above object $1, whose
+// grants it inherits.
+func (t grantTarget) ancestors() string {
+	return `up AS (
+		SELECT id, parent_id FROM folders WHERE id = (` + t.parent + `)
+		UNION
+		SELECT f.id, f.parent_id FROM folders f JOIN up ON f.id = up.parent_id
+	)`
+}
+
+// grantedTo matches the grants in table p made to user $2 or to a group
+// they belong to.
+const grantedTo = `(p.user_id=$2 OR p.group_id IN (SELECT group_id FROM group_members WHERE user_id=$2))`
+
+// lookup returns userID's access to object id, or pgx.ErrNoRows when it
+// does not exist or belongs to another organization. The owner of a folder
+// is a co-owner of what others added under it. Of equal roles, one granted
+// to the user is preferred to one granted to a group.
+func (t grantTarget) lookup(ctx context.Context, userID, id int64) (access, error) {
+	var a access
+	var role *string
+	err := db.Pool.QueryRow(ctx,
+		`WITH RECURSIVE `+t.ancestors()+`
+		 SELECT o.owner_id, `+t.public+`, r.role, r.group_id
+		 FROM `+t.objects+` o
+		 LEFT JOIN LATERAL (
+		   SELECT g.role, g.group_id FROM (
+		     SELECT p.role, p.group_id FROM `+t.table+` p WHERE p.`+t.column+`=$1 AND `+grantedTo+`
+		     UNION ALL
+		     SELECT p.role, p.group_id FROM folder_permissions p JOIN up ON p.folder_id = up.id WHERE `+grantedTo+`
+		     UNION ALL
+		     SELECT $4::text, NULL::bigint FROM folders f JOIN up ON f.id = up.id WHERE f.owner_id=$2
+		   ) g
+		   ORDER BY array_position($3::text[], g.role) DESC, g.group_id NULLS FIRST LIMIT 1
+		 ) r ON true
+		 WHERE o.id=$1
+		   AND (SELECT org_id FROM users WHERE id = o.owner_id) = (SELECT org_id FROM users WHERE id=$2)`,
+		id, userID, api.FileRoles, api.RoleCoOwner,
+	).Scan(&a.OwnerID, &a.Public, &role, &a.GroupID)
+	if err != nil {
+		return a, err
+	}
+	switch {
+	case a.OwnerID == userID:
+		a.Role, a.GroupID = api.RoleOwner, nil
+	case role != nil:
+		a.Role = *role
+	}
+	return a, nil
+}
+
+// authorize checks that userID may take act on object id, answering 404
+// when it does not exist and 403 when the user may not. A token restricted
+// to a folder only reaches the user's own files and folders. When the
+// access comes through a group grant, the group is kept for auditMeta.
+func (t grantTarget) authorize(c *gin.Context, userID, id int64, act action) (access, bool) {
+	a, err := t.lookup(c, userID, id)
+	if err == pgx.ErrNoRows {
+		c.JSON(404, gin.H{"error": t.kind + " not found"})
+		return a, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to check permissions"})
+		return a, false
+	}
+	if pat := tokenAccess(c); pat != nil && pat.FolderID != nil && a.Role != api.RoleOwner {
+		c.JSON(403, gin.H{"error": "outside the folder this token is restricted to"})
+		return a, false
+	}
+	if !a.can(act) {
+		c.JSON(403, gin.H{"error": "permission denied"})
+		return a, false
+	}
+	if a.GroupID != nil && !(act == actionRead && a.Public) {
+		c.Set("via_group_id", *a.GroupID)
+	}
+	return a, true
+}
+
+// auditMeta returns meta, a JSON object or "", for an audit log entry about
+// an object authorize allowed, adding via_group_id when the access came
+// through a group grant. It returns nil when there is nothing to record.
+func auditMeta(c *gin.Context, meta string) *string {
+	groupID, viaGroup := c.Get("via_group_id")
+	if !viaGroup {
+		return nullIfEmpty(meta)
+	}
+	m := map[string]any{}
+	if meta != "" && json.Unmarshal([]byte(meta), &m) != nil {
+		return &meta
+	}
+	m["via_group_id"] = groupID
+	data, _ := json.Marshal(m)
+	s := string(data)
+	return &s
+}
+
+// authorize checks that userID may take act on fileID; see
+// grantTarget.authorize.
+func authorize(c *gin.Context, userID, fileID int64, act action) (access, bool) {
+	return fileGrants.authorize(c, userID, fileID, act)
+}
+
+// authorizeFolder checks that userID may take act on folderID.
+func authorizeFolder(c *gin.Context, userID, folderID int64, act action) (access, bool) {
+	return folderGrants.authorize(c, userID, folderID, act)
+}
+
+// listAccess returns the owner and the roles granted to users and groups
+// on object id and on the folders above it.
+func (t grantTarget) listAccess(ctx context.Context, id int64, a access) ([]api.AccessEntry, error) {
+	owner := api.AccessEntry{UserID: a.OwnerID, Role: api.RoleOwner}
+	err := db.Pool.QueryRow(ctx,
+		"SELECT username, COALESCE(email, '') FROM users WHERE id=$1", a.OwnerID,
+	).Scan(&owner.Username, &owner.Email)
+	if err != nil {
+		return nil, err
+	}
+	entries := []api.AccessEntry{owner}
+
+	rows, err := db.Pool.Query(ctx,
+		`WITH RECURSIVE `+t.ancestors()+`
+		 SELECT COALESCE(u.id, 0), COALESCE(u.username, ''), COALESCE(u.email, ''),
+		   COALESCE(gr.id, 0), COALESCE(gr.name, ''), g.role, g.via, g.granted_by, g.created_at
+		 FROM (
+		   SELECT user_id, group_id, role, NULL::bigint AS via, granted_by, created_at FROM `+t.table+` WHERE `+t.column+`=$1
+		   UNION ALL
+		   SELECT p.user_id, p.group_id, p.role, p.folder_id, p.granted_by, p.created_at
+		   FROM folder_permissions p JOIN up ON p.folder_id = up.id
+		 ) g
+		 LEFT JOIN users u ON u.id = g.user_id
+		 LEFT JOIN groups gr ON gr.id = g.group_id
+		 ORDER BY array_position($2::text[], g.role) DESC, COALESCE(u.username, gr.name), g.via NULLS FIRST`,
+		id, api.FileRoles,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e api.AccessEntry
+		if err := rows.Scan(&e.UserID, &e.Username, &e.Email, &e.GroupID, &e.Group, &e.Role, &e.ViaFolderID, &e.GrantedBy, &e.GrantedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// principal is who a role is granted to: a user or a group.
+type principal struct {
+	// column is "user_id" or "group_id" in the grant tables.
+	column string
+	id     int64
+}
+
+func (p principal) String() string {
+	if p.column == "group_id" {
+		return "group"
+	}
+	return "user"
+}
+
+// grant gives the user with req.Email, or the group with req.GroupID,
+// req.Role on object id for userID, who must be allowed to share it and,
+// for a group, be a member of it. Only the owner adds or changes
+// co-owners.
+func (t grantTarget) grant(c *gin.Context, userID, id int64, req api.GrantAccessRequest) (api.AccessEntry, bool) {
+	e := api.AccessEntry{Role: req.Role}
+	a, ok := t.authorize(c, userID, id, actionShare)
+	if !ok {
+		return e, false
+	}
+
+	var to principal
+	if req.GroupID != 0 {
+		err := db.Pool.QueryRow(c,
+			`SELECT g.id, g.name FROM groups g JOIN group_members m ON m.group_id = g.id
+			 WHERE g.id=$1 AND m.user_id=$2`,
+			req.GroupID, userID,
+		).Scan(&e.GroupID, &e.Group)
+		if err != nil {
+			c.JSON(404, gin.H{"error": "group not found"})
+			return e, false
+		}
+		to = principal{"group_id", e.GroupID}
+	} else {
+		err := db.Pool.QueryRow(c,
+			"SELECT id, username, email FROM users WHERE lower(email)=lower($1) AND org_id=(SELECT org_id FROM users WHERE id=$2)",
+			strings.TrimSpace(req.Email), userID,
+		).Scan(&e.UserID, &e.Username, &e.Email)
+		if err != nil {
+			c.JSON(404, gin.H{"error": "user not found"})
+			return e, false
+		}
+		if e.UserID == a.OwnerID {
+			c.JSON(400, gin.H{"error": "the owner already has full access"})
+			return e, false
+		}
+		if e.UserID == userID {
+			c.JSON(400, gin.H{"error": "you cannot change your own access"})
+			return e, false
+		}
+		to = principal{"user_id", e.UserID}
+	}
+
+	var previous string
+	_ = db.Pool.QueryRow(c,
+		"SELECT role FROM "+t.table+" WHERE "+t.column+"=$1 AND "+to.column+"=$2", id, to.id,
+	).Scan(&previous)
+	if a.Role != api.RoleOwner && (req.Role == api.RoleCoOwner || previous == api.RoleCoOwner) {
+		c.JSON(403, gin.H{"error": "only the owner can add or change co-owners"})
+		return e, false
+	}
+
+	err := db.Pool.QueryRow(c,
+		`INSERT INTO `+t.table+` (`+t.column+`, `+to.column+`, role, granted_by) VALUES ($1,$2,$3,$4)
+		 ON CONFLICT (`+t.column+`, `+to.column+`) DO UPDATE SET role=EXCLUDED.role, granted_by=EXCLUDED.granted_by
+		 RETURNING granted_by, created_at`,
+		id, to.id, req.Role, userID,
+	).Scan(&e.GrantedBy, &e.GrantedAt)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to grant access"})
+		return e, false
+	}
+
+	meta, _ := json.Marshal(map[string]any{to.column: to.id, "role": req.Role, "previous_role": nullIfEmpty(previous)})
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "grant_access", t.kind, id, auditMeta(c, string(meta)),
+	)
+	return e, true
+}
+
+// revoke removes the role granted to from on object id, for userID, who
+// must be allowed to share it or be removing themselves. A role inherited
+// from a folder above is revoked on that folder.
+func (t grantTarget) revoke(c *gin.Context, userID, id int64, from principal) bool {
+	self := from == principal{"user_id", userID}
+	act := actionShare
+	if self {
+		act = actionRead
+	}
+	a, ok := t.authorize(c, userID, id, act)
+	if !ok {
+		return false
+	}
+
+	var role string
+	err := db.Pool.QueryRow(c,
+		"SELECT role FROM "+t.table+" WHERE "+t.column+"=$1 AND "+from.column+"=$2", id, from.id,
+	).Scan(&role)
+	if err != nil {
+		c.JSON(404, gin.H{"error": from.String() + " has no role granted on this " + t.kind})
+		return false
+	}
+	if role == api.RoleCoOwner && a.Role != api.RoleOwner && !self {
+		c.JSON(403, gin.H{"error": "only the owner can add or change co-owners"})
+		return false
+	}
+	_, err = db.Pool.Exec(c, "DELETE FROM "+t.table+" WHERE "+t.column+"=$1 AND "+from.column+"=$2", id, from.id)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to remove access"})
+		return false
+	}
+
+	meta, _ := json.Marshal(map[string]any{from.column: from.id, "role": role})
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "revoke_access", t.kind, id, auditMeta(c, string(meta)),
+	)
+	return true
+}
+
+// ListFileAccessHandler lists who has access to a file, for those who may
+// manage it.
+func (h *Handler) ListFileAccessHandler(c *gin.Context) {
+	fileID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	a, ok := authorize(c, c.GetInt64("user_id"), fileID, actionShare)
+	if !ok {
+		return
+	}
+	entries, err := fileGrants.listAccess(c, fileID, a)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list access"})
+		return
+	}
+	c.JSON(200, api.FileAccess{FileID: fileID, Public: a.Public, Access: entries})
+}
+
+// ListFolderAccessHandler lists who has access to a folder and so to
+// everything in it.
+func (h *Handler) ListFolderAccessHandler(c *gin.Context) {
+	folderID, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	a, ok := authorizeFolder(c, c.GetInt64("user_id"), folderID, actionShare)
+	if !ok {
+		return
+	}
+	entries, err := folderGrants.listAccess(c, folderID, a)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list access"})
+		return
+	}
+	c.JSON(200, api.FolderAccess{FolderID: folderID, Access: entries})
+}
+
+// GrantAccessHandler gives a user or group a role on a file, or changes
+// the role they have.
+func (h *Handler) GrantAccessHandler(c *gin.Context) {
+	grantHandler(c, fileGrants)
+}
+
+// GrantFolderAccessHandler gives a user or group a role on a folder and
+// everything in it.
+func (h *Handler) GrantFolderAccessHandler(c *gin.Context) {
+	grantHandler(c, folderGrants)
+}
+
+func grantHandler(c *gin.Context, t grantTarget) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var body api.GrantAccessRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if (body.Email == "") == (body.GroupID == 0) {
+		c.JSON(400, gin.H{"error": "give either email or group_id"})
+		return
+	}
+	if !slices.Contains(api.FileRoles, body.Role) {
+		c.JSON(400, gin.H{"error": "role must be one of " + strings.Join(api.FileRoles, ", ")})
+		return
+	}
+	entry, ok := t.grant(c, c.GetInt64("user_id"), id, body)
+	if !ok {
+		return
+	}
+	c.JSON(200, entry)
+}
+
+// RevokeAccessHandler and RevokeFolderAccessHandler take away the role a
+// user was granted on a file or folder. Anyone can remove their own.
+func (h *Handler) RevokeAccessHandler(c *gin.Context) {
+	revokeHandler(c, fileGrants, "user_id")
+}
+
+func (h *Handler) RevokeFolderAccessHandler(c *gin.Context) {
+	revokeHandler(c, folderGrants, "user_id")
+}
+
+// RevokeGroupAccessHandler and RevokeFolderGroupAccessHandler take away the
+// role a group was granted on a file or folder.
+func (h *Handler) RevokeGroupAccessHandler(c *gin.Context) {
+	revokeHandler(c, fileGrants, "group_id")
+}
+
+func (h *Handler) RevokeFolderGroupAccessHandler(c *gin.Context) {
+	revokeHandler(c, folderGrants, "group_id")
+}
+
+// revokeHandler revokes the role granted to the user or group named by the
+// route parameter param, "user_id" or "group_id".
+func revokeHandler(c *gin.Context, t grantTarget, param string) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	targetID, ok := paramID(c, param)
+	if !ok {
+		return
+	}
+	if !t.revoke(c, c.GetInt64("user_id"), id, principal{param, targetID}) {
+		return
+	}
+	c.JSON(200, api.Message{Message: "access removed"})
+}
+
+// sharedFolders is a recursive CTE of the folders granted to user $1, or
+// to a group they belong to, and the folders within them, each with the
+// role and the folder it was granted on. A folder under several grants
+// appears once for each.
+const sharedFolders = `reach AS (
+	SELECT p.folder_id AS id, p.role, p.folder_id AS via
+	FROM folder_permissions p JOIN folders f ON f.id = p.folder_id
+	WHERE (p.user_id=$1 OR p.group_id IN (SELECT group_id FROM group_members WHERE user_id=$1))
+	  AND f.trashed=false
+	UNION
+	SELECT f.id, reach.role, reach.via
+	FROM folders f JOIN reach ON f.parent_id = reach.id
+	WHERE f.trashed=false
+)`
+
+// SharedWithMeHandler lists everything other users have given the caller
+// a role on: files and folders granted directly, and every file and folder
+// within the granted folders, with the highest role that applies.
+func (h *Handler) SharedWithMeHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	out := api.SharedWithMe{Folders: []api.SharedWithMeFolder{}, Files: []api.SharedWithMeFile{}}
+
+	rows, err := db.Pool.Query(c,
+		`WITH RECURSIVE `+sharedFolders+`
+		 SELECT DISTINCT ON (f.id) f.id, f.name, f.parent_id, f.created_at, u.username, r.role, r.via
+		 FROM reach r
+		 JOIN folders f ON f.id = r.id
+		 JOIN users u ON u.id = f.owner_id
+		 WHERE f.owner_id <> $1
+		 ORDER BY f.id, array_position($2::text[], r.role) DESC`,
+		userID, api.FileRoles,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list shared items"})
+		return
+	}
+	for rows.Next() {
+		var f api.SharedWithMeFolder
+		if err := rows.Scan(&f.ID, &f.Name, &f.ParentID, &f.CreatedAt, &f.Owner, &f.Role, &f.ViaFolderID); err != nil {
+			rows.Close()
+			c.JSON(500, gin.H{"error": "failed to list shared items"})
+			return
+		}
+		out.Folders = append(out.Folders, f)
+	}
+	rows.Close()
+
+	rows, err = db.Pool.Query(c,
+		`WITH RECURSIVE `+sharedFolders+`,
+		 grants AS (
+		   SELECT f.id, r.role, r.via FROM reach r JOIN files f ON f.folder_id = r.id
+		   UNION ALL
+		   SELECT file_id, role, NULL::bigint FROM file_permissions
+		   WHERE user_id=$1 OR group_id IN (SELECT group_id FROM group_members WHERE user_id=$1)
+		 )
+		 SELECT DISTINCT ON (f.id) `+fileColumns+`, u.username, g.role, g.via
+		 FROM grants g
+		 JOIN files f ON f.id = g.id
+		 JOIN blobs b ON f.blob_id = b.id
+		 JOIN users u ON u.id = f.owner_id
+		 WHERE f.trashed=false AND f.owner_id <> $1
+		 ORDER BY f.id, array_position($2::text[], g.role) DESC, g.via NULLS FIRST`,
+		userID, api.FileRoles,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list shared items"})
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f api.SharedWithMeFile
+		f.File, err = scanFile(rows, &f.Owner, &f.Role, &f.ViaFolderID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to list shared items"})
+			return
+		}
+		out.Files = append(out.Files, f)
+	}
+
+	slices.SortFunc(out.Files, func(a, b api.SharedWithMeFile) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	c.JSON(200, out)
+}
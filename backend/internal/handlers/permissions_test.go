@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+func TestAccessCan(t *testing.T) {
+	actions := []action{actionRead, actionComment, actionEdit, actionShare, actionOwn}
+	for _, c := range []struct {
+		a       access
+		allowed int // actions[:allowed] are allowed
+	}{
+		{access{}, 0},
+		{access{Public: true}, 1},
+		{access{Role: api.RoleViewer}, 1},
+		{access{Role: api.RoleCommenter}, 2},
+		{access{Role: api.RoleEditor}, 3},
+		{access{Role: api.RoleCoOwner}, 4},
+		{access{Role: api.RoleOwner}, 5},
+		{access{Role: "admin"}, 0},
+	} {
+		for i, act := range actions {
+			if got := c.a.can(act); got != (i < c.allowed) {
+				t.Errorf("%+v can(%d) = %v", c.a, act, got)
+			}
+		}
+	}
+}
+
+// TestFileRoles checks the roles granted on a file itself and the access
+// a public file gives.
+func TestFileRoles(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	org := testOrg(t, "org")
+	alice, bob, carol := testUser(t, org, "alice"), testUser(t, org, "bob"), testUser(t, org, "carol")
+	file := testFile(t, alice, "report.txt", 0)
+	insertID(t, "INSERT INTO file_permissions (file_id, user_id, role) VALUES ($1,$2,'viewer') RETURNING id", file, bob)
+
+	ctx := context.Background()
+	for _, c := range []struct {
+		user int64
+		role string
+	}{{alice, api.RoleOwner}, {bob, api.RoleViewer}, {carol, ""}} {
+		a, err := fileGrants.lookup(ctx, c.user, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Role != c.role {
+			t.Errorf("user %d has role %q, want %q", c.user, a.Role, c.role)
+		}
+	}
+
+	tag := func(user int64) int {
+		return serve(user, h.UpdateTagsHandler, "PATCH", "/files/:id/tags", fmt.Sprintf("/files/%d/tags", file), `{"tags":["q3"]}`).Code
+	}
+	if code := tag(bob); code != 403 {
+		t.Errorf("tagging as a viewer: got %d, want 403", code)
+	}
+	execSQL(t, "UPDATE file_permissions SET role='editor' WHERE file_id=$1 AND user_id=$2", file, bob)
+	if code := tag(bob); code != 200 {
+		t.Errorf("tagging as an editor: got %d, want 200", code)
+	}
+
+	execSQL(t, "UPDATE files SET is_public=true WHERE id=$1", file)
+	a, err := fileGrants.lookup(ctx, carol, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.can(actionRead) || a.can(actionEdit) {
+		t.Error("a public file should be readable, and only readable, by the organization")
+	}
+}
+
+func TestMoveFolderIntoSubtree(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	org := testOrg(t, "org")
+	alice := testUser(t, org, "alice")
+	a := testFolder(t, alice, "a", 0)
+	b := testFolder(t, alice, "b", a)
+	c := testFolder(t, alice, "c", b)
+
+	for _, parent := range []int64{a, b, c} {
+		w := serve(alice, h.MoveFolderHandler, "PATCH", "/folders/:id/move", fmt.Sprintf("/folders/%d/move", a),
+			fmt.Sprintf(`{"new_parent_id":%d}`, parent))
+		if w.Code != 400 {
+			t.Errorf("moving a folder under %d in its own subtree: got %d, want 400", parent, w.Code)
+		}
+	}
+	if n := count(t, "SELECT count(*) FROM folders WHERE id=$1 AND parent_id IS NULL", a); n != 1 {
+		t.Error("the folder was moved")
+	}
+}
+
+// TestFolderLoop checks that the walks over the folder tree finish even
+// when parent_id loops, as only bad data could make it.
+func TestFolderLoop(t *testing.T) {
+	testDB(t)
+	org := testOrg(t, "org")
+	alice, bob := testUser(t, org, "alice"), testUser(t, org, "bob")
+	a := testFolder(t, alice, "a", 0)
+	b := testFolder(t, alice, "b", a)
+	execSQL(t, "UPDATE folders SET parent_id=$1 WHERE id=$2", b, a)
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'viewer') RETURNING id", a, bob)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	got, err := folderGrants.lookup(ctx, bob, b)
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if got.Role != "viewer" {
+		t.Errorf("inherited role is %q, want viewer", got.Role)
+	}
+	_, folders, _, err := loadSharedTree(ctx, a)
+	if err != nil {
+		t.Fatalf("loadSharedTree: %v", err)
+	}
+	if len(folders) != 1 || folders[0].ID != b {
+		t.Errorf("shared tree of a is %+v, want just b", folders)
+	}
+}
+
+// TestFolderGrantInheritance checks that folder grants reach everything
+// below the folder and that the highest role that applies counts.
+func TestFolderGrantInheritance(t *testing.T) {
+	testDB(t)
+	org := testOrg(t, "org")
+	alice, bob := testUser(t, org, "alice"), testUser(t, org, "bob")
+	a := testFolder(t, alice, "a", 0)
+	b := testFolder(t, alice, "b", a)
+	c := testFolder(t, alice, "c", b)
+	other := testFolder(t, alice, "other", 0)
+	deep := testFile(t, alice, "deep.txt", c)
+	outside := testFile(t, alice, "outside.txt", other)
+
+	ctx := context.Background()
+	role := func(target grantTarget, id int64) string {
+		t.Helper()
+		a, err := target.lookup(ctx, bob, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a.Role
+	}
+
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'viewer') RETURNING id", a, bob)
+	if got := role(fileGrants, deep); got != api.RoleViewer {
+		t.Errorf("a file three folders down has role %q, want viewer", got)
+	}
+	if got := role(folderGrants, c); got != api.RoleViewer {
+		t.Errorf("a subfolder has role %q, want viewer", got)
+	}
+	if got := role(fileGrants, outside); got != "" {
+		t.Errorf("a file outside the granted folder has role %q, want none", got)
+	}
+
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'editor') RETURNING id", b, bob)
+	insertID(t, "INSERT INTO file_permissions (file_id, user_id, role) VALUES ($1,$2,'commenter') RETURNING id", deep, bob)
+	if got := role(fileGrants, deep); got != api.RoleEditor {
+		t.Errorf("with viewer, editor and commenter grants the role is %q, want editor", got)
+	}
+	if got := role(folderGrants, a); got != api.RoleViewer {
+		t.Errorf("a grant below a folder gave it role %q, want viewer", got)
+	}
+}
+
+// TestFolderTrashRoles checks who may trash, restore and delete a folder
+// for good.
+func TestFolderTrashRoles(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	org := testOrg(t, "org")
+	alice, bob, carol := testUser(t, org, "alice"), testUser(t, org, "bob"), testUser(t, org, "carol")
+	outsider := testUser(t, testOrg(t, "other"), "dave")
+	folder := testFolder(t, alice, "shared", 0)
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'editor') RETURNING id", folder, bob)
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'viewer') RETURNING id", folder, carol)
+
+	trash := func(user int64) int {
+		return serve(user, h.TrashFolderHandler, "PATCH", "/folders/:id/trash", fmt.Sprintf("/folders/%d/trash", folder), "").Code
+	}
+	restore := func(user int64) int {
+		return serve(user, h.RestoreFolderHandler, "PATCH", "/folders/:id/restore", fmt.Sprintf("/folders/%d/restore", folder), "").Code
+	}
+	remove := func(user int64) int {
+		return serve(user, h.PermanentlyDeleteFolderHandler, "DELETE", "/trash/folders/:id", fmt.Sprintf("/trash/folders/%d", folder), "").Code
+	}
+	for _, c := range []struct {
+		name string
+		code int
+		want int
+	}{
+		{"trashing as a viewer", trash(carol), 403},
+		{"trashing from another organization", trash(outsider), 404},
+		{"trashing as an editor", trash(bob), 200},
+		{"restoring as an editor", restore(bob), 200},
+		{"trashing as the owner", trash(alice), 200},
+		{"deleting as an editor", remove(bob), 403},
+		{"deleting as the owner", remove(alice), 200},
+	} {
+		if c.code != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, c.code, c.want)
+		}
+	}
+	if n := count(t, "SELECT count(*) FROM folders WHERE id=$1", folder); n != 0 {
+		t.Error("the folder was not deleted")
+	}
+}
+
+// TestEditorUploadInSharedFolder checks that an editor's upload of a name
+// already in someone else's folder versions that file, and that the
+// folder's owner sees and can open what the editor added.
+func TestEditorUploadInSharedFolder(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	org := testOrg(t, "org")
+	alice, bob := testUser(t, org, "alice"), testUser(t, org, "bob")
+	folder := testFolder(t, alice, "shared", 0)
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'editor') RETURNING id", folder, bob)
+
+	first := upload(t, h, alice, folder, "notes.txt", "first draft")
+	second := upload(t, h, bob, folder, "notes.txt", "second draft")
+	if second.FileID != first.FileID {
+		t.Errorf("the editor's upload made file %d next to %d instead of a version", second.FileID, first.FileID)
+	}
+	if n := count(t, "SELECT count(*) FROM file_versions WHERE file_id=$1", first.FileID); n != 1 {
+		t.Errorf("%d versions, want 1", n)
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE owner_id=$1", alice); n != 1 {
+		t.Error("the versioned file changed owner")
+	}
+
+	added := upload(t, h, bob, folder, "ideas.txt", "bob's")
+	w := serve(alice, h.FSGetHandler, "GET", "/fs/*path", "/fs/shared?op=list", "")
+	var list api.EntryList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("listing the folder: %d %s", w.Code, w.Body)
+	}
+	var names []string
+	for _, e := range list.Entries {
+		names = append(names, e.Name)
+	}
+	if len(names) != 2 || names[0] != "ideas.txt" || names[1] != "notes.txt" {
+		t.Errorf("the owner lists %v, want [ideas.txt notes.txt]", names)
+	}
+	a, err := fileGrants.lookup(context.Background(), alice, added.FileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Role != api.RoleCoOwner {
+		t.Errorf("the folder's owner has role %q on a file added to it, want %q", a.Role, api.RoleCoOwner)
+	}
+	if code := serve(alice, h.FSPostHandler, "POST", "/fs/*path", "/fs/shared/ideas.txt?op=move", `{"to":"/shared/mine.txt"}`).Code; code != 403 {
+		t.Errorf("renaming someone else's file: got %d, want 403", code)
+	}
+}
@@ -0,0 +1,669 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/sigv4"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
+)
+
+// A minimal S3 API over user storage, for path-style requests
+// (http://host/bucket/key). A user's top-level folders are their buckets
+// and the folders below form key prefixes; files at the root are not
+// reachable. Requests are signed with SigV4 using the user's access keys.
+// Multipart uploads are not supported.
+
+const (
+	s3Namespace    = "http://s3.amazonaws.com/doc/2006-03-01/"
+	s3TimeFormat   = "2006-01-02T15:04:05.000Z"
+	s3MaxClockSkew = 15 * time.Minute
+	s3MaxKeys      = 1000
+)
+
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type s3Error struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string
+	Message  string
+	Resource string
+}
+
+func s3XML(c *gin.Context, status int, v any) {
+	c.Header("Content-Type", "application/xml")
+	c.Status(status)
+	_, _ = io.WriteString(c.Writer, xml.Header)
+	_ = xml.NewEncoder(c.Writer).Encode(v)
+}
+
+func s3Fail(c *gin.Context, status int, code, message string) {
+	if c.Request.Method == http.MethodHead {
+		c.AbortWithStatus(status)
+		return
+	}
+	s3XML(c, status, s3Error{Code: code, Message: message, Resource: c.Request.URL.Path})
+	c.Abort()
+}
+
+func s3UploadFail(c *gin.Context, uerr *uploadError) {
+	switch {
+	case uerr.Message == "quota exceeded", uerr.Message == "organization quota exceeded":
+		s3Fail(c, 403, "QuotaExceeded", uerr.Message)
+	case uerr.Status == 413:
+		s3Fail(c, 400, "EntityTooLarge", uerr.Message)
+	case uerr.Status == 400:
+		s3Fail(c, 400, "InvalidArgument", uerr.Message)
+	default:
+		s3Fail(c, 500, "InternalError", uerr.Message)
+	}
+}
+
+// S3AuthMiddleware verifies the SigV4 signature of a request, from its
+// Authorization header or presigned URL query.
+func S3AuthMiddleware(c *gin.Context) {
+	r := c.Request
+	var auth *sigv4.Authorization
+	var signedAt time.Time
+	var payloadHash string
+
+	if header := r.Header.Get("Authorization"); header != "" {
+		a, err := sigv4.ParseAuthorization(header)
+		if err != nil {
+			s3Fail(c, 400, "AuthorizationHeaderMalformed", "the authorization header is malformed")
+			return
+		}
+		t, err := time.Parse(sigv4.TimeFormat, r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			s3Fail(c, 403, "AccessDenied", "missing or invalid X-Amz-Date")
+			return
+		}
+		if d := time.Since(t); d > s3MaxClockSkew || d < -s3MaxClockSkew {
+			s3Fail(c, 403, "RequestTimeTooSkewed", "the difference between the request time and the server's time is too large")
+			return
+		}
+		payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+		if payloadHash == "" {
+			s3Fail(c, 400, "InvalidRequest", "missing x-amz-content-sha256")
+			return
+		}
+		auth, signedAt = a, t
+	} else if r.URL.Query().Has("X-Amz-Algorithm") {
+		a, t, expires, err := sigv4.ParseQuery(r.URL.Query())
+		if err != nil {
+			s3Fail(c, 400, "AuthorizationQueryParametersError", "the presigned URL is malformed")
+			return
+		}
+		if time.Now().After(t.Add(expires)) {
+			s3Fail(c, 403, "AccessDenied", "request has expired")
+			return
+		}
+		auth, signedAt, payloadHash = a, t, sigv4.UnsignedPayload
+	} else {
+		s3Fail(c, 403, "AccessDenied", "anonymous access is not allowed")
+		return
+	}
+
+	var keyID, userID int64
+	var secret string
+	err := db.Pool.QueryRow(c,
+		"SELECT id, user_id, secret_key FROM access_keys WHERE access_key=$1",
+		auth.AccessKey,
+	).Scan(&keyID, &userID, &secret)
+	if err != nil {
+		s3Fail(c, 403, "InvalidAccessKeyId", "the access key does not exist")
+		return
+	}
+	if auth.Service != "s3" || !auth.Verify(r, secret, signedAt, payloadHash) {
+		s3Fail(c, 403, "SignatureDoesNotMatch", "the request signature does not match")
+		return
+	}
+
+	switch payloadHash {
+	case sigv4.StreamingPayload, sigv4.StreamingPayloadTrailer, sigv4.StreamingUnsignedTrailer:
+		r.Body = io.NopCloser(sigv4.NewChunkedReader(r.Body, payloadHash, auth, secret, signedAt))
+	default:
+		// The content hash is checked once the body has been staged.
+		if sha256Hex.MatchString(payloadHash) {
+			c.Set("s3_payload_sha256", payloadHash)
+		}
+	}
+
+	_, _ = db.Pool.Exec(c, "UPDATE access_keys SET last_used_at=now() WHERE id=$1", keyID)
+	c.Set("user_id", userID)
+	c.Next()
+}
+
+// S3Handler dispatches S3 operations by method and path.
+func (h *Handler) S3Handler(c *gin.Context) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(c.Request.URL.Path, "/"), "/")
+	method := c.Request.Method
+	q := c.Request.URL.Query()
+
+	if q.Has("uploads") || q.Has("uploadId") {
+		s3Fail(c, 501, "NotImplemented", "multipart uploads are not supported")
+		return
+	}
+
+	if bucket == "" {
+		if method != http.MethodGet {
+			s3Fail(c, 405, "MethodNotAllowed", "the method is not allowed against this resource")
+			return
+		}
+		h.s3ListBuckets(c)
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	b, err := lookupFolder(c, userID, nil, bucket)
+	if err != nil {
+		s3Fail(c, 404, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+
+	if key == "" {
+		switch {
+		case method == http.MethodHead:
+			c.Status(200)
+		case method == http.MethodGet && q.Has("location"):
+			s3XML(c, 200, struct {
+				XMLName xml.Name `xml:"LocationConstraint"`
+				Xmlns   string   `xml:"xmlns,attr"`
+			}{Xmlns: s3Namespace})
+		case method == http.MethodGet && len(q) == len(s3ListParams(q)):
+			h.s3ListObjects(c, b)
+		default:
+			s3Fail(c, 501, "NotImplemented", "the operation is not supported")
+		}
+		return
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		h.s3GetObject(c, b, key)
+	case http.MethodPut:
+		if c.GetHeader("X-Amz-Copy-Source") != "" {
+			h.s3CopyObject(c, b, key)
+		} else {
+			h.s3PutObject(c, b, key)
+		}
+	case http.MethodDelete:
+		h.s3DeleteObject(c, b, key)
+	default:
+		s3Fail(c, 405, "MethodNotAllowed", "the method is not allowed against this resource")
+	}
+}
+
+// s3ListParams returns the query parameters of q that belong to
+// ListObjects; anything else on a bucket GET is another subresource.
+func s3ListParams(q url.Values) []string {
+	var params []string
+	for k := range q {
+		switch k {
+		case "list-type", "prefix", "delimiter", "max-keys", "continuation-token",
+			"start-after", "marker", "encoding-type", "fetch-owner":
+			params = append(params, k)
+		}
+	}
+	return params
+}
+
+// s3KeyParts splits an object key into folder names and a file name.
+// Keys ending in "/" have an empty file name.
+func s3KeyParts(key string) ([]string, string, bool) {
+	parts := strings.Split(key, "/")
+	dirs, name := parts[:len(parts)-1], parts[len(parts)-1]
+	for _, d := range dirs {
+		if d == "" || d == "." || d == ".." {
+			return nil, "", false
+		}
+	}
+	if name == "." || name == ".." {
+		return nil, "", false
+	}
+	return dirs, name, true
+}
+
+func (h *Handler) s3LookupObject(c *gin.Context, bucket *pathFolder, key string) (*pathFile, error) {
+	dirs, name, ok := s3KeyParts(key)
+	if !ok || name == "" {
+		return nil, os.ErrNotExist
+	}
+	userID := c.GetInt64("user_id")
+	folderID, err := resolveFolderPath(c, userID, &bucket.ID, dirs)
+	if err != nil {
+		return nil, err
+	}
+	return lookupFile(c, userID, folderID, name)
+}
+
+func (h *Handler) s3ListBuckets(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var username string
+	_ = db.Pool.QueryRow(c, "SELECT username FROM users WHERE id=$1", userID).Scan(&username)
+
+	folders, _, err := listFolder(c, userID, nil)
+	if err != nil {
+		s3Fail(c, 500, "InternalError", "failed to list buckets")
+		return
+	}
+
+	type bucket struct {
+		Name         string
+		CreationDate string
+	}
+	res := struct {
+		XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Owner   struct {
+			ID          string
+			DisplayName string
+		}
+		Buckets []bucket `xml:"Buckets>Bucket"`
+	}{Xmlns: s3Namespace}
+	res.Owner.ID = strconv.FormatInt(userID, 10)
+	res.Owner.DisplayName = username
+	for _, f := range folders {
+		res.Buckets = append(res.Buckets, bucket{Name: f.Name, CreationDate: f.Created.UTC().Format(s3TimeFormat)})
+	}
+
+	s3XML(c, 200, res)
+}
+
+type s3Entry struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+	Hash     string
+	Dir      bool
+}
+
+type s3Object struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+type s3Prefix struct {
+	Prefix string
+}
+
+type s3ListResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Xmlns                 string   `xml:"xmlns,attr"`
+	Name                  string
+	Prefix                string
+	Delimiter             string `xml:",omitempty"`
+	Marker                string `xml:",omitempty"`
+	NextMarker            string `xml:",omitempty"`
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
+	StartAfter            string `xml:",omitempty"`
+	KeyCount              *int   `xml:",omitempty"`
+	MaxKeys               int
+	IsTruncated           bool
+	Contents              []s3Object
+	CommonPrefixes        []s3Prefix
+}
+
+// s3ListObjects serves ListObjectsV2, and ListObjects (v1) without list-type.
+func (h *Handler) s3ListObjects(c *gin.Context, bucket *pathFolder) {
+	userID := c.GetInt64("user_id")
+	prefix := c.Query("prefix")
+	delimiter := c.Query("delimiter")
+	v2 := c.Query("list-type") == "2"
+
+	maxKeys := s3MaxKeys
+	if v := c.Query("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s3Fail(c, 400, "InvalidArgument", "invalid max-keys")
+			return
+		}
+		if n < maxKeys {
+			maxKeys = n
+		}
+	}
+
+	res := s3ListResult{Xmlns: s3Namespace, Name: bucket.Name, Prefix: prefix, Delimiter: delimiter, MaxKeys: maxKeys}
+	marker := c.Query("marker")
+	if v2 {
+		marker = c.Query("start-after")
+		res.StartAfter = marker
+		if token := c.Query("continuation-token"); token != "" {
+			decoded, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil {
+				s3Fail(c, 400, "InvalidArgument", "invalid continuation token")
+				return
+			}
+			res.ContinuationToken = token
+			marker = string(decoded)
+		}
+	} else {
+		res.Marker = marker
+	}
+
+	entries, err := h.s3Entries(c, userID, bucket, prefix, delimiter)
+	if err != nil {
+		s3Fail(c, 500, "InternalError", "failed to list objects")
+		return
+	}
+
+	// A marker ending in the delimiter is a common prefix that was already
+	// returned; everything under it is skipped.
+	skipUnder := delimiter != "" && marker != "" && strings.HasSuffix(marker, delimiter)
+
+	var last string
+	count := 0
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Key, prefix) || e.Key <= marker {
+			continue
+		}
+		if skipUnder && strings.HasPrefix(e.Key, marker) {
+			continue
+		}
+
+		key := e.Key
+		isPrefix := false
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				key = key[:len(prefix)+i+len(delimiter)]
+				isPrefix = true
+			}
+		}
+		if !isPrefix && e.Dir {
+			continue
+		}
+		if isPrefix && len(res.CommonPrefixes) > 0 && res.CommonPrefixes[len(res.CommonPrefixes)-1].Prefix == key {
+			continue
+		}
+
+		if count == maxKeys {
+			res.IsTruncated = true
+			break
+		}
+		count++
+		last = key
+		if isPrefix {
+			res.CommonPrefixes = append(res.CommonPrefixes, s3Prefix{Prefix: key})
+		} else {
+			res.Contents = append(res.Contents, s3Object{
+				Key:          key,
+				LastModified: e.Modified.UTC().Format(s3TimeFormat),
+				ETag:         `"` + e.Hash + `"`,
+				Size:         e.Size,
+				StorageClass: "STANDARD",
+			})
+		}
+	}
+
+	if res.IsTruncated {
+		if v2 {
+			res.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+		} else {
+			res.NextMarker = last
+		}
+	}
+	if v2 {
+		res.KeyCount = &count
+	}
+
+	s3XML(c, 200, res)
+}
+
+// s3Entries collects the keys a listing may return, sorted. Folders are
+// included as "name/" entries so empty ones show up as common prefixes.
+// Only the folder named by the prefix's directory part is walked, and with
+// a "/" delimiter only its direct children are needed.
+func (h *Handler) s3Entries(c *gin.Context, userID int64, bucket *pathFolder, prefix, delimiter string) ([]s3Entry, error) {
+	base := prefix[:strings.LastIndex(prefix, "/")+1]
+	var dirs []string
+	if base != "" {
+		dirs = strings.Split(strings.TrimSuffix(base, "/"), "/")
+	}
+	folderID, err := resolveFolderPath(c, userID, &bucket.ID, dirs)
+	if err != nil {
+		return nil, nil
+	}
+
+	var entries []s3Entry
+	if delimiter == "/" {
+		folders, files, err := listFolder(c, userID, folderID)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range folders {
+			entries = append(entries, s3Entry{Key: base + f.Name + "/", Modified: f.Created, Dir: true})
+		}
+		for _, f := range files {
+			entries = append(entries, s3Entry{Key: base + f.Name, Size: f.Size, Modified: f.Created, Hash: f.Hash})
+		}
+	} else {
+		rows, err := db.Pool.Query(c,
+			`WITH RECURSIVE tree AS (
+			   SELECT id, $3::text AS prefix, created_at FROM folders WHERE id=$2
+			   UNION ALL
+			   SELECT f.id, t.prefix || f.name || '/', f.created_at
+			   FROM folders f JOIN tree t ON f.parent_id = t.id
+			   WHERE f.owner_id=$1 AND f.trashed=false
+			 ) CYCLE id SET looped USING visited
+			 SELECT prefix, 0::bigint, created_at, '', true FROM tree WHERE id <> $2 AND NOT looped
+			 UNION ALL
+			 SELECT t.prefix || f.filename, b.size, f.created_at, b.hash, false
+			 FROM files f
+			 JOIN tree t ON f.folder_id = t.id AND NOT t.looped
+			 JOIN blobs b ON f.blob_id = b.id
+			 WHERE f.owner_id=$1 AND f.trashed=false`,
+			userID, *folderID, base,
+		)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var e s3Entry
+			if err := rows.Scan(&e.Key, &e.Size, &e.Modified, &e.Hash, &e.Dir); err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+func (h *Handler) s3GetObject(c *gin.Context, bucket *pathFolder, key string) {
+	userID := c.GetInt64("user_id")
+	f, err := h.s3LookupObject(c, bucket, key)
+	if err != nil {
+		s3Fail(c, 404, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+
+	obj, err := storage.Open(c, h.Blobs, f.BlobKey)
+	if err != nil {
+		s3Fail(c, 500, "InternalError", "failed to open object")
+		return
+	}
+	defer obj.Close()
+
+	if c.Request.Method == http.MethodGet {
+		_, _ = db.Pool.Exec(c, "UPDATE files SET download_count = download_count + 1 WHERE id=$1", f.ID)
+		_, _ = db.Pool.Exec(c,
+			"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+			userID, "download_file", "file", f.ID, fmt.Sprintf(`{"filename":%q}`, f.Name),
+		)
+	}
+
+	mimeType := f.MIME
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	c.Header("Content-Type", mimeType)
+	c.Header("ETag", `"`+f.Hash+`"`)
+	c.Header("Accept-Ranges", "bytes")
+	http.ServeContent(c.Writer, c.Request, "", f.Created, obj)
+}
+
+func (h *Handler) s3PutObject(c *gin.Context, bucket *pathFolder, key string) {
+	userID := c.GetInt64("user_id")
+	dirs, name, ok := s3KeyParts(key)
+	if !ok {
+		s3Fail(c, 400, "InvalidArgument", "invalid object key")
+		return
+	}
+
+	// A key ending in "/" is a folder marker.
+	if name == "" {
+		if _, err := ensureFolderPath(c, userID, &bucket.ID, dirs); err != nil {
+			s3Fail(c, 400, "InvalidArgument", "invalid object key")
+			return
+		}
+		c.Status(200)
+		return
+	}
+
+	maxSizeMB, _ := strconv.ParseInt(os.Getenv("MAX_FILE_SIZE_MB"), 10, 64)
+	if maxSizeMB > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSizeMB*1024*1024)
+	}
+
+	staged, err := h.stageUpload(c.Request.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxErr):
+			s3Fail(c, 400, "EntityTooLarge", "file too large")
+		case errors.Is(err, sigv4.ErrChunkSignature):
+			s3Fail(c, 403, "SignatureDoesNotMatch", "a chunk signature does not match")
+		default:
+			s3Fail(c, 400, "IncompleteBody", "failed to read request body")
+		}
+		return
+	}
+	defer os.Remove(staged.Path)
+
+	if expected := c.GetString("s3_payload_sha256"); expected != "" && expected != staged.Hash {
+		s3Fail(c, 400, "XAmzContentSHA256Mismatch", "the provided x-amz-content-sha256 does not match the content")
+		return
+	}
+	if staged.Size == 0 {
+		s3Fail(c, 400, "InvalidArgument", "empty objects are not supported")
+		return
+	}
+
+	folderID, err := ensureFolderPath(c, userID, &bucket.ID, dirs)
+	if err != nil {
+		s3Fail(c, 400, "InvalidArgument", "invalid object key")
+		return
+	}
+
+	// Content types from S3 clients are guessed from the extension, so the
+	// stored type is the detected one.
+	res, uerr := h.saveUpload(c, userID, staged, name, "", nil, folderID)
+	if uerr != nil {
+		s3UploadFail(c, uerr)
+		return
+	}
+
+	c.Header("ETag", `"`+res.Hash+`"`)
+	c.Status(200)
+}
+
+func (h *Handler) s3CopyObject(c *gin.Context, bucket *pathFolder, key string) {
+	userID := c.GetInt64("user_id")
+
+	source, err := url.PathUnescape(c.GetHeader("X-Amz-Copy-Source"))
+	if err != nil {
+		s3Fail(c, 400, "InvalidArgument", "invalid copy source")
+		return
+	}
+	source, _, _ = strings.Cut(strings.TrimPrefix(source, "/"), "?")
+	srcBucketName, srcKey, _ := strings.Cut(source, "/")
+	srcBucket, err := lookupFolder(c, userID, nil, srcBucketName)
+	if err != nil {
+		s3Fail(c, 404, "NoSuchBucket", "the source bucket does not exist")
+		return
+	}
+	src, err := h.s3LookupObject(c, srcBucket, srcKey)
+	if err != nil {
+		s3Fail(c, 404, "NoSuchKey", "the source key does not exist")
+		return
+	}
+
+	dirs, name, ok := s3KeyParts(key)
+	if !ok || name == "" {
+		s3Fail(c, 400, "InvalidArgument", "invalid object key")
+		return
+	}
+	folderID, err := ensureFolderPath(c, userID, &bucket.ID, dirs)
+	if err != nil {
+		s3Fail(c, 400, "InvalidArgument", "invalid object key")
+		return
+	}
+
+	res, uerr := h.copyFile(c, userID, src, name, folderID)
+	if uerr != nil {
+		s3UploadFail(c, uerr)
+		return
+	}
+
+	s3XML(c, 200, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		Xmlns        string   `xml:"xmlns,attr"`
+		LastModified string
+		ETag         string
+	}{Xmlns: s3Namespace, LastModified: time.Now().UTC().Format(s3TimeFormat), ETag: `"` + res.Hash + `"`})
+}
+
+// s3DeleteObject moves the object (or, for a folder marker key, the folder)
+// to the trash. Like S3 it succeeds for keys that do not exist.
+func (h *Handler) s3DeleteObject(c *gin.Context, bucket *pathFolder, key string) {
+	userID := c.GetInt64("user_id")
+	dirs, name, ok := s3KeyParts(key)
+	if !ok {
+		c.Status(204)
+		return
+	}
+
+	if name == "" {
+		folderID, err := resolveFolderPath(c, userID, &bucket.ID, dirs)
+		if err == nil && len(dirs) > 0 {
+			if err := trashFolder(c, userID, *folderID); err != nil {
+				s3Fail(c, 500, "InternalError", "failed to delete object")
+				return
+			}
+		}
+		c.Status(204)
+		return
+	}
+
+	if f, err := h.s3LookupObject(c, bucket, key); err == nil {
+		if err := trashFile(c, userID, f); err != nil {
+			s3Fail(c, 500, "InternalError", "failed to delete object")
+			return
+		}
+	}
+	c.Status(204)
+}
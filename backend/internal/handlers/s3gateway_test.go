@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/sigv4"
+)
+
+func TestS3KeyParts(t *testing.T) {
+	for _, c := range []struct {
+		key  string
+		dirs []string
+		name string
+		ok   bool
+	}{
+		{"a.txt", nil, "a.txt", true},
+		{"2024/may/a.txt", []string{"2024", "may"}, "a.txt", true},
+		{"2024/", []string{"2024"}, "", true},
+		{"2024//a.txt", nil, "", false},
+		{"../a.txt", nil, "", false},
+		{"2024/..", nil, "", false},
+	} {
+		dirs, name, ok := s3KeyParts(c.key)
+		if ok != c.ok || name != c.name || !slices.Equal(dirs, c.dirs) {
+			t.Errorf("s3KeyParts(%q) = %v, %q, %v", c.key, dirs, name, ok)
+		}
+	}
+}
+
+// s3Request sends a request signed with creds through the S3 gateway,
+// with headers given as name, value pairs.
+func s3Request(h *Handler, creds sigv4.Credentials, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	sum := sha256.Sum256([]byte(body))
+	sigv4.Sign(req, creds, "us-east-1", "s3", hex.EncodeToString(sum[:]), time.Now())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(S3AuthMiddleware)
+	r.Any("/*path", h.S3Handler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestS3Auth(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	insertID(t, "INSERT INTO access_keys (user_id, access_key, secret_key) VALUES ($1,'AKALICE','secret') RETURNING id", alice)
+
+	if w := s3Request(h, sigv4.Credentials{AccessKey: "AKALICE", SecretKey: "secret"}, "GET", "/", ""); w.Code != 200 {
+		t.Errorf("listing buckets: got %d %s", w.Code, w.Body)
+	}
+	for _, c := range []struct {
+		creds sigv4.Credentials
+		code  string
+	}{
+		{sigv4.Credentials{AccessKey: "AKALICE", SecretKey: "wrong"}, "SignatureDoesNotMatch"},
+		{sigv4.Credentials{AccessKey: "AKNOBODY", SecretKey: "secret"}, "InvalidAccessKeyId"},
+	} {
+		if w := s3Request(h, c.creds, "GET", "/", ""); w.Code != 403 || !strings.Contains(w.Body.String(), c.code) {
+			t.Errorf("signing with %+v: got %d %s, want 403 %s", c.creds, w.Code, w.Body, c.code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r := gin.New()
+	r.Use(S3AuthMiddleware)
+	r.Any("/*path", h.S3Handler)
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 403 {
+		t.Errorf("an unsigned request: got %d, want 403", w.Code)
+	}
+}
+
+func TestS3Objects(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	testFolder(t, alice, "photos", 0)
+	insertID(t, "INSERT INTO access_keys (user_id, access_key, secret_key) VALUES ($1,'AKALICE','secret') RETURNING id", alice)
+	creds := sigv4.Credentials{AccessKey: "AKALICE", SecretKey: "secret"}
+	s3 := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		return s3Request(h, creds, method, path, body, headers...)
+	}
+
+	if w := s3("PUT", "/photos/2024/a.txt", "hello"); w.Code != 200 || w.Header().Get("ETag") == "" {
+		t.Fatalf("PUT: got %d %s", w.Code, w.Body)
+	}
+	if n := count(t, "SELECT count(*) FROM folders WHERE owner_id=$1 AND name='2024'", alice); n != 1 {
+		t.Error("PUT did not create the folder in the key")
+	}
+	if w := s3("GET", "/photos/2024/a.txt", ""); w.Code != 200 || w.Body.String() != "hello" {
+		t.Errorf("GET: got %d %q", w.Code, w.Body)
+	}
+	if w := s3("PUT", "/photos/empty.txt", ""); w.Code != 400 {
+		t.Errorf("PUT with no content: got %d, want 400", w.Code)
+	}
+
+	w := s3("GET", "/photos?list-type=2&delimiter=/", "")
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "<Prefix>2024/</Prefix>") || strings.Contains(w.Body.String(), "<Key>") {
+		t.Errorf("listing the bucket: got %d %s", w.Code, w.Body)
+	}
+	w = s3("GET", "/photos?list-type=2&prefix=2024/", "")
+	if !strings.Contains(w.Body.String(), "<Key>2024/a.txt</Key>") {
+		t.Errorf("listing a prefix: got %d %s", w.Code, w.Body)
+	}
+
+	if w := s3("PUT", "/photos/b.txt", "", "X-Amz-Copy-Source", "/photos/2024/a.txt"); w.Code != 200 {
+		t.Errorf("copying: got %d %s", w.Code, w.Body)
+	}
+	if w := s3("GET", "/photos/b.txt", ""); w.Body.String() != "hello" {
+		t.Errorf("GET on the copy: got %d %q", w.Code, w.Body)
+	}
+
+	if w := s3("DELETE", "/photos/2024/a.txt", ""); w.Code != 204 {
+		t.Errorf("DELETE: got %d, want 204", w.Code)
+	}
+	if w := s3("GET", "/photos/2024/a.txt", ""); w.Code != 404 || !strings.Contains(w.Body.String(), "NoSuchKey") {
+		t.Errorf("GET after DELETE: got %d %s", w.Code, w.Body)
+	}
+	if w := s3("GET", "/nothing/a.txt", ""); w.Code != 404 || !strings.Contains(w.Body.String(), "NoSuchBucket") {
+		t.Errorf("GET in a missing bucket: got %d %s", w.Code, w.Body)
+	}
+	if w := s3("POST", "/photos/big.bin?uploads", ""); w.Code != 501 {
+		t.Errorf("starting a multipart upload: got %d, want 501", w.Code)
+	}
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// A session is a family of refresh tokens: login starts one and every
+// refresh replaces its token with a new one. Access tokens are short-lived
+// JWTs checked against revoked_tokens (single tokens, by jti) and
+// users.token_generation (all of a user's tokens).
+
+var errTokenRevoked = errors.New("token revoked")
+
+// bearerToken returns the token from an Authorization header, with or
+// without the Bearer prefix.
+func bearerToken(header string) string {
+	if strings.HasPrefix(strings.ToLower(header), "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return header
+}
+
+// checkAccessToken parses an access token and checks that it has not been
+// revoked.
+func checkAccessToken(c *gin.Context, token string) (*auth.Claims, error) {
+	claims, err := auth.ParseJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	var generation int64
+	var revoked bool
+	err = db.Pool.QueryRow(c,
+		"SELECT token_generation, EXISTS (SELECT 1 FROM revoked_tokens WHERE jti=$2) FROM users WHERE id=$1",
+		claims.UserID, claims.ID,
+	).Scan(&generation, &revoked)
+	if err != nil {
+		return nil, errTokenRevoked
+	}
+	if revoked || generation != claims.Generation {
+		return nil, errTokenRevoked
+	}
+	return claims, nil
+}
+
+func AuthMiddleware(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(401, gin.H{"error": "missing token"})
+		c.Abort()
+		return
+	}
+
+	token := bearerToken(authHeader)
+	if strings.HasPrefix(token, api.PersonalAccessTokenPrefix) {
+		userID, pat, err := checkPersonalAccessToken(c, token)
+		if !authFailed(c, err) {
+			c.Set("user_id", userID)
+			c.Set("pat", pat)
+			c.Next()
+		}
+		return
+	}
+
+	claims, err := checkAccessToken(c, token)
+	if !authFailed(c, err) {
+		c.Set("user_id", claims.UserID)
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// authFailed answers 401 and aborts when err is set. Expired tokens get
+// their own message so clients know to refresh rather than log in again.
+func authFailed(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, errTokenRevoked):
+		c.JSON(401, gin.H{"error": "token revoked"})
+	case errors.Is(err, errTokenExpired), errors.Is(err, jwt.ErrTokenExpired):
+		c.JSON(401, gin.H{"error": "token expired"})
+	default:
+		c.JSON(401, gin.H{"error": "invalid token"})
+	}
+	c.Abort()
+	return true
+}
+
+func VerifyTokenHandler(c *gin.Context) {
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		c.JSON(401, gin.H{"error": "missing token"})
+		return
+	}
+
+	claims, err := checkAccessToken(c, bearerToken(token))
+	if authFailed(c, err) {
+		return
+	}
+
+	c.JSON(200, api.TokenInfo{
+		UserID:   claims.UserID,
+		Username: claims.Username,
+		Status:   "valid",
+	})
+}
+
+// JWKSHandler publishes the public keys that verify access tokens, so
+// other services can check them without calling /verify-token. A token
+// that checks out may still have been revoked by logging out.
+func JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, auth.PublicKeys())
+}
+
+// issueTokens creates an access token and a refresh token for userID. The
+// refresh token joins familyID, or starts a new family when it is nil.
+func issueTokens(c *gin.Context, tx pgx.Tx, userID int64, username string, familyID *int64) (api.TokenPair, error) {
+	var generation int64
+	err := tx.QueryRow(c, "SELECT token_generation FROM users WHERE id=$1", userID).Scan(&generation)
+	if err != nil {
+		return api.TokenPair{}, err
+	}
+
+	access, claims, err := auth.GenerateJWT(userID, username, generation)
+	if err != nil {
+		return api.TokenPair{}, err
+	}
+	refresh, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return api.TokenPair{}, err
+	}
+	refreshExpires := time.Now().Add(auth.RefreshTokenTTL)
+
+	_, err = tx.Exec(c,
+		`INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, generation, expires_at, user_agent, ip)
+		 SELECT n, $1, COALESCE($2, n), $3, $4, $5, $6, $7 FROM nextval('refresh_tokens_id_seq') AS n`,
+		userID, familyID, hash, generation, refreshExpires, c.Request.UserAgent(), c.ClientIP(),
+	)
+	if err != nil {
+		return api.TokenPair{}, err
+	}
+	_, _ = tx.Exec(c, "DELETE FROM refresh_tokens WHERE user_id=$1 AND expires_at < now()", userID)
+
+	return api.TokenPair{
+		Token:            access,
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExpires,
+	}, nil
+}
+
+// RefreshHandler exchanges a refresh token for a new access token and a new
+// refresh token. A refresh token works once: presenting one that was
+// already exchanged revokes its whole session, since either it or its
+// successor is in the wrong hands.
+func (h *Handler) RefreshHandler(c *gin.Context) {
+	var body api.RefreshRequest
+	if err := c.BindJSON(&body); err != nil || body.RefreshToken == "" {
+		c.JSON(400, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to refresh token"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	var id, userID, familyID, generation, currentGeneration int64
+	var expiresAt time.Time
+	var revokedAt *time.Time
+	var username string
+	err = tx.QueryRow(c,
+		`SELECT rt.id, rt.user_id, rt.family_id, rt.generation, rt.expires_at, rt.revoked_at, u.username, u.token_generation
+		 FROM refresh_tokens rt JOIN users u ON u.id = rt.user_id
+		 WHERE rt.token_hash=$1 FOR UPDATE OF rt`,
+		auth.HashToken(body.RefreshToken),
+	).Scan(&id, &userID, &familyID, &generation, &expiresAt, &revokedAt, &username, &currentGeneration)
+	if err == pgx.ErrNoRows {
+		c.JSON(401, gin.H{"error": "invalid refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to refresh token"})
+		return
+	}
+
+	if revokedAt != nil {
+		_, err = tx.Exec(c,
+			"UPDATE refresh_tokens SET revoked_at=now() WHERE family_id=$1 AND revoked_at IS NULL",
+			familyID,
+		)
+		if err == nil {
+			meta, _ := json.Marshal(map[string]any{"family_id": familyID, "ip": c.ClientIP()})
+			_, _ = tx.Exec(c,
+				"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+				userID, "refresh_token_reuse", "user", userID, string(meta),
+			)
+			err = tx.Commit(c)
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to refresh token"})
+			return
+		}
+		c.JSON(401, gin.H{"error": "refresh token already used, session revoked"})
+		return
+	}
+	if generation != currentGeneration {
+		c.JSON(401, gin.H{"error": "session revoked"})
+		return
+	}
+	if time.Now().After(expiresAt) {
+		c.JSON(401, gin.H{"error": "refresh token expired"})
+		return
+	}
+
+	if _, err := tx.Exec(c, "UPDATE refresh_tokens SET revoked_at=now() WHERE id=$1", id); err != nil {
+		c.JSON(500, gin.H{"error": "failed to refresh token"})
+		return
+	}
+	tokens, err := issueTokens(c, tx, userID, username, &familyID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to refresh token"})
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		c.JSON(500, gin.H{"error": "failed to refresh token"})
+		return
+	}
+
+	c.JSON(200, tokens)
+}
+
+// LogoutHandler revokes the access token it is called with and, when the
+// body carries one, the session of a refresh token.
+func (h *Handler) LogoutHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	claims := c.MustGet("claims").(*auth.Claims)
+
+	var body api.LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(400, gin.H{"error": "invalid request"})
+			return
+		}
+	}
+
+	_, err := db.Pool.Exec(c,
+		"INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1,$2,$3) ON CONFLICT (jti) DO NOTHING",
+		claims.ID, userID, claims.ExpiresAt.Time,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to log out"})
+		return
+	}
+	if body.RefreshToken != "" {
+		_, err = db.Pool.Exec(c,
+			`UPDATE refresh_tokens SET revoked_at=now()
+			 WHERE user_id=$1 AND revoked_at IS NULL
+			   AND family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash=$2 AND user_id=$1)`,
+			userID, auth.HashToken(body.RefreshToken),
+		)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to log out"})
+			return
+		}
+	}
+	_, _ = db.Pool.Exec(c, "DELETE FROM revoked_tokens WHERE expires_at < now()")
+
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "logout", "user", userID,
+	)
+
+	c.JSON(200, api.Message{Message: "logged out"})
+}
+
+// LogoutAllHandler revokes every access and refresh token of the caller,
+// on all devices.
+func (h *Handler) LogoutAllHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to log out"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	if err := revokeSessions(c, tx, userID); err != nil {
+		c.JSON(500, gin.H{"error": "failed to log out"})
+		return
+	}
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "logout_all", "user", userID,
+	)
+	if err := tx.Commit(c); err != nil {
+		c.JSON(500, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	c.JSON(200, api.Message{Message: "logged out everywhere"})
+}
+
+// revokeSessions invalidates all of a user's access tokens, by bumping
+// their token generation, and all of their refresh tokens, and makes
+// WebDAV check their credentials again. Anything that changes a user's
+// credentials should call it.
+func revokeSessions(c *gin.Context, tx pgx.Tx, userID int64) error {
+	forgetDAVCredentials(userID)
+	_, err := tx.Exec(c, "UPDATE users SET token_generation = token_generation + 1 WHERE id=$1", userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(c,
+		"UPDATE refresh_tokens SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL",
+		userID,
+	)
+	return err
+}
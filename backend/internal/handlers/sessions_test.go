@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
+)
+
+func TestExpiredAccessToken(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.LoadKeys(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err != nil {
+		t.Fatal(err)
+	}
+	ttl := auth.AccessTokenTTL
+	auth.AccessTokenTTL = -time.Minute
+	token, _, err := auth.GenerateJWT(1, "alice", 0)
+	auth.AccessTokenTTL = ttl
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, route := range []string{"/files", "/verify-token"} {
+		req := httptest.NewRequest("GET", route, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		handler := AuthMiddleware
+		if route == "/verify-token" {
+			handler = VerifyTokenHandler
+		}
+		w := serveRequest(0, handler, route, req)
+
+		var body struct{ Error string }
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != 401 || body.Error != "token expired" {
+			t.Errorf("%s with an expired token: got %d %q, want 401 \"token expired\"", route, w.Code, body.Error)
+		}
+	}
+}
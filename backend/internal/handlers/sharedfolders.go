@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
+)
+
+// Folder links share a folder and everything under it as it is when they
+// are visited, leaving out the trash. GET /s/:token/tree lists the
+// subtree; GET /s/:token/zip streams it as a ZIP, or just the files and
+// folders picked with repeated file and folder query parameters. The ZIP
+// is written as its blobs are read, so nothing is spooled to disk, its
+// size is not known up front, and a blob that fails part way cuts the
+// archive short, which unzipping then reports. A ZIP takes one of the
+// link's downloads.
+
+// sharedTree is a recursive CTE of folder $1 and the folders below it that
+// are not in the trash, with their depth. It is empty once folder $1 is in
+// the trash. Rows with looped set close a loop in parent_id and are left
+// out.
+const sharedTree = `tree AS (
+	SELECT id, parent_id, name, 0 AS depth FROM folders WHERE id=$1 AND trashed=false
+	UNION ALL
+	SELECT f.id, f.parent_id, f.name, tree.depth + 1
+	FROM folders f JOIN tree ON f.parent_id = tree.id
+	WHERE f.trashed=false
+) CYCLE id SET looped USING visited`
+
+// sharedFile is a file under a shared folder and the blob it reads from.
+type sharedFile struct {
+	api.SharedFolderFile
+	blobKey string
+}
+
+// zipName makes name safe as one step of a path in a ZIP.
+func zipName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	if name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+// uniqueZipPath returns dir+name, unless an entry already took that path,
+// as zipName can make different names equal and a folder and a file may
+// share a name; then " (id)" goes before ext, name's extension, until the
+// path is free. The path returned is marked taken.
+func uniqueZipPath(taken map[string]bool, dir, name, ext string, id int64) string {
+	p := dir + name
+	for taken[p] {
+		name = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), id, ext)
+		p = dir + name
+	}
+	taken[p] = true
+	return p
+}
+
+// loadSharedTree returns the name of folder id and the folders and files
+// under it, sorted by their paths relative to it, so parents come before
+// what they hold. Paths are unique; folders keep their names over files,
+// and earlier entries over later ones. It returns pgx.ErrNoRows when the
+// folder is gone or in the trash.
+func loadSharedTree(ctx context.Context, id int64) (string, []api.SharedSubfolder, []sharedFile, error) {
+	rows, err := db.Pool.Query(ctx,
+		"WITH RECURSIVE "+sharedTree+" SELECT id, COALESCE(parent_id, 0), name, depth FROM tree WHERE NOT looped ORDER BY depth, id",
+		id,
+	)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var name string
+	paths := map[int64]string{}
+	taken := map[string]bool{}
+	folders := []api.SharedSubfolder{}
+	for rows.Next() {
+		var f api.SharedSubfolder
+		var depth int
+		if err := rows.Scan(&f.ID, &f.ParentID, &f.Name, &depth); err != nil {
+			rows.Close()
+			return "", nil, nil, err
+		}
+		if depth == 0 {
+			name, paths[f.ID] = f.Name, ""
+			continue
+		}
+		f.Path = uniqueZipPath(taken, paths[f.ParentID], zipName(f.Name), "", f.ID) + "/"
+		paths[f.ID] = f.Path
+		folders = append(folders, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", nil, nil, err
+	}
+	if len(paths) == 0 {
+		return "", nil, nil, pgx.ErrNoRows
+	}
+
+	rows, err = db.Pool.Query(ctx,
+		`WITH RECURSIVE `+sharedTree+`
+		 SELECT f.id, f.folder_id, f.filename, f.size, COALESCE(f.mime_type, ''), f.created_at, b.path
+		 FROM files f JOIN tree ON f.folder_id = tree.id AND NOT tree.looped JOIN blobs b ON b.id = f.blob_id
+		 WHERE f.trashed=false
+		 ORDER BY f.id`,
+		id,
+	)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer rows.Close()
+	files := []sharedFile{}
+	for rows.Next() {
+		var f sharedFile
+		if err := rows.Scan(&f.ID, &f.FolderID, &f.Filename, &f.Size, &f.MIMEType, &f.CreatedAt, &f.blobKey); err != nil {
+			return "", nil, nil, err
+		}
+		file := zipName(f.Filename)
+		f.Path = uniqueZipPath(taken, paths[f.FolderID], file, path.Ext(file), f.ID)
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, nil, err
+	}
+
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Path < folders[j].Path })
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return name, folders, files, nil
+}
+
+// CreateFolderShareHandler makes a link to a folder, taking the same
+// options as CreateShareHandler.
+func (h *Handler) CreateFolderShareHandler(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	opts, ok := readShareOptions(c)
+	if !ok {
+		return
+	}
+
+	if !requireVerifiedEmail(c) {
+		return
+	}
+	if _, ok := authorizeFolder(c, c.GetInt64("user_id"), id, actionShare); !ok {
+		return
+	}
+
+	var name string
+	err := db.Pool.QueryRow(c, "SELECT name FROM folders WHERE id=$1 AND trashed=false", id).Scan(&name)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "folder not found"})
+		return
+	}
+
+	createShare(c, shareTarget{ID: id, Folder: true, Name: name}, opts)
+}
+
+// ListFolderSharesHandler lists a folder's links, to those who may share
+// it.
+func (h *Handler) ListFolderSharesHandler(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := authorizeFolder(c, c.GetInt64("user_id"), id, actionShare); !ok {
+		return
+	}
+	listShares(c, "s.folder_id=$1", id)
+}
+
+// SharedFolderHandler lists what a folder link shares.
+func (h *Handler) SharedFolderHandler(c *gin.Context) {
+	token := c.Param("token")
+	s, email, ok := openFolderShare(c, token)
+	if !ok {
+		return
+	}
+
+	name, folders, files, err := loadSharedTree(c, *s.FolderID)
+	if err == pgx.ErrNoRows {
+		c.JSON(404, gin.H{"error": "invalid or expired link"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list folder"})
+		return
+	}
+
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		nil, "view_share", "share", s.ID, publicAuditMeta(c, s, email, map[string]any{}),
+	)
+
+	out := api.SharedFolder{
+		FolderID:      *s.FolderID,
+		Name:          name,
+		AllowDownload: s.AllowDownload,
+		Folders:       folders,
+		Files:         make([]api.SharedFolderFile, len(files)),
+		DownloadURL:   shareURL(token) + "/zip",
+		DownloadsLeft: s.downloadsLeft(),
+	}
+	for i, f := range files {
+		out.Files[i] = f.SharedFolderFile
+	}
+	c.JSON(200, out)
+}
+
+// queryIDs reads the ids given in the repeated query parameter key,
+// answering 400 for one that is not a number.
+func queryIDs(c *gin.Context, key string) (map[int64]bool, bool) {
+	ids := map[int64]bool{}
+	for _, v := range c.QueryArray(key) {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid %s id %q", key, v)})
+			return nil, false
+		}
+		ids[id] = true
+	}
+	return ids, true
+}
+
+// zipMethod stores media and archives, which deflating hardly shrinks, and
+// deflates everything else.
+func zipMethod(mimeType string) uint16 {
+	for _, prefix := range []string{"image/", "video/", "audio/"} {
+		if strings.HasPrefix(mimeType, prefix) && mimeType != "image/svg+xml" {
+			return zip.Store
+		}
+	}
+	for _, kind := range []string{"zip", "compressed", "gzip", "zstd"} {
+		if strings.Contains(mimeType, kind) {
+			return zip.Store
+		}
+	}
+	return zip.Deflate
+}
+
+func writeZipFile(ctx context.Context, zw *zip.Writer, blobs storage.BlobStore, f sharedFile) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Path, Method: zipMethod(f.MIMEType), Modified: f.CreatedAt})
+	if err != nil {
+		return err
+	}
+	r, err := blobs.Get(ctx, f.blobKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// DownloadSharedFolderHandler streams a folder link's subtree, or the files
+// and folders picked from it, as a ZIP.
+func (h *Handler) DownloadSharedFolderHandler(c *gin.Context) {
+	s, email, ok := openFolderShare(c, c.Param("token"))
+	if !ok {
+		return
+	}
+	if !s.AllowDownload {
+		c.JSON(403, gin.H{"error": "download not allowed"})
+		return
+	}
+	pickedFiles, ok := queryIDs(c, "file")
+	if !ok {
+		return
+	}
+	pickedFolders, ok := queryIDs(c, "folder")
+	if !ok {
+		return
+	}
+
+	rootID := *s.FolderID
+	name, folders, files, err := loadSharedTree(c, rootID)
+	if err == pgx.ErrNoRows {
+		c.JSON(404, gin.H{"error": "invalid or expired link"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list folder"})
+		return
+	}
+
+	// A folder is in the ZIP when it or a folder above it was picked, and
+	// a file when it or its folder is; nothing picked means everything.
+	included := map[int64]bool{rootID: (len(pickedFiles) == 0 && len(pickedFolders) == 0) || pickedFolders[rootID]}
+	for _, f := range folders {
+		included[f.ID] = pickedFolders[f.ID] || included[f.ParentID]
+	}
+	for id := range pickedFolders {
+		if _, ok := included[id]; !ok {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("folder %d is not in this shared folder", id)})
+			return
+		}
+	}
+	var zipped []sharedFile
+	var fileIDs []int64
+	var size int64
+	for _, f := range files {
+		if included[f.FolderID] || pickedFiles[f.ID] {
+			zipped = append(zipped, f)
+			fileIDs = append(fileIDs, f.ID)
+			size += f.Size
+		}
+		delete(pickedFiles, f.ID)
+	}
+	for id := range pickedFiles {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("file %d is not in this shared folder", id)})
+		return
+	}
+
+	res, err := db.Pool.Exec(c,
+		`UPDATE shares SET download_count = download_count + 1
+		 WHERE id=$1 AND (max_downloads IS NULL OR download_count < max_downloads)`,
+		s.ID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to count download"})
+		return
+	}
+	if res.RowsAffected() == 0 {
+		c.JSON(410, gin.H{"error": "download limit reached"})
+		return
+	}
+
+	_, _ = db.Pool.Exec(c, "UPDATE files SET download_count = download_count + 1 WHERE id = ANY($1)", fileIDs)
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		nil, "download_folder_public", "folder", rootID,
+		publicAuditMeta(c, s, email, map[string]any{"folder": name, "files": len(zipped), "bytes": size}),
+	)
+
+	broadcastUpdate(c, gin.H{
+		"event":     "download",
+		"folder_id": rootID,
+		"folder":    name,
+		"files":     len(zipped),
+		"public":    true,
+		"ts":        time.Now(),
+	})
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".zip"}))
+	c.Status(200)
+
+	zw := zip.NewWriter(c.Writer)
+	for _, f := range folders {
+		if !included[f.ID] {
+			continue
+		}
+		if _, err := zw.CreateHeader(&zip.FileHeader{Name: f.Path, Method: zip.Store}); err != nil {
+			log.Printf("zipping share %d: %v", s.ID, err)
+			return
+		}
+	}
+	for _, f := range zipped {
+		if err := writeZipFile(c, zw, h.Blobs, f); err != nil {
+			// Leaving out the central directory marks the ZIP as broken.
+			log.Printf("zipping share %d: %v", s.ID, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("zipping share %d: %v", s.ID, err)
+	}
+}
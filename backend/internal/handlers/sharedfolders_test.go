@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestUniqueZipPath(t *testing.T) {
+	taken := map[string]bool{}
+	for _, c := range []struct {
+		name, ext string
+		id        int64
+		want      string
+	}{
+		{"x", "", 1, "dir/x"},
+		{"x", "", 2, "dir/x (2)"},
+		{"a.txt", ".txt", 3, "dir/a.txt"},
+		{"a.txt", ".txt", 4, "dir/a (4).txt"},
+		{"a (4).txt", ".txt", 4, "dir/a (4) (4).txt"},
+	} {
+		if got := uniqueZipPath(taken, "dir/", c.name, c.ext, c.id); got != c.want {
+			t.Errorf("uniqueZipPath(%q, %d) = %q, want %q", c.name, c.id, got, c.want)
+		}
+	}
+}
+
+// storedFile creates a file holding content, owned by ownerID, in folderID.
+func storedFile(t *testing.T, h *Handler, ownerID, folderID int64, name, content string) int64 {
+	t.Helper()
+	key := fmt.Sprintf("blob-%d-%s", folderID, strings.ReplaceAll(name, "/", "_"))
+	if err := h.Blobs.Put(context.Background(), key, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+	blob := insertID(t, "INSERT INTO blobs (hash, size, path, ref_count) VALUES ($1,$2,$1,1) RETURNING id", key, len(content))
+	return insertID(t,
+		"INSERT INTO files (blob_id, owner_id, filename, size, folder_id, mime_type) VALUES ($1,$2,$3,$4,$5,'text/plain') RETURNING id",
+		blob, ownerID, name, len(content), folderID,
+	)
+}
+
+// readZip returns the entries of a ZIP and the content of its files.
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("reading the ZIP: %v", err)
+	}
+	entries := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		entries[f.Name] = string(content)
+	}
+	return entries
+}
+
+func TestSharedFolderZip(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	root := testFolder(t, alice, "project", 0)
+	sub := testFolder(t, alice, "sub", root)
+	x := testFolder(t, alice, "x", root)
+	top := storedFile(t, h, alice, root, "top.txt", "top")
+	inSub := storedFile(t, h, alice, sub, "b.txt", "b")
+	storedFile(t, h, alice, x, "c.txt", "c")
+	namedX := storedFile(t, h, alice, root, "x", "file named x")
+	storedFile(t, h, alice, root, "p/q", "slash")
+	underscore := storedFile(t, h, alice, root, "p_q", "underscore")
+	insertID(t, "INSERT INTO shares (folder_id, token, allow_download) VALUES ($1,'tok',true) RETURNING id", root)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		return serveRequest(0, h.DownloadSharedFolderHandler, "/s/:token/zip", httptest.NewRequest("GET", "/s/tok/zip"+query, nil))
+	}
+	names := func(entries map[string]string) []string {
+		var names []string
+		for name := range entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	w := get("")
+	if w.Code != 200 {
+		t.Fatalf("downloading the folder: got %d %s", w.Code, w.Body)
+	}
+	// "p/q" comes first and keeps "p_q"; the file x gives way to the
+	// folder.
+	entries := readZip(t, w.Body.Bytes())
+	want := []string{
+		"p_q", fmt.Sprintf("p_q (%d)", underscore), "sub/", "sub/b.txt", "top.txt",
+		fmt.Sprintf("x (%d)", namedX), "x/", "x/c.txt",
+	}
+	if got := names(entries); !slices.Equal(got, want) {
+		t.Errorf("the ZIP holds %v, want %v", got, want)
+	}
+	if entries["p_q"] != "slash" || entries["x/c.txt"] != "c" {
+		t.Error("the ZIP holds the wrong content")
+	}
+
+	w = get(fmt.Sprintf("?folder=%d&file=%d", sub, top))
+	if w.Code != 200 {
+		t.Fatalf("downloading a selection: got %d %s", w.Code, w.Body)
+	}
+	if got := names(readZip(t, w.Body.Bytes())); !slices.Equal(got, []string{"sub/", "sub/b.txt", "top.txt"}) {
+		t.Errorf("the selection holds %v, want [sub/ sub/b.txt top.txt]", got)
+	}
+	w = get(fmt.Sprintf("?file=%d", inSub))
+	if got := names(readZip(t, w.Body.Bytes())); !slices.Equal(got, []string{"sub/b.txt"}) {
+		t.Errorf("picking one file holds %v, want [sub/b.txt]", got)
+	}
+
+	if w := get("?file=999999"); w.Code != 404 {
+		t.Errorf("picking a file outside the folder: got %d, want 404", w.Code)
+	}
+	if w := get("?folder=abc"); w.Code != 400 {
+		t.Errorf("picking a folder by a bad id: got %d, want 400", w.Code)
+	}
+}
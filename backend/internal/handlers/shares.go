@@ -0,0 +1,666 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// Share links can be protected. A link with a password, or one that asks
+// for the visitor's email, answers 401 until POST /s/:token/unlock passes;
+// that sets a cookie, scoped to the link, which opens it for
+// shareUnlockTTL. The emails links are unlocked with are audited as
+// unlock_share and listed to whoever manages the link. A link can also
+// cap its downloads; DownloadShareHandler takes one at a time with a
+// single conditional UPDATE, so concurrent downloads cannot overshoot.
+//
+// Visits are audited with the share's id, the visitor's IP and referring
+// site in meta, and ShareStatsHandler sums them up per link.
+//
+// A link points at a file or, made with POST /folders/:id/share, at a
+// folder; see sharedfolders.go for what a folder link serves. The file
+// routes under /s/:token answer 404 for folder links and the other way
+// round.
+
+const (
+	shareUnlockCookie = "share_unlock"
+	shareUnlockTTL    = time.Hour
+	// maxShareExpiry bounds expiries, in hours, to 100 years.
+	maxShareExpiry = 100 * 365 * 24
+)
+
+func shareURL(token string) string {
+	return "http://localhost:8080/s/" + token
+}
+
+// shareExpiry turns an expiry in hours into the time a link expires, nil
+// for 0.
+func shareExpiry(hours float64) (*time.Time, error) {
+	if !(hours >= 0 && hours <= maxShareExpiry) {
+		return nil, fmt.Errorf("expiry must be a number of hours from 0 to %d", maxShareExpiry)
+	}
+	if hours == 0 {
+		return nil, nil
+	}
+	t := time.Now().Add(time.Duration(hours * float64(time.Hour)))
+	return &t, nil
+}
+
+// parseShareExpiry parses the expiry query parameter of POST /share/:id,
+// a positive number of hours or empty for none.
+func parseShareExpiry(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	hours, err := strconv.ParseFloat(s, 64)
+	if err != nil || hours == 0 {
+		return nil, fmt.Errorf("expiry must be a positive number of hours, got %q", s)
+	}
+	return shareExpiry(hours)
+}
+
+// shareColumns are the columns scanShare reads, from shareTables.
+const shareColumns = `s.id, s.token, COALESCE(s.file_id, 0), COALESCE(f.filename, ''), s.folder_id, COALESCE(d.name, ''),
+	s.expires_at, COALESCE(s.allow_download, false), s.password_hash IS NOT NULL, s.require_email,
+	s.max_downloads, s.download_count, s.created_by, s.created_at`
+
+// shareTables are shares s joined to the file f or folder d they point at.
+const shareTables = `shares s LEFT JOIN files f ON s.file_id = f.id LEFT JOIN folders d ON s.folder_id = d.id`
+
+func scanShare(row pgx.Row) (api.Share, error) {
+	var s api.Share
+	var token string
+	err := row.Scan(&s.ID, &token, &s.FileID, &s.Filename, &s.FolderID, &s.FolderName,
+		&s.ExpiresAt, &s.AllowDownload, &s.HasPassword, &s.RequireEmail,
+		&s.MaxDownloads, &s.Downloads, &s.CreatedBy, &s.CreatedAt)
+	s.ShareURL = shareURL(token)
+	s.Expired = s.ExpiresAt != nil && time.Now().After(*s.ExpiresAt)
+	return s, err
+}
+
+// shareObject is the audit object of share s: its file or folder.
+func shareObject(s api.Share) (string, int64) {
+	if s.FolderID != nil {
+		return "folder", *s.FolderID
+	}
+	return "file", s.FileID
+}
+
+// manageShare loads share id for userID, answering 404 when it does not
+// exist and 403 unless they may share its file or folder.
+func manageShare(c *gin.Context, userID, id int64) (api.Share, bool) {
+	s, err := scanShare(db.Pool.QueryRow(c,
+		"SELECT "+shareColumns+" FROM "+shareTables+" WHERE s.id=$1", id,
+	))
+	if err == pgx.ErrNoRows {
+		c.JSON(404, gin.H{"error": "share not found"})
+		return s, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to load share"})
+		return s, false
+	}
+	if s.FolderID != nil {
+		_, ok := authorizeFolder(c, userID, *s.FolderID, actionShare)
+		return s, ok
+	}
+	_, ok := authorize(c, userID, s.FileID, actionShare)
+	return s, ok
+}
+
+// shareOptions are the settings of a new link.
+type shareOptions struct {
+	ExpiresAt     *time.Time
+	AllowDownload bool
+	PasswordHash  *string
+	MaxDownloads  *int
+	RequireEmail  bool
+}
+
+// readShareOptions reads the expiry and download query parameters and the
+// optional api.CreateShareRequest body of a new link, answering 400 when
+// they are invalid.
+func readShareOptions(c *gin.Context) (shareOptions, bool) {
+	var o shareOptions
+	expiresAt, err := parseShareExpiry(c.Query("expiry"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return o, false
+	}
+	o.ExpiresAt = expiresAt
+	o.AllowDownload = c.Query("download") == "true"
+
+	var body api.CreateShareRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(400, gin.H{"error": "invalid request"})
+			return o, false
+		}
+	}
+	if len(body.Password) > 72 {
+		c.JSON(400, gin.H{"error": "password must be at most 72 bytes"})
+		return o, false
+	}
+	if body.MaxDownloads != nil && *body.MaxDownloads < 1 {
+		c.JSON(400, gin.H{"error": "max_downloads must be at least 1"})
+		return o, false
+	}
+	if body.Password != "" {
+		hash, err := auth.HashPassword(body.Password)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to hash password"})
+			return o, false
+		}
+		o.PasswordHash = &hash
+	}
+	o.MaxDownloads = body.MaxDownloads
+	o.RequireEmail = body.RequireEmail
+	return o, true
+}
+
+// shareTarget is what a new link points at: file ID, or folder ID when
+// Folder is set. Name is its filename or folder name.
+type shareTarget struct {
+	ID     int64
+	Folder bool
+	Name   string
+}
+
+// createShare makes a link to t with o, for the caller, and responds with
+// it.
+func createShare(c *gin.Context, t shareTarget, o shareOptions) {
+	token, err := generateToken()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	s := api.Share{
+		ExpiresAt:     o.ExpiresAt,
+		AllowDownload: o.AllowDownload,
+		HasPassword:   o.PasswordHash != nil,
+		RequireEmail:  o.RequireEmail,
+		MaxDownloads:  o.MaxDownloads,
+		CreatedBy:     &userID,
+	}
+	var fileID, folderID *int64
+	if t.Folder {
+		folderID, s.FolderID, s.FolderName = &t.ID, &t.ID, t.Name
+	} else {
+		fileID, s.FileID, s.Filename = &t.ID, t.ID, t.Name
+	}
+	err = db.Pool.QueryRow(c,
+		`INSERT INTO shares (file_id, folder_id, token, expires_at, allow_download, password_hash, max_downloads,
+		   require_email, created_by)
+		 VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING id, created_at`,
+		fileID, folderID, token, o.ExpiresAt, o.AllowDownload, o.PasswordHash, o.MaxDownloads, o.RequireEmail, userID,
+	).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create share"})
+		return
+	}
+	s.ShareURL = shareURL(token)
+
+	objectType, objectID := shareObject(s)
+	meta, _ := json.Marshal(map[string]any{"share_url": s.ShareURL, "share_id": s.ID})
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "create_share", objectType, objectID, auditMeta(c, string(meta)),
+	)
+
+	broadcastUpdate(c, gin.H{
+		"event":            objectType + "_shared",
+		objectType + "_id": objectID,
+		"share_url":        s.ShareURL,
+		"allow_download":   s.AllowDownload,
+		"expires_at":       s.ExpiresAt,
+		"timestamp":        time.Now(),
+	})
+
+	c.JSON(200, s)
+}
+
+// shareLink is a share as its public routes check it. FileID is 0 for a
+// folder link.
+type shareLink struct {
+	ID            int64
+	FileID        int64
+	FolderID      *int64
+	ExpiresAt     *time.Time
+	AllowDownload bool
+	PasswordHash  *string
+	RequireEmail  bool
+	MaxDownloads  *int
+	Downloads     int
+}
+
+func (s shareLink) protected() bool {
+	return s.PasswordHash != nil || s.RequireEmail
+}
+
+// downloadsLeft is nil for a link without a download cap.
+func (s shareLink) downloadsLeft() *int {
+	if s.MaxDownloads == nil {
+		return nil
+	}
+	left := max(*s.MaxDownloads-s.Downloads, 0)
+	return &left
+}
+
+// loadShare looks up the link with token, answering 404 for an unknown
+// token and 410 once it has expired.
+func loadShare(c *gin.Context, token string) (shareLink, bool) {
+	var s shareLink
+	err := db.Pool.QueryRow(c,
+		`SELECT id, COALESCE(file_id, 0), folder_id, expires_at, COALESCE(allow_download, false), password_hash,
+		   require_email, max_downloads, download_count
+		 FROM shares WHERE token=$1`,
+		token,
+	).Scan(&s.ID, &s.FileID, &s.FolderID, &s.ExpiresAt, &s.AllowDownload, &s.PasswordHash, &s.RequireEmail,
+		&s.MaxDownloads, &s.Downloads)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "invalid or expired link"})
+		return s, false
+	}
+	if s.ExpiresAt != nil && time.Now().After(*s.ExpiresAt) {
+		c.JSON(410, gin.H{"error": "link expired"})
+		return s, false
+	}
+	return s, true
+}
+
+// openShare is loadShare for the routes that show the shared file: a
+// protected link also answers 401 without a live unlock cookie. It returns
+// the email the link was unlocked with, if any.
+func openShare(c *gin.Context, token string) (shareLink, string, bool) {
+	s, ok := loadShare(c, token)
+	if !ok || !s.protected() {
+		return s, "", ok
+	}
+	var email *string
+	cookie, _ := c.Cookie(shareUnlockCookie)
+	err := db.Pool.QueryRow(c,
+		"SELECT email FROM share_unlocks WHERE token_hash=$1 AND share_id=$2 AND expires_at > now()",
+		auth.HashToken(cookie), s.ID,
+	).Scan(&email)
+	if err != nil {
+		c.JSON(401, api.Error{
+			Error:            "this link is protected, unlock it first",
+			PasswordRequired: s.PasswordHash != nil,
+			EmailRequired:    s.RequireEmail,
+		})
+		return s, "", false
+	}
+	if email == nil {
+		return s, "", true
+	}
+	return s, *email, true
+}
+
+// openFileShare is openShare for the routes of a file link.
+func openFileShare(c *gin.Context, token string) (shareLink, string, bool) {
+	s, email, ok := openShare(c, token)
+	if ok && s.FolderID != nil {
+		c.JSON(404, gin.H{"error": "this link is to a folder, see /s/" + token + "/tree"})
+		return s, email, false
+	}
+	return s, email, ok
+}
+
+// openFolderShare is openShare for the routes of a folder link.
+func openFolderShare(c *gin.Context, token string) (shareLink, string, bool) {
+	s, email, ok := openShare(c, token)
+	if ok && s.FolderID == nil {
+		c.JSON(404, gin.H{"error": "this link is to a file, see /s/" + token})
+		return s, email, false
+	}
+	return s, email, ok
+}
+
+// publicAuditMeta is the meta of an audit entry about a visit to share s
+// by someone who unlocked it with email, if any.
+func publicAuditMeta(c *gin.Context, s shareLink, email string, meta map[string]any) string {
+	meta["share_id"] = s.ID
+	meta["ip"] = c.ClientIP()
+	meta["referrer"] = referrerSite(c.Request.Referer())
+	if email != "" {
+		meta["email"] = email
+	}
+	data, _ := json.Marshal(meta)
+	return string(data)
+}
+
+// referrerSite is the scheme and host of a Referer header, or "direct".
+// Paths are left out; they can carry private data and scatter the counts.
+func referrerSite(referer string) string {
+	u, err := url.Parse(referer)
+	if referer == "" || err != nil || u.Host == "" {
+		return "direct"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// UnlockShareHandler checks a protected link's password and takes the
+// visitor's email, as the link requires, and sets the cookie that opens
+// it.
+func (h *Handler) UnlockShareHandler(c *gin.Context) {
+	token := c.Param("token")
+	var body api.UnlockShareRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	s, ok := loadShare(c, token)
+	if !ok {
+		return
+	}
+	if !s.protected() {
+		c.JSON(400, gin.H{"error": "this link is not protected"})
+		return
+	}
+
+	var email *string
+	if s.RequireEmail {
+		e := strings.TrimSpace(body.Email)
+		if !validateEmail(e) {
+			c.JSON(400, api.Error{Error: "a valid email is required", EmailRequired: true, PasswordRequired: s.PasswordHash != nil})
+			return
+		}
+		email = &e
+	}
+	if s.PasswordHash != nil && !auth.CheckPasswordHash(body.Password, *s.PasswordHash) {
+		c.JSON(403, api.Error{Error: "incorrect password", PasswordRequired: true, EmailRequired: s.RequireEmail})
+		return
+	}
+
+	unlock, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to unlock link"})
+		return
+	}
+	expiresAt := time.Now().Add(shareUnlockTTL)
+	_, _ = db.Pool.Exec(c, "DELETE FROM share_unlocks WHERE expires_at < now()")
+	_, err = db.Pool.Exec(c,
+		"INSERT INTO share_unlocks (token_hash, share_id, email, expires_at) VALUES ($1,$2,$3,$4)",
+		hash, s.ID, email, expiresAt,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to unlock link"})
+		return
+	}
+
+	visitor := ""
+	if email != nil {
+		visitor = *email
+	}
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		nil, "unlock_share", "share", s.ID, publicAuditMeta(c, s, visitor, map[string]any{}),
+	)
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(shareUnlockCookie, unlock, int(shareUnlockTTL.Seconds()), "/s/"+token, "", c.Request.TLS != nil, true)
+	c.JSON(200, api.ShareUnlocked{Message: "link unlocked", ExpiresAt: expiresAt})
+}
+
+// ShareViewersHandler lists the emails a link was unlocked with, to those
+// who may share what it points at.
+func (h *Handler) ShareViewersHandler(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := manageShare(c, c.GetInt64("user_id"), id); !ok {
+		return
+	}
+
+	rows, err := db.Pool.Query(c,
+		`SELECT meta->>'email', count(*), min(created_at), max(created_at)
+		 FROM audit_logs
+		 WHERE action='unlock_share' AND object_type='share' AND object_id=$1 AND meta->>'email' IS NOT NULL
+		 GROUP BY meta->>'email'
+		 ORDER BY max(created_at) DESC`,
+		id,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list viewers"})
+		return
+	}
+	defer rows.Close()
+
+	viewers := []api.ShareViewer{}
+	for rows.Next() {
+		var v api.ShareViewer
+		if err := rows.Scan(&v.Email, &v.Views, &v.FirstViewedAt, &v.LastViewedAt); err != nil {
+			c.JSON(500, gin.H{"error": "failed to list viewers"})
+			return
+		}
+		viewers = append(viewers, v)
+	}
+	c.JSON(200, api.ShareViewerList{Viewers: viewers})
+}
+
+// listShares responds with the shares matching where, a condition on
+// shares s and files f, newest first.
+func listShares(c *gin.Context, where string, args ...any) {
+	rows, err := db.Pool.Query(c,
+		"SELECT "+shareColumns+" FROM "+shareTables+" WHERE "+where+" ORDER BY s.id DESC",
+		args...,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list shares"})
+		return
+	}
+	defer rows.Close()
+
+	shares := []api.Share{}
+	for rows.Next() {
+		s, err := scanShare(rows)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to list shares"})
+			return
+		}
+		shares = append(shares, s)
+	}
+	c.JSON(200, api.ShareList{Shares: shares})
+}
+
+// ListFileSharesHandler lists a file's links, to those who may share it.
+func (h *Handler) ListFileSharesHandler(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := authorize(c, c.GetInt64("user_id"), id, actionShare); !ok {
+		return
+	}
+	listShares(c, "s.file_id=$1", id)
+}
+
+// GetShareHandler returns a link, to those who may share what it points
+// at.
+func (h *Handler) GetShareHandler(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	s, ok := manageShare(c, c.GetInt64("user_id"), id)
+	if !ok {
+		return
+	}
+	c.JSON(200, s)
+}
+
+// UpdateShareHandler changes a link's expiry, download permission or
+// protection. Changing the password or the email requirement signs out
+// visitors who unlocked the link before.
+func (h *Handler) UpdateShareHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var body api.UpdateShareRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if body.Expiry != nil {
+		var err error
+		if expiresAt, err = shareExpiry(*body.Expiry); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var passwordHash *string
+	if body.Password != nil && *body.Password != "" {
+		if len(*body.Password) > 72 {
+			c.JSON(400, gin.H{"error": "password must be at most 72 bytes"})
+			return
+		}
+		hash, err := auth.HashPassword(*body.Password)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to hash password"})
+			return
+		}
+		passwordHash = &hash
+	}
+	var maxDownloads *int
+	if body.MaxDownloads != nil {
+		if *body.MaxDownloads < 0 {
+			c.JSON(400, gin.H{"error": "max_downloads must be 0, for no cap, or more"})
+			return
+		}
+		if *body.MaxDownloads > 0 {
+			maxDownloads = body.MaxDownloads
+		}
+	}
+
+	s, ok := manageShare(c, userID, id)
+	if !ok {
+		return
+	}
+
+	// Each field is only written when it was sent; the flags say which.
+	_, err := db.Pool.Exec(c,
+		`UPDATE shares SET
+		   expires_at     = CASE WHEN $2 THEN $3::timestamptz ELSE expires_at END,
+		   allow_download = COALESCE($4::boolean, allow_download),
+		   password_hash  = CASE WHEN $5 THEN $6::text ELSE password_hash END,
+		   max_downloads  = CASE WHEN $7 THEN $8::int ELSE max_downloads END,
+		   require_email  = COALESCE($9::boolean, require_email)
+		 WHERE id=$1`,
+		id, body.Expiry != nil, expiresAt, body.AllowDownload,
+		body.Password != nil, passwordHash, body.MaxDownloads != nil, maxDownloads, body.RequireEmail,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to update share"})
+		return
+	}
+	if body.Password != nil || (body.RequireEmail != nil && *body.RequireEmail != s.RequireEmail) {
+		_, _ = db.Pool.Exec(c, "DELETE FROM share_unlocks WHERE share_id=$1", id)
+	}
+
+	changed := map[string]any{"share_id": id}
+	if body.Expiry != nil {
+		changed["expires_at"] = expiresAt
+	}
+	if body.AllowDownload != nil {
+		changed["allow_download"] = *body.AllowDownload
+	}
+	if body.Password != nil {
+		changed["has_password"] = passwordHash != nil
+	}
+	if body.MaxDownloads != nil {
+		changed["max_downloads"] = maxDownloads
+	}
+	if body.RequireEmail != nil {
+		changed["require_email"] = *body.RequireEmail
+	}
+	meta, _ := json.Marshal(changed)
+	objectType, objectID := shareObject(s)
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "update_share", objectType, objectID, auditMeta(c, string(meta)),
+	)
+
+	s, ok = manageShare(c, userID, id)
+	if !ok {
+		return
+	}
+	c.JSON(200, s)
+}
+
+// ShareStatsHandler sums up the audited visits to a link, to those who may
+// share what it points at.
+func (h *Handler) ShareStatsHandler(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := manageShare(c, c.GetInt64("user_id"), id); !ok {
+		return
+	}
+
+	const visits = `FROM audit_logs
+		 WHERE meta ? 'share_id' AND meta->>'share_id' = $1::text
+		   AND action IN ('view_share', 'preview_file_public', 'download_file_public', 'download_folder_public',
+		     'unlock_share')`
+
+	stats := api.ShareStats{ShareID: id}
+	err := db.Pool.QueryRow(c,
+		`SELECT count(*) FILTER (WHERE action='view_share'),
+		   count(*) FILTER (WHERE action='preview_file_public'),
+		   count(*) FILTER (WHERE action IN ('download_file_public', 'download_folder_public')),
+		   count(*) FILTER (WHERE action='unlock_share'),
+		   max(created_at)
+		 `+visits,
+		strconv.FormatInt(id, 10),
+	).Scan(&stats.Views, &stats.Previews, &stats.Downloads, &stats.Unlocks, &stats.LastAccessAt)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to load share stats"})
+		return
+	}
+
+	for _, source := range []struct {
+		key  string
+		into *[]api.ShareSource
+	}{{"referrer", &stats.Referrers}, {"ip", &stats.IPs}} {
+		*source.into = []api.ShareSource{}
+		rows, err := db.Pool.Query(c,
+			`SELECT COALESCE(meta->>$2, 'unknown'), count(*), max(created_at)
+			 `+visits+`
+			 GROUP BY 1 ORDER BY 2 DESC, 3 DESC LIMIT 10`,
+			strconv.FormatInt(id, 10), source.key,
+		)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to load share stats"})
+			return
+		}
+		for rows.Next() {
+			var s api.ShareSource
+			if err := rows.Scan(&s.Value, &s.Count, &s.LastAccessAt); err != nil {
+				rows.Close()
+				c.JSON(500, gin.H{"error": "failed to load share stats"})
+				return
+			}
+			*source.into = append(*source.into, s)
+		}
+		rows.Close()
+	}
+	c.JSON(200, stats)
+}
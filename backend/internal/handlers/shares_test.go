@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
+)
+
+func TestParseShareExpiry(t *testing.T) {
+	if at, err := parseShareExpiry(""); at != nil || err != nil {
+		t.Errorf("no expiry: got %v, %v", at, err)
+	}
+	at, err := parseShareExpiry("1.5")
+	if err != nil || at == nil || time.Until(*at) < 89*time.Minute || time.Until(*at) > 90*time.Minute {
+		t.Errorf("1.5 hours: got %v, %v", at, err)
+	}
+	for _, s := range []string{"0", "-1", "abc", "NaN", "1e9"} {
+		if _, err := parseShareExpiry(s); err == nil {
+			t.Errorf("parsing %q: no error", s)
+		}
+	}
+}
+
+func TestReferrerSite(t *testing.T) {
+	for referer, want := range map[string]string{
+		"":                                    "direct",
+		"https://mail.example.com/inbox?id=1": "https://mail.example.com",
+		"not a url":                           "direct",
+		"/relative/path":                      "direct",
+	} {
+		if got := referrerSite(referer); got != want {
+			t.Errorf("referrerSite(%q) = %q, want %q", referer, got, want)
+		}
+	}
+}
+
+// TestShareGate checks that a link with a password and an email gate
+// stays closed until unlocked, and that its download cap holds.
+func TestShareGate(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	if err := h.Blobs.Put(context.Background(), "blob", strings.NewReader("hello"), 5); err != nil {
+		t.Fatal(err)
+	}
+	blob := insertID(t, "INSERT INTO blobs (hash, size, path, ref_count) VALUES ('blob',5,'blob',1) RETURNING id")
+	file := insertID(t, "INSERT INTO files (blob_id, owner_id, filename, size) VALUES ($1,$2,'hello.txt',5) RETURNING id", blob, alice)
+	hash, err := auth.HashPassword("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	insertID(t,
+		`INSERT INTO shares (file_id, token, allow_download, password_hash, require_email, max_downloads)
+		 VALUES ($1,'gated',true,$2,true,2) RETURNING id`, file, hash)
+	insertID(t, "INSERT INTO shares (file_id, token, password_hash) VALUES ($1,'other',$2) RETURNING id", file, hash)
+
+	request := func(handler gin.HandlerFunc, method, route, path, body string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		return serveRequest(0, handler, route, req)
+	}
+	access, download, unlock := h.AccessShareHandler, h.DownloadShareHandler, h.UnlockShareHandler
+
+	if w := request(access, "GET", "/s/:token", "/s/gated", ""); w.Code != 401 {
+		t.Errorf("opening a locked link: got %d, want 401", w.Code)
+	}
+	if w := request(download, "GET", "/s/:token/download", "/s/gated/download", ""); w.Code != 401 {
+		t.Errorf("downloading from a locked link: got %d, want 401", w.Code)
+	}
+	for body, want := range map[string]int{
+		`{"password":"hunter2"}`:                          400,
+		`{"password":"wrong","email":"bob@example.com"}`:  403,
+		`{"password":"hunter2","email":"not an address"}`: 400,
+	} {
+		if w := request(unlock, "POST", "/s/:token/unlock", "/s/gated/unlock", body); w.Code != want {
+			t.Errorf("unlocking with %s: got %d, want %d", body, w.Code, want)
+		}
+	}
+
+	w := request(unlock, "POST", "/s/:token/unlock", "/s/gated/unlock", `{"password":"hunter2","email":"bob@example.com"}`)
+	if w.Code != 200 {
+		t.Fatalf("unlocking: got %d %s, want 200", w.Code, w.Body)
+	}
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == shareUnlockCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Path != "/s/gated" {
+		t.Fatalf("unlock cookie is %+v, want one scoped to the link", cookie)
+	}
+
+	if w := request(access, "GET", "/s/:token", "/s/gated", "", cookie); w.Code != 200 {
+		t.Errorf("opening an unlocked link: got %d, want 200", w.Code)
+	}
+	if w := request(access, "GET", "/s/:token", "/s/other", "", cookie); w.Code != 401 {
+		t.Errorf("opening another link with the cookie: got %d, want 401", w.Code)
+	}
+	for i, want := range []int{200, 200, 410} {
+		w := request(download, "GET", "/s/:token/download", "/s/gated/download", "", cookie)
+		if w.Code != want {
+			t.Errorf("download %d: got %d, want %d", i+1, w.Code, want)
+		}
+		if w.Code == 200 && w.Body.String() != "hello" {
+			t.Errorf("download %d returned %q", i+1, w.Body)
+		}
+	}
+	if n := count(t, "SELECT count(*) FROM audit_logs WHERE action='unlock_share' AND meta->>'email' = 'bob@example.com'"); n != 1 {
+		t.Errorf("%d audited unlocks by bob, want 1", n)
+	}
+}
+
+// TestShareLinks creates, lists, updates and revokes a link, and checks
+// that only those who may share the file manage it.
+func TestShareLinks(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	org := testOrg(t, "org")
+	alice, bob := testUser(t, org, "alice"), testUser(t, org, "bob")
+	file := testFile(t, alice, "report.pdf", 0)
+	decode := func(w *httptest.ResponseRecorder) api.Share {
+		var s api.Share
+		_ = json.Unmarshal(w.Body.Bytes(), &s)
+		return s
+	}
+
+	create := func(user int64, query, body string) *httptest.ResponseRecorder {
+		return serve(user, h.CreateShareHandler, "POST", "/share/:id", fmt.Sprintf("/share/%d%s", file, query), body)
+	}
+	if w := create(alice, "?expiry=0", ""); w.Code != 400 {
+		t.Errorf("creating with a zero expiry: got %d, want 400", w.Code)
+	}
+	if w := create(alice, "", `{"max_downloads":0}`); w.Code != 400 {
+		t.Errorf("creating with no downloads allowed: got %d, want 400", w.Code)
+	}
+	if w := create(bob, "", ""); w.Code == 200 {
+		t.Error("bob shared alice's file")
+	}
+	w := create(alice, "?expiry=24&download=true", `{"password":"hunter2","max_downloads":3}`)
+	if w.Code != 200 {
+		t.Fatalf("creating: got %d %s", w.Code, w.Body)
+	}
+	s := decode(w)
+	if !s.AllowDownload || !s.HasPassword || s.MaxDownloads == nil || *s.MaxDownloads != 3 || s.ExpiresAt == nil || s.Filename != "report.pdf" {
+		t.Errorf("created %+v", s)
+	}
+	route, path := "/shares/:id", fmt.Sprintf("/shares/%d", s.ID)
+
+	list := func(user int64) []api.Share {
+		var out api.ShareList
+		_ = json.Unmarshal(serve(user, h.ListSharesHandler, "GET", "/shares", "/shares", "").Body.Bytes(), &out)
+		return out.Shares
+	}
+	if got := list(alice); len(got) != 1 || got[0].ID != s.ID {
+		t.Errorf("alice lists %+v", got)
+	}
+	if got := list(bob); len(got) != 0 {
+		t.Errorf("bob lists %+v", got)
+	}
+	if w := serve(bob, h.GetShareHandler, "GET", route, path, ""); w.Code == 200 {
+		t.Error("bob read alice's link")
+	}
+
+	if w := serve(alice, h.UpdateShareHandler, "PATCH", route, path, `{"max_downloads":-1}`); w.Code != 400 {
+		t.Errorf("updating to a negative cap: got %d, want 400", w.Code)
+	}
+	w = serve(alice, h.UpdateShareHandler, "PATCH", route, path, `{"allow_download":false,"password":"","max_downloads":0}`)
+	if s := decode(w); w.Code != 200 || s.AllowDownload || s.HasPassword || s.MaxDownloads != nil || s.ExpiresAt == nil {
+		t.Errorf("updating: got %d %+v", w.Code, s)
+	}
+	if w := serve(bob, h.UpdateShareHandler, "PATCH", route, path, `{"allow_download":true}`); w.Code == 200 {
+		t.Error("bob updated alice's link")
+	}
+
+	if w := serve(bob, h.RevokeShareHandler, "DELETE", route, path, ""); w.Code == 200 {
+		t.Error("bob revoked alice's link")
+	}
+	if w := serve(alice, h.RevokeShareHandler, "DELETE", route, path, ""); w.Code != 200 {
+		t.Errorf("revoking: got %d", w.Code)
+	}
+	if n := count(t, "SELECT count(*) FROM shares WHERE id=$1", s.ID); n != 0 {
+		t.Error("the link is still there")
+	}
+}
+
+func TestShareStats(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	file := testFile(t, alice, "report.pdf", 0)
+	share := insertID(t, "INSERT INTO shares (file_id, token) VALUES ($1,'tok') RETURNING id", file)
+	other := insertID(t, "INSERT INTO shares (file_id, token) VALUES ($1,'other') RETURNING id", file)
+	visit := func(id int64, action, referrer, ip string) {
+		meta := fmt.Sprintf(`{"share_id":%d,"referrer":%q,"ip":%q}`, id, referrer, ip)
+		execSQL(t, "INSERT INTO audit_logs (action, object_type, object_id, meta) VALUES ($1,'file',$2,$3)", action, file, meta)
+	}
+	visit(share, "view_share", "https://mail.example.com", "192.0.2.1")
+	visit(share, "view_share", "direct", "192.0.2.1")
+	visit(share, "preview_file_public", "direct", "192.0.2.2")
+	visit(share, "download_file_public", "direct", "192.0.2.2")
+	visit(share, "download_file", "direct", "192.0.2.2")
+	visit(other, "view_share", "direct", "192.0.2.3")
+
+	w := serve(alice, h.ShareStatsHandler, "GET", "/shares/:id/stats", fmt.Sprintf("/shares/%d/stats", share), "")
+	if w.Code != 200 {
+		t.Fatalf("loading stats: got %d %s", w.Code, w.Body)
+	}
+	var stats api.ShareStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Views != 2 || stats.Previews != 1 || stats.Downloads != 1 || stats.Unlocks != 0 || stats.LastAccessAt == nil {
+		t.Errorf("stats %+v", stats)
+	}
+	if len(stats.Referrers) != 2 || stats.Referrers[0].Value != "direct" || stats.Referrers[0].Count != 3 {
+		t.Errorf("referrers %+v", stats.Referrers)
+	}
+	if len(stats.IPs) != 2 || stats.IPs[0].Count != 2 {
+		t.Errorf("IPs %+v", stats.IPs)
+	}
+}
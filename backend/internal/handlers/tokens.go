@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// Personal access tokens let scripts and CI jobs call the API without a
+// password. AuthMiddleware accepts them next to JWTs; main.go guards each
+// route group with RequireScope, and routes over a single file or folder
+// with InScope, which keeps folder-restricted tokens inside their folder.
+// Routes that span the whole account refuse restricted tokens
+// (RequireWholeAccount), while /fs treats their folder as the root.
+
+var errTokenExpired = errors.New("token expired")
+
+// patAccess is what a personal access token allows. AuthMiddleware stores
+// it under "pat"; it is absent for logins, which may do everything.
+type patAccess struct {
+	ID     int64
+	Scopes []string
+	// FolderID restricts the token to a folder and its subfolders.
+	FolderID *int64
+}
+
+func tokenAccess(c *gin.Context) *patAccess {
+	if v, ok := c.Get("pat"); ok {
+		return v.(*patAccess)
+	}
+	return nil
+}
+
+// checkPersonalAccessToken looks up a personal access token and records
+// that it was used.
+func checkPersonalAccessToken(c *gin.Context, token string) (int64, *patAccess, error) {
+	var userID int64
+	var expiresAt, revokedAt *time.Time
+	access := &patAccess{}
+	err := db.Pool.QueryRow(c,
+		`SELECT id, user_id, scopes, folder_id, expires_at, revoked_at
+		 FROM personal_access_tokens WHERE token_hash=$1`,
+		auth.HashToken(token),
+	).Scan(&access.ID, &userID, pq.Array(&access.Scopes), &access.FolderID, &expiresAt, &revokedAt)
+	if err != nil {
+		return 0, nil, err
+	}
+	if revokedAt != nil {
+		return 0, nil, errTokenRevoked
+	}
+	if expiresAt != nil && time.Now().After(*expiresAt) {
+		return 0, nil, errTokenExpired
+	}
+
+	// Busy CI jobs call in many times a minute; a minute is precise enough.
+	_, _ = db.Pool.Exec(c,
+		`UPDATE personal_access_tokens SET last_used_at=now()
+		 WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')`,
+		access.ID,
+	)
+	return userID, access, nil
+}
+
+// RequireScope lets personal access tokens through only when they carry
+// scope.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if pat := tokenAccess(c); pat != nil && !slices.Contains(pat.Scopes, scope) {
+			c.JSON(403, gin.H{"error": "token lacks the " + scope + " scope"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession refuses personal access tokens, for routes that manage
+// credentials and sessions.
+func RequireSession(c *gin.Context) {
+	if tokenAccess(c) != nil {
+		c.JSON(403, gin.H{"error": "personal access tokens cannot manage credentials, log in instead"})
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// RequireWholeAccount refuses tokens restricted to a folder, for routes
+// that list or change the whole account.
+func RequireWholeAccount(c *gin.Context) {
+	if pat := tokenAccess(c); pat != nil && pat.FolderID != nil {
+		c.JSON(403, gin.H{"error": "token is restricted to a folder, use /fs or routes within it"})
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// InScope checks that the file, folder or share in the :id parameter lies
+// within the folder of a restricted token; a share lies where the file or
+// folder it points at does. Objects that do not exist or
+// belong to someone else are left to the handler to report.
+func InScope(kind string) gin.HandlerFunc {
+	query := map[string]string{
+		"file":   "SELECT folder_id FROM files WHERE id=$1 AND owner_id=$2",
+		"folder": "SELECT id FROM folders WHERE id=$1 AND owner_id=$2",
+		"share": `SELECT COALESCE(f.folder_id, s.folder_id)
+		          FROM shares s LEFT JOIN files f ON f.id = s.file_id LEFT JOIN folders d ON d.id = s.folder_id
+		          WHERE s.id=$1 AND COALESCE(f.owner_id, d.owner_id)=$2`,
+	}[kind]
+	if query == "" {
+		panic("handlers: unknown InScope kind " + kind)
+	}
+
+	return func(c *gin.Context) {
+		pat := tokenAccess(c)
+		if pat == nil || pat.FolderID == nil {
+			c.Next()
+			return
+		}
+		var folderID *int64
+		err := db.Pool.QueryRow(c, query, c.Param("id"), c.GetInt64("user_id")).Scan(&folderID)
+		if err == pgx.ErrNoRows {
+			c.Next()
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to check token scope"})
+			c.Abort()
+			return
+		}
+		ok, err := folderWithin(c, folderID, *pat.FolderID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to check token scope"})
+			c.Abort()
+			return
+		}
+		if !ok {
+			c.JSON(403, gin.H{"error": "outside the folder this token is restricted to"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// folderWithin reports whether folderID is root or one of its subfolders.
+// The account root (nil) is within no folder. Moving a folder checks it
+// too, so parent_id never loops; the walks up and down the tree still use
+// UNION, or a CYCLE clause, so that bad data cannot make them run forever.
+func folderWithin(c *gin.Context, folderID *int64, root int64) (bool, error) {
+	if folderID == nil {
+		return false, nil
+	}
+	var within bool
+	err := db.Pool.QueryRow(c,
+		`WITH RECURSIVE up AS (
+		   SELECT id, parent_id FROM folders WHERE id=$1
+		   UNION
+		   SELECT f.id, f.parent_id FROM folders f JOIN up ON f.id = up.parent_id
+		 )
+		 SELECT EXISTS (SELECT 1 FROM up WHERE id=$2)`,
+		*folderID, root,
+	).Scan(&within)
+	return within, err
+}
+
+// targetFolder checks a folder that a request puts files or folders into.
+// The caller must be allowed to edit it, which also keeps it within their
+// organization. For a restricted token the root means the token's folder,
+// and any other folder must be within it. Otherwise it answers 403 or 404
+// and returns false.
+func targetFolder(c *gin.Context, folderID *int64) (*int64, bool) {
+	pat := tokenAccess(c)
+	if folderID == nil {
+		if pat != nil {
+			return pat.FolderID, true
+		}
+		return nil, true
+	}
+	if _, ok := authorizeFolder(c, c.GetInt64("user_id"), *folderID, actionEdit); !ok {
+		return nil, false
+	}
+	if pat == nil || pat.FolderID == nil {
+		return folderID, true
+	}
+	ok, err := folderWithin(c, folderID, *pat.FolderID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to check token scope"})
+		return nil, false
+	}
+	if !ok {
+		c.JSON(403, gin.H{"error": "outside the folder this token is restricted to"})
+		return nil, false
+	}
+	return folderID, true
+}
+
+// fsRoot is the folder /fs paths start from: the account root, or the
+// folder of a restricted token.
+func fsRoot(c *gin.Context) *int64 {
+	if pat := tokenAccess(c); pat != nil {
+		return pat.FolderID
+	}
+	return nil
+}
+
+func (h *Handler) CreateTokenHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var body api.CreateTokenRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > 100 {
+		c.JSON(400, gin.H{"error": "name is required, up to 100 characters"})
+		return
+	}
+	if len(body.Scopes) == 0 {
+		c.JSON(400, gin.H{"error": "at least one scope is required"})
+		return
+	}
+	slices.Sort(body.Scopes)
+	body.Scopes = slices.Compact(body.Scopes)
+	for _, s := range body.Scopes {
+		if !slices.Contains(api.Scopes, s) {
+			c.JSON(400, gin.H{"error": "unknown scope " + s})
+			return
+		}
+	}
+	if slices.Contains(body.Scopes, api.ScopeAdmin) {
+		if _, ok := checkAdmin(c, "role = 'admin' OR instance_admin"); !ok {
+			return
+		}
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		c.JSON(400, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	if body.FolderID != nil {
+		var exists bool
+		err := db.Pool.QueryRow(c,
+			"SELECT EXISTS (SELECT 1 FROM folders WHERE id=$1 AND owner_id=$2 AND trashed=false)",
+			*body.FolderID, userID,
+		).Scan(&exists)
+		if err != nil || !exists {
+			c.JSON(404, gin.H{"error": "folder not found"})
+			return
+		}
+	}
+
+	token, hash, prefix, err := auth.NewPersonalAccessToken()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	out := api.NewPersonalAccessToken{
+		PersonalAccessToken: api.PersonalAccessToken{
+			Name:      body.Name,
+			Prefix:    prefix,
+			Scopes:    body.Scopes,
+			FolderID:  body.FolderID,
+			ExpiresAt: body.ExpiresAt,
+		},
+		Token:   token,
+		Message: "store the token now, it is not shown again",
+	}
+	err = db.Pool.QueryRow(c,
+		`INSERT INTO personal_access_tokens (user_id, name, token_hash, prefix, scopes, folder_id, expires_at)
+		 VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING id, created_at`,
+		userID, body.Name, hash, prefix, pq.Array(body.Scopes), body.FolderID, body.ExpiresAt,
+	).Scan(&out.ID, &out.CreatedAt)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create token"})
+		return
+	}
+
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "create_token", "token", out.ID,
+	)
+
+	c.JSON(200, out)
+}
+
+func (h *Handler) ListTokensHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	rows, err := db.Pool.Query(c,
+		`SELECT id, name, prefix, scopes, folder_id, expires_at, created_at, last_used_at, revoked_at
+		 FROM personal_access_tokens WHERE user_id=$1 ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to list tokens"})
+		return
+	}
+	defer rows.Close()
+
+	tokens := []api.PersonalAccessToken{}
+	for rows.Next() {
+		var t api.PersonalAccessToken
+		if err := rows.Scan(&t.ID, &t.Name, &t.Prefix, pq.Array(&t.Scopes), &t.FolderID,
+			&t.ExpiresAt, &t.CreatedAt, &t.LastUsedAt, &t.RevokedAt); err != nil {
+			continue
+		}
+		tokens = append(tokens, t)
+	}
+
+	c.JSON(200, api.PersonalAccessTokenList{Tokens: tokens})
+}
+
+// RevokeTokenHandler revokes a token. It stays listed, with revoked_at.
+func (h *Handler) RevokeTokenHandler(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	userID := c.GetInt64("user_id")
+
+	res, err := db.Pool.Exec(c,
+		"UPDATE personal_access_tokens SET revoked_at=now() WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL",
+		id, userID,
+	)
+	if err != nil || res.RowsAffected() == 0 {
+		c.JSON(404, gin.H{"error": "token not found"})
+		return
+	}
+	// WebDAV may have the token remembered as a password.
+	forgetDAVCredentials(userID)
+
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "revoke_token", "token", id,
+	)
+
+	c.JSON(200, api.Message{Message: "token revoked"})
+}
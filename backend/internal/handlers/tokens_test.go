@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// TestTokenGates checks the middleware that keeps personal access tokens
+// to their scopes, away from credentials and, when restricted to a folder,
+// away from routes over the whole account.
+func TestTokenGates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	folder := int64(7)
+	read := &patAccess{Scopes: []string{api.ScopeFilesRead}}
+	restricted := &patAccess{Scopes: []string{api.ScopeFilesRead}, FolderID: &folder}
+
+	for _, c := range []struct {
+		name string
+		gate gin.HandlerFunc
+		pat  *patAccess
+		want int
+	}{
+		{"login, scope", RequireScope(api.ScopeFilesWrite), nil, 200},
+		{"token with the scope", RequireScope(api.ScopeFilesRead), read, 200},
+		{"token without the scope", RequireScope(api.ScopeFilesWrite), read, 403},
+		{"login, session", RequireSession, nil, 200},
+		{"token, session", RequireSession, read, 403},
+		{"token, whole account", RequireWholeAccount, read, 200},
+		{"restricted token, whole account", RequireWholeAccount, restricted, 403},
+	} {
+		r := gin.New()
+		r.GET("/", func(ctx *gin.Context) {
+			if c.pat != nil {
+				ctx.Set("pat", c.pat)
+			}
+		}, c.gate, func(ctx *gin.Context) { ctx.Status(200) })
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, w.Code, c.want)
+		}
+	}
+}
+
+func TestCreateToken(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	org := testOrg(t, "org")
+	alice, bob := testUser(t, org, "alice"), testUser(t, org, "bob")
+	mine, theirs := testFolder(t, alice, "ci", 0), testFolder(t, bob, "ci", 0)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		return serve(alice, h.CreateTokenHandler, "POST", "/tokens", "/tokens", body)
+	}
+	for body, want := range map[string]int{
+		`{"name":"","scopes":["files:read"]}`:                                       400,
+		`{"name":"ci","scopes":[]}`:                                                 400,
+		`{"name":"ci","scopes":["files:delete"]}`:                                   400,
+		`{"name":"ci","scopes":["admin"]}`:                                          403,
+		`{"name":"ci","scopes":["files:read"],"expires_at":"2000-01-01T00:00:00Z"}`: 400,
+		fmt.Sprintf(`{"name":"ci","scopes":["files:read"],"folder_id":%d}`, theirs): 404,
+	} {
+		if w := create(body); w.Code != want {
+			t.Errorf("creating %s: got %d, want %d", body, w.Code, want)
+		}
+	}
+
+	w := create(fmt.Sprintf(`{"name":" ci ","scopes":["files:read","files:read"],"folder_id":%d}`, mine))
+	if w.Code != 200 {
+		t.Fatalf("creating a token: got %d %s", w.Code, w.Body)
+	}
+	var out api.NewPersonalAccessToken
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "ci" || len(out.Scopes) != 1 || out.FolderID == nil || *out.FolderID != mine {
+		t.Errorf("created %+v", out.PersonalAccessToken)
+	}
+	if n := count(t, "SELECT count(*) FROM personal_access_tokens WHERE user_id=$1 AND prefix=$2", alice, out.Prefix); n != 1 {
+		t.Error("the token was not stored")
+	}
+}
+
+// TestRevokeForgetsDAVCredentials checks that WebDAV stops accepting
+// remembered credentials once a token is revoked or the user logs out
+// everywhere.
+func TestRevokeForgetsDAVCredentials(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	remember := func(key string) {
+		davAuthCache.Store(key, davAuthEntry{userID: alice, expires: time.Now().Add(time.Minute)})
+	}
+	remembered := func(key string) bool {
+		_, ok := davAuthCache.Load(key)
+		return ok
+	}
+
+	token := insertID(t,
+		`INSERT INTO personal_access_tokens (user_id, name, token_hash, prefix, scopes)
+		 VALUES ($1,'dav','hash','prefix','{files:read,files:write}') RETURNING id`, alice)
+	remember("token")
+	if w := serve(alice, h.RevokeTokenHandler, "DELETE", "/tokens/:id", fmt.Sprintf("/tokens/%d", token), ""); w.Code != 200 {
+		t.Fatalf("revoking the token: got %d %s", w.Code, w.Body)
+	}
+	if remembered("token") {
+		t.Error("the credentials are remembered after revoking the token")
+	}
+
+	remember("password")
+	if w := serve(alice, h.LogoutAllHandler, "POST", "/logout-all", "/logout-all", ""); w.Code != 200 {
+		t.Fatalf("logging out everywhere: got %d %s", w.Code, w.Body)
+	}
+	if remembered("password") {
+		t.Error("the credentials are remembered after logging out everywhere")
+	}
+}
+
+// TestInScopeShare checks that a token restricted to a folder only manages
+// the links to files and folders within it.
+func TestInScopeShare(t *testing.T) {
+	testDB(t)
+	org := testOrg(t, "org")
+	alice := testUser(t, org, "alice")
+	in := testFolder(t, alice, "in", 0)
+	below := testFolder(t, alice, "below", in)
+	out := testFolder(t, alice, "out", 0)
+	share := func(folderID int64) int64 {
+		return insertID(t, "INSERT INTO shares (folder_id, token) VALUES ($1, $2) RETURNING id", folderID, fmt.Sprint("t", folderID))
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/shares/:id", func(c *gin.Context) {
+		c.Set("user_id", alice)
+		c.Set("pat", &patAccess{FolderID: &in})
+	}, InScope("share"), func(c *gin.Context) { c.Status(200) })
+
+	for folderID, want := range map[int64]int{in: 200, below: 200, out: 403} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/shares/%d", share(folderID)), nil))
+		if w.Code != want {
+			t.Errorf("link to folder %d: got %d, want %d", folderID, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// Ownership transfers. The owner of a file or folder can hand it to
+// someone else in their organization, and admins can hand everything a
+// departing member owns to another member. Transferred items move to the
+// top of the new owner's tree, a folder with everything under it that its
+// owner owns; a bulk transfer puts them in a new "From <username>" folder
+// there. What collaborators added to a folder stays theirs. Shares,
+// versions and grants stay with the files, and roles the items inherited
+// from folders they leave behind become grants on them, so nobody loses
+// access. Usage is summed from files.owner_id, so moving the rows moves
+// the usage; the new owner's quota must have room for it.
+
+// subtree is a recursive CTE of folder $1 and every folder below it.
+const subtree = `down AS (
+	SELECT id FROM folders WHERE id=$1
+	UNION
+	SELECT f.id FROM folders f JOIN down ON f.parent_id = down.id
+)`
+
+func userUsage(ctx context.Context, userID int64) (api.Usage, error) {
+	var u api.Usage
+	err := db.Pool.QueryRow(ctx,
+		`SELECT u.id, u.username, COALESCE(u.quota, 0),
+		   COALESCE((SELECT SUM(size) FROM files WHERE owner_id = u.id AND trashed=false), 0)
+		 FROM users u WHERE u.id=$1`,
+		userID,
+	).Scan(&u.UserID, &u.Username, &u.Quota, &u.Used)
+	return u, err
+}
+
+// transferRecipient looks up the user with email in from's organization
+// and checks that bytes more fit in their quota.
+func transferRecipient(c *gin.Context, from int64, email string, bytes int64) (api.Usage, bool) {
+	var to int64
+	err := db.Pool.QueryRow(c,
+		"SELECT id FROM users WHERE lower(email)=lower($1) AND org_id=(SELECT org_id FROM users WHERE id=$2)",
+		strings.TrimSpace(email), from,
+	).Scan(&to)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "user not found"})
+		return api.Usage{}, false
+	}
+	if to == from {
+		c.JSON(400, gin.H{"error": "they already own it"})
+		return api.Usage{}, false
+	}
+	u, err := userUsage(c, to)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to calculate usage"})
+		return u, false
+	}
+	if u.Quota > 0 && u.Used+bytes > u.Quota {
+		c.JSON(413, gin.H{"error": "the new owner's quota is exceeded"})
+		return u, false
+	}
+	return u, true
+}
+
+// transferObject hands file or folder id, with everything user from owns
+// in a folder, to user to inside tx, and returns how many files and
+// folders moved.
+func transferObject(ctx context.Context, tx pgx.Tx, t grantTarget, id, from, to int64) (files, folders int64, err error) {
+	// Roles inherited from the folders left behind become grants on the
+	// object, keeping the higher of those and any granted on it already.
+	for _, col := range []string{"user_id", "group_id"} {
+		_, err = tx.Exec(ctx,
+			`WITH RECURSIVE `+t.ancestors()+`
+			 INSERT INTO `+t.table+` AS q (`+t.column+`, `+col+`, role, granted_by)
+			 SELECT DISTINCT ON (p.`+col+`) $1, p.`+col+`, p.role, p.granted_by
+			 FROM folder_permissions p JOIN up ON p.folder_id = up.id
+			 WHERE p.`+col+` IS NOT NULL
+			 ORDER BY p.`+col+`, array_position($2::text[], p.role) DESC
+			 ON CONFLICT (`+t.column+`, `+col+`) DO UPDATE SET role=EXCLUDED.role
+			 WHERE array_position($2::text[], EXCLUDED.role) > array_position($2::text[], q.role)`,
+			id, api.FileRoles,
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// The new owner's own grants on what they now own are dropped.
+	if t.kind == "file" {
+		_, err = tx.Exec(ctx, "UPDATE files SET owner_id=$2, folder_id=NULL WHERE id=$1", id, to)
+		if err == nil {
+			_, err = tx.Exec(ctx, "DELETE FROM file_permissions WHERE file_id=$1 AND user_id=$2", id, to)
+		}
+		return 1, 0, err
+	}
+	err = tx.QueryRow(ctx,
+		`WITH RECURSIVE `+subtree+`,
+		 moved AS (
+		   UPDATE folders SET owner_id=$2, parent_id = CASE WHEN id=$1 THEN NULL ELSE parent_id END
+		   WHERE id IN (SELECT id FROM down) AND owner_id=$3
+		   RETURNING id
+		 ),
+		 dropped AS (
+		   DELETE FROM folder_permissions WHERE user_id=$2 AND folder_id IN (SELECT id FROM moved)
+		 )
+		 SELECT count(*) FROM moved`,
+		id, to, from,
+	).Scan(&folders)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = tx.QueryRow(ctx,
+		`WITH RECURSIVE `+subtree+`,
+		 moved AS (
+		   UPDATE files SET owner_id=$2 WHERE folder_id IN (SELECT id FROM down) AND owner_id=$3 RETURNING id
+		 ),
+		 dropped AS (
+		   DELETE FROM file_permissions WHERE user_id=$2 AND file_id IN (SELECT id FROM moved)
+		 )
+		 SELECT count(*) FROM moved`,
+		id, to, from,
+	).Scan(&files)
+	return files, folders, err
+}
+
+// transferHandler hands the file or folder in the id parameter from its
+// owner, the caller, to another user.
+func transferHandler(c *gin.Context, t grantTarget) {
+	userID := c.GetInt64("user_id")
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var body api.TransferRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if body.KeepRole != "" && !slices.Contains(api.FileRoles, body.KeepRole) {
+		c.JSON(400, gin.H{"error": "keep_role must be one of " + strings.Join(api.FileRoles, ", ")})
+		return
+	}
+	if _, ok := t.authorize(c, userID, id, actionOwn); !ok {
+		return
+	}
+
+	var trashed bool
+	var bytes int64
+	var err error
+	if t.kind == "file" {
+		err = db.Pool.QueryRow(c,
+			"SELECT COALESCE(trashed, false), CASE WHEN trashed THEN 0 ELSE size END FROM files WHERE id=$1", id,
+		).Scan(&trashed, &bytes)
+	} else {
+		err = db.Pool.QueryRow(c,
+			`WITH RECURSIVE `+subtree+`
+			 SELECT (SELECT COALESCE(trashed, false) FROM folders WHERE id=$1),
+			   COALESCE((SELECT SUM(size) FROM files
+			     WHERE folder_id IN (SELECT id FROM down) AND owner_id=$2 AND trashed=false), 0)`,
+			id, userID,
+		).Scan(&trashed, &bytes)
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to transfer " + t.kind})
+		return
+	}
+	if trashed {
+		c.JSON(409, gin.H{"error": "restore the " + t.kind + " from the trash first"})
+		return
+	}
+	to, ok := transferRecipient(c, userID, body.Email, bytes)
+	if !ok {
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to transfer " + t.kind})
+		return
+	}
+	defer tx.Rollback(c)
+
+	res := api.Transfer{Bytes: bytes}
+	res.Files, res.Folders, err = transferObject(c, tx, t, id, userID, to.UserID)
+	if isUniqueViolation(err) {
+		c.JSON(409, gin.H{"error": "the new owner already has a " + t.kind + " with that name at the top of their tree"})
+		return
+	}
+	if err == nil && body.KeepRole != "" {
+		_, err = tx.Exec(c,
+			`INSERT INTO `+t.table+` (`+t.column+`, user_id, role, granted_by) VALUES ($1,$2,$3,$2)
+			 ON CONFLICT (`+t.column+`, user_id) DO UPDATE SET role=EXCLUDED.role, granted_by=EXCLUDED.granted_by`,
+			id, userID, body.KeepRole,
+		)
+	}
+	if err != nil || tx.Commit(c) != nil {
+		c.JSON(500, gin.H{"error": "failed to transfer " + t.kind})
+		return
+	}
+
+	meta, _ := json.Marshal(map[string]any{"to": to.UserID, "files": res.Files, "folders": res.Folders, "bytes": bytes, "keep_role": body.KeepRole})
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "transfer_ownership", t.kind, id, string(meta),
+	)
+	broadcastUpdate(c, gin.H{
+		"event":        "ownership_transferred",
+		t.kind + "_id": id,
+		"from":         userID,
+		"to":           to.UserID,
+	})
+	respondTransfer(c, res, userID, to.UserID)
+}
+
+// respondTransfer answers with res and both users' usage after it.
+func respondTransfer(c *gin.Context, res api.Transfer, from, to int64) {
+	var err error
+	if res.From, err = userUsage(c, from); err == nil {
+		res.To, err = userUsage(c, to)
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to calculate usage"})
+		return
+	}
+	c.JSON(200, res)
+}
+
+// TransferFileHandler hands a file to another user; only its owner can.
+func (h *Handler) TransferFileHandler(c *gin.Context) {
+	transferHandler(c, fileGrants)
+}
+
+// TransferFolderHandler hands a folder and everything under it that its
+// owner owns to another user; only its owner can.
+func (h *Handler) TransferFolderHandler(c *gin.Context) {
+	transferHandler(c, folderGrants)
+}
+
+// TransferMemberFilesHandler hands every file and folder a member owns,
+// trashed ones included, to another member, for admins offboarding them.
+func (h *Handler) TransferMemberFilesHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	from, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	var body api.TransferRequest
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if body.KeepRole != "" {
+		c.JSON(400, gin.H{"error": "keep_role does not apply to a bulk transfer"})
+		return
+	}
+	fromUsage, err := userUsage(c, from)
+	var member bool
+	if err == nil {
+		err = db.Pool.QueryRow(c, "SELECT org_id=$2 FROM users WHERE id=$1", from, orgID).Scan(&member)
+	}
+	if err == pgx.ErrNoRows || err == nil && !member {
+		c.JSON(404, gin.H{"error": "user not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to transfer files"})
+		return
+	}
+	to, ok := transferRecipient(c, from, body.Email, fromUsage.Used)
+	if !ok {
+		return
+	}
+
+	res := api.Transfer{Bytes: fromUsage.Used}
+	var owned bool
+	err = db.Pool.QueryRow(c,
+		"SELECT EXISTS (SELECT 1 FROM files WHERE owner_id=$1) OR EXISTS (SELECT 1 FROM folders WHERE owner_id=$1)", from,
+	).Scan(&owned)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to transfer files"})
+		return
+	}
+	if !owned {
+		respondTransfer(c, res, from, to.UserID)
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to transfer files"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	// Find a free name for the folder everything goes in.
+	name := "From " + fromUsage.Username
+	for n := 2; ; n++ {
+		var taken bool
+		err = tx.QueryRow(c,
+			"SELECT EXISTS (SELECT 1 FROM folders WHERE owner_id=$1 AND parent_id IS NULL AND name=$2 AND trashed=false)",
+			to.UserID, name,
+		).Scan(&taken)
+		if err != nil || !taken {
+			break
+		}
+		name = fmt.Sprintf("From %s (%d)", fromUsage.Username, n)
+	}
+	var folderID int64
+	if err == nil {
+		err = tx.QueryRow(c,
+			"INSERT INTO folders (owner_id, name, parent_id) VALUES ($1,$2,NULL) RETURNING id", to.UserID, name,
+		).Scan(&folderID)
+	}
+	if err == nil {
+		err = tx.QueryRow(c,
+			`WITH moved AS (
+			   UPDATE folders SET owner_id=$2, parent_id = COALESCE(parent_id, $3) WHERE owner_id=$1 RETURNING id
+			 ),
+			 dropped AS (
+			   DELETE FROM folder_permissions WHERE user_id=$2 AND folder_id IN (SELECT id FROM moved)
+			 )
+			 SELECT count(*) FROM moved`,
+			from, to.UserID, folderID,
+		).Scan(&res.Folders)
+	}
+	if err == nil {
+		err = tx.QueryRow(c,
+			`WITH moved AS (
+			   UPDATE files SET owner_id=$2, folder_id = COALESCE(folder_id, $3) WHERE owner_id=$1 RETURNING id
+			 ),
+			 dropped AS (
+			   DELETE FROM file_permissions WHERE user_id=$2 AND file_id IN (SELECT id FROM moved)
+			 )
+			 SELECT count(*) FROM moved`,
+			from, to.UserID, folderID,
+		).Scan(&res.Files)
+	}
+	if err != nil || tx.Commit(c) != nil {
+		c.JSON(500, gin.H{"error": "failed to transfer files"})
+		return
+	}
+	res.FolderID = &folderID
+
+	auditOrg(c, "transfer_member_files", orgID, map[string]any{
+		"from": from, "to": to.UserID, "folder_id": folderID,
+		"files": res.Files, "folders": res.Folders, "bytes": res.Bytes,
+	})
+	respondTransfer(c, res, from, to.UserID)
+}
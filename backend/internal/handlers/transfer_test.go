@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+)
+
+// TestTransferFolder checks that a folder transfer moves what its owner
+// owns under it, and leaves what a collaborator added with them.
+func TestTransferFolder(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	org := testOrg(t, "org")
+	alice, bob, carol := testUser(t, org, "alice"), testUser(t, org, "bob"), testUser(t, org, "carol")
+	project := testFolder(t, alice, "project", 0)
+	drafts := testFolder(t, alice, "drafts", project)
+	mine := testFile(t, alice, "plan.txt", drafts)
+	insertID(t, "INSERT INTO folder_permissions (folder_id, user_id, role) VALUES ($1,$2,'editor') RETURNING id", project, bob)
+	theirs := testFile(t, bob, "notes.txt", project)
+	theirFolder := testFolder(t, bob, "bob's", project)
+	execSQL(t, "UPDATE files SET size=100 WHERE id=$1", mine)
+	execSQL(t, "UPDATE files SET size=5000 WHERE id=$1", theirs)
+
+	transfer := func(user, folder int64, email string) (int, api.Transfer) {
+		w := serve(user, h.TransferFolderHandler, "POST", "/folders/:id/transfer",
+			fmt.Sprintf("/folders/%d/transfer", folder), fmt.Sprintf(`{"email":%q}`, email))
+		var res api.Transfer
+		_ = json.Unmarshal(w.Body.Bytes(), &res)
+		return w.Code, res
+	}
+	if code, _ := transfer(bob, project, "carol@example.com"); code != 403 {
+		t.Errorf("transferring as an editor: got %d, want 403", code)
+	}
+	code, res := transfer(alice, project, "carol@example.com")
+	if code != 200 {
+		t.Fatalf("transferring as the owner: got %d", code)
+	}
+	if res.Files != 1 || res.Folders != 2 || res.Bytes != 100 {
+		t.Errorf("moved %d files, %d folders and %d bytes, want 1, 2 and 100", res.Files, res.Folders, res.Bytes)
+	}
+
+	owner := func(table string, id int64) int64 {
+		return int64(count(t, "SELECT owner_id FROM "+table+" WHERE id=$1", id))
+	}
+	for _, c := range []struct {
+		table string
+		id    int64
+		want  int64
+	}{
+		{"folders", project, carol},
+		{"folders", drafts, carol},
+		{"files", mine, carol},
+		{"files", theirs, bob},
+		{"folders", theirFolder, bob},
+	} {
+		if got := owner(c.table, c.id); got != c.want {
+			t.Errorf("%s %d is owned by %d, want %d", c.table, c.id, got, c.want)
+		}
+	}
+	if n := count(t, "SELECT count(*) FROM folders WHERE id=$1 AND parent_id IS NULL", project); n != 1 {
+		t.Error("the folder did not move to the top of the new owner's tree")
+	}
+}
+
+// TestTransferFileQuota checks that a transfer the new owner has no room
+// for is refused.
+func TestTransferFileQuota(t *testing.T) {
+	testDB(t)
+	h := &Handler{}
+	org := testOrg(t, "org")
+	alice, bob := testUser(t, org, "alice"), testUser(t, org, "bob")
+	file := testFile(t, alice, "big.bin", 0)
+	execSQL(t, "UPDATE files SET size=2000 WHERE id=$1", file)
+	execSQL(t, "UPDATE users SET quota=1000 WHERE id=$1", bob)
+
+	send := func() int {
+		return serve(alice, h.TransferFileHandler, "POST", "/files/:id/transfer",
+			fmt.Sprintf("/files/%d/transfer", file), `{"email":"bob@example.com"}`).Code
+	}
+	if code := send(); code != 413 {
+		t.Errorf("transferring past the quota: got %d, want 413", code)
+	}
+	execSQL(t, "UPDATE users SET quota=0 WHERE id=$1", bob)
+	if code := send(); code != 200 {
+		t.Errorf("transferring without a quota: got %d, want 200", code)
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE id=$1 AND owner_id=$2", file, bob); n != 1 {
+		t.Error("the file was not handed over")
+	}
+}
@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+)
+
+// tus 1.0 resumable uploads (https://tus.io/protocols/resumable-upload).
+// Partial uploads are kept under STORAGE_PATH/uploads and handed to
+// saveUpload once the last byte arrives. SweepUploads removes them once
+// they expire.
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+	tusUploadTTL  = 24 * time.Hour
+)
+
+// tusLocks serializes the requests to each upload. An entry only lives
+// while its lock is held or waited for.
+var tusLocks = struct {
+	sync.Mutex
+	m map[string]*uploadLock
+}{m: make(map[string]*uploadLock)}
+
+type uploadLock struct {
+	sync.Mutex
+	users int
+}
+
+func lockUpload(id string) func() {
+	tusLocks.Lock()
+	l := tusLocks.m[id]
+	if l == nil {
+		l = &uploadLock{}
+		tusLocks.m[id] = l
+	}
+	l.users++
+	tusLocks.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		tusLocks.Lock()
+		if l.users--; l.users == 0 {
+			delete(tusLocks.m, id)
+		}
+		tusLocks.Unlock()
+	}
+}
+
+func (h *Handler) uploadPartPath(id string) string {
+	return filepath.Join(h.StoragePath, "uploads", id+".part")
+}
+
+func tusHeaders(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Cache-Control", "no-store")
+}
+
+func tusMaxSize() int64 {
+	maxMB, _ := strconv.ParseInt(os.Getenv("TUS_MAX_SIZE_MB"), 10, 64)
+	return maxMB * 1024 * 1024
+}
+
+// checkTusVersion rejects requests from clients speaking another protocol version.
+func checkTusVersion(c *gin.Context) bool {
+	tusHeaders(c)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.AbortWithStatus(412)
+		return false
+	}
+	return true
+}
+
+// parseTusMetadata decodes an Upload-Metadata header ("key base64,key base64").
+func parseTusMetadata(header string) (map[string]string, error) {
+	meta := make(map[string]string)
+	if header == "" {
+		return meta, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Fields(pair)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, errors.New("malformed metadata")
+		}
+		value := ""
+		if len(parts) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			value = string(decoded)
+		}
+		meta[parts[0]] = value
+	}
+	return meta, nil
+}
+
+func (h *Handler) TusOptionsHandler(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	if max := tusMaxSize(); max > 0 {
+		c.Header("Tus-Max-Size", strconv.FormatInt(max, 10))
+	}
+	c.Status(204)
+}
+
+// Create a new upload
+func (h *Handler) TusCreateHandler(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+	userID := c.GetInt64("user_id")
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(400, gin.H{"error": "invalid Upload-Length"})
+		return
+	}
+	if max := tusMaxSize(); max > 0 && length > max {
+		c.JSON(413, gin.H{"error": "upload too large"})
+		return
+	}
+
+	meta, err := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid Upload-Metadata"})
+		return
+	}
+	filename := filepath.Base(meta["filename"])
+	filename = strings.ReplaceAll(filename, "..", "")
+	if !validateFilename(filename) {
+		c.JSON(400, gin.H{"error": "invalid filename"})
+		return
+	}
+	tagArray, ok := parseUploadTags(meta["tags"])
+	if !ok {
+		c.JSON(400, gin.H{"error": "invalid tag length"})
+		return
+	}
+	folderID, ok := targetFolder(c, parseUploadFolder(meta["folder_id"]))
+	if !ok {
+		return
+	}
+
+	// Uploads still in progress will count once they finish.
+	var pending int64
+	err = db.Pool.QueryRow(c,
+		"SELECT COALESCE(SUM(upload_length),0) FROM uploads WHERE user_id=$1 AND file_id IS NULL AND expires_at > now()",
+		userID,
+	).Scan(&pending)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to calculate usage"})
+		return
+	}
+	if uerr := checkQuota(c, userID, pending+length); uerr != nil {
+		c.JSON(uerr.Status, gin.H{"error": uerr.Message})
+		return
+	}
+
+	id, err := generateToken()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate upload id"})
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.uploadPartPath(id)), 0o755); err != nil {
+		c.JSON(500, gin.H{"error": "failed to create upload"})
+		return
+	}
+	part, err := os.Create(h.uploadPartPath(id))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to create upload"})
+		return
+	}
+	part.Close()
+
+	expiresAt := time.Now().Add(tusUploadTTL)
+	_, err = db.Pool.Exec(c,
+		`INSERT INTO uploads (id, user_id, filename, mime_type, folder_id, tags, upload_length, expires_at)
+		 VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
+		id, userID, filename, meta["filetype"], folderID, pq.Array(tagArray), length, expiresAt,
+	)
+	if err != nil {
+		os.Remove(h.uploadPartPath(id))
+		c.JSON(500, gin.H{"error": "failed to create upload"})
+		return
+	}
+
+	// An empty upload is complete as soon as it exists.
+	if length == 0 {
+		fileID, ok := h.finishUpload(c, &tusUpload{ID: id, Filename: filename, MimeType: meta["filetype"], FolderID: folderID, Tags: tagArray})
+		if !ok {
+			h.removeUpload(c, id)
+			return
+		}
+		c.Header("Location", "/tus/"+id)
+		c.Header("Upload-Offset", "0")
+		c.Header("Upload-File-Id", strconv.FormatInt(fileID, 10))
+		c.Status(201)
+		return
+	}
+
+	c.Header("Location", "/tus/"+id)
+	c.Header("Upload-Expires", expiresAt.UTC().Format(http.TimeFormat))
+	c.Status(201)
+}
+
+type tusUpload struct {
+	ID       string
+	Filename string
+	MimeType string
+	FolderID *int64
+	Tags     []string
+	Length   int64
+	Offset   int64
+	FileID   *int64
+	Expires  time.Time
+}
+
+// loadUpload fetches an upload owned by the caller, writing the error
+// response itself when it cannot be used.
+func (h *Handler) loadUpload(c *gin.Context) (*tusUpload, bool) {
+	u := &tusUpload{ID: c.Param("id")}
+	var mimeType *string
+	err := db.Pool.QueryRow(c,
+		`SELECT filename, mime_type, folder_id, COALESCE(tags, '{}'), upload_length, upload_offset, file_id, expires_at
+		 FROM uploads WHERE id=$1 AND user_id=$2`,
+		u.ID, c.GetInt64("user_id"),
+	).Scan(&u.Filename, &mimeType, &u.FolderID, pq.Array(&u.Tags), &u.Length, &u.Offset, &u.FileID, &u.Expires)
+	if err != nil {
+		c.AbortWithStatus(404)
+		return nil, false
+	}
+	if mimeType != nil {
+		u.MimeType = *mimeType
+	}
+	if u.FileID == nil && time.Now().After(u.Expires) {
+		h.removeUpload(c, u.ID)
+		c.AbortWithStatus(410)
+		return nil, false
+	}
+	return u, true
+}
+
+func (h *Handler) removeUpload(ctx context.Context, id string) {
+	os.Remove(h.uploadPartPath(id))
+	_, _ = db.Pool.Exec(ctx, "DELETE FROM uploads WHERE id=$1", id)
+}
+
+// SweepUploads removes expired uploads, finished or not, together with
+// their partial data, once now and then every interval until ctx is done.
+func (h *Handler) SweepUploads(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := h.sweepUploads(ctx); err != nil {
+			log.Printf("sweeping expired uploads: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *Handler) sweepUploads(ctx context.Context) error {
+	rows, err := db.Pool.Query(ctx, "SELECT id FROM uploads WHERE expires_at < now()")
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		unlock := lockUpload(id)
+		h.removeUpload(ctx, id)
+		unlock()
+	}
+	return nil
+}
+
+// Report upload progress
+func (h *Handler) TusHeadHandler(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+	u, ok := h.loadUpload(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Length, 10))
+	if u.FileID == nil {
+		c.Header("Upload-Expires", u.Expires.UTC().Format(http.TimeFormat))
+	} else {
+		c.Header("Upload-File-Id", strconv.FormatInt(*u.FileID, 10))
+	}
+	c.Status(200)
+}
+
+// Append a chunk, finalizing the upload once it is complete
+func (h *Handler) TusPatchHandler(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+	if c.GetHeader("Content-Type") != "application/offset+octet-stream" {
+		c.AbortWithStatus(415)
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(400, gin.H{"error": "invalid Upload-Offset"})
+		return
+	}
+
+	unlock := lockUpload(c.Param("id"))
+	defer unlock()
+
+	u, ok := h.loadUpload(c)
+	if !ok {
+		return
+	}
+	if u.FileID != nil || offset != u.Offset {
+		c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+		c.AbortWithStatus(409)
+		return
+	}
+
+	part, err := os.OpenFile(h.uploadPartPath(u.ID), os.O_WRONLY, 0)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to open upload"})
+		return
+	}
+	// Drop anything past the recorded offset left by an interrupted write.
+	_ = part.Truncate(u.Offset)
+	_, _ = part.Seek(u.Offset, io.SeekStart)
+
+	// Whatever arrives before the connection drops is kept, so the client
+	// can resume from the new offset.
+	written, copyErr := io.Copy(part, io.LimitReader(c.Request.Body, u.Length-u.Offset))
+	if err := part.Close(); err != nil {
+		c.JSON(500, gin.H{"error": "failed to write upload"})
+		return
+	}
+	u.Offset += written
+
+	_, err = db.Pool.Exec(c, "UPDATE uploads SET upload_offset=$1 WHERE id=$2", u.Offset, u.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to record offset"})
+		return
+	}
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+
+	if u.Offset < u.Length {
+		if copyErr != nil {
+			c.AbortWithStatus(400)
+			return
+		}
+		c.Header("Upload-Expires", u.Expires.UTC().Format(http.TimeFormat))
+		c.Status(204)
+		return
+	}
+
+	fileID, ok := h.finishUpload(c, u)
+	if !ok {
+		return
+	}
+	c.Header("Upload-File-Id", strconv.FormatInt(fileID, 10))
+	c.Status(204)
+}
+
+// finishUpload saves a complete upload as a file and records the file on
+// the upload, writing the error response itself when that fails.
+func (h *Handler) finishUpload(c *gin.Context, u *tusUpload) (int64, bool) {
+	staged, err := stageFile(h.uploadPartPath(u.ID))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to open upload"})
+		return 0, false
+	}
+	res, uerr := h.saveUpload(c, c.GetInt64("user_id"), staged, u.Filename, u.MimeType, u.Tags, u.FolderID)
+	if uerr != nil {
+		c.JSON(uerr.Status, gin.H{"error": uerr.Message, "detected": uerr.Detected})
+		return 0, false
+	}
+
+	os.Remove(h.uploadPartPath(u.ID))
+	_, _ = db.Pool.Exec(c, "UPDATE uploads SET file_id=$1 WHERE id=$2", res.FileID, u.ID)
+	return res.FileID, true
+}
+
+// Terminate an upload
+func (h *Handler) TusDeleteHandler(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+
+	unlock := lockUpload(c.Param("id"))
+	defer unlock()
+
+	if _, ok := h.loadUpload(c); !ok {
+		return
+	}
+	h.removeUpload(c, c.Param("id"))
+	c.Status(204)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUploadLocksAreDropped(t *testing.T) {
+	unlock := lockUpload("a")
+	done := make(chan struct{})
+	go func() {
+		lockUpload("a")()
+		close(done)
+	}()
+	unlock()
+	<-done
+
+	tusLocks.Lock()
+	defer tusLocks.Unlock()
+	if len(tusLocks.m) != 0 {
+		t.Errorf("%d upload locks left after every lock was released", len(tusLocks.m))
+	}
+}
+
+func TestParseTusMetadata(t *testing.T) {
+	meta, err := parseTusMetadata("filename bm90ZXMudHh0, is_confidential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta["filename"] != "notes.txt" || len(meta) != 2 {
+		t.Errorf("got %v", meta)
+	}
+	for _, header := range []string{"filename a b", "filename !!", ","} {
+		if _, err := parseTusMetadata(header); err == nil {
+			t.Errorf("parsing %q: no error", header)
+		}
+	}
+}
+
+func tusCreate(userID int64, h *Handler, length, filename string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/tus", nil)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Upload-Length", length)
+	req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte(filename)))
+	return serveRequest(userID, h.TusCreateHandler, "/tus", req)
+}
+
+// tusPatch sends data at offset to an upload.
+func tusPatch(userID int64, h *Handler, id string, offset, data string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PATCH", "/tus/"+id, strings.NewReader(data))
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", offset)
+	return serveRequest(userID, h.TusPatchHandler, "/tus/:id", req)
+}
+
+// TestTusResume uploads a file in two parts, resuming from the offset HEAD
+// reports after a PATCH sent at the wrong one.
+func TestTusResume(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	org := testOrg(t, "org")
+	alice, bob := testUser(t, org, "alice"), testUser(t, org, "bob")
+
+	w := tusCreate(alice, h, "11", "greeting.txt")
+	if w.Code != 201 {
+		t.Fatalf("creating an upload: got %d %s, want 201", w.Code, w.Body)
+	}
+	id := strings.TrimPrefix(w.Header().Get("Location"), "/tus/")
+
+	if w := tusPatch(alice, h, id, "0", "hello "); w.Code != 204 || w.Header().Get("Upload-Offset") != "6" {
+		t.Fatalf("sending the first part: got %d at offset %s", w.Code, w.Header().Get("Upload-Offset"))
+	}
+	if w := tusPatch(alice, h, id, "0", "hello "); w.Code != 409 || w.Header().Get("Upload-Offset") != "6" {
+		t.Errorf("sending at a stale offset: got %d at offset %s, want 409 at 6", w.Code, w.Header().Get("Upload-Offset"))
+	}
+
+	head := func(userID int64, version string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("HEAD", "/tus/"+id, nil)
+		req.Header.Set("Tus-Resumable", version)
+		return serveRequest(userID, h.TusHeadHandler, "/tus/:id", req)
+	}
+	if w := head(alice, tusVersion); w.Code != 200 || w.Header().Get("Upload-Offset") != "6" {
+		t.Errorf("HEAD: got %d at offset %s, want 200 at 6", w.Code, w.Header().Get("Upload-Offset"))
+	}
+	if w := head(bob, tusVersion); w.Code != 404 {
+		t.Errorf("HEAD on someone else's upload: got %d, want 404", w.Code)
+	}
+	if w := head(alice, "0.2.2"); w.Code != 412 {
+		t.Errorf("HEAD with another protocol version: got %d, want 412", w.Code)
+	}
+
+	w = tusPatch(alice, h, id, "6", "world")
+	if w.Code != 204 || w.Header().Get("Upload-File-Id") == "" {
+		t.Fatalf("sending the last part: got %d %s", w.Code, w.Body)
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE owner_id=$1 AND filename='greeting.txt' AND size=11", alice); n != 1 {
+		t.Errorf("%d files recorded, want 1", n)
+	}
+	if w := tusPatch(alice, h, id, "11", "!"); w.Code != 409 {
+		t.Errorf("sending to a finished upload: got %d, want 409", w.Code)
+	}
+}
+
+func TestTusEmptyUpload(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+
+	w := tusCreate(alice, h, "0", "empty.txt")
+	if w.Code != 201 {
+		t.Fatalf("creating an empty upload: got %d %s, want 201", w.Code, w.Body)
+	}
+	if w.Header().Get("Upload-File-Id") == "" {
+		t.Error("an empty upload was not finished at creation")
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE owner_id=$1 AND filename='empty.txt' AND size=0", alice); n != 1 {
+		t.Errorf("%d empty files recorded, want 1", n)
+	}
+	if w := tusCreate(alice, h, "-1", "negative.txt"); w.Code != 400 {
+		t.Errorf("creating an upload of negative length: got %d, want 400", w.Code)
+	}
+}
+
+// TestTusPendingQuota checks that unfinished uploads count against the
+// quota of the next one.
+func TestTusPendingQuota(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+	execSQL(t, "UPDATE users SET quota=100 WHERE id=$1", alice)
+
+	if w := tusCreate(alice, h, "60", "first.bin"); w.Code != 201 {
+		t.Fatalf("creating the first upload: got %d %s, want 201", w.Code, w.Body)
+	}
+	if w := tusCreate(alice, h, "60", "second.bin"); w.Code != 413 {
+		t.Errorf("creating an upload past the quota with one pending: got %d, want 413", w.Code)
+	}
+}
+
+func TestSweepUploads(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+
+	var ids []string
+	for _, name := range []string{"live.bin", "stale.bin"} {
+		w := tusCreate(alice, h, "10", name)
+		if w.Code != 201 {
+			t.Fatalf("creating an upload: got %d %s, want 201", w.Code, w.Body)
+		}
+		ids = append(ids, strings.TrimPrefix(w.Header().Get("Location"), "/tus/"))
+	}
+	execSQL(t, "UPDATE uploads SET expires_at = now() - interval '1 hour' WHERE id=$1", ids[1])
+
+	if err := h.sweepUploads(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, "SELECT count(*) FROM uploads WHERE id=$1", ids[0]); n != 1 {
+		t.Error("the sweep removed an upload that has not expired")
+	}
+	if n := count(t, "SELECT count(*) FROM uploads WHERE id=$1", ids[1]); n != 0 {
+		t.Error("the sweep left an expired upload")
+	}
+	if _, err := os.Stat(h.uploadPartPath(ids[1])); err == nil {
+		t.Error("the sweep left an expired upload's data")
+	}
+}
@@ -0,0 +1,516 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/totp"
+)
+
+// Two-factor authentication with TOTP (package totp). Enrollment stores a
+// pending secret that a first code confirms; from then on /login answers
+// with a TwoFactorChallenge, and /login/2fa trades its token and a code, or
+// a recovery code, for the usual tokens. With an organization's
+// require_admin_2fa setting on, requireAdmin refuses its admins who have
+// not enrolled.
+
+// twoFactorTTL is how long the second login step may take.
+const twoFactorTTL = 5 * time.Minute
+
+// twoFactorAttempts is how many wrong codes a challenge survives.
+const twoFactorAttempts = 5
+
+const recoveryCodeCount = 10
+
+// settingRequireAdmin2FA is the org_settings key behind
+// api.SecuritySettings.RequireAdminTwoFactor.
+const settingRequireAdmin2FA = "require_admin_2fa"
+
+// twoFactorEnabled reports whether the user has confirmed a TOTP secret.
+func twoFactorEnabled(c *gin.Context, userID int64) (bool, error) {
+	var enabled bool
+	err := db.Pool.QueryRow(c,
+		"SELECT totp_enabled_at IS NOT NULL FROM users WHERE id=$1", userID,
+	).Scan(&enabled)
+	return enabled, err
+}
+
+// startTwoFactorLogin answers the password step of a login for a user with
+// two-factor authentication on.
+func startTwoFactorLogin(c *gin.Context, userID int64) {
+	token, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start login"})
+		return
+	}
+	expiresAt := time.Now().Add(twoFactorTTL)
+
+	_, _ = db.Pool.Exec(c, "DELETE FROM two_factor_challenges WHERE expires_at < now()")
+	_, err = db.Pool.Exec(c,
+		"INSERT INTO two_factor_challenges (token_hash, user_id, expires_at) VALUES ($1,$2,$3)",
+		hash, userID, expiresAt,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start login"})
+		return
+	}
+
+	c.JSON(200, api.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		TwoFactorToken:    token,
+		ExpiresAt:         expiresAt,
+	})
+}
+
+// TwoFactorLoginHandler is the second step of a login with two-factor
+// authentication.
+func (h *Handler) TwoFactorLoginHandler(c *gin.Context) {
+	var body api.TwoFactorLoginRequest
+	if err := c.BindJSON(&body); err != nil || body.TwoFactorToken == "" || body.Code == "" {
+		c.JSON(400, gin.H{"error": "two_factor_token and code are required"})
+		return
+	}
+	hash := auth.HashToken(body.TwoFactorToken)
+
+	// Counting the attempt before checking the code keeps concurrent
+	// guesses within the limit.
+	var userID int64
+	var username, email, secret string
+	err := db.Pool.QueryRow(c,
+		`UPDATE two_factor_challenges ch SET attempts = attempts + 1
+		 FROM users u
+		 WHERE ch.token_hash=$1 AND u.id = ch.user_id AND ch.expires_at > now() AND ch.attempts < $2
+		 RETURNING u.id, u.username, COALESCE(u.email, ''), COALESCE(u.totp_secret, '')`,
+		hash, twoFactorAttempts,
+	).Scan(&userID, &username, &email, &secret)
+	if err != nil {
+		c.JSON(401, gin.H{"error": "login expired, log in again"})
+		return
+	}
+
+	// Wrong codes count as failed logins, or a password holder could guess
+	// codes through fresh challenges without end.
+	if loginBlocked(c, username) {
+		return
+	}
+	method, ok, err := checkTwoFactorCode(c, userID, secret, body.Code)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to check code"})
+		return
+	}
+	if !ok {
+		loginFailed(c, username)
+		_, _ = db.Pool.Exec(c,
+			"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+			userID, "two_factor_failed", "user", userID,
+		)
+		c.JSON(401, gin.H{"error": "invalid code"})
+		return
+	}
+	_, _ = db.Pool.Exec(c, "DELETE FROM two_factor_challenges WHERE token_hash=$1", hash)
+	loginSucceeded(c, username)
+
+	finishLogin(c, userID, username, email, map[string]any{"two_factor": method})
+}
+
+// checkTwoFactorCode accepts a current TOTP code or an unused recovery
+// code, using it up, and returns which of the two it was.
+func checkTwoFactorCode(c *gin.Context, userID int64, secret, code string) (string, bool, error) {
+	code = strings.Join(strings.Fields(code), "")
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(secret, code, time.Now())
+		if !ok {
+			return "totp", false, nil
+		}
+		res, err := db.Pool.Exec(c,
+			"UPDATE users SET totp_last_step=$2 WHERE id=$1 AND (totp_last_step IS NULL OR totp_last_step < $2)",
+			userID, step,
+		)
+		if err != nil {
+			return "totp", false, err
+		}
+		return "totp", res.RowsAffected() == 1, nil
+	}
+
+	res, err := db.Pool.Exec(c,
+		"UPDATE recovery_codes SET used_at=now() WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL",
+		userID, hashRecoveryCode(code),
+	)
+	if err != nil {
+		return "recovery_code", false, err
+	}
+	return "recovery_code", res.RowsAffected() == 1, nil
+}
+
+// newRecoveryCodes replaces a user's recovery codes and returns the new
+// ones, formatted XXXX-XXXX-XXXX-XXXX.
+func newRecoveryCodes(c *gin.Context, tx pgx.Tx, userID int64) ([]string, error) {
+	if _, err := tx.Exec(c, "DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := base32.StdEncoding.EncodeToString(b)
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+		_, err := tx.Exec(c,
+			"INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1,$2)",
+			userID, hashRecoveryCode(raw),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode ignores dashes and case, which users get wrong when
+// typing codes in.
+func hashRecoveryCode(code string) string {
+	return auth.HashToken(strings.ToUpper(strings.ReplaceAll(code, "-", "")))
+}
+
+// adminTwoFactorRequired reads the require_admin_2fa setting of an
+// organization.
+func adminTwoFactorRequired(c *gin.Context, orgID int64) (bool, error) {
+	var required bool
+	err := db.Pool.QueryRow(c,
+		"SELECT EXISTS (SELECT 1 FROM org_settings WHERE org_id=$1 AND key=$2 AND value='true')",
+		orgID, settingRequireAdmin2FA,
+	).Scan(&required)
+	return required, err
+}
+
+func (h *Handler) TwoFactorStatusHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var status api.TwoFactorStatus
+	var role string
+	var orgID int64
+	err := db.Pool.QueryRow(c,
+		`SELECT totp_enabled_at, COALESCE(role, 'user'), org_id,
+		   (SELECT count(*) FROM recovery_codes WHERE user_id=$1 AND used_at IS NULL)
+		 FROM users WHERE id=$1`,
+		userID,
+	).Scan(&status.EnabledAt, &role, &orgID, &status.RecoveryCodesLeft)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to load two-factor status"})
+		return
+	}
+	status.Enabled = status.EnabledAt != nil
+	if role == "admin" {
+		if status.Required, err = adminTwoFactorRequired(c, orgID); err != nil {
+			c.JSON(500, gin.H{"error": "failed to load two-factor status"})
+			return
+		}
+	}
+
+	c.JSON(200, status)
+}
+
+// EnrollTwoFactorHandler generates a secret for the user's authenticator
+// app. Calling it again replaces a secret that was not confirmed yet.
+func (h *Handler) EnrollTwoFactorHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var username string
+	var enabled bool
+	err := db.Pool.QueryRow(c,
+		"SELECT username, totp_enabled_at IS NOT NULL FROM users WHERE id=$1", userID,
+	).Scan(&username, &enabled)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start enrollment"})
+		return
+	}
+	if enabled {
+		c.JSON(409, gin.H{"error": "two-factor authentication is already on, turn it off first"})
+		return
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start enrollment"})
+		return
+	}
+	if _, err := db.Pool.Exec(c, "UPDATE users SET totp_pending_secret=$1 WHERE id=$2", secret, userID); err != nil {
+		c.JSON(500, gin.H{"error": "failed to start enrollment"})
+		return
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Balkan Storage"
+	}
+	c.JSON(200, api.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(issuer, username, secret),
+	})
+}
+
+// EnableTwoFactorHandler confirms enrollment with a code from the app and
+// returns the recovery codes.
+func (h *Handler) EnableTwoFactorHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var body api.TwoFactorCodeRequest
+	if err := c.BindJSON(&body); err != nil || body.Code == "" {
+		c.JSON(400, gin.H{"error": "code is required"})
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	var pending *string
+	err = tx.QueryRow(c, "SELECT totp_pending_secret FROM users WHERE id=$1 FOR UPDATE", userID).Scan(&pending)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+	if pending == nil {
+		c.JSON(409, gin.H{"error": "no enrollment in progress, call /2fa/enroll first"})
+		return
+	}
+	step, ok := totp.Validate(*pending, strings.Join(strings.Fields(body.Code), ""), time.Now())
+	if !ok {
+		c.JSON(400, gin.H{"error": "invalid code, check the authenticator app's clock"})
+		return
+	}
+
+	_, err = tx.Exec(c,
+		`UPDATE users SET totp_secret=totp_pending_secret, totp_pending_secret=NULL,
+		   totp_enabled_at=now(), totp_last_step=$2
+		 WHERE id=$1`,
+		userID, step,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+	codes, err := newRecoveryCodes(c, tx, userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "enable_2fa", "user", userID,
+	)
+	if err := tx.Commit(c); err != nil {
+		c.JSON(500, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(200, api.RecoveryCodes{
+		Codes:   codes,
+		Message: "store the recovery codes now, they are not shown again",
+	})
+}
+
+// DisableTwoFactorHandler turns two-factor authentication off. It takes a
+// code and, for accounts with one, the password.
+func (h *Handler) DisableTwoFactorHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var body api.DisableTwoFactorRequest
+	if err := c.BindJSON(&body); err != nil || body.Code == "" {
+		c.JSON(400, gin.H{"error": "code is required"})
+		return
+	}
+
+	var hash, secret, role string
+	var orgID int64
+	err := db.Pool.QueryRow(c,
+		"SELECT COALESCE(password_hash, ''), COALESCE(totp_secret, ''), COALESCE(role, 'user'), org_id FROM users WHERE id=$1",
+		userID,
+	).Scan(&hash, &secret, &role, &orgID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+	if secret == "" {
+		c.JSON(409, gin.H{"error": "two-factor authentication is not on"})
+		return
+	}
+	if hash != "" && !auth.CheckPasswordHash(body.Password, hash) {
+		c.JSON(401, gin.H{"error": "invalid password"})
+		return
+	}
+	if role == "admin" {
+		required, err := adminTwoFactorRequired(c, orgID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to disable two-factor authentication"})
+			return
+		}
+		if required {
+			c.JSON(403, gin.H{"error": "two-factor authentication is required for admins"})
+			return
+		}
+	}
+	if _, ok, err := checkTwoFactorCode(c, userID, secret, body.Code); err != nil || !ok {
+		c.JSON(401, gin.H{"error": "invalid code"})
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	_, err = tx.Exec(c,
+		`UPDATE users SET totp_secret=NULL, totp_pending_secret=NULL, totp_enabled_at=NULL, totp_last_step=NULL
+		 WHERE id=$1`,
+		userID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+	_, _ = tx.Exec(c, "DELETE FROM recovery_codes WHERE user_id=$1", userID)
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "disable_2fa", "user", userID,
+	)
+	if err := tx.Commit(c); err != nil {
+		c.JSON(500, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(200, api.Message{Message: "two-factor authentication turned off"})
+}
+
+// RegenerateRecoveryCodesHandler replaces the recovery codes, taking a code
+// from the app.
+func (h *Handler) RegenerateRecoveryCodesHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	var body api.TwoFactorCodeRequest
+	if err := c.BindJSON(&body); err != nil || body.Code == "" {
+		c.JSON(400, gin.H{"error": "code is required"})
+		return
+	}
+
+	var secret string
+	err := db.Pool.QueryRow(c, "SELECT COALESCE(totp_secret, '') FROM users WHERE id=$1", userID).Scan(&secret)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+	if secret == "" {
+		c.JSON(409, gin.H{"error": "two-factor authentication is not on"})
+		return
+	}
+	if _, ok, err := checkTwoFactorCode(c, userID, secret, body.Code); err != nil || !ok {
+		c.JSON(401, gin.H{"error": "invalid code"})
+		return
+	}
+
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+	defer tx.Rollback(c)
+
+	codes, err := newRecoveryCodes(c, tx, userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+	_, _ = tx.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id) VALUES ($1,$2,$3,$4)",
+		userID, "regenerate_recovery_codes", "user", userID,
+	)
+	if err := tx.Commit(c); err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+
+	c.JSON(200, api.RecoveryCodes{
+		Codes:   codes,
+		Message: "store the recovery codes now, the old ones no longer work",
+	})
+}
+
+func (h *Handler) GetSecuritySettingsHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	required, err := adminTwoFactorRequired(c, orgID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to load settings"})
+		return
+	}
+	c.JSON(200, api.SecuritySettings{RequireAdminTwoFactor: required})
+}
+
+func (h *Handler) UpdateSecuritySettingsHandler(c *gin.Context) {
+	orgID, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	userID := c.GetInt64("user_id")
+
+	var body api.SecuritySettings
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if body.RequireAdminTwoFactor {
+		// Otherwise the admin would lose their rights on the spot.
+		enabled, err := twoFactorEnabled(c, userID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to update settings"})
+			return
+		}
+		if !enabled {
+			c.JSON(409, gin.H{"error": "turn on two-factor authentication for your own account first"})
+			return
+		}
+	}
+
+	_, err := db.Pool.Exec(c,
+		`INSERT INTO org_settings (org_id, key, value, updated_by) VALUES ($1,$2,$3,$4)
+		 ON CONFLICT (org_id, key) DO UPDATE SET value=EXCLUDED.value, updated_at=now(), updated_by=EXCLUDED.updated_by`,
+		orgID, settingRequireAdmin2FA, boolSetting(body.RequireAdminTwoFactor), userID,
+	)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to update settings"})
+		return
+	}
+
+	meta, _ := json.Marshal(body)
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "update_security_settings", "organization", orgID, string(meta),
+	)
+
+	c.JSON(200, body)
+}
+
+func boolSetting(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
+)
+
+type uploadResult struct {
+	FileID   int64
+	BlobID   int64
+	Filename string
+	Hash     string
+	Size     int64
+	MIME     string
+}
+
+// response reports the upload the way every upload route does.
+func (r *uploadResult) response(tags []string, folderID *int64) api.UploadResult {
+	if tags == nil {
+		tags = []string{}
+	}
+	return api.UploadResult{
+		FileID:   r.FileID,
+		BlobID:   r.BlobID,
+		Filename: r.Filename,
+		Size:     r.Size,
+		Hash:     r.Hash,
+		MIMEType: r.MIME,
+		Tags:     tags,
+		FolderID: folderID,
+		Status:   api.UploadStatusUploaded,
+	}
+}
+
+type uploadError struct {
+	Status   int
+	Message  string
+	Detected string
+}
+
+// stagedUpload is an upload that has been spooled to disk once, hashing and
+// sniffing it on the way through.
+type stagedUpload struct {
+	Path  string
+	Hash  string
+	Size  int64
+	Sniff []byte
+}
+
+type stagedPart struct {
+	*stagedUpload
+	Filename string
+	Declared string
+}
+
+type uploadForm struct {
+	Files  []stagedPart
+	Fields map[string]string
+}
+
+func (f *uploadForm) cleanup() {
+	for _, p := range f.Files {
+		os.Remove(p.Path)
+	}
+}
+
+// sniffWriter keeps the first 512 bytes written to it for MIME detection.
+type sniffWriter struct {
+	buf []byte
+}
+
+func (w *sniffWriter) Write(p []byte) (int, error) {
+	if room := 512 - len(w.buf); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		w.buf = append(w.buf, p[:room]...)
+	}
+	return len(p), nil
+}
+
+// stageUpload streams r into a temp file in the staging dir, hashing and
+// sniffing it in the same pass.
+func (h *Handler) stageUpload(r io.Reader) (*stagedUpload, error) {
+	dir := storage.StagingDir(h.Blobs, h.StoragePath)
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	sniff := &sniffWriter{}
+	size, err := io.Copy(io.MultiWriter(tmp, hasher, sniff), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return &stagedUpload{
+		Path:  tmp.Name(),
+		Hash:  hex.EncodeToString(hasher.Sum(nil)),
+		Size:  size,
+		Sniff: sniff.buf,
+	}, nil
+}
+
+// stageFile hashes and sniffs a file that is already on disk (a finished
+// tus upload) so it can be committed in place.
+func stageFile(path string) (*stagedUpload, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	sniff := &sniffWriter{}
+	size, err := io.Copy(io.MultiWriter(hasher, sniff), f)
+	if err != nil {
+		return nil, err
+	}
+	return &stagedUpload{Path: path, Hash: hex.EncodeToString(hasher.Sum(nil)), Size: size, Sniff: sniff.buf}, nil
+}
+
+// readUploadForm walks a multipart body part by part, staging every file
+// part named fileField and collecting the plain form fields.
+func (h *Handler) readUploadForm(c *gin.Context, fileField string) (*uploadForm, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	form := &uploadForm{Fields: make(map[string]string)}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			form.cleanup()
+			return nil, err
+		}
+
+		if part.FileName() == "" {
+			value, _ := io.ReadAll(io.LimitReader(part, 64*1024))
+			form.Fields[part.FormName()] = string(value)
+			part.Close()
+			continue
+		}
+		if part.FormName() != fileField {
+			part.Close()
+			continue
+		}
+
+		staged, err := h.stageUpload(part)
+		part.Close()
+		if err != nil {
+			form.cleanup()
+			return nil, err
+		}
+		form.Files = append(form.Files, stagedPart{
+			stagedUpload: staged,
+			Filename:     part.FileName(),
+			Declared:     part.Header.Get("Content-Type"),
+		})
+	}
+}
+
+// saveUpload runs a staged file through MIME validation, quota, blob
+// dedup and versioning, and records the file (see recordFile). Every
+// upload path (form uploads, tus, WebDAV, the S3 gateway) finishes here.
+// The staged file is moved into the blob store when its content is new;
+// callers remove it otherwise.
+func (h *Handler) saveUpload(c *gin.Context, userID int64, file *stagedUpload, name, declared string, tags []string, folderID *int64) (*uploadResult, *uploadError) {
+	filename := filepath.Base(name)
+	filename = strings.ReplaceAll(filename, "..", "")
+	if !validateFilename(filename) {
+		return nil, &uploadError{Status: 400, Message: "invalid filename"}
+	}
+
+	detected, valid := detectAndValidateMIME(file.Sniff, declared)
+	if !valid {
+		return nil, &uploadError{Status: 400, Message: "MIME type mismatch", Detected: detected}
+	}
+
+	hash, size := file.Hash, file.Size
+
+	if uerr := checkQuota(c, userID, size); uerr != nil {
+		return nil, uerr
+	}
+
+	// With per-user encryption a blob is only shared between uploads that use
+	// the same data key, so the key id is part of the dedup identity.
+	blobKey := hash
+	var keyID *int64
+	if h.Encryption != nil && !h.Encryption.Convergent {
+		id, err := h.Encryption.UserKeyID(c, userID)
+		if err != nil {
+			return nil, &uploadError{Status: 500, Message: "failed to load encryption key"}
+		}
+		keyID = &id
+		blobKey = fmt.Sprintf("%s-%d", hash, id)
+	}
+
+	var blobID int64
+	err := db.Pool.QueryRow(c, "SELECT id FROM blobs WHERE hash=$1 AND key_id IS NOT DISTINCT FROM $2", hash, keyID).Scan(&blobID)
+	if err == nil {
+		_, _ = db.Pool.Exec(c, "UPDATE blobs SET ref_count = ref_count + 1 WHERE id=$1", blobID)
+	} else {
+		ctx := context.Context(c)
+		if keyID != nil {
+			ctx = storage.WithKeyID(c, *keyID)
+		}
+		if err := storage.Commit(ctx, h.Blobs, blobKey, file.Path, size); err != nil {
+			return nil, &uploadError{Status: 500, Message: "failed to save file"}
+		}
+
+		err = db.Pool.QueryRow(
+			c,
+			"INSERT INTO blobs (hash, size, path, ref_count, created_at, key_id) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id",
+			hash, size, blobKey, 1, time.Now(), keyID,
+		).Scan(&blobID)
+		if err != nil {
+			return nil, &uploadError{Status: 500, Message: "failed to insert blob"}
+		}
+	}
+
+	return h.recordFile(c, userID, blobID, hash, filename, detected, size, tags, folderID)
+}
+
+// copyFile records a file with the same content as src under name. The
+// blob is shared, so only quota and the files row are involved.
+func (h *Handler) copyFile(c *gin.Context, userID int64, src *pathFile, name string, folderID *int64) (*uploadResult, *uploadError) {
+	filename := filepath.Base(name)
+	filename = strings.ReplaceAll(filename, "..", "")
+	if !validateFilename(filename) {
+		return nil, &uploadError{Status: 400, Message: "invalid filename"}
+	}
+	if uerr := checkQuota(c, userID, src.Size); uerr != nil {
+		return nil, uerr
+	}
+
+	var tags []string
+	_ = db.Pool.QueryRow(c, "SELECT COALESCE(tags, '{}') FROM files WHERE id=$1", src.ID).Scan(pq.Array(&tags))
+
+	_, err := db.Pool.Exec(c, "UPDATE blobs SET ref_count = ref_count + 1 WHERE id=$1", src.BlobID)
+	if err != nil {
+		return nil, &uploadError{Status: 500, Message: "failed to copy file"}
+	}
+	return h.recordFile(c, userID, src.BlobID, src.Hash, filename, src.MIME, src.Size, tags, folderID)
+}
+
+// checkQuota checks that size more bytes fit in the user's quota and in
+// their organization's.
+func checkQuota(c *gin.Context, userID, size int64) *uploadError {
+	var quota, orgID int64
+	err := db.Pool.QueryRow(c, "SELECT quota, org_id FROM users WHERE id=$1", userID).Scan(&quota, &orgID)
+	if err != nil {
+		return &uploadError{Status: 500, Message: "failed to get quota"}
+	}
+	var used int64
+	err = db.Pool.QueryRow(c, "SELECT COALESCE(SUM(size),0) FROM files WHERE owner_id=$1 AND trashed=false", userID).Scan(&used)
+	if err != nil {
+		return &uploadError{Status: 500, Message: "failed to calculate usage"}
+	}
+	if quota > 0 && used+size > quota {
+		return &uploadError{Status: 413, Message: "quota exceeded"}
+	}
+
+	orgQuota, orgUsed, err := orgUsage(c, orgID)
+	if err != nil {
+		return &uploadError{Status: 500, Message: "failed to calculate usage"}
+	}
+	if orgQuota > 0 && orgUsed+size > orgQuota {
+		return &uploadError{Status: 413, Message: "organization quota exceeded"}
+	}
+	return nil
+}
+
+// recordFile records a blob the caller holds a reference on under filename.
+// A live file of the same name in the folder, whoever owns it, gets it as
+// a new version (see addVersion); otherwise a files row is inserted. At the
+// top of the tree only the caller's own files count. Either way the upload
+// is announced.
+func (h *Handler) recordFile(c *gin.Context, userID, blobID int64, hash, filename, detected string, size int64, tags []string, folderID *int64) (*uploadResult, *uploadError) {
+	previewAvailable := false
+	if strings.HasPrefix(detected, "image/") ||
+		detected == "application/pdf" ||
+		strings.HasPrefix(detected, "text/") {
+		previewAvailable = true
+	}
+
+	var fileID int64
+	for attempt := 0; ; attempt++ {
+		var oldBlobID int64
+		err := db.Pool.QueryRow(
+			c,
+			`SELECT id, blob_id FROM files
+			 WHERE folder_id IS NOT DISTINCT FROM $3 AND (folder_id IS NOT NULL OR owner_id=$1) AND filename=$2 AND trashed=false`,
+			userID, filename, folderID,
+		).Scan(&fileID, &oldBlobID)
+		if err == nil {
+			if err := addVersion(c, fileID, oldBlobID, blobID, detected, size, previewAvailable, tags); err != nil {
+				return nil, &uploadError{Status: 500, Message: "failed to add file version"}
+			}
+			break
+		}
+
+		err = db.Pool.QueryRow(
+			c,
+			"INSERT INTO files (blob_id, owner_id, filename, mime_type, size, created_at, tags, folder_id, preview_available) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING id",
+			blobID, userID, filename, detected, size, time.Now(), pq.Array(tags), folderID, previewAvailable,
+		).Scan(&fileID)
+		if err == nil {
+			break
+		}
+		// Another upload of the same name won the insert; version it instead.
+		if !isUniqueViolation(err) || attempt > 0 {
+			return nil, &uploadError{Status: 500, Message: "failed to insert file"}
+		}
+	}
+
+	_, _ = db.Pool.Exec(c,
+		"INSERT INTO audit_logs (user_id, action, object_type, object_id, meta) VALUES ($1,$2,$3,$4,$5)",
+		userID, "upload_file", "file", fileID, fmt.Sprintf(`{"filename":%q}`, filename),
+	)
+
+	broadcastUpdate(c, gin.H{
+		"event":     "file_uploaded",
+		"file_id":   fileID,
+		"filename":  filename,
+		"size":      size,
+		"mime":      detected,
+		"tags":      tags,
+		"folder_id": folderID,
+		"user":      userID,
+		"timestamp": time.Now(),
+	})
+
+	return &uploadResult{
+		FileID:   fileID,
+		BlobID:   blobID,
+		Filename: filename,
+		Hash:     hash,
+		Size:     size,
+		MIME:     detected,
+	}, nil
+}
+
+// addVersion makes blobID the current content of file fileID, keeping both
+// the old and the new content as file_versions rows. Every files row and
+// every file_versions row holds one reference on its blob; the caller's
+// reference on blobID goes to the files row. New tags replace the old ones,
+// no tags keeps them.
+func addVersion(c *gin.Context, fileID, oldBlobID, blobID int64, mime string, size int64, preview bool, tags []string) error {
+	tx, err := db.Pool.Begin(c)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(c)
+
+	// The same content again is not a new version.
+	if blobID == oldBlobID {
+		if _, err := tx.Exec(c, "UPDATE blobs SET ref_count = ref_count - 1 WHERE id=$1", blobID); err != nil {
+			return err
+		}
+		return tx.Commit(c)
+	}
+
+	if err := retireBlob(c, tx, fileID, oldBlobID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(c,
+		`INSERT INTO file_versions (file_id, version, blob_id, created_at)
+		 SELECT $1, COALESCE(MAX(version),0)+1, $2, $3 FROM file_versions WHERE file_id=$1`,
+		fileID, blobID, time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(c, "UPDATE blobs SET ref_count = ref_count + 1 WHERE id=$1", blobID); err != nil {
+		return err
+	}
+
+	if len(tags) > 0 {
+		_, err = tx.Exec(c,
+			"UPDATE files SET blob_id=$1, mime_type=$2, size=$3, preview_available=$4, tags=$5 WHERE id=$6",
+			blobID, mime, size, preview, pq.Array(tags), fileID,
+		)
+	} else {
+		_, err = tx.Exec(c,
+			"UPDATE files SET blob_id=$1, mime_type=$2, size=$3, preview_available=$4 WHERE id=$5",
+			blobID, mime, size, preview, fileID,
+		)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(c)
+}
+
+// retireBlob drops the files row's reference on blobID, its current
+// content, before the row is pointed elsewhere. Content that is not yet one
+// of the file's versions becomes the next version, which takes the
+// reference over instead.
+func retireBlob(c *gin.Context, tx pgx.Tx, fileID, blobID int64) error {
+	var versioned bool
+	err := tx.QueryRow(c,
+		"SELECT EXISTS (SELECT 1 FROM file_versions WHERE file_id=$1 AND blob_id=$2)",
+		fileID, blobID,
+	).Scan(&versioned)
+	if err != nil {
+		return err
+	}
+	if versioned {
+		_, err = tx.Exec(c, "UPDATE blobs SET ref_count = ref_count - 1 WHERE id=$1", blobID)
+		return err
+	}
+	_, err = tx.Exec(c,
+		`INSERT INTO file_versions (file_id, version, blob_id, created_at)
+		 SELECT $1, COALESCE(MAX(version),0)+1, $2, $3 FROM file_versions WHERE file_id=$1`,
+		fileID, blobID, time.Now(),
+	)
+	return err
+}
+
+// purgeFile deletes a files row together with its versions and drops the
+// blob references they held, removing blobs nothing refers to any more.
+func (h *Handler) purgeFile(c *gin.Context, tx pgx.Tx, fileID int64) error {
+	rows, err := tx.Query(c,
+		"SELECT blob_id FROM files WHERE id=$1 UNION ALL SELECT blob_id FROM file_versions WHERE file_id=$1",
+		fileID,
+	)
+	if err != nil {
+		return err
+	}
+	var blobIDs []int64
+	for rows.Next() {
+		var bid int64
+		if err := rows.Scan(&bid); err != nil {
+			rows.Close()
+			return err
+		}
+		blobIDs = append(blobIDs, bid)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(c, "DELETE FROM files WHERE id=$1", fileID); err != nil {
+		return err
+	}
+
+	for _, bid := range blobIDs {
+		var refCount int
+		var blobKey string
+		err := tx.QueryRow(c,
+			"UPDATE blobs SET ref_count = ref_count - 1 WHERE id=$1 RETURNING ref_count, path",
+			bid,
+		).Scan(&refCount, &blobKey)
+		if err != nil {
+			continue
+		}
+		if refCount <= 0 {
+			_, _ = tx.Exec(c, "DELETE FROM blobs WHERE id=$1", bid)
+			_ = h.Blobs.Delete(c, blobKey)
+		}
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is Postgres refusing a duplicate,
+// such as a second live entry of the same name in a folder.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// parseUploadTags splits a comma separated tag list, reporting false if any
+// tag is out of bounds.
+func parseUploadTags(tagStr string) ([]string, bool) {
+	if tagStr == "" {
+		return nil, true
+	}
+	tags := strings.Split(tagStr, ",")
+	for _, t := range tags {
+		if !validateTag(t) {
+			return nil, false
+		}
+	}
+	return tags, true
+}
+
+func parseUploadFolder(folderIDStr string) *int64 {
+	if folderIDStr == "" {
+		return nil
+	}
+	if fid, err := strconv.ParseInt(folderIDStr, 10, 64); err == nil {
+		return &fid
+	}
+	return nil
+}
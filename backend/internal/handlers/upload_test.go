@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStageUpload(t *testing.T) {
+	h := &Handler{StoragePath: t.TempDir()}
+	content := strings.Repeat("0123456789", 100)
+
+	staged, err := h.stageUpload(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(staged.Path)
+
+	sum := sha256.Sum256([]byte(content))
+	if staged.Hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("hash %s, want %x", staged.Hash, sum)
+	}
+	if staged.Size != int64(len(content)) {
+		t.Errorf("size %d, want %d", staged.Size, len(content))
+	}
+	if string(staged.Sniff) != content[:512] {
+		t.Errorf("sniffed %d bytes, want the first 512", len(staged.Sniff))
+	}
+	if filepath.Dir(staged.Path) != h.StoragePath {
+		t.Errorf("staged in %s, want %s", filepath.Dir(staged.Path), h.StoragePath)
+	}
+	if data, _ := os.ReadFile(staged.Path); string(data) != content {
+		t.Error("the staged file does not hold the upload")
+	}
+
+	again, err := stageFile(staged.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Hash != staged.Hash || again.Size != staged.Size || string(again.Sniff) != string(staged.Sniff) {
+		t.Error("staging the file on disk gives a different result")
+	}
+}
+
+// TestUploadDedup checks that uploading the same content twice stores one
+// blob and leaves nothing staged behind.
+func TestUploadDedup(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+
+	first := upload(t, h, alice, 0, "a.txt", "same content")
+	second := upload(t, h, alice, 0, "b.txt", "same content")
+	if first.FileID == second.FileID {
+		t.Fatal("both uploads recorded the same file")
+	}
+	if n := count(t, "SELECT count(*) FROM blobs"); n != 1 {
+		t.Errorf("%d blobs stored, want 1", n)
+	}
+	if n := count(t, "SELECT ref_count FROM blobs"); n != 2 {
+		t.Errorf("the blob has %d references, want 2", n)
+	}
+
+	staging := filepath.Join(h.StoragePath, "blobs")
+	left, _ := filepath.Glob(filepath.Join(staging, ".upload-*"))
+	if len(left) != 0 {
+		t.Errorf("staged files left behind: %v", left)
+	}
+}
@@ -0,0 +1,549 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"golang.org/x/net/webdav"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
+)
+
+// WebDAV (RFC 4918) access to a user's own folders and files, served under
+// /dav. Folders are collections and files are resources; writes finish in
+// saveUpload like every other upload path.
+
+const davPrefix = "/dav"
+
+// WebDAVMethods are the methods routed to WebDAVHandler.
+var WebDAVMethods = []string{
+	"OPTIONS", "GET", "HEAD", "PUT", "DELETE",
+	"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK",
+}
+
+// Locks are held in memory, one lock namespace per user since every user
+// sees their own tree under the same paths.
+var davLocks sync.Map
+
+func davLockSystem(userID int64) webdav.LockSystem {
+	ls, _ := davLocks.LoadOrStore(userID, webdav.NewMemLS())
+	return ls.(webdav.LockSystem)
+}
+
+const davAuthTTL = time.Minute
+
+type davAuthEntry struct {
+	userID  int64
+	expires time.Time
+}
+
+// DAV clients send Basic credentials with every request; verified ones are
+// remembered briefly so each request doesn't pay for a bcrypt comparison.
+var davAuthCache sync.Map
+
+// forgetDAVCredentials drops a user's remembered credentials, once their
+// password changed, they logged out everywhere or revoked a token.
+func forgetDAVCredentials(userID int64) {
+	davAuthCache.Range(func(key, e any) bool {
+		if e.(davAuthEntry).userID == userID {
+			davAuthCache.Delete(key)
+		}
+		return true
+	})
+}
+
+// BasicAuthMiddleware authenticates with HTTP Basic credentials checked
+// the same way as LoginHandler.
+func BasicAuthMiddleware(c *gin.Context) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		c.Header("WWW-Authenticate", `Basic realm="Balkan Storage"`)
+		c.AbortWithStatus(401)
+		return
+	}
+
+	cacheKey := sha256.Sum256([]byte(username + "\x00" + password))
+	if e, found := davAuthCache.Load(cacheKey); found {
+		entry := e.(davAuthEntry)
+		if time.Now().Before(entry.expires) {
+			c.Set("user_id", entry.userID)
+			c.Next()
+			return
+		}
+		davAuthCache.Delete(cacheKey)
+	}
+
+	if loginBlocked(c, username) {
+		c.Abort()
+		return
+	}
+	userID, ok := davCredentials(c, username, password)
+	if !ok {
+		loginFailed(c, username)
+		c.Header("WWW-Authenticate", `Basic realm="Balkan Storage"`)
+		c.AbortWithStatus(401)
+		return
+	}
+	loginSucceeded(c, username)
+	davAuthCache.Store(cacheKey, davAuthEntry{userID: userID, expires: time.Now().Add(davAuthTTL)})
+
+	c.Set("user_id", userID)
+	c.Next()
+}
+
+// davCredentials checks Basic credentials. Users with two-factor
+// authentication cannot use their password, which would get around it, and
+// use a personal access token instead; it needs both file scopes and no
+// folder restriction.
+func davCredentials(c *gin.Context, username, password string) (int64, bool) {
+	if strings.HasPrefix(password, api.PersonalAccessTokenPrefix) {
+		userID, pat, err := checkPersonalAccessToken(c, password)
+		if err != nil || pat.FolderID != nil ||
+			!slices.Contains(pat.Scopes, api.ScopeFilesRead) || !slices.Contains(pat.Scopes, api.ScopeFilesWrite) {
+			return 0, false
+		}
+		var owner string
+		if db.Pool.QueryRow(c, "SELECT username FROM users WHERE id=$1", userID).Scan(&owner) != nil || owner != username {
+			return 0, false
+		}
+		return userID, true
+	}
+
+	userID, _, ok := checkCredentials(c, username, password)
+	if !ok {
+		return 0, false
+	}
+	if twoFactor, err := twoFactorEnabled(c, userID); err != nil || twoFactor {
+		return 0, false
+	}
+	return userID, true
+}
+
+func (h *Handler) WebDAVHandler(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	fs := &davFS{h: h, c: c, userID: userID}
+
+	// Remember why the body stopped short so a truncated PUT is not saved.
+	c.Request.Body = &davBody{ReadCloser: c.Request.Body, fs: fs}
+
+	dav := &webdav.Handler{
+		Prefix:     davPrefix,
+		FileSystem: fs,
+		LockSystem: davLockSystem(userID),
+	}
+	dav.ServeHTTP(&davResponseWriter{ResponseWriter: c.Writer, fs: fs}, c.Request)
+}
+
+type davBody struct {
+	io.ReadCloser
+	fs *davFS
+}
+
+func (b *davBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			b.fs.uploadErr = &uploadError{Status: 413, Message: "file too large"}
+		}
+		b.fs.bodyErr = err
+	}
+	return n, err
+}
+
+// davResponseWriter replaces the generic status the webdav package uses
+// for failed writes with the status saveUpload gave (quota, MIME, ...).
+type davResponseWriter struct {
+	http.ResponseWriter
+	fs      *davFS
+	replied bool
+}
+
+func (w *davResponseWriter) WriteHeader(code int) {
+	if w.fs.uploadErr == nil {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.replied = true
+	w.ResponseWriter.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.ResponseWriter.WriteHeader(w.fs.uploadErr.Status)
+	_, _ = io.WriteString(w.ResponseWriter, w.fs.uploadErr.Message)
+}
+
+func (w *davResponseWriter) Write(p []byte) (int, error) {
+	if w.replied {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// davFS is a webdav.FileSystem over one user's folders and files tables.
+type davFS struct {
+	h      *Handler
+	c      *gin.Context
+	userID int64
+
+	uploadErr *uploadError
+	bodyErr   error
+}
+
+// davNode is a resolved path: the root, a folder or a file.
+type davNode struct {
+	info   davInfo
+	folder *pathFolder
+	file   *pathFile
+}
+
+// folderID is the folder a directory node stands for (nil for the root).
+func (n *davNode) folderID() *int64 {
+	if n.folder == nil {
+		return nil
+	}
+	return &n.folder.ID
+}
+
+func folderInfo(f pathFolder) davInfo {
+	return davInfo{name: f.Name, dir: true, modTime: f.Created}
+}
+
+func fileInfo(f pathFile) davInfo {
+	return davInfo{name: f.Name, size: f.Size, modTime: f.Created, mime: f.MIME, etag: f.Hash}
+}
+
+// resolve looks name up; a folder shadows a file of the same name.
+func (fs *davFS) resolve(name string) (*davNode, error) {
+	parentID, base, err := fs.resolveParent(name)
+	if err == os.ErrPermission {
+		return &davNode{info: davInfo{name: "/", dir: true}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f, err := lookupFolder(fs.c, fs.userID, parentID, base); err == nil {
+		return &davNode{info: folderInfo(*f), folder: f}, nil
+	}
+	f, err := lookupFile(fs.c, fs.userID, parentID, base)
+	if err != nil {
+		return nil, err
+	}
+	return &davNode{info: fileInfo(*f), file: f}, nil
+}
+
+// resolveParent resolves the folder that would contain name. The root has
+// no parent and reports os.ErrPermission.
+func (fs *davFS) resolveParent(name string) (*int64, string, error) {
+	parts := splitPath(name)
+	if len(parts) == 0 {
+		return nil, "", os.ErrPermission
+	}
+	parentID, err := resolveFolderPath(fs.c, fs.userID, nil, parts[:len(parts)-1])
+	if err != nil {
+		return nil, "", err
+	}
+	return parentID, parts[len(parts)-1], nil
+}
+
+func (fs *davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	n, err := fs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return n.info, nil
+}
+
+func (fs *davFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	parentID, base, err := fs.resolveParent(name)
+	if err != nil {
+		return err
+	}
+	if _, err := fs.resolve(name); err == nil {
+		return os.ErrExist
+	}
+	_, err = createFolder(fs.c, fs.userID, parentID, base)
+	return err
+}
+
+// RemoveAll moves the file or folder to the trash, as the trash endpoints do.
+func (fs *davFS) RemoveAll(ctx context.Context, name string) error {
+	n, err := fs.resolve(name)
+	if err != nil {
+		return err
+	}
+	if n.file != nil {
+		return trashFile(fs.c, fs.userID, n.file)
+	}
+	if n.folder == nil {
+		return os.ErrPermission
+	}
+	return trashFolder(fs.c, fs.userID, n.folder.ID)
+}
+
+func (fs *davFS) Rename(ctx context.Context, oldName, newName string) error {
+	n, err := fs.resolve(oldName)
+	if err != nil {
+		return err
+	}
+	parentID, base, err := fs.resolveParent(newName)
+	if err != nil {
+		return err
+	}
+	if n.file != nil {
+		return moveFile(fs.c, fs.userID, n.file, parentID, base)
+	}
+	if n.folder == nil {
+		return os.ErrPermission
+	}
+	return moveFolder(fs.c, fs.userID, n.folder.ID, parentID, base)
+}
+
+func (fs *davFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		return fs.create(name)
+	}
+
+	n, err := fs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if n.info.dir {
+		return &davDir{fs: fs, node: n}, nil
+	}
+	return &davReader{fs: fs, node: n}, nil
+}
+
+func (fs *davFS) create(name string) (webdav.File, error) {
+	parentID, base, err := fs.resolveParent(name)
+	if err != nil {
+		return nil, err
+	}
+	if !validateFilename(base) {
+		return nil, os.ErrInvalid
+	}
+
+	// Overwriting keeps the tags of the file being replaced.
+	var tags []string
+	if n, err := fs.resolve(name); err == nil {
+		if n.file == nil {
+			return nil, os.ErrExist
+		}
+		_ = db.Pool.QueryRow(fs.c, "SELECT COALESCE(tags, '{}') FROM files WHERE id=$1", n.file.ID).Scan(pq.Array(&tags))
+	}
+
+	pr, pw := io.Pipe()
+	w := &davWriter{fs: fs, name: base, folderID: parentID, tags: tags, pw: pw, done: make(chan struct{})}
+	go func() {
+		w.staged, w.stageErr = fs.h.stageUpload(pr)
+		pr.CloseWithError(w.stageErr)
+		close(w.done)
+	}()
+	return w, nil
+}
+
+// davInfo is the os.FileInfo for a node. It also provides the content type
+// and ETag (the content hash) so PROPFIND doesn't have to read the blob.
+type davInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	mime    string
+	etag    string
+}
+
+func (i davInfo) Name() string       { return i.name }
+func (i davInfo) Size() int64        { return i.size }
+func (i davInfo) ModTime() time.Time { return i.modTime }
+func (i davInfo) IsDir() bool        { return i.dir }
+func (i davInfo) Sys() any           { return nil }
+
+func (i davInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func (i davInfo) ContentType(ctx context.Context) (string, error) {
+	if i.mime == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return i.mime, nil
+}
+
+func (i davInfo) ETag(ctx context.Context) (string, error) {
+	if i.etag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return `"` + i.etag + `"`, nil
+}
+
+// davDir is an open collection.
+type davDir struct {
+	fs      *davFS
+	node    *davNode
+	entries []os.FileInfo
+	listed  bool
+}
+
+func (d *davDir) Read(p []byte) (int, error)                   { return 0, os.ErrInvalid }
+func (d *davDir) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+func (d *davDir) Write(p []byte) (int, error)                  { return 0, os.ErrPermission }
+func (d *davDir) Close() error                                 { return nil }
+func (d *davDir) Stat() (os.FileInfo, error)                   { return d.node.info, nil }
+
+func (d *davDir) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.listed {
+		entries, err := d.fs.list(d.node.folderID())
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.listed = entries, true
+	}
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(d.entries) {
+		count = len(d.entries)
+	}
+	entries := d.entries[:count]
+	d.entries = d.entries[count:]
+	return entries, nil
+}
+
+// list returns the children of a folder. Folders shadow files of the same
+// name, matching resolve.
+func (fs *davFS) list(folderID *int64) ([]os.FileInfo, error) {
+	folders, files, err := listFolder(fs.c, fs.userID, folderID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var entries []os.FileInfo
+	for _, f := range folders {
+		seen[f.Name] = true
+		entries = append(entries, folderInfo(f))
+	}
+	for _, f := range files {
+		if !seen[f.Name] {
+			entries = append(entries, fileInfo(f))
+		}
+	}
+	return entries, nil
+}
+
+// davReader is a file opened for reading. The blob is only opened once the
+// content is actually read, since PROPFIND opens every file it lists.
+type davReader struct {
+	fs   *davFS
+	node *davNode
+	obj  *storage.Object
+}
+
+func (f *davReader) object() (*storage.Object, error) {
+	if f.obj == nil {
+		obj, err := storage.Open(f.fs.c, f.fs.h.Blobs, f.node.file.BlobKey)
+		if err != nil {
+			return nil, err
+		}
+		f.obj = obj
+	}
+	return f.obj, nil
+}
+
+func (f *davReader) Read(p []byte) (int, error) {
+	obj, err := f.object()
+	if err != nil {
+		return 0, err
+	}
+	return obj.Read(p)
+}
+
+func (f *davReader) Seek(offset int64, whence int) (int64, error) {
+	obj, err := f.object()
+	if err != nil {
+		return 0, err
+	}
+	return obj.Seek(offset, whence)
+}
+
+func (f *davReader) Close() error {
+	if f.obj != nil {
+		return f.obj.Close()
+	}
+	return nil
+}
+
+func (f *davReader) Readdir(count int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
+func (f *davReader) Stat() (os.FileInfo, error)               { return f.node.info, nil }
+func (f *davReader) Write(p []byte) (int, error)              { return 0, os.ErrPermission }
+
+// davWriter streams a PUT into stageUpload and hands the result to
+// saveUpload on Close.
+type davWriter struct {
+	fs       *davFS
+	name     string
+	folderID *int64
+	tags     []string
+	written  int64
+
+	pw       *io.PipeWriter
+	done     chan struct{}
+	staged   *stagedUpload
+	stageErr error
+}
+
+func (w *davWriter) Write(p []byte) (int, error) {
+	n, err := w.pw.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *davWriter) Close() error {
+	w.pw.Close()
+	<-w.done
+	if w.stageErr != nil {
+		return w.stageErr
+	}
+	defer os.Remove(w.staged.Path)
+
+	if w.fs.bodyErr != nil {
+		return w.fs.bodyErr
+	}
+	// LOCK on a missing name creates an empty placeholder that the PUT
+	// after it replaces; storing it would only add an empty version.
+	if w.fs.c.Request.Method == "LOCK" {
+		return nil
+	}
+
+	_, uerr := w.fs.h.saveUpload(w.fs.c, w.fs.userID, w.staged, w.name, "", w.tags, w.folderID)
+	if uerr != nil {
+		w.fs.uploadErr = uerr
+		return errors.New(uerr.Message)
+	}
+	return nil
+}
+
+func (w *davWriter) Stat() (os.FileInfo, error) {
+	return davInfo{name: w.name, size: w.written, modTime: time.Now()}, nil
+}
+
+func (w *davWriter) Read(p []byte) (int, error)                   { return 0, os.ErrInvalid }
+func (w *davWriter) Seek(offset int64, whence int) (int64, error) { return 0, os.ErrInvalid }
+func (w *davWriter) Readdir(count int) ([]os.FileInfo, error)     { return nil, os.ErrInvalid }
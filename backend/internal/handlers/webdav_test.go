@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// dav sends a WebDAV request as userID, with headers given as name, value
+// pairs.
+func dav(h *Handler, userID int64, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	return serveRequest(userID, h.WebDAVHandler, davPrefix+"/*path", req)
+}
+
+func TestDAVFiles(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+
+	if w := dav(h, alice, "MKCOL", "/dav/docs", ""); w.Code != 201 {
+		t.Fatalf("MKCOL: got %d, want 201", w.Code)
+	}
+	if w := dav(h, alice, "PUT", "/dav/docs/a.txt", "hello"); w.Code != 201 {
+		t.Fatalf("PUT: got %d %s, want 201", w.Code, w.Body)
+	}
+	if w := dav(h, alice, "PUT", "/dav/docs/a.txt", "hello again"); w.Code != 201 && w.Code != 204 {
+		t.Fatalf("PUT over a file: got %d %s", w.Code, w.Body)
+	}
+	if n := count(t, "SELECT count(*) FROM file_versions v JOIN files f ON v.file_id = f.id WHERE f.filename='a.txt'"); n != 1 {
+		t.Errorf("overwriting made %d versions, want 1", n)
+	}
+
+	w := dav(h, alice, "GET", "/dav/docs/a.txt", "")
+	if body, _ := io.ReadAll(w.Body); w.Code != 200 || string(body) != "hello again" {
+		t.Errorf("GET: got %d %q", w.Code, body)
+	}
+	w = dav(h, alice, "PROPFIND", "/dav/docs/", "", "Depth", "1")
+	if w.Code != 207 || !strings.Contains(w.Body.String(), "/dav/docs/a.txt") {
+		t.Errorf("PROPFIND: got %d %s", w.Code, w.Body)
+	}
+
+	if w := dav(h, alice, "MOVE", "/dav/docs/a.txt", "", "Destination", "/dav/b.txt"); w.Code != 201 {
+		t.Errorf("MOVE: got %d %s, want 201", w.Code, w.Body)
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE filename='b.txt' AND folder_id IS NULL"); n != 1 {
+		t.Error("MOVE did not rename the file to the root")
+	}
+	if w := dav(h, alice, "DELETE", "/dav/b.txt", ""); w.Code != 204 {
+		t.Errorf("DELETE: got %d, want 204", w.Code)
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE filename='b.txt' AND trashed=true"); n != 1 {
+		t.Error("DELETE did not move the file to the trash")
+	}
+	if w := dav(h, alice, "GET", "/dav/b.txt", ""); w.Code != 404 {
+		t.Errorf("GET after DELETE: got %d, want 404", w.Code)
+	}
+}
+
+// TestDAVEmptyPut checks that a zero-byte PUT stores an empty file, while
+// the placeholder LOCK creates for a new name stores nothing.
+func TestDAVEmptyPut(t *testing.T) {
+	testDB(t)
+	h := testHandler(t)
+	alice := testUser(t, testOrg(t, "org"), "alice")
+
+	if w := dav(h, alice, "PUT", "/dav/empty.txt", ""); w.Code != 201 {
+		t.Fatalf("PUT with no body: got %d %s, want 201", w.Code, w.Body)
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE owner_id=$1 AND filename='empty.txt' AND size=0", alice); n != 1 {
+		t.Errorf("%d empty files stored, want 1", n)
+	}
+	if w := dav(h, alice, "GET", "/dav/empty.txt", ""); w.Code != 200 || w.Body.Len() != 0 {
+		t.Errorf("GET: got %d with %d bytes", w.Code, w.Body.Len())
+	}
+
+	lock := `<?xml version="1.0" encoding="utf-8"?>
+<D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype></D:lockinfo>`
+	if w := dav(h, alice, "LOCK", "/dav/locked.txt", lock, "Timeout", "Second-60"); w.Code != 201 {
+		t.Fatalf("LOCK on a new name: got %d %s, want 201", w.Code, w.Body)
+	}
+	if n := count(t, "SELECT count(*) FROM files WHERE filename='locked.txt'"); n != 0 {
+		t.Error("the placeholder LOCK creates was stored")
+	}
+}
@@ -2,41 +2,82 @@ package handlers
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
 )
 
-var clients = make(map[*websocket.Conn]bool)
-var broadcast = make(chan gin.H)
+// clients maps each live connection to the organization whose events it
+// receives.
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[*websocket.Conn]int64)
+)
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
 func (h *Handler) StatsWS(c *gin.Context) {
+	var orgID int64
+	if err := db.Pool.QueryRow(c, "SELECT org_id FROM users WHERE id=$1", c.GetInt64("user_id")).Scan(&orgID); err != nil {
+		c.JSON(500, gin.H{"error": "failed to load organization"})
+		return
+	}
 	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		return
 	}
 	defer ws.Close()
-	clients[ws] = true
+	clientsMu.Lock()
+	clients[ws] = orgID
+	clientsMu.Unlock()
 
 	for {
 		var msg gin.H
 		err := ws.ReadJSON(&msg)
 		if err != nil {
+			clientsMu.Lock()
 			delete(clients, ws)
+			clientsMu.Unlock()
 			break
 		}
 	}
 }
 
-func broadcastUpdate(event gin.H) {
-	for client := range clients {
+// broadcastUpdate sends event to the clients of the organization it
+// happened in: the caller's or, on share links, the file or folder
+// owner's.
+func broadcastUpdate(c *gin.Context, event gin.H) {
+	var orgID int64
+	var err error
+	if userID := c.GetInt64("user_id"); userID != 0 {
+		err = db.Pool.QueryRow(c, "SELECT org_id FROM users WHERE id=$1", userID).Scan(&orgID)
+	} else if folderID, ok := event["folder_id"]; ok {
+		err = db.Pool.QueryRow(c,
+			"SELECT u.org_id FROM folders d JOIN users u ON u.id = d.owner_id WHERE d.id=$1", folderID,
+		).Scan(&orgID)
+	} else {
+		err = db.Pool.QueryRow(c,
+			"SELECT u.org_id FROM files f JOIN users u ON u.id = f.owner_id WHERE f.id=$1", event["file_id"],
+		).Scan(&orgID)
+	}
+	if err != nil {
+		return
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	for client, org := range clients {
+		if org != orgID {
+			continue
+		}
 		err := client.WriteJSON(event)
 		if err != nil {
 			client.Close()
 			delete(clients, client)
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	redis_rate "github.com/go-redis/redis_rate/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+// Brute-force protection for password checks. Failed attempts are counted
+// per username and per client IP. Past a few free attempts, each failure
+// blocks the next attempt for an exponentially growing delay; past the
+// lockout threshold the username or IP is locked for a while. Blocks are
+// Redis keys with a TTL, so they lift by themselves; Unlock lifts them
+// early.
+
+const (
+	userFreeAttempts = 3
+	userLockoutAfter = 10
+	userWindow       = 24 * time.Hour
+	userLockout      = 15 * time.Minute
+
+	// One IP may be a whole office behind NAT, so it gets more room.
+	ipFreeAttempts = 10
+	ipLockoutAfter = 100
+	ipWindow       = time.Hour
+	ipLockout      = time.Hour
+
+	backoffBase = time.Second
+	backoffMax  = 5 * time.Minute
+)
+
+const (
+	blockBackoff = "backoff"
+	blockLocked  = "locked"
+)
+
+// Block says why and for how long login attempts are refused.
+type Block struct {
+	// Locked is set for a lockout, as opposed to a backoff delay.
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+// Lockout is reported by LoginFailed when a failure locks a username or IP.
+type Lockout struct {
+	// Kind is "user" or "ip".
+	Kind     string
+	Key      string
+	Failures int64
+	Duration time.Duration
+}
+
+func failKey(kind, key string) string  { return "bf:fail:" + kind + ":" + key }
+func blockKey(kind, key string) string { return "bf:block:" + kind + ":" + key }
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// LoginBlocked returns the block on ip or username with the longest time
+// left, or nil if an attempt may go ahead.
+func LoginBlocked(ctx context.Context, ip, username string) (*Block, error) {
+	var worst *Block
+	for _, k := range [][2]string{{"user", normalizeUsername(username)}, {"ip", ip}} {
+		key := blockKey(k[0], k[1])
+		pipe := redisClient.Pipeline()
+		kind := pipe.Get(ctx, key)
+		ttl := pipe.PTTL(ctx, key)
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		if kind.Err() != nil || ttl.Val() <= 0 {
+			continue
+		}
+		if worst == nil || ttl.Val() > worst.RetryAfter {
+			worst = &Block{Locked: kind.Val() == blockLocked, RetryAfter: ttl.Val()}
+		}
+	}
+	return worst, nil
+}
+
+// LoginFailed records a failed attempt and blocks further attempts as
+// needed. It returns the lockouts this failure caused, for the audit log.
+func LoginFailed(ctx context.Context, ip, username string) ([]Lockout, error) {
+	var lockouts []Lockout
+	for _, k := range []struct {
+		kind, key       string
+		free, lockAfter int64
+		window, lockFor time.Duration
+	}{
+		{"user", normalizeUsername(username), userFreeAttempts, userLockoutAfter, userWindow, userLockout},
+		{"ip", ip, ipFreeAttempts, ipLockoutAfter, ipWindow, ipLockout},
+	} {
+		if k.key == "" {
+			continue
+		}
+		pipe := redisClient.TxPipeline()
+		incr := pipe.Incr(ctx, failKey(k.kind, k.key))
+		pipe.Expire(ctx, failKey(k.kind, k.key), k.window)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return lockouts, err
+		}
+		n := incr.Val()
+
+		switch {
+		case n >= k.lockAfter:
+			if err := redisClient.Set(ctx, blockKey(k.kind, k.key), blockLocked, k.lockFor).Err(); err != nil {
+				return lockouts, err
+			}
+			lockouts = append(lockouts, Lockout{Kind: k.kind, Key: k.key, Failures: n, Duration: k.lockFor})
+		case n > k.free:
+			if err := redisClient.Set(ctx, blockKey(k.kind, k.key), blockBackoff, backoff(n-k.free)).Err(); err != nil {
+				return lockouts, err
+			}
+		}
+	}
+	return lockouts, nil
+}
+
+// backoff is the delay after the nth failure past the free ones: 1s, 2s,
+// 4s and so on up to backoffMax.
+func backoff(n int64) time.Duration {
+	if n > 20 {
+		return backoffMax
+	}
+	return min(backoffBase<<(n-1), backoffMax)
+}
+
+// LoginSucceeded clears the username's failures. The IP's count is kept,
+// so that one right password does not reset a spray over many usernames.
+func LoginSucceeded(ctx context.Context, username string) error {
+	u := normalizeUsername(username)
+	return redisClient.Del(ctx, failKey("user", u), blockKey("user", u)).Err()
+}
+
+// Unlock clears the failures and blocks of a username or IP.
+func Unlock(ctx context.Context, kind, key string) (bool, error) {
+	if kind == "user" {
+		key = normalizeUsername(key)
+	}
+	n, err := redisClient.Del(ctx, failKey(kind, key), blockKey(kind, key)).Result()
+	return n > 0, err
+}
+
+// BlockedEntry is a username or IP that is currently blocked.
+type BlockedEntry struct {
+	Kind       string
+	Key        string
+	Failures   int64
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+// Blocked lists the usernames and IPs whose attempts are refused.
+func Blocked(ctx context.Context) ([]BlockedEntry, error) {
+	var out []BlockedEntry
+	iter := redisClient.Scan(ctx, 0, "bf:block:*", 100).Iterator()
+	for iter.Next(ctx) {
+		kind, key, ok := strings.Cut(strings.TrimPrefix(iter.Val(), "bf:block:"), ":")
+		if !ok {
+			continue
+		}
+		pipe := redisClient.Pipeline()
+		state := pipe.Get(ctx, iter.Val())
+		ttl := pipe.PTTL(ctx, iter.Val())
+		failures := pipe.Get(ctx, failKey(kind, key))
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		if state.Err() != nil || ttl.Val() <= 0 {
+			continue
+		}
+		n, _ := strconv.ParseInt(failures.Val(), 10, 64)
+		out = append(out, BlockedEntry{
+			Kind:       kind,
+			Key:        key,
+			Failures:   n,
+			Locked:     state.Val() == blockLocked,
+			RetryAfter: ttl.Val(),
+		})
+	}
+	return out, iter.Err()
+}
+
+// IPRateLimiter limits requests per client IP to perMinute, for routes
+// used before logging in, where RateLimiter has no user to go by.
+func IPRateLimiter(perMinute int) gin.HandlerFunc {
+	limit := redis_rate.PerMinute(perMinute)
+	return func(c *gin.Context) {
+		res, err := limiter.Allow(c, "rl:ip:"+c.ClientIP(), limit)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "rate limiter error"})
+			c.Abort()
+			return
+		}
+		if res.Allowed == 0 {
+			c.Header("Retry-After", fmt.Sprintf("%d", int(res.RetryAfter.Seconds())+1))
+			c.JSON(429, gin.H{"error": "rate limit exceeded"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
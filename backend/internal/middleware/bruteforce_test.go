@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	for n, want := range map[int64]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		5:  16 * time.Second,
+		9:  256 * time.Second,
+		10: backoffMax,
+		64: backoffMax,
+	} {
+		if got := backoff(n); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+// TestLoginLockout runs against the Redis in REDIS_URL and is skipped when
+// there is none.
+func TestLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+	username := fmt.Sprintf("Test-%d", time.Now().UnixNano())
+	ip := fmt.Sprintf("192.0.2.%d", time.Now().UnixNano()%250+1)
+	t.Cleanup(func() {
+		Unlock(ctx, "user", username)
+		Unlock(ctx, "ip", ip)
+	})
+
+	fail := func() []Lockout {
+		t.Helper()
+		lockouts, err := LoginFailed(ctx, ip, username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lockouts
+	}
+	blocked := func() *Block {
+		t.Helper()
+		block, err := LoginBlocked(ctx, ip, username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return block
+	}
+
+	for range userFreeAttempts {
+		fail()
+	}
+	if block := blocked(); block != nil {
+		t.Fatalf("blocked after the free attempts: %+v", block)
+	}
+	fail()
+	if block := blocked(); block == nil || block.Locked || block.RetryAfter > backoffBase {
+		t.Errorf("after one attempt too many: %+v, want a backoff of up to %v", block, backoffBase)
+	}
+
+	var lockouts []Lockout
+	for range userLockoutAfter - userFreeAttempts - 1 {
+		lockouts = fail()
+	}
+	if len(lockouts) != 1 || lockouts[0].Kind != "user" || lockouts[0].Failures != userLockoutAfter {
+		t.Errorf("reached the threshold with lockouts %+v", lockouts)
+	}
+	// The username is matched however it is written.
+	if block, _ := LoginBlocked(ctx, "198.51.100.1", " "+username+" "); block == nil || !block.Locked {
+		t.Errorf("after the threshold: %+v, want locked", block)
+	}
+
+	if err := LoginSucceeded(ctx, username); err != nil {
+		t.Fatal(err)
+	}
+	if block, _ := LoginBlocked(ctx, "198.51.100.1", username); block != nil {
+		t.Errorf("still blocked after a login: %+v", block)
+	}
+	// The IP's failures are kept, so the next one is past its free
+	// attempts.
+	fail()
+	if block := blocked(); block == nil || block.Locked {
+		t.Errorf("the IP after a login and a failure: %+v, want a backoff", block)
+	}
+}
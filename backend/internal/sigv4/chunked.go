@@ -0,0 +1,118 @@
+package sigv4
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payload hashes announcing an aws-chunked body.
+const (
+	StreamingPayload         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	StreamingPayloadTrailer  = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	StreamingUnsignedTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+)
+
+const maxChunkSize = 16 << 20
+
+var (
+	ErrChunkSignature = errors.New("sigv4: chunk signature mismatch")
+	ErrMalformedChunk = errors.New("sigv4: malformed aws-chunked body")
+)
+
+var emptySHA256 = hex.EncodeToString(sha256.New().Sum(nil))
+
+// ChunkedReader decodes an aws-chunked request body. For signed streaming
+// payloads every chunk signature is checked, chained from the signature
+// of the request itself. Trailers after the last chunk are not read.
+type ChunkedReader struct {
+	r      *bufio.Reader
+	auth   *Authorization
+	secret string
+	t      time.Time
+	prev   string
+	signed bool
+	buf    []byte
+	done   bool
+}
+
+func NewChunkedReader(body io.Reader, payloadHash string, auth *Authorization, secret string, t time.Time) *ChunkedReader {
+	return &ChunkedReader{
+		r:      bufio.NewReader(body),
+		auth:   auth,
+		secret: secret,
+		t:      t,
+		prev:   auth.Signature,
+		signed: payloadHash != StreamingUnsignedTrailer,
+	}
+}
+
+func (c *ChunkedReader) Read(p []byte) (int, error) {
+	for len(c.buf) == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+func (c *ChunkedReader) next() error {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	sizeStr, ext, _ := strings.Cut(strings.TrimRight(line, "\r\n"), ";")
+	size, err := strconv.ParseInt(sizeStr, 16, 64)
+	if err != nil || size < 0 || size > maxChunkSize {
+		return ErrMalformedChunk
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	if size > 0 {
+		var crlf [2]byte
+		if _, err := io.ReadFull(c.r, crlf[:]); err != nil || string(crlf[:]) != "\r\n" {
+			return ErrMalformedChunk
+		}
+	}
+
+	if c.signed {
+		sig, ok := strings.CutPrefix(ext, "chunk-signature=")
+		if !ok {
+			return ErrChunkSignature
+		}
+		sum := sha256.Sum256(data)
+		sts := strings.Join([]string{
+			Algorithm + "-PAYLOAD",
+			c.t.UTC().Format(TimeFormat),
+			Scope(c.t, c.auth.Region, c.auth.Service),
+			c.prev,
+			emptySHA256,
+			hex.EncodeToString(sum[:]),
+		}, "\n")
+		expected := Signature(c.secret, c.t, c.auth.Region, c.auth.Service, sts)
+		if !hmac.Equal([]byte(expected), []byte(sig)) {
+			return ErrChunkSignature
+		}
+		c.prev = sig
+	}
+
+	if size == 0 {
+		c.done = true
+	}
+	c.buf = data
+	return nil
+}
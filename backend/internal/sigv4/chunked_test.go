@@ -0,0 +1,47 @@
+package sigv4
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestChunkedExample decodes the streaming upload example of the S3 SigV4
+// documentation: 66560 bytes of 'a' in a 64 KiB and a 1 KiB chunk.
+func TestChunkedExample(t *testing.T) {
+	a := &Authorization{
+		AccessKey: exampleAccessKey,
+		Region:    "us-east-1",
+		Service:   "s3",
+		Signature: "4f232c4386841ef735655705268965c44a0e4690baa4adea153f7db9fa80a0a9",
+	}
+	chunk1 := strings.Repeat("a", 65536)
+	chunk2 := strings.Repeat("a", 1024)
+	body := "10000;chunk-signature=ad80c730a21e5b8d04586a2213dd63b9a0e99e0e2307b0ade35a65485a288648\r\n" + chunk1 + "\r\n" +
+		"400;chunk-signature=0055627c9e194cb4542bae2aa5492e3c1575bbb81b612b7d234b86a503ef5497\r\n" + chunk2 + "\r\n" +
+		"0;chunk-signature=b6c6ea8a5354eaf15b3cb7646744f4275b71ea724fed81ceb9323e279d449df9\r\n\r\n"
+
+	got, err := io.ReadAll(NewChunkedReader(strings.NewReader(body), StreamingPayload, a, exampleSecret, exampleTime))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != chunk1+chunk2 {
+		t.Errorf("decoded %d bytes, want %d", len(got), len(chunk1)+len(chunk2))
+	}
+
+	tampered := strings.Replace(body, "aaaa\r\n400", "aaab\r\n400", 1)
+	if _, err := io.ReadAll(NewChunkedReader(strings.NewReader(tampered), StreamingPayload, a, exampleSecret, exampleTime)); err != ErrChunkSignature {
+		t.Errorf("a changed chunk gave %v, want ErrChunkSignature", err)
+	}
+	truncated := body[:len(body)-100]
+	if _, err := io.ReadAll(NewChunkedReader(strings.NewReader(truncated), StreamingPayload, a, exampleSecret, exampleTime)); err == nil {
+		t.Error("a truncated body decoded")
+	}
+
+	unsigned := "3\r\nabc\r\n0\r\n\r\n"
+	got, err = io.ReadAll(NewChunkedReader(bytes.NewBufferString(unsigned), StreamingUnsignedTrailer, a, exampleSecret, exampleTime))
+	if err != nil || string(got) != "abc" {
+		t.Errorf("unsigned body decoded to %q, %v", got, err)
+	}
+}
@@ -0,0 +1,248 @@
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Algorithm       = "AWS4-HMAC-SHA256"
+	TimeFormat      = "20060102T150405Z"
+	DateFormat      = "20060102"
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+var ErrMalformed = errors.New("sigv4: malformed authorization")
+
+type Credentials struct {
+	AccessKey string
+	SecretKey string
+}
+
+// Sign adds the x-amz-date, x-amz-content-sha256 and Authorization headers
+// to req for the given region and service.
+func Sign(req *http.Request, creds Credentials, region, service, payloadHash string, now time.Time) {
+	now = now.UTC()
+	if payloadHash == "" {
+		payloadHash = UnsignedPayload
+	}
+	req.Header.Set("X-Amz-Date", now.Format(TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+
+	signed := []string{"host"}
+	for k := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") || lk == "content-type" || lk == "range" {
+			signed = append(signed, lk)
+		}
+	}
+	sort.Strings(signed)
+
+	scope := Scope(now, region, service)
+	canonical := CanonicalRequest(req, signed, payloadHash)
+	signature := Signature(creds.SecretKey, now, region, service, StringToSign(now, scope, canonical))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		Algorithm, creds.AccessKey, scope, strings.Join(signed, ";"), signature))
+}
+
+// Authorization is the signature information of a signed request, taken
+// from its Authorization header or presigned URL query.
+type Authorization struct {
+	AccessKey     string
+	Date          string
+	Region        string
+	Service       string
+	SignedHeaders []string
+	Signature     string
+}
+
+// ParseAuthorization parses an Authorization header of the form
+// "AWS4-HMAC-SHA256 Credential=..., SignedHeaders=..., Signature=...".
+func ParseAuthorization(header string) (*Authorization, error) {
+	rest, ok := strings.CutPrefix(header, Algorithm+" ")
+	if !ok {
+		return nil, ErrMalformed
+	}
+	fields := make(map[string]string)
+	for _, part := range strings.Split(rest, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, ErrMalformed
+		}
+		fields[k] = v
+	}
+	return newAuthorization(fields["Credential"], fields["SignedHeaders"], fields["Signature"])
+}
+
+// ParseQuery parses the X-Amz-* parameters of a presigned URL, returning
+// the signing time and how long the URL stays valid as well.
+func ParseQuery(q url.Values) (*Authorization, time.Time, time.Duration, error) {
+	if q.Get("X-Amz-Algorithm") != Algorithm {
+		return nil, time.Time{}, 0, ErrMalformed
+	}
+	a, err := newAuthorization(q.Get("X-Amz-Credential"), q.Get("X-Amz-SignedHeaders"), q.Get("X-Amz-Signature"))
+	if err != nil {
+		return nil, time.Time{}, 0, err
+	}
+	t, err := time.Parse(TimeFormat, q.Get("X-Amz-Date"))
+	if err != nil {
+		return nil, time.Time{}, 0, ErrMalformed
+	}
+	secs, err := strconv.Atoi(q.Get("X-Amz-Expires"))
+	if err != nil || secs <= 0 || secs > 7*24*3600 {
+		return nil, time.Time{}, 0, ErrMalformed
+	}
+	return a, t, time.Duration(secs) * time.Second, nil
+}
+
+func newAuthorization(credential, signedHeaders, signature string) (*Authorization, error) {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" || signedHeaders == "" || signature == "" {
+		return nil, ErrMalformed
+	}
+	return &Authorization{
+		AccessKey:     parts[0],
+		Date:          parts[1],
+		Region:        parts[2],
+		Service:       parts[3],
+		SignedHeaders: strings.Split(signedHeaders, ";"),
+		Signature:     signature,
+	}, nil
+}
+
+// Verify reports whether a's signature matches req signed at t with secret.
+func (a *Authorization) Verify(req *http.Request, secret string, t time.Time, payloadHash string) bool {
+	if a.Date != t.UTC().Format(DateFormat) {
+		return false
+	}
+	scope := Scope(t, a.Region, a.Service)
+	sts := StringToSign(t, scope, CanonicalRequest(req, a.SignedHeaders, payloadHash))
+	expected := Signature(secret, t, a.Region, a.Service, sts)
+	return hmac.Equal([]byte(expected), []byte(a.Signature))
+}
+
+func Scope(t time.Time, region, service string) string {
+	return fmt.Sprintf("%s/%s/%s/aws4_request", t.UTC().Format(DateFormat), region, service)
+}
+
+// CanonicalRequest builds the canonical request string over the given
+// lower-cased header names.
+func CanonicalRequest(req *http.Request, signedHeaders []string, payloadHash string) string {
+	var headers strings.Builder
+	for _, h := range signedHeaders {
+		var v string
+		if h == "host" {
+			v = req.Host
+			if v == "" {
+				v = req.URL.Host
+			}
+		} else {
+			v = strings.Join(req.Header.Values(h), ",")
+		}
+		headers.WriteString(h)
+		headers.WriteByte(':')
+		headers.WriteString(strings.Join(strings.Fields(v), " "))
+		headers.WriteByte('\n')
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		canonicalQuery(req.URL.Query()),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+func StringToSign(t time.Time, scope, canonicalRequest string) string {
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	return strings.Join([]string{
+		Algorithm,
+		t.UTC().Format(TimeFormat),
+		scope,
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+}
+
+func Signature(secret string, t time.Time, region, service, stringToSign string) string {
+	k := hmacSHA256([]byte("AWS4"+secret), t.UTC().Format(DateFormat))
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, service)
+	k = hmacSHA256(k, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(k, stringToSign))
+}
+
+// EscapePath percent-encodes each segment of an object key per the SigV4 rules.
+func EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalPath(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		p = EscapePath(unescaped)
+	}
+	return p
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		if k == "X-Amz-Signature" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/chunker"
+)
+
+type ChunkRef struct {
+	Hash   string
+	Offset int64
+	Size   int64
+}
+
+// ChunkIndex records which chunks exist, how many references each has and
+// which chunks make up each blob. A chunk's data is stored by whoever takes
+// its count from zero, and removed only by RemoveChunk, which a concurrent
+// RefChunk waits for; so a chunk with references always has its data.
+type ChunkIndex interface {
+	// RefChunk takes a reference on a chunk, adding it if it is new.
+	// fresh is true when the chunk had no references, so its data may be
+	// missing and has to be stored.
+	RefChunk(ctx context.Context, hash string, size int64) (fresh bool, err error)
+	// UnrefChunks drops one reference per hash and returns the chunks left
+	// without any.
+	UnrefChunks(ctx context.Context, hashes []string) (orphans []string, err error)
+	// RemoveChunk calls remove and forgets the chunk if it still has no
+	// references, holding off RefChunk on it until done.
+	RemoveChunk(ctx context.Context, hash string, remove func() error) error
+	// SaveManifest stores the chunk list for a blob, whose references the
+	// caller holds. saved is false when the blob already had one.
+	SaveManifest(ctx context.Context, blobKey string, chunks []ChunkRef) (saved bool, err error)
+	// Manifest returns the chunk list for a blob, or nil if the blob was
+	// stored whole. An empty blob's list holds one empty chunk.
+	Manifest(ctx context.Context, blobKey string) ([]ChunkRef, error)
+	// DropManifest removes a blob's manifest, dropping its references, and
+	// returns the chunks left without any. found is false for blobs that
+	// were stored whole.
+	DropManifest(ctx context.Context, blobKey string) (found bool, orphans []string, err error)
+}
+
+// ChunkedStore splits blobs into content-defined chunks so that blobs with
+// mostly equal content (e.g. versions of one file) share storage. Chunks
+// live in the wrapped store under "chunks/<sha256>". Blobs written before
+// chunking was enabled are still read from the wrapped store as-is.
+type ChunkedStore struct {
+	Chunks BlobStore
+	Index  ChunkIndex
+}
+
+func NewChunkedStore(chunks BlobStore, index ChunkIndex) *ChunkedStore {
+	return &ChunkedStore{Chunks: chunks, Index: index}
+}
+
+func chunkKey(hash string) string {
+	return "chunks/" + hash
+}
+
+func (s *ChunkedStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	refs, err := s.putChunks(ctx, r)
+	if err != nil {
+		s.release(ctx, refs)
+		return err
+	}
+	saved, err := s.Index.SaveManifest(ctx, key, refs)
+	if err != nil || !saved {
+		s.release(ctx, refs)
+	}
+	return err
+}
+
+// putChunks stores the chunks of r and returns the references taken on
+// them, also when it fails part way.
+func (s *ChunkedStore) putChunks(ctx context.Context, r io.Reader) ([]ChunkRef, error) {
+	c := chunker.New(r)
+	var refs []ChunkRef
+	var offset int64
+	for {
+		data, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return refs, err
+		}
+		hash, err := s.putChunk(ctx, data)
+		if err != nil {
+			return refs, err
+		}
+		refs = append(refs, ChunkRef{Hash: hash, Offset: offset, Size: int64(len(data))})
+		offset += int64(len(data))
+	}
+	// An empty blob gets one empty chunk; an empty manifest would read as
+	// a blob stored whole.
+	if len(refs) == 0 {
+		hash, err := s.putChunk(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		refs = []ChunkRef{{Hash: hash}}
+	}
+	return refs, nil
+}
+
+// putChunk takes a reference on the chunk holding data, storing it unless
+// it is already stored, and returns its hash.
+func (s *ChunkedStore) putChunk(ctx context.Context, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if keyID := KeyIDFrom(ctx); keyID != 0 {
+		// Chunks encrypted under different data keys must not be shared.
+		hash = fmt.Sprintf("%s-%d", hash, keyID)
+	}
+	fresh, err := s.Index.RefChunk(ctx, hash, int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	if fresh {
+		if err := s.Chunks.Put(ctx, chunkKey(hash), bytes.NewReader(data), int64(len(data))); err != nil {
+			s.release(ctx, []ChunkRef{{Hash: hash}})
+			return "", err
+		}
+	}
+	return hash, nil
+}
+
+// release drops the references a failed Put took. Errors are ignored: at
+// worst a chunk is kept that nothing uses.
+func (s *ChunkedStore) release(ctx context.Context, refs []ChunkRef) {
+	if len(refs) == 0 {
+		return
+	}
+	hashes := make([]string, len(refs))
+	for i, ref := range refs {
+		hashes[i] = ref.Hash
+	}
+	orphans, err := s.Index.UnrefChunks(ctx, hashes)
+	if err == nil {
+		_ = s.removeChunks(ctx, orphans)
+	}
+}
+
+// removeChunks removes the data of chunks left without references.
+func (s *ChunkedStore) removeChunks(ctx context.Context, hashes []string) error {
+	for _, hash := range hashes {
+		err := s.Index.RemoveChunk(ctx, hash, func() error {
+			return s.Chunks.Delete(ctx, chunkKey(hash))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RepairEmpty gives an empty blob written before empty blobs got a chunk
+// of their own its empty chunk; until then it reads as a blob stored whole
+// that does not exist. Blobs that are stored whole are left alone. ctx
+// carries the blob's key id as for Put.
+func (s *ChunkedStore) RepairEmpty(ctx context.Context, key string) error {
+	refs, err := s.Index.Manifest(ctx, key)
+	if err != nil || refs != nil {
+		return err
+	}
+	if _, err := s.Chunks.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return s.Put(ctx, key, bytes.NewReader(nil), 0)
+}
+
+func (s *ChunkedStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	refs, err := s.Index.Manifest(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if refs == nil {
+		return s.Chunks.Get(ctx, key)
+	}
+	return &chunkReader{ctx: ctx, store: s.Chunks, refs: refs, remaining: manifestSize(refs)}, nil
+}
+
+func (s *ChunkedStore) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	refs, err := s.Index.Manifest(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if refs == nil {
+		return s.Chunks.GetRange(ctx, key, offset, length)
+	}
+
+	for len(refs) > 0 && refs[0].Offset+refs[0].Size <= offset {
+		refs = refs[1:]
+	}
+	var skip int64
+	if len(refs) > 0 {
+		skip = offset - refs[0].Offset
+	}
+	return &chunkReader{ctx: ctx, store: s.Chunks, refs: refs, skip: skip, remaining: length}, nil
+}
+
+func (s *ChunkedStore) Stat(ctx context.Context, key string) (BlobInfo, error) {
+	refs, err := s.Index.Manifest(ctx, key)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	if refs == nil {
+		return s.Chunks.Stat(ctx, key)
+	}
+	return BlobInfo{Size: manifestSize(refs)}, nil
+}
+
+func (s *ChunkedStore) Delete(ctx context.Context, key string) error {
+	found, orphans, err := s.Index.DropManifest(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return s.Chunks.Delete(ctx, key)
+	}
+	return s.removeChunks(ctx, orphans)
+}
+
+func manifestSize(refs []ChunkRef) int64 {
+	if len(refs) == 0 {
+		return 0
+	}
+	last := refs[len(refs)-1]
+	return last.Offset + last.Size
+}
+
+// chunkReader streams a run of chunks, opening each one only when reached.
+type chunkReader struct {
+	ctx       context.Context
+	store     BlobStore
+	refs      []ChunkRef
+	skip      int64
+	remaining int64
+	cur       io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.remaining <= 0 {
+			return 0, io.EOF
+		}
+		if r.cur == nil {
+			if len(r.refs) == 0 {
+				return 0, io.ErrUnexpectedEOF
+			}
+			ref := r.refs[0]
+			r.refs = r.refs[1:]
+			var err error
+			if r.skip > 0 {
+				r.cur, err = r.store.GetRange(r.ctx, chunkKey(ref.Hash), r.skip, ref.Size-r.skip)
+				r.skip = 0
+			} else {
+				r.cur, err = r.store.Get(r.ctx, chunkKey(ref.Hash))
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if int64(len(p)) > r.remaining {
+			p = p[:r.remaining]
+		}
+		n, err := r.cur.Read(p)
+		r.remaining -= int64(n)
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.cur != nil {
+		return r.cur.Close()
+	}
+	return nil
+}
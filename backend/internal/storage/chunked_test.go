@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// memIndex is an in-memory ChunkIndex. Chunks left without references
+// keep their entry until RemoveChunk, like rows in the Postgres index.
+type memIndex struct {
+	refs      map[string]int
+	manifests map[string][]ChunkRef
+}
+
+func newMemIndex() *memIndex {
+	return &memIndex{refs: map[string]int{}, manifests: map[string][]ChunkRef{}}
+}
+
+func (m *memIndex) RefChunk(ctx context.Context, hash string, size int64) (bool, error) {
+	if m.refs[hash] < 0 {
+		m.refs[hash] = 0
+	}
+	m.refs[hash]++
+	return m.refs[hash] == 1, nil
+}
+
+func (m *memIndex) UnrefChunks(ctx context.Context, hashes []string) ([]string, error) {
+	var orphans []string
+	for _, hash := range hashes {
+		if m.refs[hash]--; m.refs[hash] == 0 {
+			orphans = append(orphans, hash)
+		}
+	}
+	return orphans, nil
+}
+
+func (m *memIndex) RemoveChunk(ctx context.Context, hash string, remove func() error) error {
+	if refs, ok := m.refs[hash]; !ok || refs > 0 {
+		return nil
+	}
+	if err := remove(); err != nil {
+		return err
+	}
+	delete(m.refs, hash)
+	return nil
+}
+
+func (m *memIndex) SaveManifest(ctx context.Context, blobKey string, chunks []ChunkRef) (bool, error) {
+	if _, ok := m.manifests[blobKey]; ok {
+		return false, nil
+	}
+	m.manifests[blobKey] = chunks
+	return true, nil
+}
+
+// Manifest returns nil for blobs without rows, like the Postgres index.
+func (m *memIndex) Manifest(ctx context.Context, blobKey string) ([]ChunkRef, error) {
+	refs := m.manifests[blobKey]
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	return refs, nil
+}
+
+func (m *memIndex) DropManifest(ctx context.Context, blobKey string) (bool, []string, error) {
+	refs, ok := m.manifests[blobKey]
+	if !ok || len(refs) == 0 {
+		return false, nil, nil
+	}
+	delete(m.manifests, blobKey)
+	hashes := make([]string, len(refs))
+	for i, ref := range refs {
+		hashes[i] = ref.Hash
+	}
+	orphans, err := m.UnrefChunks(ctx, hashes)
+	return true, orphans, err
+}
+
+func newChunkedStore(t *testing.T) (*ChunkedStore, string) {
+	t.Helper()
+	root := t.TempDir()
+	local, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewChunkedStore(local, newMemIndex()), root
+}
+
+// readAll returns a function that reads what Get or GetRange returned.
+func readAll(t *testing.T) func(io.ReadCloser, error) []byte {
+	return func(r io.ReadCloser, err error) []byte {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+}
+
+func TestChunkedRoundTrip(t *testing.T) {
+	big := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(big)
+
+	for name, data := range map[string][]byte{"empty": {}, "one byte": {'x'}, "1MiB": big} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s, _ := newChunkedStore(t)
+			if err := s.Put(ctx, "blob", bytes.NewReader(data), int64(len(data))); err != nil {
+				t.Fatal(err)
+			}
+
+			got := readAll(t)(s.Get(ctx, "blob"))
+			if !bytes.Equal(got, data) {
+				t.Errorf("Get returned %d bytes, want %d", len(got), len(data))
+			}
+			info, err := s.Stat(ctx, "blob")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size != int64(len(data)) {
+				t.Errorf("Stat size is %d, want %d", info.Size, len(data))
+			}
+			if len(data) > 0 {
+				off, n := int64(len(data)/3), int64(len(data)/2)
+				if n == 0 {
+					n = 1
+				}
+				got := readAll(t)(s.GetRange(ctx, "blob", off, n))
+				if !bytes.Equal(got, data[off:off+n]) {
+					t.Errorf("GetRange(%d, %d) returned the wrong bytes", off, n)
+				}
+			}
+		})
+	}
+}
+
+// TestChunkedEmptyBlob checks that an empty blob is not mistaken for one
+// stored whole, which was never written to the wrapped store.
+func TestChunkedEmptyBlob(t *testing.T) {
+	ctx := context.Background()
+	s, root := newChunkedStore(t)
+	if err := s.Put(ctx, "empty", bytes.NewReader(nil), 0); err != nil {
+		t.Fatal(err)
+	}
+	if refs, _ := s.Index.Manifest(ctx, "empty"); refs == nil {
+		t.Fatal("an empty blob has no manifest")
+	}
+	if _, err := os.Stat(filepath.Join(root, "empty")); !os.IsNotExist(err) {
+		t.Errorf("the empty blob was stored whole: %v", err)
+	}
+	if got := readAll(t)(s.Get(ctx, "empty")); len(got) != 0 {
+		t.Errorf("Get returned %q, want nothing", got)
+	}
+	if err := s.Delete(ctx, "empty"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChunkedSharedChunks(t *testing.T) {
+	ctx := context.Background()
+	s, root := newChunkedStore(t)
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(2)).Read(data)
+
+	for _, key := range []string{"a", "b"} {
+		if err := s.Put(ctx, key, bytes.NewReader(data), int64(len(data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(root, "chunks", "*"))
+	stored := len(files)
+	if manifest, _ := s.Index.Manifest(ctx, "a"); stored != len(manifest) {
+		t.Errorf("%d chunk files for %d chunks", stored, len(manifest))
+	}
+
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t)(s.Get(ctx, "b")); !bytes.Equal(got, data) {
+		t.Error("deleting a blob broke another one sharing its chunks")
+	}
+	if err := s.Delete(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(root, "chunks", "*")); len(files) != 0 {
+		t.Errorf("%d chunks left after deleting every blob", len(files))
+	}
+}
+
+func TestChunkedKeyedChunks(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newChunkedStore(t)
+	data := []byte("same content")
+	if err := s.Put(ctx, "plain", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(WithKeyID(ctx, 7), "keyed", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := s.Index.Manifest(ctx, "plain")
+	keyed, _ := s.Index.Manifest(ctx, "keyed")
+	if plain[0].Hash == keyed[0].Hash {
+		t.Error("chunks under a data key are shared with unkeyed ones")
+	}
+}
+
+// TestChunkedPutAfterDelete checks that a chunk whose last blob was deleted
+// is written again by the next blob that uses it, rather than referenced
+// while its file is gone.
+func TestChunkedPutAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newChunkedStore(t)
+	data := []byte("short enough for one chunk")
+	if err := s.Put(ctx, "a", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "b", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t)(s.Get(ctx, "b")); !bytes.Equal(got, data) {
+		t.Errorf("Get returned %q, want %q", got, data)
+	}
+}
+
+// TestChunkedPutTwice checks that storing a blob again keeps one reference
+// per chunk, so deleting it leaves no chunks behind.
+func TestChunkedPutTwice(t *testing.T) {
+	ctx := context.Background()
+	s, root := newChunkedStore(t)
+	data := []byte("stored twice")
+	for i := 0; i < 2; i++ {
+		if err := s.Put(ctx, "blob", bytes.NewReader(data), int64(len(data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Delete(ctx, "blob"); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(root, "chunks", "*")); len(files) != 0 {
+		t.Errorf("%d chunks left after deleting the blob", len(files))
+	}
+}
+
+func TestChunkedRepairEmpty(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newChunkedStore(t)
+
+	// Stored whole before chunking was turned on.
+	if err := s.Chunks.Put(ctx, "whole", bytes.NewReader(nil), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RepairEmpty(ctx, "whole"); err != nil {
+		t.Fatal(err)
+	}
+	if refs, _ := s.Index.Manifest(ctx, "whole"); refs != nil {
+		t.Error("an empty blob stored whole was given a manifest")
+	}
+
+	// Written in chunks before empty blobs got an empty chunk.
+	if err := s.RepairEmpty(ctx, "chunked"); err != nil {
+		t.Fatal(err)
+	}
+	if refs, _ := s.Index.Manifest(ctx, "chunked"); refs == nil {
+		t.Error("an empty blob written in chunks was not repaired")
+	}
+	if got := readAll(t)(s.Get(ctx, "chunked")); len(got) != 0 {
+		t.Errorf("Get returned %q, want nothing", got)
+	}
+}
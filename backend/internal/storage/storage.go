@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+type BlobInfo struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// BlobStore holds content-addressed blobs. Keys are blobs.path: the content
+// hash, suffixed with the data key id when blobs are encrypted per user.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (BlobInfo, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type keyIDCtxKey struct{}
+
+// WithKeyID tells stores below which data key a Put belongs to. Stores that
+// encrypt use it to pick the key; ChunkedStore uses it to keep chunk dedup
+// within that key.
+func WithKeyID(ctx context.Context, keyID int64) context.Context {
+	return context.WithValue(ctx, keyIDCtxKey{}, keyID)
+}
+
+func KeyIDFrom(ctx context.Context) int64 {
+	id, _ := ctx.Value(keyIDCtxKey{}).(int64)
+	return id
+}
+
+// NewFromEnv picks the blob store named by STORAGE_BACKEND ("local" by
+// default, or "s3").
+func NewFromEnv() (BlobStore, error) {
+	switch strings.ToLower(os.Getenv("STORAGE_BACKEND")) {
+	case "", "local":
+		root := os.Getenv("STORAGE_PATH")
+		if root == "" {
+			root = "./storage"
+		}
+		return NewLocalStore(root)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q", os.Getenv("STORAGE_BACKEND"))
+	}
+}
+
+// Commit stores the file at path as the blob for key. Stores that can take
+// ownership of a local file (LocalStore) do so with a rename; others get a
+// regular Put. Stores wrapping a LocalStore (chunked, encrypted) rewrite
+// the content and so get a Put too. The file at path is gone afterwards
+// either way.
+func Commit(ctx context.Context, store BlobStore, key, path string, size int64) error {
+	defer os.Remove(path)
+
+	if a, ok := store.(interface {
+		Adopt(ctx context.Context, key, path string) error
+	}); ok {
+		if err := a.Adopt(ctx, key, path); err == nil {
+			return nil
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return store.Put(ctx, key, f, size)
+}
+
+// StagingDir is where uploads are spooled before Commit. For a LocalStore it
+// is the store root so Commit can rename; otherwise fallback is used.
+func StagingDir(store BlobStore, fallback string) string {
+	if ls, ok := store.(*LocalStore); ok {
+		return ls.Root
+	}
+	return fallback
+}
+
+// Object is a seekable view of a stored blob. Reads are served lazily with
+// range requests so it can be handed to http.ServeContent.
+type Object struct {
+	ctx    context.Context
+	store  BlobStore
+	key    string
+	info   BlobInfo
+	offset int64
+	body   io.ReadCloser
+}
+
+func Open(ctx context.Context, store BlobStore, key string) (*Object, error) {
+	info, err := store.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{ctx: ctx, store: store, key: key, info: info}, nil
+}
+
+func (o *Object) Size() int64        { return o.info.Size }
+func (o *Object) ModTime() time.Time { return o.info.ModTime }
+
+func (o *Object) Read(p []byte) (int, error) {
+	if o.offset >= o.info.Size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		body, err := o.store.GetRange(o.ctx, o.key, o.offset, o.info.Size-o.offset)
+		if err != nil {
+			return 0, err
+		}
+		o.body = body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	if err == io.EOF && o.offset < o.info.Size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (o *Object) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = o.offset + offset
+	case io.SeekEnd:
+		abs = o.info.Size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("negative position")
+	}
+	if abs != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = abs
+	return abs, nil
+}
+
+func (o *Object) Close() error {
+	if o.body != nil {
+		return o.body.Close()
+	}
+	return nil
+}
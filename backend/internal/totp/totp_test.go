@@ -0,0 +1,82 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+// TestRFC6238 checks the RFC 6238 SHA-1 test vectors, cut to six digits.
+func TestRFC6238(t *testing.T) {
+	for _, v := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	} {
+		at := time.Unix(v.unix, 0)
+		step, ok := Validate(rfcSecret, v.code, at)
+		if !ok || step != v.unix/30 {
+			t.Errorf("%s at %d: got step %d, %v", v.code, v.unix, step, ok)
+		}
+		// Secrets are often typed in lower case.
+		if _, ok := Validate(strings.ToLower(rfcSecret), v.code, at); !ok {
+			t.Errorf("%s at %d was refused for a lower-case secret", v.code, v.unix)
+		}
+	}
+}
+
+func TestSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code := "050471"
+	for _, d := range []time.Duration{-Period, Period} {
+		if _, ok := Validate(rfcSecret, code, now.Add(d)); !ok {
+			t.Errorf("a code %v off was refused", d)
+		}
+	}
+	for _, d := range []time.Duration{-3 * Period, 3 * Period} {
+		if _, ok := Validate(rfcSecret, code, now.Add(d)); ok {
+			t.Errorf("a code %v off was accepted", d)
+		}
+	}
+	for _, bad := range []string{"", "05047", "0504710", "050472"} {
+		if _, ok := Validate(rfcSecret, bad, now); ok {
+			t.Errorf("code %q was accepted", bad)
+		}
+	}
+	if _, ok := Validate("not base32!", code, now); ok {
+		t.Error("a code was accepted for a malformed secret")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := encoding.DecodeString(secret); err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes, %v", secret, len(key), err)
+	}
+
+	u, err := url.Parse(ProvisioningURI("Balkan Drive", "alice@example.com", secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Balkan Drive:alice@example.com" {
+		t.Errorf("URI is %s", u)
+	}
+	q := u.Query()
+	if q.Get("secret") != secret || q.Get("digits") != "6" || q.Get("period") != "30" || q.Get("issuer") != "Balkan Drive" {
+		t.Errorf("URI parameters are %v", q)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -11,15 +12,24 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/api"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/auth"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/db"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/encryption"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/handlers"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/mailer"
 	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/middleware"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/oidc"
+	"github.com/BalkanID-University/vit-2026-capstone-internship-hiring-task-mahidharreddyg/backend/internal/storage"
 )
 
 func main() {
 	_ = godotenv.Load(".env")
 
+	if err := auth.LoadKeysFromEnv(); err != nil {
+		log.Fatalf("failed to load token signing keys: %v", err)
+	}
+
 	if err := db.InitDB(); err != nil {
 		log.Fatalf("failed to connect DB: %v", err)
 	}
@@ -29,9 +39,9 @@ func main() {
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata"},
+		ExposeHeaders:    []string{"Content-Length", "Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Expires", "Upload-File-Id", api.VersionHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -46,88 +56,240 @@ func main() {
 		c.Next()
 	})
 
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+	r.Use(func(c *gin.Context) {
+		c.Header(api.VersionHeader, api.Version)
+		c.Next()
 	})
 
-	h := handlers.NewHandler(os.Getenv("STORAGE_PATH"))
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(200, api.Health{Status: "ok"})
+	})
 
-	r.POST("/signup", h.SignupHandler)
-	r.POST("/login", h.LoginHandler)
-	r.GET("/ws/stats", h.StatsWS)
+	blobs, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatalf("failed to init blob store: %v", err)
+	}
+	enc, err := encryption.NewFromEnv(blobs, db.KeyIndex{})
+	if err != nil {
+		log.Fatalf("failed to init encryption: %v", err)
+	}
+	if enc != nil {
+		blobs = enc
+	}
+	// Chunking is opt-in: a chunked (or encrypted) store has to read every
+	// upload through Put, so uploads to a local store lose the rename that
+	// storage.Commit otherwise does.
+	if os.Getenv("STORAGE_CHUNKING") == "on" {
+		chunked := storage.NewChunkedStore(blobs, db.ChunkIndex{})
+		if err := db.RepairEmptyBlobs(context.Background(), chunked); err != nil {
+			log.Printf("failed to repair empty blobs: %v", err)
+		}
+		blobs = chunked
+	}
 
-	r.GET("/verify-token", func(c *gin.Context) {
-    token := c.GetHeader("Authorization")
-    if token == "" {
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
-        return
-    }
+	h := handlers.NewHandler(os.Getenv("STORAGE_PATH"), blobs)
+	h.Encryption = enc
+	go h.SweepUploads(context.Background(), time.Hour)
+	if h.OIDC, err = oidc.NewFromEnv(); err != nil {
+		log.Fatalf("failed to init single sign-on: %v", err)
+	}
+	if h.Mailer, err = mailer.NewFromEnv(); err != nil {
+		log.Fatalf("failed to init mailer: %v", err)
+	}
 
-    userID, username, err := auth.ParseJWT(token)
-    if err != nil {
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-        return
-    }
+	// Routes used before logging in are limited per IP; /login and
+	// /login/2fa also back off and lock out after failed attempts. Share
+	// link passwords are checked here too.
+	public := r.Group("/", middleware.IPRateLimiter(30))
+	{
+		public.POST("/signup", h.SignupHandler)
+		public.POST("/login", h.LoginHandler)
+		public.POST("/login/2fa", h.TwoFactorLoginHandler)
+		public.POST("/verify-email", h.VerifyEmailHandler)
+		public.POST("/password-reset", h.RequestPasswordResetHandler)
+		public.POST("/password-reset/confirm", h.ResetPasswordHandler)
+		public.POST("/s/:token/unlock", h.UnlockShareHandler)
+	}
+	r.OPTIONS("/tus", h.TusOptionsHandler)
 
-    c.JSON(http.StatusOK, gin.H{
-        "user_id":  userID,
-        "username": username,
-        "status":   "valid",
-    })
-})
+	r.GET("/verify-token", handlers.VerifyTokenHandler)
+	r.GET("/.well-known/jwks.json", handlers.JWKSHandler)
+	r.POST("/refresh", h.RefreshHandler)
+	r.GET("/auth/oidc/login", h.OIDCLoginHandler)
+	r.GET("/auth/oidc/callback", h.OIDCCallbackHandler)
 
 	authGroup := r.Group("/")
 	authGroup.Use(handlers.AuthMiddleware, middleware.RateLimiter())
+
+	// Personal access tokens reach each group only with its scope; routes
+	// that span the whole account are closed to tokens restricted to a
+	// folder, and routes on one object check it lies within that folder.
+	whole := handlers.RequireWholeAccount
+	file, folder, share := handlers.InScope("file"), handlers.InScope("folder"), handlers.InScope("share")
+
+	// Sessions and credentials are managed by logging in.
+	session := authGroup.Group("/", handlers.RequireSession)
 	{
-		authGroup.POST("/upload", h.UploadHandler)
-		authGroup.POST("/multi-upload", h.MultiUploadHandler)
+		session.POST("/logout", h.LogoutHandler)
+		session.POST("/logout-all", h.LogoutAllHandler)
 
-		authGroup.GET("/files", h.ListFilesHandler)
-		authGroup.GET("/files/search", h.SearchFilesHandler)
-		authGroup.GET("/files/:id/download", h.DownloadHandler)
-		authGroup.GET("/files/:id/preview", h.PreviewFileHandler)
-		authGroup.PATCH("/files/:id/move", h.MoveFileHandler)
-		authGroup.PATCH("/files/move-bulk", h.BulkMoveFilesHandler)
+		session.POST("/tokens", h.CreateTokenHandler)
+		session.GET("/tokens", h.ListTokensHandler)
+		session.DELETE("/tokens/:id", h.RevokeTokenHandler)
 
-		authGroup.PATCH("/files/:id/tags", h.UpdateTagsHandler)
-		authGroup.GET("/files/:id/tags", h.GetTagsHandler)
+		session.POST("/access-keys", h.CreateAccessKeyHandler)
+		session.GET("/access-keys", h.ListAccessKeysHandler)
+		session.DELETE("/access-keys/:id", h.DeleteAccessKeyHandler)
 
-		authGroup.POST("/files/:id/add-editor", h.AddEditorHandler)
-		authGroup.DELETE("/files/:id/remove-editor", h.RemoveEditorHandler)
+		session.POST("/verify-email/resend", h.ResendVerificationHandler)
 
-		authGroup.GET("/files/:id/versions", h.ListFileVersionsHandler)
-		authGroup.POST("/files/:id/restore-version/:version", h.RestoreFileVersionHandler)
+		session.GET("/2fa", h.TwoFactorStatusHandler)
+		session.POST("/2fa/enroll", h.EnrollTwoFactorHandler)
+		session.POST("/2fa/enable", h.EnableTwoFactorHandler)
+		session.POST("/2fa/disable", h.DisableTwoFactorHandler)
+		session.POST("/2fa/recovery-codes", h.RegenerateRecoveryCodesHandler)
+	}
 
-		authGroup.PATCH("/files/:id/trash", h.TrashFileHandler)
-		authGroup.PATCH("/files/:id/restore", h.RestoreFileHandler)
-		authGroup.DELETE("/trash/:id", h.PermanentlyDeleteFileHandler)
+	read := authGroup.Group("/", handlers.RequireScope(api.ScopeFilesRead))
+	{
+		read.GET("/files", whole, h.ListFilesHandler)
+		read.GET("/files/search", whole, h.SearchFilesHandler)
+		read.GET("/files/:id/download", file, h.DownloadHandler)
+		read.GET("/files/:id/preview", file, h.PreviewFileHandler)
+		read.GET("/files/:id/tags", file, h.GetTagsHandler)
+		read.GET("/files/:id/versions", file, h.ListFileVersionsHandler)
 
-		authGroup.POST("/folders", h.CreateFolderHandler)
-		authGroup.GET("/folders", h.ListFoldersHandler)
-		authGroup.PATCH("/folders/:id", h.RenameFolderHandler)
-		authGroup.GET("/folders/:id/files", h.ListFolderFilesHandler)
-		authGroup.GET("/folders/tree", h.GetFolderTreeHandler)
-		authGroup.PATCH("/folders/:id/move", h.MoveFolderHandler)
-		authGroup.PATCH("/folders/:id/trash", h.TrashFolderHandler)
-		authGroup.PATCH("/folders/:id/restore", h.RestoreFolderHandler)
-		authGroup.DELETE("/trash/folders/:id", h.PermanentlyDeleteFolderHandler)
+		read.GET("/folders", whole, h.ListFoldersHandler)
+		read.GET("/folders/:id/files", folder, h.ListFolderFilesHandler)
+		read.GET("/folders/tree", whole, h.GetFolderTreeHandler)
+		read.GET("/shared", whole, h.SharedWithMeHandler)
+		read.GET("/groups", whole, h.ListGroupsHandler)
+		read.GET("/groups/:id", whole, h.GetGroupHandler)
+		read.GET("/org", whole, h.GetOrganizationHandler)
 
-		authGroup.GET("/trash", h.ListTrashHandler)
-		authGroup.DELETE("/trash/empty", h.EmptyTrashHandler)
+		read.GET("/trash", whole, h.ListTrashHandler)
+		read.GET("/stats", whole, h.StatsHandler)
+		read.GET("/audit-logs", whole, h.GetAuditLogsHandler)
+		read.GET("/ws/stats", whole, h.StatsWS)
 
-		authGroup.GET("/stats", h.StatsHandler)
+		read.GET("/fs/*path", h.FSGetHandler)
+	}
+
+	write := authGroup.Group("/", handlers.RequireScope(api.ScopeFilesWrite))
+	{
+		write.POST("/upload", h.UploadHandler)
+		write.POST("/multi-upload", h.MultiUploadHandler)
+
+		write.POST("/tus", h.TusCreateHandler)
+		write.HEAD("/tus/:id", h.TusHeadHandler)
+		write.PATCH("/tus/:id", h.TusPatchHandler)
+		write.DELETE("/tus/:id", h.TusDeleteHandler)
+
+		write.PATCH("/files/:id/move", file, h.MoveFileHandler)
+		write.PATCH("/files/move-bulk", h.BulkMoveFilesHandler)
+		write.PATCH("/files/:id/tags", file, h.UpdateTagsHandler)
+		write.POST("/files/:id/add-editor", file, h.AddEditorHandler)
+		write.DELETE("/files/:id/remove-editor", file, h.RemoveEditorHandler)
+		write.POST("/files/:id/restore-version/:version", file, h.RestoreFileVersionHandler)
+		write.PATCH("/files/:id/trash", file, h.TrashFileHandler)
+		write.PATCH("/files/:id/restore", file, h.RestoreFileHandler)
+		write.DELETE("/trash/:id", file, h.PermanentlyDeleteFileHandler)
+
+		write.POST("/folders", h.CreateFolderHandler)
+		write.PATCH("/folders/:id", folder, h.RenameFolderHandler)
+		write.PATCH("/folders/:id/move", folder, h.MoveFolderHandler)
+		write.PATCH("/folders/:id/trash", folder, h.TrashFolderHandler)
+		write.PATCH("/folders/:id/restore", folder, h.RestoreFolderHandler)
+		write.DELETE("/trash/folders/:id", folder, h.PermanentlyDeleteFolderHandler)
+		write.DELETE("/trash/empty", whole, h.EmptyTrashHandler)
+
+		write.PUT("/fs/*path", h.FSPutHandler)
+		write.POST("/fs/*path", h.FSPostHandler)
+		write.DELETE("/fs/*path", h.FSDeleteHandler)
+	}
+
+	shares := authGroup.Group("/", handlers.RequireScope(api.ScopeSharesManage))
+	{
+		shares.POST("/share/:id", file, h.CreateShareHandler)
+		shares.GET("/shares", whole, h.ListSharesHandler)
+		shares.GET("/files/:id/shares", file, h.ListFileSharesHandler)
+		shares.POST("/folders/:id/share", folder, h.CreateFolderShareHandler)
+		shares.GET("/folders/:id/shares", folder, h.ListFolderSharesHandler)
+		shares.GET("/shares/:id", share, h.GetShareHandler)
+		shares.PATCH("/shares/:id", share, h.UpdateShareHandler)
+		shares.DELETE("/shares/:id", share, h.RevokeShareHandler)
+		shares.GET("/shares/:id/viewers", share, h.ShareViewersHandler)
+		shares.GET("/shares/:id/stats", share, h.ShareStatsHandler)
+
+		shares.GET("/files/:id/access", file, h.ListFileAccessHandler)
+		shares.PUT("/files/:id/access", file, h.GrantAccessHandler)
+		shares.DELETE("/files/:id/access/:user_id", file, h.RevokeAccessHandler)
+		shares.GET("/folders/:id/access", folder, h.ListFolderAccessHandler)
+		shares.PUT("/folders/:id/access", folder, h.GrantFolderAccessHandler)
+		shares.DELETE("/folders/:id/access/:user_id", folder, h.RevokeFolderAccessHandler)
+		shares.DELETE("/files/:id/access/groups/:group_id", file, h.RevokeGroupAccessHandler)
+		shares.DELETE("/folders/:id/access/groups/:group_id", folder, h.RevokeFolderGroupAccessHandler)
+		shares.POST("/files/:id/transfer", file, h.TransferFileHandler)
+		shares.POST("/folders/:id/transfer", folder, h.TransferFolderHandler)
 
-		authGroup.GET("/admin/files", h.AdminListFiles)
-		authGroup.GET("/admin/stats", h.AdminStats)
+		shares.POST("/groups", whole, h.CreateGroupHandler)
+		shares.PATCH("/groups/:id", whole, h.UpdateGroupHandler)
+		shares.DELETE("/groups/:id", whole, h.DeleteGroupHandler)
+		shares.PUT("/groups/:id/members", whole, h.AddGroupMemberHandler)
+		shares.DELETE("/groups/:id/members/:user_id", whole, h.RemoveGroupMemberHandler)
+	}
+
+	admin := authGroup.Group("/admin", handlers.RequireScope(api.ScopeAdmin), whole)
+	{
+		admin.GET("/files", h.AdminListFiles)
+		admin.GET("/stats", h.AdminStats)
+		admin.GET("/security", h.GetSecuritySettingsHandler)
+		admin.PUT("/security", h.UpdateSecuritySettingsHandler)
+		admin.GET("/lockouts", h.AdminLockoutsHandler)
+		admin.POST("/unlock", h.AdminUnlockHandler)
 
-		authGroup.GET("/audit-logs", h.GetAuditLogsHandler)
+		admin.PATCH("/org", h.UpdateOrganizationHandler)
+		admin.GET("/members", h.ListMembersHandler)
+		admin.PATCH("/members/:id", h.UpdateMemberHandler)
+		admin.POST("/members/:id/transfer", h.TransferMemberFilesHandler)
+		admin.GET("/invites", h.ListInvitesHandler)
+		admin.POST("/invites", h.CreateInviteHandler)
+		admin.DELETE("/invites/:id", h.RevokeInviteHandler)
 
-		authGroup.POST("/share/:id", h.CreateShareHandler)
+		// Instance admins only.
+		admin.GET("/orgs", h.ListOrganizationsHandler)
+		admin.POST("/orgs", h.CreateOrganizationHandler)
+		admin.PATCH("/orgs/:id", h.UpdateOrganizationAdminHandler)
+	}
+
+	// WebDAV clients authenticate with Basic credentials on every request
+	// and issue bursts of PROPFINDs, so the per-user rate limit is not
+	// applied here.
+	davGroup := r.Group("/dav")
+	davGroup.Use(handlers.BasicAuthMiddleware)
+	for _, method := range handlers.WebDAVMethods {
+		davGroup.Handle(method, "/*path", h.WebDAVHandler)
 	}
 
 	r.GET("/s/:token", h.AccessShareHandler)
 	r.GET("/s/:token/download", h.DownloadShareHandler)
 	r.GET("/s/:token/preview", h.PreviewShareHandler)
+	r.GET("/s/:token/tree", h.SharedFolderHandler)
+	r.GET("/s/:token/zip", h.DownloadSharedFolderHandler)
+
+	// The S3 gateway gets its own listener since S3 clients address
+	// buckets at the root of the endpoint.
+	if addr := os.Getenv("S3_GATEWAY_ADDR"); addr != "" {
+		s3 := gin.Default()
+		s3.Use(handlers.S3AuthMiddleware)
+		s3.Any("/*path", h.S3Handler)
+		go func() {
+			log.Printf("S3 gateway running on %s", addr)
+			if err := s3.Run(addr); err != nil {
+				log.Fatalf("S3 gateway stopped: %v", err)
+			}
+		}()
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS uploads (
+  id TEXT PRIMARY KEY,
+  user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+  filename TEXT NOT NULL,
+  mime_type TEXT,
+  folder_id BIGINT REFERENCES folders(id) ON DELETE SET NULL,
+  tags TEXT [],
+  upload_length BIGINT NOT NULL,
+  upload_offset BIGINT NOT NULL DEFAULT 0,
+  file_id BIGINT REFERENCES files(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS uploads_user_id_idx ON uploads(user_id);
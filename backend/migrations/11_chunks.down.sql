@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS blob_chunks;
+DROP TABLE IF EXISTS chunks;
\ No newline at end of file
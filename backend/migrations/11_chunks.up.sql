@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS chunks (
+  id BIGSERIAL PRIMARY KEY,
+  hash TEXT UNIQUE NOT NULL,
+  size BIGINT NOT NULL,
+  ref_count INT DEFAULT 0,
+  created_at TIMESTAMPTZ DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS blob_chunks (
+  blob_hash TEXT NOT NULL,
+  seq INT NOT NULL,
+  chunk_id BIGINT REFERENCES chunks(id),
+  chunk_offset BIGINT NOT NULL,
+  PRIMARY KEY (blob_hash, seq)
+);
+CREATE INDEX IF NOT EXISTS blob_chunks_chunk_id_idx ON blob_chunks(chunk_id);
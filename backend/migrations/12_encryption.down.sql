@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS blobs_hash_key_id_idx;
+ALTER TABLE blobs ADD CONSTRAINT blobs_hash_key UNIQUE (hash);
+ALTER TABLE blobs DROP COLUMN IF EXISTS key_id;
+DROP TABLE IF EXISTS data_keys;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS data_keys (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT UNIQUE REFERENCES users(id) ON DELETE RESTRICT,
+  wrapped_key BYTEA NOT NULL,
+  created_at TIMESTAMPTZ DEFAULT now()
+);
+ALTER TABLE blobs
+ADD COLUMN IF NOT EXISTS key_id BIGINT REFERENCES data_keys(id);
+-- blobs.path is now the blob store key rather than a filesystem path.
+UPDATE blobs SET path = hash;
+-- Per-user encrypted blobs are only deduplicated within one data key.
+ALTER TABLE blobs DROP CONSTRAINT IF EXISTS blobs_hash_key;
+CREATE UNIQUE INDEX IF NOT EXISTS blobs_hash_key_id_idx ON blobs (hash, COALESCE(key_id, 0));
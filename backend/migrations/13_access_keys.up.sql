@@ -0,0 +1,10 @@
+-- SigV4 needs the plain secret to verify signatures, so it is stored as is.
+CREATE TABLE IF NOT EXISTS access_keys (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+  access_key TEXT UNIQUE NOT NULL,
+  secret_key TEXT NOT NULL,
+  created_at TIMESTAMPTZ DEFAULT now(),
+  last_used_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS access_keys_user_id_idx ON access_keys(user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS files_unique_name_idx;
+DROP INDEX IF EXISTS folders_unique_name_idx;
+UPDATE blobs b SET ref_count = (SELECT COUNT(*) FROM files WHERE blob_id = b.id);
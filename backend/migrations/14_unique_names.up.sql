@@ -0,0 +1,24 @@
+-- Names become unique among the live entries of a folder. Earlier
+-- duplicates are kept under a "name (id)" name; for files the newest row
+-- keeps the name, since it is the one that was shown as current.
+UPDATE folders f SET name = left(f.name, 240) || ' (' || f.id || ')'
+WHERE f.trashed = false AND EXISTS (
+    SELECT 1 FROM folders o
+    WHERE o.owner_id = f.owner_id AND o.parent_id IS NOT DISTINCT FROM f.parent_id
+      AND o.name = f.name AND o.trashed = false AND o.id < f.id
+  );
+UPDATE files f SET filename = left(regexp_replace(f.filename, '(\.[^.]*)?$', ' (' || f.id || ')\1'), 255)
+WHERE f.trashed = false AND EXISTS (
+    SELECT 1 FROM files o
+    WHERE o.owner_id = f.owner_id AND o.folder_id IS NOT DISTINCT FROM f.folder_id
+      AND o.filename = f.filename AND o.trashed = false AND o.id > f.id
+  );
+CREATE UNIQUE INDEX IF NOT EXISTS folders_unique_name_idx ON folders (owner_id, COALESCE(parent_id, 0), name)
+WHERE trashed = false;
+CREATE UNIQUE INDEX IF NOT EXISTS files_unique_name_idx ON files (owner_id, COALESCE(folder_id, 0), filename)
+WHERE trashed = false;
+-- A re-upload now versions the existing row in place, so file_versions
+-- rows hold a blob reference of their own.
+UPDATE blobs b SET ref_count =
+  (SELECT COUNT(*) FROM files WHERE blob_id = b.id) +
+  (SELECT COUNT(*) FROM file_versions WHERE blob_id = b.id);
@@ -0,0 +1,24 @@
+-- Users who sign in through OIDC have no password.
+ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL;
+
+-- An external identity is the (issuer, subject) pair from the ID token.
+CREATE TABLE IF NOT EXISTS user_identities (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  issuer TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  email TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_login_at TIMESTAMPTZ,
+  UNIQUE (issuer, subject)
+);
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON user_identities(user_id);
+
+-- Logins in progress, from the redirect to the IdP until its callback.
+CREATE TABLE IF NOT EXISTS oidc_logins (
+  state TEXT PRIMARY KEY,
+  nonce TEXT NOT NULL,
+  code_verifier TEXT NOT NULL,
+  return_to TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
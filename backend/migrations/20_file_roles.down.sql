@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS file_permissions_user_id_idx;
+ALTER TABLE file_permissions DROP CONSTRAINT IF EXISTS file_permissions_file_user_key;
+ALTER TABLE file_permissions ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE file_permissions ALTER COLUMN file_id DROP NOT NULL;
+ALTER TABLE file_permissions DROP COLUMN IF EXISTS granted_by;
+ALTER TABLE file_permissions ADD COLUMN IF NOT EXISTS can_edit BOOLEAN DEFAULT false;
+UPDATE file_permissions SET can_edit = role IN ('editor', 'co_owner');
+ALTER TABLE file_permissions DROP CONSTRAINT IF EXISTS file_permissions_role_check;
+ALTER TABLE file_permissions DROP COLUMN IF EXISTS role;
@@ -0,0 +1,21 @@
+-- A grant gives a user one role on a file instead of an edit flag:
+-- 'viewer', 'commenter', 'editor' or 'co_owner', in order of access. The
+-- owner holds every right without a row.
+ALTER TABLE file_permissions ADD COLUMN IF NOT EXISTS role TEXT;
+UPDATE file_permissions SET role = CASE WHEN can_edit THEN 'editor' ELSE 'viewer' END WHERE role IS NULL;
+ALTER TABLE file_permissions ALTER COLUMN role SET NOT NULL;
+ALTER TABLE file_permissions ADD CONSTRAINT file_permissions_role_check
+  CHECK (role IN ('viewer', 'commenter', 'editor', 'co_owner'));
+ALTER TABLE file_permissions DROP COLUMN IF EXISTS can_edit;
+
+ALTER TABLE file_permissions ADD COLUMN IF NOT EXISTS granted_by BIGINT REFERENCES users(id) ON DELETE SET NULL;
+
+-- Adding an editor twice used to add two rows; keep the strongest.
+DELETE FROM file_permissions p USING file_permissions q
+WHERE p.file_id = q.file_id AND p.user_id = q.user_id
+  AND (p.role = 'viewer' AND q.role = 'editor' OR p.role = q.role AND p.id > q.id);
+DELETE FROM file_permissions WHERE file_id IS NULL OR user_id IS NULL;
+ALTER TABLE file_permissions ALTER COLUMN file_id SET NOT NULL;
+ALTER TABLE file_permissions ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE file_permissions ADD CONSTRAINT file_permissions_file_user_key UNIQUE (file_id, user_id);
+CREATE INDEX IF NOT EXISTS file_permissions_user_id_idx ON file_permissions(user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS folders_parent_id_idx;
+DROP TABLE IF EXISTS folder_permissions;
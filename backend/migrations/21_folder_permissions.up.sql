@@ -0,0 +1,13 @@
+-- Roles granted on a folder apply to everything under it, through
+-- parent_id, including files and subfolders added later.
+CREATE TABLE IF NOT EXISTS folder_permissions (
+  id BIGSERIAL PRIMARY KEY,
+  folder_id BIGINT NOT NULL REFERENCES folders(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role TEXT NOT NULL CHECK (role IN ('viewer', 'commenter', 'editor', 'co_owner')),
+  granted_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (folder_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS folder_permissions_user_id_idx ON folder_permissions(user_id);
+CREATE INDEX IF NOT EXISTS folders_parent_id_idx ON folders(parent_id);
@@ -0,0 +1,14 @@
+DELETE FROM folder_permissions WHERE group_id IS NOT NULL;
+ALTER TABLE folder_permissions DROP CONSTRAINT IF EXISTS folder_permissions_folder_group_key;
+ALTER TABLE folder_permissions DROP CONSTRAINT IF EXISTS folder_permissions_principal_check;
+ALTER TABLE folder_permissions DROP COLUMN IF EXISTS group_id;
+ALTER TABLE folder_permissions ALTER COLUMN user_id SET NOT NULL;
+
+DELETE FROM file_permissions WHERE group_id IS NOT NULL;
+ALTER TABLE file_permissions DROP CONSTRAINT IF EXISTS file_permissions_file_group_key;
+ALTER TABLE file_permissions DROP CONSTRAINT IF EXISTS file_permissions_principal_check;
+ALTER TABLE file_permissions DROP COLUMN IF EXISTS group_id;
+ALTER TABLE file_permissions ALTER COLUMN user_id SET NOT NULL;
+
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
@@ -0,0 +1,33 @@
+-- Groups of users that roles can be granted to. Group admins manage the
+-- members.
+CREATE TABLE IF NOT EXISTS groups (
+  id BIGSERIAL PRIMARY KEY,
+  name TEXT NOT NULL CHECK (char_length(name) BETWEEN 1 AND 100),
+  description TEXT NOT NULL DEFAULT '',
+  created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS groups_name_idx ON groups (lower(name));
+
+CREATE TABLE IF NOT EXISTS group_members (
+  group_id BIGINT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  is_admin BOOLEAN NOT NULL DEFAULT false,
+  added_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (group_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS group_members_user_id_idx ON group_members(user_id);
+
+-- A grant goes to a user or to a group.
+ALTER TABLE file_permissions ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE file_permissions ADD COLUMN IF NOT EXISTS group_id BIGINT REFERENCES groups(id) ON DELETE CASCADE;
+ALTER TABLE file_permissions ADD CONSTRAINT file_permissions_principal_check
+  CHECK ((user_id IS NULL) <> (group_id IS NULL));
+ALTER TABLE file_permissions ADD CONSTRAINT file_permissions_file_group_key UNIQUE (file_id, group_id);
+
+ALTER TABLE folder_permissions ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE folder_permissions ADD COLUMN IF NOT EXISTS group_id BIGINT REFERENCES groups(id) ON DELETE CASCADE;
+ALTER TABLE folder_permissions ADD CONSTRAINT folder_permissions_principal_check
+  CHECK ((user_id IS NULL) <> (group_id IS NULL));
+ALTER TABLE folder_permissions ADD CONSTRAINT folder_permissions_folder_group_key UNIQUE (folder_id, group_id);
@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS org_invites;
+
+DROP INDEX IF EXISTS groups_org_name_idx;
+ALTER TABLE groups DROP COLUMN IF EXISTS org_id;
+CREATE UNIQUE INDEX IF NOT EXISTS groups_name_idx ON groups (lower(name));
+
+CREATE TABLE IF NOT EXISTS settings (
+  key TEXT PRIMARY KEY,
+  value TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_by BIGINT REFERENCES users(id) ON DELETE SET NULL
+);
+INSERT INTO settings (key, value, updated_at, updated_by)
+  SELECT s.key, s.value, s.updated_at, s.updated_by
+  FROM org_settings s JOIN organizations o ON o.id = s.org_id WHERE o.slug='default'
+  ON CONFLICT DO NOTHING;
+DROP TABLE IF EXISTS org_settings;
+
+ALTER TABLE users DROP COLUMN IF EXISTS instance_admin;
+DROP INDEX IF EXISTS users_org_id_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS organizations;
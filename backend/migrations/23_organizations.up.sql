@@ -0,0 +1,62 @@
+-- Organizations are the tenants of a deployment. Every user belongs to
+-- one, and users.role is now their role in it: admins administer their
+-- own organization. instance_admin marks the operators of the deployment,
+-- who create organizations. Existing users join the default organization.
+CREATE TABLE IF NOT EXISTS organizations (
+  id BIGSERIAL PRIMARY KEY,
+  name TEXT NOT NULL CHECK (char_length(name) BETWEEN 1 AND 100),
+  slug TEXT NOT NULL UNIQUE CHECK (slug ~ '^[a-z0-9][a-z0-9-]{0,62}$'),
+  -- storage_quota caps what the members store together, 0 for no cap.
+  storage_quota BIGINT NOT NULL DEFAULT 0,
+  -- member_quota is the quota new members start with.
+  member_quota BIGINT NOT NULL DEFAULT 10485760,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+INSERT INTO organizations (name, slug) VALUES ('Default', 'default') ON CONFLICT (slug) DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS org_id BIGINT REFERENCES organizations(id);
+UPDATE users SET org_id = (SELECT id FROM organizations WHERE slug='default') WHERE org_id IS NULL;
+ALTER TABLE users ALTER COLUMN org_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS users_org_id_idx ON users(org_id);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS instance_admin BOOLEAN NOT NULL DEFAULT false;
+UPDATE users SET instance_admin = true WHERE role='admin';
+
+-- Settings belong to an organization.
+CREATE TABLE IF NOT EXISTS org_settings (
+  org_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  key TEXT NOT NULL,
+  value TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  PRIMARY KEY (org_id, key)
+);
+INSERT INTO org_settings (org_id, key, value, updated_at, updated_by)
+  SELECT o.id, s.key, s.value, s.updated_at, s.updated_by
+  FROM settings s, organizations o WHERE o.slug='default'
+  ON CONFLICT DO NOTHING;
+DROP TABLE IF EXISTS settings;
+
+-- Group names are unique within an organization.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS org_id BIGINT REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE groups g SET org_id = COALESCE(
+  (SELECT org_id FROM users WHERE id = g.created_by),
+  (SELECT id FROM organizations WHERE slug='default')
+) WHERE org_id IS NULL;
+ALTER TABLE groups ALTER COLUMN org_id SET NOT NULL;
+DROP INDEX IF EXISTS groups_name_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS groups_org_name_idx ON groups (org_id, lower(name));
+
+-- Invitations to join an organization, redeemed at signup. The token is
+-- stored as a SHA-256 hash.
+CREATE TABLE IF NOT EXISTS org_invites (
+  id BIGSERIAL PRIMARY KEY,
+  org_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  email TEXT NOT NULL,
+  role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+  token_hash TEXT UNIQUE NOT NULL,
+  invited_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS org_invites_org_id_idx ON org_invites(org_id);
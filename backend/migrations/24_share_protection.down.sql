@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS share_unlocks;
+ALTER TABLE shares DROP COLUMN IF EXISTS require_email;
+ALTER TABLE shares DROP COLUMN IF EXISTS download_count;
+ALTER TABLE shares DROP COLUMN IF EXISTS max_downloads;
+ALTER TABLE shares DROP COLUMN IF EXISTS password_hash;
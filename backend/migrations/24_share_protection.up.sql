@@ -0,0 +1,16 @@
+-- Optional protections on share links: a password (bcrypt), a cap on
+-- downloads and an email visitors leave before viewing.
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS password_hash TEXT;
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS max_downloads INT CHECK (max_downloads > 0);
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS download_count INT NOT NULL DEFAULT 0;
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS require_email BOOLEAN NOT NULL DEFAULT false;
+
+-- Visitors who passed a protected link's unlock step. The cookie handed to
+-- the browser is stored as a SHA-256 hash.
+CREATE TABLE IF NOT EXISTS share_unlocks (
+  token_hash TEXT PRIMARY KEY,
+  share_id BIGINT NOT NULL REFERENCES shares(id) ON DELETE CASCADE,
+  email TEXT,
+  expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS share_unlocks_expires_at_idx ON share_unlocks(expires_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS audit_logs_share_id_idx;
+ALTER TABLE shares DROP COLUMN IF EXISTS created_at;
+ALTER TABLE shares DROP COLUMN IF EXISTS created_by;
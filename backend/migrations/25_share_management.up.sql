@@ -0,0 +1,9 @@
+-- Who created a link and when; links made earlier have neither.
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS created_by BIGINT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ;
+ALTER TABLE shares ALTER COLUMN created_at SET DEFAULT now();
+
+-- Visits to share links are audited with the share's id in meta, which
+-- per-share statistics group by.
+CREATE INDEX IF NOT EXISTS audit_logs_share_id_idx ON audit_logs ((meta->>'share_id'))
+WHERE meta ? 'share_id';
@@ -0,0 +1,4 @@
+DELETE FROM shares WHERE folder_id IS NOT NULL;
+ALTER TABLE shares DROP CONSTRAINT IF EXISTS shares_target_check;
+DROP INDEX IF EXISTS shares_folder_id_idx;
+ALTER TABLE shares DROP COLUMN IF EXISTS folder_id;
@@ -0,0 +1,6 @@
+-- A share link points at a file or, now, at a folder and everything
+-- under it. Links to no file at all could never be opened.
+DELETE FROM shares WHERE file_id IS NULL;
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS folder_id BIGINT REFERENCES folders(id) ON DELETE CASCADE;
+ALTER TABLE shares ADD CONSTRAINT shares_target_check CHECK ((file_id IS NULL) <> (folder_id IS NULL));
+CREATE INDEX IF NOT EXISTS shares_folder_id_idx ON shares(folder_id) WHERE folder_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- The repair only adds the empty chunks the blobs get on upload now; there
+-- is nothing to undo.
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS files_unique_root_name_idx;
+DROP INDEX IF EXISTS files_unique_name_idx;
+DROP INDEX IF EXISTS folders_unique_root_name_idx;
+DROP INDEX IF EXISTS folders_unique_name_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS folders_unique_name_idx ON folders (owner_id, COALESCE(parent_id, 0), name)
+WHERE trashed = false;
+CREATE UNIQUE INDEX IF NOT EXISTS files_unique_name_idx ON files (owner_id, COALESCE(folder_id, 0), filename)
+WHERE trashed = false;
@@ -0,0 +1,23 @@
+-- Names inside a folder become unique across owners, since editors add
+-- files and folders to folders they do not own; only the top of each
+-- user's tree stays per owner. Duplicates are renamed as in migration 14.
+UPDATE folders f SET name = left(f.name, 240) || ' (' || f.id || ')'
+WHERE f.trashed = false AND f.parent_id IS NOT NULL AND EXISTS (
+    SELECT 1 FROM folders o
+    WHERE o.parent_id = f.parent_id AND o.name = f.name AND o.trashed = false AND o.id < f.id
+  );
+UPDATE files f SET filename = left(regexp_replace(f.filename, '(\.[^.]*)?$', ' (' || f.id || ')\1'), 255)
+WHERE f.trashed = false AND f.folder_id IS NOT NULL AND EXISTS (
+    SELECT 1 FROM files o
+    WHERE o.folder_id = f.folder_id AND o.filename = f.filename AND o.trashed = false AND o.id > f.id
+  );
+DROP INDEX IF EXISTS folders_unique_name_idx;
+DROP INDEX IF EXISTS files_unique_name_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS folders_unique_name_idx ON folders (parent_id, name)
+WHERE trashed = false AND parent_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS folders_unique_root_name_idx ON folders (owner_id, name)
+WHERE trashed = false AND parent_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS files_unique_name_idx ON files (folder_id, filename)
+WHERE trashed = false AND folder_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS files_unique_root_name_idx ON files (owner_id, filename)
+WHERE trashed = false AND folder_id IS NULL;
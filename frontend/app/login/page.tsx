@@ -144,7 +144,12 @@ export default function LoginPage() {
             </Button>
           </form>
 
-          <div className="mt-6 text-center">
+          <div className="mt-6 text-center space-y-2">
+            <p>
+              <Link href="/reset-password" className="font-medium" style={{ color: "#4C1D95" }}>
+                Forgot your password?
+              </Link>
+            </p>
             <p className="text-gray-600 dark:text-gray-400">
               Don't have an account?{" "}
               <Link href="/signup" className="font-medium" style={{ color: "#4C1D95" }}>
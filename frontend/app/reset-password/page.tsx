@@ -0,0 +1,124 @@
+"use client"
+
+import type React from "react"
+import { Suspense, useState } from "react"
+import { useSearchParams } from "next/navigation"
+import Link from "next/link"
+import { KeyRound } from "lucide-react"
+import { Button } from "@/components/ui/button"
+
+// Without a token the page asks for a reset link; the link in the email
+// brings the user back here with one to choose the new password.
+function ResetPassword() {
+  const token = useSearchParams().get("token")
+  const [email, setEmail] = useState("")
+  const [password, setPassword] = useState("")
+  const [message, setMessage] = useState("")
+  const [error, setError] = useState("")
+  const [isLoading, setIsLoading] = useState(false)
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault()
+    setIsLoading(true)
+    setError("")
+    setMessage("")
+    try {
+      const response = await fetch(
+        token ? "http://localhost:8080/password-reset/confirm" : "http://localhost:8080/password-reset",
+        {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify(token ? { token, password } : { email }),
+        },
+      )
+      const data = await response.json()
+      if (response.ok) {
+        setMessage(data.message)
+      } else {
+        setError(data.error)
+      }
+    } catch {
+      setError("Could not reach the server.")
+    }
+    setIsLoading(false)
+  }
+
+  const inputClass =
+    "block w-full h-12 rounded-md border-2 bg-transparent px-3 outline-none transition-all placeholder-gray-400 text-purple-700 dark:text-white"
+
+  return (
+    <div className="min-h-screen flex items-center justify-center p-4">
+      <div className="w-full max-w-md">
+        <div className="text-center mb-8">
+          <div
+            className="w-16 h-16 rounded-2xl mx-auto mb-4 flex items-center justify-center shadow-lg"
+            style={{ background: "linear-gradient(to bottom right, #7C3AED, #4C1D95)" }}
+          >
+            <KeyRound className="w-8 h-8 text-white" />
+          </div>
+          <h1 className="text-3xl font-bold text-gray-900 dark:text-white mb-2">
+            {token ? "Choose a new password" : "Forgot your password?"}
+          </h1>
+          <p className="text-gray-600 dark:text-gray-300">
+            {token ? "You will be logged out on every device." : "We will email you a link to reset it."}
+          </p>
+        </div>
+
+        <form onSubmit={handleSubmit} className="space-y-6">
+          {token ? (
+            <input
+              type="password"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              required
+              minLength={8}
+              className={inputClass}
+              style={{ borderColor: "#7C3AED" }}
+              placeholder="New password, at least 8 characters"
+            />
+          ) : (
+            <input
+              type="email"
+              value={email}
+              onChange={(e) => setEmail(e.target.value)}
+              required
+              className={inputClass}
+              style={{ borderColor: "#7C3AED" }}
+              placeholder="Your account's email"
+            />
+          )}
+
+          {error && (
+            <div className="text-red-400 text-sm text-center bg-red-500/10 p-3 rounded-lg border border-red-500/20">
+              {error}
+            </div>
+          )}
+          {message && <div className="text-sm text-center text-gray-600 dark:text-gray-300">{message}</div>}
+
+          <Button
+            type="submit"
+            disabled={isLoading}
+            className="w-full h-12 font-semibold rounded-lg text-lg text-white"
+            style={{ background: "linear-gradient(to right, #7C3AED, #4C1D95)" }}
+          >
+            {token ? "Set password" : "Send reset link"}
+          </Button>
+        </form>
+
+        <div className="mt-6 text-center">
+          <Link href="/login" className="font-medium" style={{ color: "#4C1D95" }}>
+            Back to sign in
+          </Link>
+        </div>
+      </div>
+    </div>
+  )
+}
+
+export default function ResetPasswordPage() {
+  return (
+    <Suspense>
+      <ResetPassword />
+    </Suspense>
+  )
+}
@@ -0,0 +1,60 @@
+"use client"
+
+import { Suspense, useEffect, useState } from "react"
+import { useSearchParams } from "next/navigation"
+import Link from "next/link"
+import { MailCheck } from "lucide-react"
+
+function VerifyEmail() {
+  const token = useSearchParams().get("token")
+  const [status, setStatus] = useState<"pending" | "done" | "failed">("pending")
+  const [message, setMessage] = useState("Verifying your email...")
+
+  useEffect(() => {
+    if (!token) {
+      setStatus("failed")
+      setMessage("The link has no token.")
+      return
+    }
+    fetch("http://localhost:8080/verify-email", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ token }),
+    })
+      .then(async (response) => {
+        const data = await response.json()
+        setStatus(response.ok ? "done" : "failed")
+        setMessage(response.ok ? "Your email is verified. You can now create share links." : data.error)
+      })
+      .catch(() => {
+        setStatus("failed")
+        setMessage("Could not reach the server.")
+      })
+  }, [token])
+
+  return (
+    <div className="min-h-screen flex items-center justify-center p-4">
+      <div className="w-full max-w-md text-center">
+        <div
+          className="w-16 h-16 rounded-2xl mx-auto mb-4 flex items-center justify-center shadow-lg"
+          style={{ background: "linear-gradient(to bottom right, #7C3AED, #4C1D95)" }}
+        >
+          <MailCheck className="w-8 h-8 text-white" />
+        </div>
+        <h1 className="text-3xl font-bold text-gray-900 dark:text-white mb-2">Email verification</h1>
+        <p className={status === "failed" ? "text-red-400" : "text-gray-600 dark:text-gray-300"}>{message}</p>
+        <Link href="/dashboard" className="inline-block mt-6 font-medium" style={{ color: "#4C1D95" }}>
+          Go to your files
+        </Link>
+      </div>
+    </div>
+  )
+}
+
+export default function VerifyEmailPage() {
+  return (
+    <Suspense>
+      <VerifyEmail />
+    </Suspense>
+  )
+}
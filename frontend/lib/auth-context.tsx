@@ -1,7 +1,7 @@
 "use client"
 
 import type React from "react"
-import { createContext, useContext, useState, useEffect } from "react"
+import { createContext, useContext, useState, useEffect, useCallback } from "react"
 import { useRouter } from "next/navigation"
 
 interface User {
@@ -21,12 +21,68 @@ interface AuthContextType {
 
 const AuthContext = createContext<AuthContextType | undefined>(undefined)
 
+// Access tokens live for minutes; the refresh token is exchanged for a new
+// pair shortly before the access token expires.
+const REFRESH_MARGIN_MS = 60 * 1000
+
+interface TokenPair {
+  token: string
+  expires_at: string
+  refresh_token: string
+}
+
+function storeTokens(pair: TokenPair) {
+  localStorage.setItem("auth_token", pair.token)
+  localStorage.setItem("auth_refresh_token", pair.refresh_token)
+  localStorage.setItem("auth_expires_at", pair.expires_at)
+}
+
+function clearTokens() {
+  localStorage.removeItem("auth_token")
+  localStorage.removeItem("auth_refresh_token")
+  localStorage.removeItem("auth_expires_at")
+  localStorage.removeItem("auth_user")
+}
+
 export function AuthProvider({ children }: { children: React.ReactNode }) {
   const [user, setUser] = useState<User | null>(null)
   const [token, setToken] = useState<string | null>(null)
+  const [expiresAt, setExpiresAt] = useState<string | null>(null)
   const [isLoading, setIsLoading] = useState(true)
   const router = useRouter()
 
+  const endSession = useCallback(() => {
+    setUser(null)
+    setToken(null)
+    setExpiresAt(null)
+    clearTokens()
+    router.push("/login")
+  }, [router])
+
+  useEffect(() => {
+    if (!expiresAt) return
+    const delay = Math.max(new Date(expiresAt).getTime() - Date.now() - REFRESH_MARGIN_MS, 0)
+    const timer = setTimeout(async () => {
+      const refreshToken = localStorage.getItem("auth_refresh_token")
+      if (!refreshToken) return endSession()
+      try {
+        const response = await fetch("http://localhost:8080/refresh", {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify({ refresh_token: refreshToken }),
+        })
+        if (!response.ok) return endSession()
+        const pair: TokenPair = await response.json()
+        storeTokens(pair)
+        setToken(pair.token)
+        setExpiresAt(pair.expires_at)
+      } catch (error) {
+        console.error("Token refresh error:", error)
+      }
+    }, delay)
+    return () => clearTimeout(timer)
+  }, [expiresAt, endSession])
+
   useEffect(() => {
     // Check for stored token on mount
     const storedToken = localStorage.getItem("auth_token")
@@ -35,6 +91,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     if (storedToken && storedUser) {
       setToken(storedToken)
       setUser(JSON.parse(storedUser))
+      setExpiresAt(localStorage.getItem("auth_expires_at"))
     }
     setIsLoading(false)
   }, [])
@@ -69,12 +126,26 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
       })
 
       if (response.ok) {
-        const data = await response.json()
+        let data = await response.json()
+        // With two-factor authentication on, the password only earns a
+        // challenge, which a code from the authenticator app completes.
+        if (data.two_factor_required) {
+          const code = window.prompt("Enter the code from your authenticator app, or a recovery code")
+          if (!code) return false
+          const second = await fetch("http://localhost:8080/login/2fa", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ two_factor_token: data.two_factor_token, code }),
+          })
+          if (!second.ok) return false
+          data = await second.json()
+        }
         const { token: authToken, user: userData } = data
 
         setToken(authToken)
         setUser(userData)
-        localStorage.setItem("auth_token", authToken)
+        setExpiresAt(data.expires_at)
+        storeTokens(data)
         localStorage.setItem("auth_user", JSON.stringify(userData))
 
         router.push("/dashboard")
@@ -120,11 +191,15 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
   }
 
   const logout = () => {
-    setUser(null)
-    setToken(null)
-    localStorage.removeItem("auth_token")
-    localStorage.removeItem("auth_user")
-    router.push("/login")
+    const refreshToken = localStorage.getItem("auth_refresh_token")
+    if (token) {
+      fetch("http://localhost:8080/logout", {
+        method: "POST",
+        headers: { "Content-Type": "application/json", Authorization: `Bearer ${token}` },
+        body: JSON.stringify(refreshToken ? { refresh_token: refreshToken } : {}),
+      }).catch((error) => console.error("Logout error:", error))
+    }
+    endSession()
   }
 
   return (